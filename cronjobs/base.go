@@ -8,15 +8,19 @@ import (
 
 	"github.com/vesicash/payment-ms/external/request"
 	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"github.com/vesicash/payment-ms/services/payment"
 	"github.com/vesicash/payment-ms/utility"
 )
 
 var (
 	cronJobs = map[string]CronJobObject{
-		"disbursement":       {CronJob: Disbursement, Interval: time.Minute * 1},
-		"disbursement-check": {CronJob: DisbursementCheck, Interval: time.Minute * 1},
-		"webhook-fire":       {CronJob: WebhookFire, Interval: time.Minute * 1},
-		"bank-transfer":      {CronJob: BankTransfer, Interval: time.Minute * 1},
+		"disbursement":             {CronJob: Disbursement, Interval: time.Minute * 1},
+		"disbursement-check":       {CronJob: DisbursementCheck, Interval: time.Minute * 1},
+		"webhook-fire":             {CronJob: WebhookFire, Interval: time.Minute * 1},
+		"bank-transfer":            {CronJob: BankTransfer, Interval: time.Minute * 1},
+		"gateway-health":           {CronJob: payment.SyncGatewayHealth, Interval: time.Minute * 1},
+		"wallet-transfer-recovery": {CronJob: payment.RecoverWalletTransfers, Interval: time.Minute * 1},
+		"wallet-hold-expiry":       {CronJob: payment.ExpireWalletHolds, Interval: time.Minute * 1},
 	}
 	stopSignals = map[string]chan bool{}
 )
@@ -15,9 +15,11 @@ import (
 	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
 	"github.com/vesicash/payment-ms/services/payment"
 	"github.com/vesicash/payment-ms/utility"
+	"gorm.io/gorm"
 )
 
 func Disbursement(extReq request.ExternalRequest, db postgresql.Databases) {
+	releaseSettlementBatches(extReq, db)
 
 	transactions, err := payment.ListTransactionsByStatusCode(extReq, "cdp", 1, 20)
 	if err != nil {
@@ -26,13 +28,22 @@ func Disbursement(extReq request.ExternalRequest, db postgresql.Databases) {
 	}
 
 	for _, transaction := range transactions {
-		err := beginDisbursement(extReq, db, transaction)
+		err := runDisbursement(extReq, db, transaction)
 		if err != nil {
 			extReq.Logger.Error(fmt.Sprintf("error running disbursement for transaction %v; err: %v", transaction.TransactionID, err.Error()))
 		} else {
 			extReq.Logger.Info(fmt.Sprintf("disbursement for transaction %v complete", transaction.TransactionID))
 		}
 	}
+
+	payment.SendQueuedDisbursements(extReq, db)
+}
+
+// runDisbursement runs beginDisbursement for a transaction, one run at a time, so the cron and an admin retry cannot both pay out the same transaction.
+func runDisbursement(extReq request.ExternalRequest, db postgresql.Databases, transaction external_models.TransactionByID) error {
+	return postgresql.RunWithAdvisoryLock(db.Payment, "transaction-disbursement:"+transaction.TransactionID, func(tx *gorm.DB) error {
+		return beginDisbursement(extReq, db, transaction)
+	})
 }
 
 func beginDisbursement(extReq request.ExternalRequest, db postgresql.Databases, transaction external_models.TransactionByID) error {
@@ -53,91 +64,32 @@ func beginDisbursement(extReq request.ExternalRequest, db postgresql.Databases,
 		return fmt.Errorf("payment record not found")
 	}
 
+	recipient := payment.GetDisbursementRecipient(extReq, transaction, paymnt.Currency)
 	var (
-		restricted = []string{}
-		issues     = []string{}
+		restricted  = recipient.Restricted
+		issues      = []string{}
+		user        = recipient.User
+		bankDetails = recipient.BankDetails
+		isMomo      = recipient.IsMomo
 	)
-	sellerParty := transaction.Parties["seller"]
-	user, _ := payment.GetUserWithAccountID(extReq, sellerParty.AccountID)
-
-	bankDetails, err := payment.GetBankDetail(extReq, 0, int(user.AccountID), "", paymnt.Currency)
-	if err != nil {
-		restricted = append(restricted, "Bank Account")
-	}
-
-	if !paymnt.IsPaid && paymnt.PaymentMadeAt == baseTime {
-		issues = append(issues, "Transaction has not been paid for")
-	}
-
-	if user.AccountType == "" {
-		restricted = append(restricted, "Account Type")
-	}
-
-	if strings.EqualFold(user.AccountType, "individual") {
-		profile, _ := payment.GetUserProfileByAccountID(extReq, extReq.Logger, int(user.AccountID))
-		if strings.EqualFold(profile.Country, "Nigeria") || strings.EqualFold(profile.Country, "NG") {
-			if !payment.HasVerification(extReq, user.AccountID, "bvn") {
-				restricted = append(restricted, "Identity Card")
-			}
-		}
-	}
-
-	if strings.EqualFold(user.AccountType, "business") {
-		businessprofile, _ := payment.GetBusinessProfileByAccountID(extReq, extReq.Logger, int(user.AccountID))
-		if strings.EqualFold(businessprofile.Country, "Nigeria") || strings.EqualFold(businessprofile.Country, "NG") {
-			if strings.EqualFold(businessprofile.BusinessType, "social_commerce") {
-				if !payment.HasVerification(extReq, user.AccountID, "id") {
-					restricted = append(restricted, "Identity Card")
-				}
-			} else {
-				if !payment.HasVerification(extReq, user.AccountID, "cac") {
-					restricted = append(restricted, "CAC")
-				}
-				if !payment.HasVerification(extReq, user.AccountID, "utilitybill") {
-					restricted = append(restricted, "Utility Bill")
-				}
-			}
-
-		}
-	}
 
 	businessId := transaction.BusinessID
 	businessProfile, _ := payment.GetBusinessProfileByAccountID(extReq, extReq.Logger, businessId)
-	businessCharge, _ := payment.GetBusinessChargeWithBusinessIDAndCountry(extReq, transaction.BusinessID, transaction.Country.CountryCode)
-
-	if businessCharge.DisbursementGateway == "rave_momo" {
-		momoBankDetails, err := payment.GetBankDetail(extReq, 0, int(user.AccountID), "", "", true)
-		if err != nil {
-			restricted = append(restricted, "Mobile Money Operator")
-		}
 
-		if !utility.InStringSlice(momoBankDetails.MobileMoneyOperator, []string{"mps", "mtn", "tigo", "vodafone", "airtel"}) {
-			restricted = append(restricted, "Mobile Money Operator Not Supported")
-		}
-	}
-
-	businesscheck, err := payment.GetBusinessProfileByAccountID(extReq, extReq.Logger, user.BusinessId)
-	if businesscheck.IsVerificationWaved {
-		restricted = []string{}
+	if !paymnt.IsPaid && paymnt.PaymentMadeAt == baseTime {
+		issues = append(issues, "Transaction has not been paid for")
 	}
 
 	if len(restricted) > 0 {
 		extReq.Logger.Info("transaction %v: Disbursement skipped: user has certain restrictions. %v", transaction.TransactionID, strings.Join(restricted, ", "))
-		failedDisbursements := models.FailedDisbursement{PaymentID: paymnt.PaymentID}
-		_, err := failedDisbursements.GetFailedDisbursementByPaymentID(db.Payment)
-		if err == nil {
-			return fmt.Errorf("transaction %v: Disbursement skipped: user has certain restrictions. %v", transaction.TransactionID, strings.Join(restricted, ", "))
-		}
-		failedDisbursements = models.FailedDisbursement{
-			PaymentID: paymnt.PaymentID,
-			Reasons:   strings.Join(restricted, ", "),
-		}
-
-		err = failedDisbursements.CreateFailedDisbursement(db.Payment)
+		_, created, err := payment.RecordFailedDisbursement(db, paymnt, transaction, int(user.AccountID), restricted)
 		if err != nil {
 			extReq.Logger.Error(fmt.Sprintf("err creating failed disbursement %v", err.Error()))
 			return err
 		}
+		if !created {
+			return fmt.Errorf("transaction %v: Disbursement skipped: user has certain restrictions. %v", transaction.TransactionID, strings.Join(restricted, ", "))
+		}
 
 		err = payment.SlackNotify(extReq, disbursementChannelD, `
 			Disbursement For Transaction #`+paymnt.TransactionID+`.
@@ -158,38 +110,58 @@ func beginDisbursement(extReq request.ExternalRequest, db postgresql.Databases,
 		return fmt.Errorf("transaction %v: Disbursement skipped: user has certain issues. %v", transaction.TransactionID, strings.Join(issues, ", "))
 	}
 
+	// a disbursement that already exists is only sent again once an admin has approved it
 	disbursement := models.Disbursement{PaymentID: paymnt.PaymentID}
 	code, err = disbursement.GetDisbursementByPaymentID(db.Payment)
-	if err == nil {
+	approved := err == nil && disbursement.Status == string(payment.DisbursementApproved)
+	if err == nil && !approved {
+		resolveFailedDisbursement(extReq, db, paymnt.PaymentID)
 		return nil
 	}
 
-	if code == http.StatusInternalServerError {
+	if err != nil && code == http.StatusInternalServerError {
 		extReq.Logger.Error(fmt.Sprintf("error getting disbursement with payment_id: %v; error: %v", paymnt.PaymentID, err.Error()))
 		return fmt.Errorf("error getting disbursement with payment_id: %v; error: %v", paymnt.PaymentID, err.Error())
 	}
 
+	// batched payouts are paid out by their settlement batch once its window opens
+	if !approved && payment.IsPaymentBatchedForSettlement(db, paymnt.PaymentID) {
+		return nil
+	}
+
 	disbursementID := utility.GetRandomNumbersInRange(1000000000, 9999999999)
-	reference := utility.GetRandomNumbersInRange(1000000000, 9999999999)
+	reference := strconv.Itoa(utility.GetRandomNumbersInRange(1000000000, 9999999999))
+	if approved {
+		disbursementID, reference = disbursement.DisbursementID, disbursement.Reference
+	}
 	if bankDetails.ID == 0 {
 		extReq.Logger.Error(fmt.Sprintf("User %v does not have bank details for currency %v", user.AccountID, paymnt.Currency))
 		return fmt.Errorf("User %v does not have bank details on file for currency %v", user.AccountID, paymnt.Currency)
 	}
 
-	bank, err := payment.GetBank(extReq, bankDetails.BankID, "", "", "")
-	if err != nil {
-		extReq.Logger.Error(fmt.Sprintf("bank with id %v not found, error: %v", bankDetails.BankID, err.Error()))
-		return fmt.Errorf("bank with id %v not found, error: %v", bankDetails.BankID, err.Error())
-	}
-
 	var (
-		bankCode = bank.Code
-		amount   float64
-		currency string
-		rave     = payment.Rave{ExtReq: extReq}
-		monnify  = payment.Monnify{ExtReq: extReq}
+		bankCode      string
+		accountNumber = bankDetails.AccountNo
+		amount        float64
+		currency      string
+		narration     = "Vesicash"
+		rave          = payment.Rave{ExtReq: extReq}
 	)
 
+	if isMomo {
+		bankCode = bankDetails.MobileMoneyOperator
+		if bankDetails.MobileMoneyNumber != "" {
+			accountNumber = bankDetails.MobileMoneyNumber
+		}
+	} else {
+		bank, err := payment.GetBank(extReq, bankDetails.BankID, "", "", "")
+		if err != nil {
+			extReq.Logger.Error(fmt.Sprintf("bank with id %v not found, error: %v", bankDetails.BankID, err.Error()))
+			return fmt.Errorf("bank with id %v not found, error: %v", bankDetails.BankID, err.Error())
+		}
+		bankCode = bank.Code
+	}
+
 	if paymnt.DisburseCurrency != "" {
 		if strings.EqualFold(paymnt.Currency, paymnt.DisburseCurrency) {
 			converted, err := rave.ConvertCurrency(paymnt.TotalAmount, paymnt.Currency, paymnt.DisburseCurrency)
@@ -206,26 +178,30 @@ func beginDisbursement(extReq request.ExternalRequest, db postgresql.Databases,
 	}
 
 	callback := utility.GenerateGroupByURL(config.GetConfig().App.Url, "/disbursement/callback", map[string]string{})
-	disbursement = models.Disbursement{
-		RecipientID:           int(user.AccountID),
-		PaymentID:             paymnt.PaymentID,
-		DisbursementID:        disbursementID,
-		Reference:             strconv.Itoa(reference),
-		Currency:              currency,
-		BusinessID:            businessId,
-		Amount:                fmt.Sprintf("%v", amount),
-		CallbackUrl:           callback,
-		BeneficiaryName:       bankDetails.AccountName,
-		BankAccountNumber:     bankDetails.AccountNo,
-		BankName:              bankDetails.BankName,
-		DestinationBranchCode: "",
-		DebitCurrency:         currency,
-		Status:                "pending",
-		Type:                  "credit",
+	if !approved {
+		disbursement = models.Disbursement{
+			RecipientID:           int(user.AccountID),
+			PaymentID:             paymnt.PaymentID,
+			DisbursementID:        disbursementID,
+			Reference:             reference,
+			Currency:              currency,
+			BusinessID:            businessId,
+			Amount:                fmt.Sprintf("%v", amount),
+			Narration:             narration,
+			CallbackUrl:           callback,
+			BeneficiaryName:       bankDetails.AccountName,
+			BankAccountNumber:     accountNumber,
+			BankName:              bankDetails.BankName,
+			BankCode:              bankCode,
+			DestinationBranchCode: "",
+			DebitCurrency:         currency,
+			Status:                "pending",
+			Type:                  "credit",
+		}
 	}
 
-	if strings.EqualFold(businessProfile.DisbursementSettings, "wallet") {
-		_, err := payment.CreditWallet(extReq, db, paymnt.TotalAmount, transaction.Currency, int(user.AccountID), false, "no", transaction.TransactionID)
+	if !approved && strings.EqualFold(businessProfile.DisbursementSettings, "wallet") {
+		_, err := payment.CreditWallet(extReq, db, paymnt.TotalAmount, transaction.Currency, int(user.AccountID), false, payment.DefaultWalletType, transaction.TransactionID, payment.LedgerPosting{Account: payment.LedgerPayoutAccount, Reference: disbursement.Reference})
 		if err != nil {
 			extReq.Logger.Error(fmt.Sprintf("error crediting wallet for user %v, amount:%v, currency:%v, %v", user.AccountID, paymnt.TotalAmount, transaction.Currency, err.Error()))
 			return fmt.Errorf("error crediting wallet for user %v, amount:%v, currency:%v, %v", user.AccountID, paymnt.TotalAmount, transaction.Currency, err.Error())
@@ -233,12 +209,13 @@ func beginDisbursement(extReq request.ExternalRequest, db postgresql.Databases,
 
 		disbursement.Gateway = "wallet"
 		disbursement.PaymentReleasedAt = time.Now().Format("2006-01-02 15:04:05")
-		disbursement.Status = "completed"
+		disbursement.Status = string(payment.DisbursementCompleted)
 		err = disbursement.CreateDisbursement(db.Payment)
 		if err != nil {
 			extReq.Logger.Error(fmt.Sprintf("error creating disbursement %v", err.Error()))
 			return fmt.Errorf("error creating disbursement %v", err.Error())
 		}
+		resolveFailedDisbursement(extReq, db, paymnt.PaymentID)
 
 		extReq.SendExternalRequest(request.TransactionUpdateStatus, external_models.UpdateTransactionStatusRequest{
 			AccountID:     int(paymnt.AccountID),
@@ -275,13 +252,14 @@ func beginDisbursement(extReq request.ExternalRequest, db postgresql.Databases,
 		return nil
 	}
 
-	err = disbursement.CreateDisbursement(db.Payment)
-	if err != nil {
-		extReq.Logger.Error(fmt.Sprintf("error creating disbursement %v", err.Error()))
-		return fmt.Errorf("error creating disbursement %v", err.Error())
+	if !approved {
+		batched, err := payment.BatchForSettlement(extReq, db, businessId, int(user.AccountID), paymnt, amount, currency)
+		if err != nil || batched {
+			return err
+		}
 	}
 
-	gateway := "rave"
+	gateway := payment.DefaultGateway
 	paymentInfo := models.PaymentInfo{PaymentID: paymnt.PaymentID, Status: "paid"}
 	paymentInfo.GetPaymentInfoByPaymentIDAndStatus(db.Payment)
 
@@ -289,15 +267,33 @@ func beginDisbursement(extReq request.ExternalRequest, db postgresql.Databases,
 		gateway = paymentInfo.Gateway
 	}
 
-	disbursement.Gateway = gateway
-	disbursement.UpdateAllFields(db.Payment)
+	gateways := payment.DisbursementGatewayOrder(db, businessId, transaction.Country.CountryCode, gateway)
+	if isMomo {
+		gateways = payment.AvailableGateways("rave_momo")
+	}
+
+	if len(gateways) == 0 {
+		extReq.Logger.Info(fmt.Sprintf("transaction %v: Disbursement skipped: no disbursement gateway available", transaction.TransactionID))
+		return fmt.Errorf("transaction %v: Disbursement skipped: no disbursement gateway available", transaction.TransactionID)
+	}
+
+	if !approved {
+		err = disbursement.CreateDisbursement(db.Payment)
+		if err != nil {
+			extReq.Logger.Error(fmt.Sprintf("error creating disbursement %v", err.Error()))
+			return fmt.Errorf("error creating disbursement %v", err.Error())
+		}
+		resolveFailedDisbursement(extReq, db, paymnt.PaymentID)
+	}
+
+	disbursement.Gateway = gateways[0]
+	err = disbursement.UpdateFields(db.Payment, "gateway")
 	if err != nil {
 		return err
 	}
 
-	if !strings.EqualFold(transaction.Currency, "NGN") {
-		disbursement.Status = "manual"
-		err := disbursement.UpdateAllFields(db.Payment)
+	if !strings.EqualFold(transaction.Currency, "NGN") && !(isMomo && payment.IsRaveMomoCurrency(currency)) {
+		err := payment.TransitionDisbursement(db, &disbursement, payment.DisbursementManual, "cron:disbursement", map[string]interface{}{"transaction_id": transaction.TransactionID, "currency": transaction.Currency})
 		if err != nil {
 			return err
 		}
@@ -316,36 +312,67 @@ func beginDisbursement(extReq request.ExternalRequest, db postgresql.Databases,
 		return nil
 	}
 
-	var (
-		narration  = "Vesicash"
-		requestLog interface{}
-	)
-
-	switch strings.ToLower(gateway) {
-	case "rave":
-		resData, err := rave.InitTransfer(bankCode, bankDetails.AccountNo, amount, narration, currency, strconv.Itoa(reference), "")
+	if !approved {
+		awaitingApproval, err := payment.RequireDisbursementApproval(extReq, db, &disbursement, amount, false, "")
 		if err != nil {
 			return err
 		}
-		requestLog = resData
-	case "monnify":
-		resData, err := monnify.InitTransfer(amount, strconv.Itoa(reference), narration, bankCode, bankDetails.AccountNo, currency, fmt.Sprintf("%v %v", user.Firstname, user.Lastname))
-		if err != nil {
-			return err
+		if awaitingApproval {
+			err = payment.SlackNotify(extReq, disbursementChannelD, `
+		 	Disbursement For Transaction #`+paymnt.TransactionID+` is awaiting approval.
+            Environment: `+config.GetConfig().App.Name+`
+            Account ID: `+fmt.Sprintf("%v", disbursement.RecipientID)+`
+            Beneficiary Name: `+disbursement.BeneficiaryName+`
+            Amount: `+fmt.Sprintf("%v %v", disbursement.DebitCurrency, disbursement.Amount)+`
+            Status: AWAITING APPROVAL
+			`)
+			if err != nil && !extReq.Test {
+				extReq.Logger.Error("error sending notification to slack: ", err.Error())
+			}
+			return nil
 		}
-		requestLog = resData
-	default:
-		resData, err := rave.InitTransfer(bankCode, bankDetails.AccountNo, amount, narration, currency, strconv.Itoa(reference), "")
-		if err != nil {
-			return err
+	}
+
+	sellerProfile, _ := payment.GetBusinessProfileByAccountID(extReq, extReq.Logger, int(user.AccountID))
+	held, err := payment.VerifyDisbursementBeneficiary(extReq, db, &disbursement, fmt.Sprintf("%v %v", user.Firstname, user.Lastname), sellerProfile.BusinessName)
+	if err != nil || held {
+		return err
+	}
+
+	queued, err := payment.QueueDisbursementTransfer(extReq, db, &disbursement, gateways, "cron:disbursement")
+	if err != nil || queued {
+		return err
+	}
+
+	var (
+		requestLog interface{}
+	)
+
+	_, resData, err := payment.InitTransferWithFailover(extReq, gateways, payment.GatewayTransferRequest{
+		BankCode:      bankCode,
+		AccountNumber: accountNumber,
+		AccountName:   fmt.Sprintf("%v %v", user.Firstname, user.Lastname),
+		Narration:     narration,
+		Currency:      currency,
+		Reference:     reference,
+		Amount:        amount,
+	}, func(gateway string) error {
+		disbursement.Gateway = gateway
+		return disbursement.UpdateFields(db.Payment, "gateway")
+	})
+	if err != nil {
+		_, failureErr := payment.HandleDisbursementFailure(extReq, db, &disbursement, "cron:disbursement", err, resData.Response)
+		if failureErr != nil {
+			extReq.Logger.Error(fmt.Sprintf("error handling failure of disbursement %v: %v", disbursement.DisbursementID, failureErr.Error()))
 		}
-		requestLog = resData
+		return err
 	}
+	requestLog = resData.Response
+	disbursement.GatewayReference = resData.Reference
 
 	payment.LogDisbursement(db, disbursementID, requestLog)
 
-	disbursement.Status = "pending"
-	err = disbursement.UpdateAllFields(db.Payment)
+	err = payment.TransitionDisbursement(db, &disbursement, payment.DisbursementPending, "cron:disbursement", requestLog)
 	if err != nil {
 		return err
 	}
@@ -364,3 +391,11 @@ func beginDisbursement(extReq request.ExternalRequest, db postgresql.Databases,
 	extReq.Logger.Info(fmt.Sprintf("Payment disbursement request sent for transaction %v", transaction.TransactionID))
 	return nil
 }
+
+// resolveFailedDisbursement closes the failed disbursement left by earlier restricted runs once the payout has been created.
+func resolveFailedDisbursement(extReq request.ExternalRequest, db postgresql.Databases, paymentID string) {
+	err := payment.ResolveFailedDisbursement(db, paymentID)
+	if err != nil {
+		extReq.Logger.Error(fmt.Sprintf("error resolving failed disbursement for payment %v: %v", paymentID, err.Error()))
+	}
+}
@@ -0,0 +1,63 @@
+package cronjobs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vesicash/payment-ms/external/request"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"github.com/vesicash/payment-ms/services/payment"
+)
+
+// reconcileDisbursementBatches confirms the pending disbursements of submitted gateway batches from one status query per batch.
+// It returns the disbursements it has covered, so the disbursement check does not ask the gateway about them one at a time.
+func reconcileDisbursementBatches(extReq request.ExternalRequest, db postgresql.Databases) map[int]bool {
+	var (
+		reconciled = map[int]bool{}
+		batch      = models.DisbursementBatch{Status: "submitted"}
+	)
+
+	batches, err := batch.GetDisbursementBatchesByStatus(db.Payment)
+	if err != nil {
+		extReq.Logger.Error(fmt.Sprintf("error getting submitted disbursement batches: %v", err.Error()))
+		return reconciled
+	}
+
+	for _, batch := range batches {
+		if !payment.IsGatewayAvailable(batch.Gateway) {
+			extReq.Logger.Info(fmt.Sprintf("disbursement batch %v check skipped: gateway %v is disabled", batch.BatchReference, batch.Gateway))
+			continue
+		}
+
+		results, disbursements, err := payment.DisbursementBatchResults(extReq, db, batch)
+		if err != nil {
+			extReq.Logger.Error(fmt.Sprintf("error getting results of disbursement batch %v from %v: %v", batch.BatchReference, batch.Gateway, err.Error()))
+			continue
+		}
+
+		for _, disbursement := range disbursements {
+			result, ok := results[disbursement.DisbursementID]
+			if !ok || !strings.EqualFold(disbursement.Status, string(payment.DisbursementPending)) {
+				continue
+			}
+			reconciled[disbursement.DisbursementID] = true
+
+			var statusString string
+			switch payment.GatewayDisbursementStatus(result.Status) {
+			case payment.DisbursementCompleted:
+				statusString = "completed"
+			case payment.DisbursementFailed, payment.DisbursementCancelled:
+				statusString = "failed"
+			default:
+				continue
+			}
+
+			payment.LogDisbursement(db, disbursement.DisbursementID, result.Response)
+			confirmDisbursement(extReq, db, disbursement, statusString == "completed", statusString, result.Response)
+		}
+
+		payment.RefreshDisbursementBatch(extReq, db, batch.BatchReference)
+	}
+	return reconciled
+}
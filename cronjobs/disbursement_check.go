@@ -12,91 +12,110 @@ import (
 	"github.com/vesicash/payment-ms/internal/config"
 	"github.com/vesicash/payment-ms/internal/models"
 	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	webhooksig "github.com/vesicash/payment-ms/pkg/webhook"
 	"github.com/vesicash/payment-ms/services/payment"
 )
 
 var (
-	maxTries = 3
+	disbursementCheckActor = "cron:disbursement-check"
 )
 
 func DisbursementCheck(extReq request.ExternalRequest, db postgresql.Databases) {
 	var (
-		rave         = payment.Rave{ExtReq: extReq}
-		monnify      = payment.Monnify{ExtReq: extReq}
 		disbursement = models.Disbursement{}
 	)
-	allPendingDisbursements, err := disbursement.GetAllForStatuses(db.Payment, []string{"new", "pending"})
+	allPendingDisbursements, err := disbursement.GetAllForStatuses(db.Payment, []string{"new", "pending", "retry_scheduled"})
 	if err != nil {
 		extReq.Logger.Error(fmt.Sprintf("error getting new and pending disbursements %v", err.Error()))
 		return
 	}
 
+	reconciled := reconcileDisbursementBatches(extReq, db)
+
 	for _, item := range allPendingDisbursements {
-		paymentGateway := item.Gateway
-		if paymentGateway == "" {
-			paymentGateway = "rave"
+		if item.Gateway != "" && !payment.IsGatewayAvailable(item.Gateway) {
+			extReq.Logger.Info(fmt.Sprintf("disbursement %v check skipped: gateway %v is disabled", item.DisbursementID, item.Gateway))
+			continue
 		}
-		var (
-			log          interface{}
-			status       bool
-			statusString string
-			reference    = item.Reference
-		)
-
-		switch strings.ToLower(paymentGateway) {
-		case "rave":
-			log, status, statusString, _, err = rave.Status(reference)
-			if err != nil {
-				dataByte, _ := json.Marshal(log)
-				extReq.Logger.Error(fmt.Sprintf("error getting transaction status rave,reference:%v data:%v, error: %v", reference, string(dataByte), err.Error()))
-			}
-		case "monnify":
-			log, status, statusString, _, err = monnify.Status(reference)
-			if err != nil {
-				dataByte, _ := json.Marshal(log)
-				extReq.Logger.Error(fmt.Sprintf("error getting transaction status monnify,reference:%v data:%v, error: %v", reference, string(dataByte), err.Error()))
-			}
-		default:
-			log, status, statusString, _, err = rave.Status(reference)
+
+		if item.TryAgainAt.After(time.Now()) {
+			continue
+		}
+
+		if reconciled[item.DisbursementID] {
+			continue
+		}
+
+		if item.Status == string(payment.DisbursementRetryScheduled) {
+			status, err := payment.RetryDisbursementTransfer(extReq, db, &item)
 			if err != nil {
-				dataByte, _ := json.Marshal(log)
-				extReq.Logger.Error(fmt.Sprintf("error getting transaction status rave,reference:%v data:%v, error: %v", reference, string(dataByte), err.Error()))
+				extReq.Logger.Error(fmt.Sprintf("error retrying disbursement %v: %v", item.DisbursementID, err.Error()))
+			} else if status == payment.DisbursementFailed {
+				walletDisbursementFailed(extReq, db, item)
 			}
+			continue
 		}
 
-		payment.LogDisbursement(db, disbursement.DisbursementID, log)
+		gateway, err := payment.RecordedGateway(extReq, item.Gateway)
+		if err != nil {
+			extReq.Logger.Error(fmt.Sprintf("error checking disbursement %v: %v", item.DisbursementID, err.Error()))
+			continue
+		}
+		reference := item.Reference
+		if item.GatewayReference != "" {
+			reference = item.GatewayReference
+		}
 
-		if item.PaymentID == "" || item.PaymentID == "0" {
-			//TODO complete walletConfirm function
-			walletConfirm(extReq, db, disbursement, status, statusString)
-		} else {
-			//TODO complete transConfirm function
-			transConfirm(extReq, db, disbursement, status, statusString)
+		log, status, statusString, _, err := gateway.TransferStatus(reference)
+		if err != nil {
+			dataByte, _ := json.Marshal(log)
+			extReq.Logger.Error(fmt.Sprintf("error getting transaction status %v,reference:%v data:%v, error: %v", gateway.Name(), reference, string(dataByte), err.Error()))
+			if payment.ClassifyDisbursementError(err, log) == payment.DisbursementErrorTransient {
+				err = payment.DeferDisbursementCheck(db, &item, err)
+				if err != nil {
+					extReq.Logger.Error(fmt.Sprintf("error updating disbursement %v; error: %v", item.DisbursementID, err.Error()))
+				}
+				continue
+			}
 		}
+
+		payment.LogDisbursement(db, item.DisbursementID, log)
+		confirmDisbursement(extReq, db, item, status, statusString, log)
 	}
 
 }
 
-func walletConfirm(extReq request.ExternalRequest, db postgresql.Databases, disbursement models.Disbursement, status bool, statusString string) {
+func confirmDisbursement(extReq request.ExternalRequest, db postgresql.Databases, disbursement models.Disbursement, status bool, statusString string, log interface{}) {
+	if disbursement.Type == payment.SettlementDisbursementType {
+		settlementConfirm(extReq, db, disbursement, statusString, log)
+	} else if disbursement.PaymentID == "" || disbursement.PaymentID == "0" {
+		//TODO complete walletConfirm function
+		walletConfirm(extReq, db, disbursement, status, statusString, log)
+	} else {
+		//TODO complete transConfirm function
+		transConfirm(extReq, db, disbursement, status, statusString, log)
+	}
+}
+
+func walletConfirm(extReq request.ExternalRequest, db postgresql.Databases, disbursement models.Disbursement, status bool, statusString string, log interface{}) {
 	var (
 		disbursementChannelD = config.GetConfig().Slack.DisbursementChannelID
 	)
 	if strings.EqualFold(statusString, "completed") || strings.EqualFold(statusString, "done") {
 		disbursement.PaymentReleasedAt = time.Now().Format("2006-01-02 15:04:05")
-		disbursement.Status = "completed"
-		err := disbursement.UpdateAllFields(db.Payment)
+		err := payment.TransitionDisbursement(db, &disbursement, payment.DisbursementCompleted, disbursementCheckActor, log)
 		if err != nil {
 			extReq.Logger.Error(fmt.Sprintf("error updating disbursement %v; error: %v", disbursement.DisbursementID, err.Error()))
+			return
 		}
+		payment.SettleDisbursementHold(extReq, db, disbursement.Reference, true)
 
 		businessProfileData, _ := payment.GetBusinessProfileByAccountID(extReq, extReq.Logger, disbursement.BusinessID)
-		if businessProfileData.Webhook_uri != "" {
-			payment.InitWebhook(extReq, db, businessProfileData.Webhook_uri, "disbursement.success", map[string]interface{}{
-				"disbursement_id": disbursement.DisbursementID,
-				"reference":       disbursement.Reference,
-				"status":          "success",
-			}, businessProfileData.AccountID)
-		}
+		payment.InitWebhook(extReq, db, businessProfileData.Webhook_uri, webhooksig.EventDisbursementSuccess, webhooksig.Disbursement{
+			DisbursementID: disbursement.DisbursementID,
+			Reference:      disbursement.Reference,
+			Status:         "success",
+		}, businessProfileData.AccountID)
 
 		err = payment.SlackNotify(extReq, disbursementChannelD, `
 		 	Wallet Disbursement been completed successfully.
@@ -113,58 +132,31 @@ func walletConfirm(extReq request.ExternalRequest, db postgresql.Databases, disb
 
 	} else if strings.EqualFold(statusString, "failed") {
 		disbursement.PaymentReleasedAt = time.Now().Format("2006-01-02 15:04:05")
-		disbursement.Status = "failed"
-		err := disbursement.UpdateAllFields(db.Payment)
+		outcome, err := payment.HandleDisbursementFailure(extReq, db, &disbursement, disbursementCheckActor, nil, log)
 		if err != nil {
 			extReq.Logger.Error(fmt.Sprintf("error updating disbursement %v; error: %v", disbursement.DisbursementID, err.Error()))
+			return
 		}
-
-		amount, _ := strconv.ParseFloat(disbursement.Amount, 64)
-		amount = amount + float64(disbursement.Fee)
-		_, err = payment.CreditWallet(extReq, db, amount, disbursement.DebitCurrency, disbursement.RecipientID, true, "no", "")
-		if err != nil {
-			extReq.Logger.Error(fmt.Sprintf("error crediting wallet for user %v, amount:%v; currency: %v; disbursementId:%v; error: %v", disbursement.RecipientID, amount, disbursement.DebitCurrency, disbursement.DisbursementID, err.Error()))
+		if outcome == payment.DisbursementFailed {
+			walletDisbursementFailed(extReq, db, disbursement)
 		}
 
-		businessProfileData, _ := payment.GetBusinessProfileByAccountID(extReq, extReq.Logger, disbursement.BusinessID)
-		if businessProfileData.Webhook_uri != "" {
-			payment.InitWebhook(extReq, db, businessProfileData.Webhook_uri, "disbursement.failed", map[string]interface{}{
-				"disbursement_id": disbursement.DisbursementID,
-				"reference":       disbursement.Reference,
-				"status":          "failed",
-			}, businessProfileData.AccountID)
-		}
-
-		err = payment.SlackNotify(extReq, disbursementChannelD, `
-		 	Wallet Disbursement Has Failed.
-			Note: Requires manual disbursement
-            Environment: `+config.GetConfig().App.Name+`
-            Account ID: `+fmt.Sprintf("%v", disbursement.RecipientID)+`
-            Beneficiary Name: `+disbursement.BeneficiaryName+`
-            Amount: `+fmt.Sprintf("%v %v", disbursement.DebitCurrency, disbursement.Amount)+`
-			Fee: `+fmt.Sprintf("%v %v", disbursement.DebitCurrency, disbursement.Fee)+`
-            Status: FAILED
-			`)
-		if err != nil && !extReq.Test {
-			extReq.Logger.Error("error sending notification to slack: ", err.Error())
-		}
-		extReq.Logger.Info(fmt.Sprintf("%v: Payment disbursement failed", disbursement.Reference))
-
 	} else if strings.EqualFold(statusString, "ongoing") {
 		extReq.Logger.Info(fmt.Sprintf("%v: Payment disbursement initiated/ongoing", disbursement.Reference))
 	} else if strings.EqualFold(statusString, "cancelled") {
-		disbursement.Status = "cancelled"
-		err := disbursement.UpdateAllFields(db.Payment)
+		err := payment.TransitionDisbursement(db, &disbursement, payment.DisbursementCancelled, disbursementCheckActor, log)
 		if err != nil {
 			extReq.Logger.Error(fmt.Sprintf("error updating disbursement %v; error: %v", disbursement.DisbursementID, err.Error()))
+			return
 		}
+		payment.SettleDisbursementHold(extReq, db, disbursement.Reference, false)
 
 	} else if statusString == "" {
 		disbursement.PaymentReleasedAt = time.Now().Format("2006-01-02 15:04:05")
-		disbursement.Status = "review"
-		err := disbursement.UpdateAllFields(db.Payment)
+		err := payment.TransitionDisbursement(db, &disbursement, payment.DisbursementReview, disbursementCheckActor, log)
 		if err != nil {
 			extReq.Logger.Error(fmt.Sprintf("error updating disbursement %v; error: %v", disbursement.DisbursementID, err.Error()))
+			return
 		}
 
 		extReq.Logger.Info(fmt.Sprintf("%v: Payment disbursement null or not found", disbursement.Reference))
@@ -174,18 +166,51 @@ func walletConfirm(extReq request.ExternalRequest, db postgresql.Databases, disb
 
 }
 
-func transConfirm(extReq request.ExternalRequest, db postgresql.Databases, disbursement models.Disbursement, status bool, statusString string) {
+// walletDisbursementFailed returns the funds of a failed wallet withdrawal and lets the business know.
+func walletDisbursementFailed(extReq request.ExternalRequest, db postgresql.Databases, disbursement models.Disbursement) {
 	var (
-		paymnt               = models.Payment{PaymentID: disbursement.PaymentID}
-		amount, _            = strconv.ParseFloat(disbursement.Amount, 64)
 		disbursementChannelD = config.GetConfig().Slack.DisbursementChannelID
+		err                  error
 	)
 
-	tries, isTrue := decideTries(extReq, db, &disbursement)
-	if !isTrue {
-		return
+	if !payment.SettleDisbursementHold(extReq, db, disbursement.Reference, false) {
+		amount, _ := strconv.ParseFloat(disbursement.Amount, 64)
+		amount = amount + float64(disbursement.Fee)
+		_, err = payment.CreditWallet(extReq, db, amount, disbursement.DebitCurrency, disbursement.RecipientID, true, payment.DefaultWalletType, "", payment.LedgerPosting{Account: payment.LedgerPayoutAccount, Reference: disbursement.Reference})
+		if err != nil {
+			extReq.Logger.Error(fmt.Sprintf("error crediting wallet for user %v, amount:%v; currency: %v; disbursementId:%v; error: %v", disbursement.RecipientID, amount, disbursement.DebitCurrency, disbursement.DisbursementID, err.Error()))
+		}
 	}
 
+	businessProfileData, _ := payment.GetBusinessProfileByAccountID(extReq, extReq.Logger, disbursement.BusinessID)
+	payment.InitWebhook(extReq, db, businessProfileData.Webhook_uri, webhooksig.EventDisbursementFailed, webhooksig.Disbursement{
+		DisbursementID: disbursement.DisbursementID,
+		Reference:      disbursement.Reference,
+		Status:         "failed",
+	}, businessProfileData.AccountID)
+
+	err = payment.SlackNotify(extReq, disbursementChannelD, `
+	 	Wallet Disbursement Has Failed.
+		Note: Requires manual disbursement
+            Environment: `+config.GetConfig().App.Name+`
+            Account ID: `+fmt.Sprintf("%v", disbursement.RecipientID)+`
+            Beneficiary Name: `+disbursement.BeneficiaryName+`
+            Amount: `+fmt.Sprintf("%v %v", disbursement.DebitCurrency, disbursement.Amount)+`
+		Fee: `+fmt.Sprintf("%v %v", disbursement.DebitCurrency, disbursement.Fee)+`
+            Status: FAILED
+		`)
+	if err != nil && !extReq.Test {
+		extReq.Logger.Error("error sending notification to slack: ", err.Error())
+	}
+	extReq.Logger.Info(fmt.Sprintf("%v: Payment disbursement failed", disbursement.Reference))
+}
+
+func transConfirm(extReq request.ExternalRequest, db postgresql.Databases, disbursement models.Disbursement, status bool, statusString string, log interface{}) {
+	var (
+		paymnt               = models.Payment{PaymentID: disbursement.PaymentID}
+		disbursementChannelD = config.GetConfig().Slack.DisbursementChannelID
+	)
+
 	_, err := paymnt.GetPaymentByPaymentID(db.Payment)
 	if err != nil {
 		extReq.Logger.Error(fmt.Sprintf("error getting payment for payment id: %v, error: %v", disbursement.PaymentID, err.Error()))
@@ -195,67 +220,30 @@ func transConfirm(extReq request.ExternalRequest, db postgresql.Databases, disbu
 	transaction, _ := payment.ListTransactionsByID(extReq, paymnt.TransactionID)
 
 	if strings.EqualFold(statusString, "completed") {
-		extReq.SendExternalRequest(request.TransactionUpdateStatus, external_models.UpdateTransactionStatusRequest{
-			AccountID:     int(paymnt.AccountID),
-			TransactionID: transaction.TransactionID,
-			MilestoneID:   transaction.MilestoneID,
-			Status:        "closed",
-		})
-
 		disbursement.PaymentReleasedAt = time.Now().Format("2006-01-02 15:04:05")
-		disbursement.Status = "completed"
-		err := disbursement.UpdateAllFields(db.Payment)
+		err := payment.TransitionDisbursement(db, &disbursement, payment.DisbursementCompleted, disbursementCheckActor, log)
 		if err != nil {
 			extReq.Logger.Error(fmt.Sprintf("error updating disbursement %v; error: %v", disbursement.DisbursementID, err.Error()))
+			return
 		}
-		_, err = payment.DebitWallet(extReq, db, amount, disbursement.DebitCurrency, disbursement.RecipientID, "yes", transaction.TransactionID)
-		if err != nil {
-			if err != nil {
-				extReq.Logger.Error(fmt.Sprintf("error debiting wallet for user %v, amount:%v; currency: %v; disbursementId:%v; error: %v", disbursement.RecipientID, amount, disbursement.DebitCurrency, disbursement.DisbursementID, err.Error()))
-			}
-		}
+		payment.CloseDisbursedTransaction(extReq, db, disbursement, paymnt, transaction)
 
-		if strings.EqualFold(disbursement.Type, "refund") {
-			extReq.SendExternalRequest(request.SuccessfulRefundNotification, external_models.OnlyTransactionIDAndAccountIDRequest{
-				TransactionID: paymnt.TransactionID,
-				AccountID:     disbursement.RecipientID,
-			})
-		} else {
-			extReq.SendExternalRequest(request.EscrowDisbursedSellerNotification, external_models.OnlyTransactionIDRequiredRequest{
-				TransactionID: paymnt.TransactionID,
-			})
-			extReq.SendExternalRequest(request.EscrowDisbursedBuyerNotification, external_models.OnlyTransactionIDRequiredRequest{
-				TransactionID: paymnt.TransactionID,
-			})
-			extReq.SendExternalRequest(request.TransactionClosedBuyerNotification, external_models.OnlyTransactionIDRequiredRequest{
-				TransactionID: paymnt.TransactionID,
-			})
-			extReq.SendExternalRequest(request.TransactionClosedSellerNotification, external_models.OnlyTransactionIDRequiredRequest{
-				TransactionID: paymnt.TransactionID,
-			})
-		}
-
-		err = payment.SlackNotify(extReq, disbursementChannelD, `
-		 	Payment Disbursement For Transaction #`+paymnt.TransactionID+` has been completed successfully.
-            Environment: `+config.GetConfig().App.Name+`
-            Account ID: `+fmt.Sprintf("%v", disbursement.RecipientID)+`
-            Beneficiary Name: `+disbursement.BeneficiaryName+`
-            Amount: `+fmt.Sprintf("%v %v", disbursement.DebitCurrency, disbursement.Amount)+`
-            Status: COMPLETED
-			`)
-		if err != nil && !extReq.Test {
-			extReq.Logger.Error("error sending notification to slack: ", err.Error())
+	} else if strings.EqualFold(statusString, "failed") {
+		outcome, err := payment.HandleDisbursementFailure(extReq, db, &disbursement, disbursementCheckActor, nil, log)
+		if err != nil {
+			extReq.Logger.Error(fmt.Sprintf("error updating disbursement %v; error: %v", disbursement.DisbursementID, err.Error()))
+			return
 		}
-		extReq.Logger.Info(fmt.Sprintf("%v: Payment disbursement completed", disbursement.Reference))
 
-	} else if strings.EqualFold(statusString, "failed") {
-		if tries > maxTries {
+		// dead-lettered disbursements have already been alerted, the transaction waits for manual disbursement
+		if outcome == payment.DisbursementDeadLetter {
 			extReq.SendExternalRequest(request.TransactionUpdateStatus, external_models.UpdateTransactionStatusRequest{
 				AccountID:     int(paymnt.AccountID),
 				TransactionID: transaction.TransactionID,
 				MilestoneID:   transaction.MilestoneID,
 				Status:        "cmdp",
 			})
+			return
 		}
 
 		err = payment.SlackNotify(extReq, disbursementChannelD, `
@@ -276,28 +264,29 @@ func transConfirm(extReq request.ExternalRequest, db postgresql.Databases, disbu
 
 	} else if strings.EqualFold(statusString, "null") {
 		disbursement.PaymentReleasedAt = time.Now().Format("2006-01-02 15:04:05")
-		disbursement.Status = "failed"
-		err := disbursement.UpdateAllFields(db.Payment)
+		err := payment.TransitionDisbursement(db, &disbursement, payment.DisbursementFailed, disbursementCheckActor, log)
 		if err != nil {
 			extReq.Logger.Error(fmt.Sprintf("error updating disbursement %v; error: %v", disbursement.DisbursementID, err.Error()))
+			return
 		}
+		payment.SettleDisbursementHold(extReq, db, disbursement.Reference, false)
 		extReq.Logger.Info(fmt.Sprintf("%v: Payment disbursement null or not found", disbursement.Reference))
 
 	} else if strings.EqualFold(statusString, "new") {
 		disbursement.PaymentReleasedAt = time.Now().Format("2006-01-02 15:04:05")
-		disbursement.Status = "new"
-		err := disbursement.UpdateAllFields(db.Payment)
+		err := payment.TransitionDisbursement(db, &disbursement, payment.DisbursementNew, disbursementCheckActor, log)
 		if err != nil {
 			extReq.Logger.Error(fmt.Sprintf("error updating disbursement %v; error: %v", disbursement.DisbursementID, err.Error()))
+			return
 		}
 		extReq.Logger.Info(fmt.Sprintf("%v: Payment disbursement is NEW", disbursement.Reference))
 
 	} else if strings.EqualFold(statusString, "pending") {
 		disbursement.PaymentReleasedAt = time.Now().Format("2006-01-02 15:04:05")
-		disbursement.Status = "pending"
-		err := disbursement.UpdateAllFields(db.Payment)
+		err := payment.TransitionDisbursement(db, &disbursement, payment.DisbursementPending, disbursementCheckActor, log)
 		if err != nil {
 			extReq.Logger.Error(fmt.Sprintf("error updating disbursement %v; error: %v", disbursement.DisbursementID, err.Error()))
+			return
 		}
 		extReq.Logger.Info(fmt.Sprintf("%v: Payment disbursement is Pending", disbursement.Reference))
 
@@ -306,34 +295,3 @@ func transConfirm(extReq request.ExternalRequest, db postgresql.Databases, disbu
 	}
 
 }
-
-func decideTries(extReq request.ExternalRequest, db postgresql.Databases, disbursement *models.Disbursement) (int, bool) {
-	var (
-		tries    = disbursement.Tries
-		baseTime time.Time
-	)
-
-	disbursement.Tries += 1
-	err := disbursement.UpdateAllFields(db.Payment)
-	if err != nil {
-		extReq.Logger.Error(fmt.Sprintf("error updating disbursement %v; error: %v", disbursement.DisbursementID, err.Error()))
-	}
-
-	if disbursement.TryAgainAt == baseTime {
-		disbursement.TryAgainAt = time.Now().Add(24 * time.Hour)
-		err := disbursement.UpdateAllFields(db.Payment)
-		if err != nil {
-			extReq.Logger.Error(fmt.Sprintf("error updating disbursement %v; error: %v", disbursement.DisbursementID, err.Error()))
-		}
-	} else {
-		if disbursement.TryAgainAt.After(time.Now()) {
-			return tries, false
-		}
-	}
-
-	if tries > maxTries {
-		return tries, false
-	}
-
-	return tries, true
-}
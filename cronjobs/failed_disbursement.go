@@ -0,0 +1,50 @@
+package cronjobs
+
+import (
+	"fmt"
+
+	"github.com/vesicash/payment-ms/external/request"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"github.com/vesicash/payment-ms/services/payment"
+)
+
+// RetryFailedDisbursements re-evaluates the restrictions of each failed disbursement and runs the disbursement again
+// for the ones that have been cleared.
+func RetryFailedDisbursements(extReq request.ExternalRequest, db postgresql.Databases, ids []uint) []models.RetryFailedDisbursementResult {
+	results := []models.RetryFailedDisbursementResult{}
+	for _, id := range ids {
+		results = append(results, retryFailedDisbursement(extReq, db, id))
+	}
+	return results
+}
+
+func retryFailedDisbursement(extReq request.ExternalRequest, db postgresql.Databases, id uint) models.RetryFailedDisbursementResult {
+	failedDisbursement, transaction, _, err := payment.ReEvaluateFailedDisbursementService(extReq, db, id)
+	result := models.RetryFailedDisbursementResult{
+		ID:               id,
+		PaymentID:        failedDisbursement.PaymentID,
+		ResolutionStatus: failedDisbursement.ResolutionStatus,
+		Reasons:          failedDisbursement.Reasons,
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	if failedDisbursement.ResolutionStatus != payment.FailedDisbursementCleared {
+		result.Error = fmt.Sprintf("seller still has restrictions: %v", failedDisbursement.Reasons)
+		return result
+	}
+
+	err = runDisbursement(extReq, db, transaction)
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	_, err = failedDisbursement.GetFailedDisbursementByID(db.Payment)
+	if err == nil {
+		result.ResolutionStatus = failedDisbursement.ResolutionStatus
+	}
+	return result
+}
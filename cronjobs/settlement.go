@@ -0,0 +1,238 @@
+package cronjobs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vesicash/payment-ms/external/request"
+	"github.com/vesicash/payment-ms/internal/config"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"github.com/vesicash/payment-ms/services/payment"
+	"github.com/vesicash/payment-ms/utility"
+	"gorm.io/gorm"
+)
+
+var (
+	settlementActor = "cron:settlement"
+)
+
+// releaseSettlementBatches pays out every open settlement batch whose window has opened.
+func releaseSettlementBatches(extReq request.ExternalRequest, db postgresql.Databases) {
+	batch := models.SettlementBatch{}
+	batches, err := batch.GetDueSettlementBatches(db.Payment, time.Now())
+	if err != nil {
+		extReq.Logger.Error(fmt.Sprintf("error getting due settlement batches, err: %v", err.Error()))
+		return
+	}
+
+	for _, batch := range batches {
+		err := postgresql.RunWithAdvisoryLock(db.Payment, "settlement-batch:"+batch.BatchID, func(tx *gorm.DB) error {
+			return releaseSettlementBatch(extReq, db, batch.BatchID)
+		})
+		if err != nil {
+			extReq.Logger.Error(fmt.Sprintf("error releasing settlement batch %v; err: %v", batch.BatchID, err.Error()))
+		}
+	}
+}
+
+func releaseSettlementBatch(extReq request.ExternalRequest, db postgresql.Databases, batchID string) error {
+	var (
+		disbursementChannelD = config.GetConfig().Slack.DisbursementChannelID
+		batch                = models.SettlementBatch{BatchID: batchID}
+	)
+
+	_, err := batch.GetSettlementBatchByBatchID(db.Payment)
+	if err != nil {
+		return err
+	}
+	if batch.Status != "open" {
+		return nil
+	}
+
+	item := models.SettlementBatchItem{BatchID: batch.BatchID}
+	items, err := item.GetSettlementBatchItemsByBatchID(db.Payment)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	transaction, err := payment.ListTransactionsByID(extReq, items[0].TransactionID)
+	if err != nil {
+		return fmt.Errorf("error getting transaction %v: %v", items[0].TransactionID, err.Error())
+	}
+
+	recipient := payment.GetDisbursementRecipient(extReq, transaction, batch.Currency)
+	var (
+		user        = recipient.User
+		bankDetails = recipient.BankDetails
+		isMomo      = recipient.IsMomo
+		reason      string
+	)
+	if len(recipient.Restricted) > 0 {
+		reason = strings.Join(recipient.Restricted, ", ")
+	} else if bankDetails.ID == 0 {
+		reason = fmt.Sprintf("recipient does not have bank details on file for currency %v", batch.Currency)
+	}
+	if reason != "" {
+		err = payment.PostponeSettlementBatch(db, &batch, reason)
+		if err != nil {
+			return err
+		}
+		extReq.Logger.Info(fmt.Sprintf("settlement batch %v postponed to %v: %v", batch.BatchID, batch.WindowOpensAt.Format(time.RFC3339), reason))
+		return nil
+	}
+
+	var (
+		bankCode      string
+		accountNumber = bankDetails.AccountNo
+		amount        = batch.TotalAmount
+		narration     = "Vesicash Settlement"
+	)
+
+	if isMomo {
+		bankCode = bankDetails.MobileMoneyOperator
+		if bankDetails.MobileMoneyNumber != "" {
+			accountNumber = bankDetails.MobileMoneyNumber
+		}
+	} else {
+		bank, err := payment.GetBank(extReq, bankDetails.BankID, "", "", "")
+		if err != nil {
+			return fmt.Errorf("bank with id %v not found, error: %v", bankDetails.BankID, err.Error())
+		}
+		bankCode = bank.Code
+	}
+
+	gateways := payment.DisbursementGatewayOrder(db, batch.BusinessID, transaction.Country.CountryCode, payment.DefaultGateway)
+	if isMomo {
+		gateways = payment.AvailableGateways("rave_momo")
+	}
+	if len(gateways) == 0 {
+		return fmt.Errorf("settlement batch %v: no disbursement gateway available", batch.BatchID)
+	}
+
+	disbursement := models.Disbursement{
+		RecipientID:       batch.RecipientID,
+		PaymentID:         batch.BatchID,
+		DisbursementID:    utility.GetRandomNumbersInRange(1000000000, 9999999999),
+		Reference:         strconv.Itoa(utility.GetRandomNumbersInRange(1000000000, 9999999999)),
+		Currency:          batch.Currency,
+		BusinessID:        batch.BusinessID,
+		Amount:            fmt.Sprintf("%v", amount),
+		Narration:         narration,
+		CallbackUrl:       utility.GenerateGroupByURL(config.GetConfig().App.Url, "/disbursement/callback", map[string]string{}),
+		BeneficiaryName:   bankDetails.AccountName,
+		BankAccountNumber: accountNumber,
+		BankName:          bankDetails.BankName,
+		BankCode:          bankCode,
+		DebitCurrency:     batch.Currency,
+		Gateway:           gateways[0],
+		Status:            "pending",
+		Type:              payment.SettlementDisbursementType,
+	}
+	err = disbursement.CreateDisbursement(db.Payment)
+	if err != nil {
+		return fmt.Errorf("error creating disbursement %v", err.Error())
+	}
+
+	releasedAt := time.Now()
+	batch.Status = "released"
+	batch.DisbursementID = disbursement.DisbursementID
+	batch.ReleasedAt = &releasedAt
+	err = batch.UpdateAllFields(db.Payment)
+	if err != nil {
+		return err
+	}
+
+	if !strings.EqualFold(batch.Currency, "NGN") && !(isMomo && payment.IsRaveMomoCurrency(batch.Currency)) {
+		err := payment.TransitionDisbursement(db, &disbursement, payment.DisbursementManual, settlementActor, map[string]interface{}{"batch_id": batch.BatchID, "currency": batch.Currency})
+		if err != nil {
+			return err
+		}
+		err = payment.SlackNotify(extReq, disbursementChannelD, `
+		 	Settlement Batch #`+batch.BatchID+` requires manual check.
+            Environment: `+config.GetConfig().App.Name+`
+            Account ID: `+fmt.Sprintf("%v", disbursement.RecipientID)+`
+            Beneficiary Name: `+disbursement.BeneficiaryName+`
+            Amount: `+fmt.Sprintf("%v %v", disbursement.DebitCurrency, disbursement.Amount)+`
+            Payments: `+fmt.Sprintf("%v", batch.PaymentCount)+`
+            Status: REVIEW
+			`)
+		if err != nil && !extReq.Test {
+			extReq.Logger.Error("error sending notification to slack: ", err.Error())
+		}
+		return nil
+	}
+
+	awaitingApproval, err := payment.RequireDisbursementApproval(extReq, db, &disbursement, amount, false, "")
+	if err != nil || awaitingApproval {
+		return err
+	}
+
+	sellerProfile, _ := payment.GetBusinessProfileByAccountID(extReq, extReq.Logger, int(user.AccountID))
+	held, err := payment.VerifyDisbursementBeneficiary(extReq, db, &disbursement, fmt.Sprintf("%v %v", user.Firstname, user.Lastname), sellerProfile.BusinessName)
+	if err != nil || held {
+		return err
+	}
+
+	queued, err := payment.QueueDisbursementTransfer(extReq, db, &disbursement, gateways, settlementActor)
+	if err != nil || queued {
+		return err
+	}
+
+	_, resData, err := payment.InitTransferWithFailover(extReq, gateways, payment.GatewayTransferRequest{
+		BankCode:      bankCode,
+		AccountNumber: accountNumber,
+		AccountName:   fmt.Sprintf("%v %v", user.Firstname, user.Lastname),
+		Narration:     narration,
+		Currency:      batch.Currency,
+		Reference:     disbursement.Reference,
+		Amount:        amount,
+	}, func(gateway string) error {
+		disbursement.Gateway = gateway
+		return disbursement.UpdateFields(db.Payment, "gateway")
+	})
+	if err != nil {
+		return err
+	}
+	disbursement.GatewayReference = resData.Reference
+
+	payment.LogDisbursement(db, disbursement.DisbursementID, resData.Response)
+	err = payment.TransitionDisbursement(db, &disbursement, payment.DisbursementPending, settlementActor, resData.Response)
+	if err != nil {
+		return err
+	}
+
+	extReq.Logger.Info(fmt.Sprintf("settlement batch %v of %v payments released as disbursement %v", batch.BatchID, batch.PaymentCount, disbursement.DisbursementID))
+	return nil
+}
+
+// settlementConfirm follows up the gateway status of a settlement disbursement, failures go through the retry policy like any payout.
+func settlementConfirm(extReq request.ExternalRequest, db postgresql.Databases, disbursement models.Disbursement, statusString string, log interface{}) {
+	switch strings.ToLower(statusString) {
+	case "completed":
+		disbursement.PaymentReleasedAt = time.Now().Format("2006-01-02 15:04:05")
+		err := payment.TransitionDisbursement(db, &disbursement, payment.DisbursementCompleted, disbursementCheckActor, log)
+		if err != nil {
+			extReq.Logger.Error(fmt.Sprintf("error updating disbursement %v; error: %v", disbursement.DisbursementID, err.Error()))
+			return
+		}
+		payment.CompleteSettlementBatch(extReq, db, disbursement)
+		extReq.Logger.Info(fmt.Sprintf("%v: settlement batch %v paid out", disbursement.Reference, disbursement.PaymentID))
+
+	case "failed", "null":
+		_, err := payment.HandleDisbursementFailure(extReq, db, &disbursement, disbursementCheckActor, nil, log)
+		if err != nil {
+			extReq.Logger.Error(fmt.Sprintf("error updating disbursement %v; error: %v", disbursement.DisbursementID, err.Error()))
+			return
+		}
+		extReq.Logger.Info(fmt.Sprintf("%v: settlement disbursement failed, now %v", disbursement.Reference, disbursement.Status))
+
+	default:
+		extReq.Logger.Info(fmt.Sprintf("%v: settlement disbursement is %v", disbursement.Reference, statusString))
+	}
+}
@@ -0,0 +1,36 @@
+package external_models
+
+type CampayGetTokenRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+type CampayGetTokenResponse struct {
+	Token     string `json:"token"`
+	ExpiresIn int    `json:"expires_in"`
+}
+
+type CampayInitPaymentRequest struct {
+	Amount             string `json:"amount"`
+	Currency           string `json:"currency"`
+	Description        string `json:"description"`
+	ExternalReference  string `json:"external_reference"`
+	RedirectUrl        string `json:"redirect_url"`
+	FailureRedirectUrl string `json:"failure_redirect_url"`
+	PaymentOptions     string `json:"payment_options"`
+}
+type CampayInitPaymentResponse struct {
+	Link string `json:"link"`
+}
+
+type CampayTransactionStatusResponse struct {
+	Reference         string  `json:"reference"`
+	ExternalReference string  `json:"external_reference"`
+	Status            string  `json:"status"`
+	Amount            float64 `json:"amount"`
+	Currency          string  `json:"currency"`
+	Operator          string  `json:"operator"`
+	Code              string  `json:"code"`
+	OperatorReference string  `json:"operator_reference"`
+	Description       string  `json:"description"`
+	Reason            string  `json:"reason"`
+}
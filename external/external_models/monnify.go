@@ -204,3 +204,56 @@ type MonnifyInitTransferResponseBody struct {
 	DestinationAccountNumber string  `json:"destinationAccountNumber"`
 	DestinationBankCode      string  `json:"destinationBankCode"`
 }
+
+type MonnifyInitBulkTransferRequest struct {
+	Title                string                           `json:"title"`
+	BatchReference       string                           `json:"batchReference"`
+	Narration            string                           `json:"narration"`
+	SourceAccountNumber  string                           `json:"sourceAccountNumber"`
+	OnValidationFailure  string                           `json:"onValidationFailure"`
+	NotificationInterval int                              `json:"notificationInterval"`
+	TransactionList      []MonnifyBulkTransferRequestItem `json:"transactionList"`
+}
+
+type MonnifyBulkTransferRequestItem struct {
+	Amount                   float64 `json:"amount"`
+	Reference                string  `json:"reference"`
+	Narration                string  `json:"narration"`
+	DestinationBankCode      string  `json:"destinationBankCode"`
+	DestinationAccountNumber string  `json:"destinationAccountNumber"`
+	Currency                 string  `json:"currency"`
+}
+
+type MonnifyInitBulkTransferResponse struct {
+	RequestSuccessful bool                                `json:"requestSuccessful"`
+	ResponseMessage   string                              `json:"responseMessage"`
+	ResponseCode      string                              `json:"responseCode"`
+	ResponseBody      MonnifyInitBulkTransferResponseBody `json:"responseBody"`
+}
+type MonnifyInitBulkTransferResponseBody struct {
+	TotalAmount            float64 `json:"totalAmount"`
+	TotalFee               float64 `json:"totalFee"`
+	BatchReference         string  `json:"batchReference"`
+	BatchStatus            string  `json:"batchStatus"`
+	TotalTransactionsCount int     `json:"totalTransactionsCount"`
+	DateCreated            string  `json:"dateCreated"`
+}
+
+type MonnifyGetBulkTransferRequest struct {
+	BatchReference string `json:"batchReference"`
+	PageNo         int    `json:"pageNo"`
+	PageSize       int    `json:"pageSize"`
+}
+
+type MonnifyGetBulkTransferResponse struct {
+	RequestSuccessful bool                               `json:"requestSuccessful"`
+	ResponseMessage   string                             `json:"responseMessage"`
+	ResponseCode      string                             `json:"responseCode"`
+	ResponseBody      MonnifyGetBulkTransferResponseBody `json:"responseBody"`
+}
+type MonnifyGetBulkTransferResponseBody struct {
+	Content       []MonnifyInitTransferResponseBody `json:"content"`
+	TotalElements int                               `json:"totalElements"`
+	TotalPages    int                               `json:"totalPages"`
+	Last          bool                              `json:"last"`
+}
@@ -0,0 +1,130 @@
+package external_models
+
+type PaystackInitPaymentRequest struct {
+	Email       string `json:"email"`
+	Amount      int64  `json:"amount"`
+	Currency    string `json:"currency"`
+	Reference   string `json:"reference"`
+	CallbackUrl string `json:"callback_url"`
+}
+type PaystackInitPaymentResponse struct {
+	Status  bool                            `json:"status"`
+	Message string                          `json:"message"`
+	Data    PaystackInitPaymentResponseData `json:"data"`
+}
+type PaystackInitPaymentResponseData struct {
+	AuthorizationUrl string `json:"authorization_url"`
+	AccessCode       string `json:"access_code"`
+	Reference        string `json:"reference"`
+}
+
+type PaystackVerifyTransactionResponse struct {
+	Status  bool                                  `json:"status"`
+	Message string                                `json:"message"`
+	Data    PaystackVerifyTransactionResponseData `json:"data"`
+}
+type PaystackVerifyTransactionResponseData struct {
+	ID              int64                          `json:"id"`
+	Domain          string                         `json:"domain"`
+	Status          string                         `json:"status"`
+	Reference       string                         `json:"reference"`
+	Amount          int64                          `json:"amount"`
+	GatewayResponse string                         `json:"gateway_response"`
+	PaidAt          string                         `json:"paid_at"`
+	CreatedAt       string                         `json:"created_at"`
+	Channel         string                         `json:"channel"`
+	Currency        string                         `json:"currency"`
+	Fees            int64                          `json:"fees"`
+	Authorization   *PaystackAuthorization         `json:"authorization"`
+	Customer        *PaystackVerifyCustomerDetails `json:"customer"`
+}
+type PaystackAuthorization struct {
+	AuthorizationCode string `json:"authorization_code"`
+	Bin               string `json:"bin"`
+	Last4             string `json:"last4"`
+	ExpMonth          string `json:"exp_month"`
+	ExpYear           string `json:"exp_year"`
+	Channel           string `json:"channel"`
+	CardType          string `json:"card_type"`
+	Bank              string `json:"bank"`
+	CountryCode       string `json:"country_code"`
+	Brand             string `json:"brand"`
+	Reusable          bool   `json:"reusable"`
+}
+type PaystackVerifyCustomerDetails struct {
+	ID           int64  `json:"id"`
+	Email        string `json:"email"`
+	CustomerCode string `json:"customer_code"`
+}
+
+type PaystackCreateTransferRecipientRequest struct {
+	Type          string `json:"type"`
+	Name          string `json:"name"`
+	AccountNumber string `json:"account_number"`
+	BankCode      string `json:"bank_code"`
+	Currency      string `json:"currency"`
+}
+type PaystackCreateTransferRecipientResponse struct {
+	Status  bool                                        `json:"status"`
+	Message string                                      `json:"message"`
+	Data    PaystackCreateTransferRecipientResponseData `json:"data"`
+}
+type PaystackCreateTransferRecipientResponseData struct {
+	ID            int64  `json:"id"`
+	Type          string `json:"type"`
+	Name          string `json:"name"`
+	Currency      string `json:"currency"`
+	RecipientCode string `json:"recipient_code"`
+	Active        bool   `json:"active"`
+}
+
+type PaystackInitTransferRequest struct {
+	Source    string `json:"source"`
+	Amount    int64  `json:"amount"`
+	Recipient string `json:"recipient"`
+	Reason    string `json:"reason"`
+	Currency  string `json:"currency"`
+	Reference string `json:"reference"`
+}
+type PaystackInitTransferResponse struct {
+	Status  bool                             `json:"status"`
+	Message string                           `json:"message"`
+	Data    PaystackInitTransferResponseData `json:"data"`
+}
+type PaystackInitTransferResponseData struct {
+	ID           int64  `json:"id"`
+	Reference    string `json:"reference"`
+	TransferCode string `json:"transfer_code"`
+	Amount       int64  `json:"amount"`
+	Currency     string `json:"currency"`
+	Reason       string `json:"reason"`
+	Status       string `json:"status"`
+	CreatedAt    string `json:"createdAt"`
+}
+
+type PaystackListBanksResponse struct {
+	Status  bool                    `json:"status"`
+	Message string                  `json:"message"`
+	Data    []PaystackBanksResponse `json:"data"`
+}
+type PaystackBanksResponse struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Slug     string `json:"slug"`
+	Code     string `json:"code"`
+	Country  string `json:"country"`
+	Currency string `json:"currency"`
+	Type     string `json:"type"`
+	Active   bool   `json:"active"`
+}
+
+type PaystackResolveAccountResponse struct {
+	Status  bool                               `json:"status"`
+	Message string                             `json:"message"`
+	Data    PaystackResolveAccountResponseData `json:"data"`
+}
+type PaystackResolveAccountResponseData struct {
+	AccountNumber string `json:"account_number"`
+	AccountName   string `json:"account_name"`
+	BankID        int    `json:"bank_id"`
+}
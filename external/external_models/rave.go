@@ -175,6 +175,25 @@ type RaveInitTransferRequestMeta struct {
 	RecipientAddress string `json:"recipient_address"`
 }
 
+type RaveInitMomoTransferRequest struct {
+	AccountBank     string                           `json:"account_bank"`
+	AccountNumber   string                           `json:"account_number"`
+	Amount          float64                          `json:"amount"`
+	Narration       string                           `json:"narration"`
+	Currency        string                           `json:"currency"`
+	BeneficiaryName string                           `json:"beneficiary_name"`
+	Reference       string                           `json:"reference"`
+	DebitCurrency   string                           `json:"debit_currency"`
+	CallbackUrl     string                           `json:"callback_url,omitempty"`
+	Meta            *RaveInitMomoTransferRequestMeta `json:"meta,omitempty"`
+}
+
+type RaveInitMomoTransferRequestMeta struct {
+	Sender        string `json:"sender"`
+	SenderCountry string `json:"sender_country"`
+	MobileNumber  string `json:"mobile_number"`
+}
+
 type RaveInitTransferResponse struct {
 	Status  string                       `json:"status"`
 	Message string                       `json:"message"`
@@ -199,3 +218,60 @@ type RaveInitTransferResponseData struct {
 	IsApproved       int                    `json:"is_approved"`
 	BankName         string                 `json:"bank_name"`
 }
+
+type RaveGetTransferResponse struct {
+	Status  string                       `json:"status"`
+	Message string                       `json:"message"`
+	Data    RaveInitTransferResponseData `json:"data"`
+}
+
+type RaveListTransfersResponse struct {
+	Status  string                         `json:"status"`
+	Message string                         `json:"message"`
+	Data    []RaveInitTransferResponseData `json:"data"`
+}
+
+type RaveInitBulkTransferRequest struct {
+	Title    string                    `json:"title"`
+	BulkData []RaveBulkTransferRequest `json:"bulk_data"`
+}
+
+type RaveBulkTransferRequest struct {
+	BankCode      string  `json:"bank_code"`
+	AccountNumber string  `json:"account_number"`
+	Amount        float64 `json:"amount"`
+	Currency      string  `json:"currency"`
+	Narration     string  `json:"narration"`
+	Reference     string  `json:"reference"`
+	CallbackUrl   string  `json:"callback_url,omitempty"`
+}
+
+type RaveInitBulkTransferResponse struct {
+	Status  string                           `json:"status"`
+	Message string                           `json:"message"`
+	Data    RaveInitBulkTransferResponseData `json:"data"`
+}
+type RaveInitBulkTransferResponseData struct {
+	ID        uint   `json:"id"`
+	CreatedAt string `json:"created_at"`
+	Approver  string `json:"approver"`
+}
+
+type RaveGetBulkTransferRequest struct {
+	BatchID string `json:"batch_id"`
+	Page    int    `json:"page"`
+}
+
+type RaveGetBulkTransferResponse struct {
+	Status  string                         `json:"status"`
+	Message string                         `json:"message"`
+	Meta    RaveGetBulkTransferMeta        `json:"meta"`
+	Data    []RaveInitTransferResponseData `json:"data"`
+}
+type RaveGetBulkTransferMeta struct {
+	PageInfo struct {
+		Total       int `json:"total"`
+		CurrentPage int `json:"current_page"`
+		TotalPages  int `json:"total_pages"`
+	} `json:"page_info"`
+}
@@ -0,0 +1,74 @@
+package external
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// GatewayObserver is called with every request a payment gateway sends, under the name the gateway reports for itself.
+// statusCode is 0 when no response was received.
+type GatewayObserver func(gateway, name string, statusCode int, latency time.Duration, err error)
+
+var (
+	observerMutex    = &sync.RWMutex{}
+	gatewayObservers = []GatewayObserver{}
+
+	externalRequestCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "external_requests_total",
+			Help: "Total number of requests sent to external services.",
+		},
+		[]string{"name", "status"},
+	)
+
+	externalRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "external_request_duration_seconds",
+			Help:    "Duration of requests sent to external services.",
+			Buckets: []float64{0.1, 0.5, 1, 2.5, 5, 10, 30},
+		},
+		[]string{"name"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(externalRequestCount)
+	prometheus.MustRegister(externalRequestDuration)
+}
+
+func AddGatewayObserver(observer GatewayObserver) {
+	observerMutex.Lock()
+	defer observerMutex.Unlock()
+	gatewayObservers = append(gatewayObservers, observer)
+}
+
+func ObserveGatewayRequest(gateway, name string, latency time.Duration, err error) {
+	statusCode := http.StatusOK
+	if err != nil {
+		statusCode = 0
+		var reqErr *RequestError
+		if errors.As(err, &reqErr) {
+			statusCode = reqErr.Code
+		}
+	}
+
+	observerMutex.RLock()
+	defer observerMutex.RUnlock()
+	for _, observer := range gatewayObservers {
+		observer(gateway, name, statusCode, latency, err)
+	}
+}
+
+func observeRequest(name string, statusCode int, latency time.Duration) {
+	status := strconv.Itoa(statusCode)
+	if statusCode == 0 {
+		status = "error"
+	}
+	externalRequestCount.WithLabelValues(name, status).Inc()
+	externalRequestDuration.WithLabelValues(name).Observe(latency.Seconds())
+}
@@ -20,10 +20,18 @@ func GetBank(logger *utility.Logger, idata interface{}) (external_models.Bank, e
 	}
 	logger.Info("get bank", outBoundResponse)
 
-	return external_models.Bank{
+	bank := external_models.Bank{
 		ID:      data.ID,
 		Code:    "221",
 		Name:    "vesicash bank",
 		Country: "NG",
-	}, nil
+	}
+	// banks looked up by code are always found
+	if data.Code != "" {
+		bank.Code = data.Code
+		if bank.ID == 0 {
+			bank.ID = 1
+		}
+	}
+	return bank, nil
 }
@@ -0,0 +1,53 @@
+package campay_mocks
+
+import (
+	"fmt"
+
+	"github.com/vesicash/payment-ms/external/external_models"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+func CampayInitPayment(logger *utility.Logger, idata interface{}) (external_models.CampayInitPaymentResponse, error) {
+
+	var (
+		outBoundResponse external_models.CampayInitPaymentResponse
+	)
+
+	data, ok := idata.(external_models.CampayInitPaymentRequest)
+	if !ok {
+		logger.Error("init payment campay", idata, "request data format error")
+		return outBoundResponse, fmt.Errorf("request data format error")
+	}
+
+	logger.Info("init payment campay", outBoundResponse, data)
+
+	return external_models.CampayInitPaymentResponse{
+		Link: "https://demo.campay.net/pay/" + data.ExternalReference,
+	}, nil
+}
+
+func CampayTransactionStatus(logger *utility.Logger, idata interface{}) (external_models.CampayTransactionStatusResponse, error) {
+
+	var (
+		outBoundResponse external_models.CampayTransactionStatusResponse
+	)
+
+	data, ok := idata.(string)
+	if !ok {
+		logger.Error("campay transaction status", idata, "request data format error")
+		return outBoundResponse, fmt.Errorf("request data format error")
+	}
+
+	logger.Info("campay transaction status", outBoundResponse)
+
+	return external_models.CampayTransactionStatusResponse{
+		Reference:         "bcedde9b-62a7-4421-96ac-2e6179552a1a",
+		ExternalReference: data,
+		Status:            "SUCCESSFUL",
+		Amount:            200,
+		Currency:          "XAF",
+		Operator:          "MTN",
+		Code:              "CP201027T00005",
+		OperatorReference: "1880106956",
+	}, nil
+}
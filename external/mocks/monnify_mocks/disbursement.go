@@ -2,11 +2,20 @@ package monnify_mocks
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/vesicash/payment-ms/external/external_models"
 	"github.com/vesicash/payment-ms/utility"
 )
 
+var (
+	// BulkTransferStatus is the status every transfer of a mocked bulk transfer reports
+	BulkTransferStatus = "SUCCESS"
+
+	bulkTransferMutex = &sync.Mutex{}
+	bulkTransfers     = map[string][]external_models.MonnifyInitTransferResponseBody{}
+)
+
 func MonnifyInitTransfer(logger *utility.Logger, idata interface{}) (external_models.MonnifyInitTransferResponse, error) {
 
 	var (
@@ -36,3 +45,96 @@ func MonnifyInitTransfer(logger *utility.Logger, idata interface{}) (external_mo
 		},
 	}, nil
 }
+
+func MonnifyGetTransferSummary(logger *utility.Logger, idata interface{}) (external_models.MonnifyInitTransferResponseBody, error) {
+
+	var (
+		outBoundResponse external_models.MonnifyInitTransferResponseBody
+	)
+
+	data, ok := idata.(string)
+	if !ok {
+		logger.Error("monnify get transfer summary", idata, "request data format error")
+		return outBoundResponse, fmt.Errorf("request data format error")
+	}
+
+	return external_models.MonnifyInitTransferResponseBody{
+		Amount:      200,
+		Reference:   data,
+		Status:      "SUCCESS",
+		DateCreated: "2022-11-03T14:11:12.659+0000",
+	}, nil
+}
+
+func MonnifyInitBulkTransfer(logger *utility.Logger, idata interface{}) (external_models.MonnifyInitBulkTransferResponse, error) {
+
+	var (
+		outBoundResponse external_models.MonnifyInitBulkTransferResponse
+		totalAmount      float64
+	)
+
+	data, ok := idata.(external_models.MonnifyInitBulkTransferRequest)
+	if !ok {
+		logger.Error("monnify init bulk transfer", idata, "request data format error")
+		return outBoundResponse, fmt.Errorf("request data format error")
+	}
+
+	bulkTransferMutex.Lock()
+	defer bulkTransferMutex.Unlock()
+	transfers := []external_models.MonnifyInitTransferResponseBody{}
+	for _, item := range data.TransactionList {
+		totalAmount += item.Amount
+		transfers = append(transfers, external_models.MonnifyInitTransferResponseBody{
+			Amount:                   item.Amount,
+			Reference:                item.Reference,
+			DateCreated:              "2022-11-03T14:11:12.659+0000",
+			DestinationAccountNumber: item.DestinationAccountNumber,
+			DestinationBankCode:      item.DestinationBankCode,
+		})
+	}
+	bulkTransfers[data.BatchReference] = transfers
+
+	return external_models.MonnifyInitBulkTransferResponse{
+		RequestSuccessful: true,
+		ResponseMessage:   "success",
+		ResponseCode:      "0",
+		ResponseBody: external_models.MonnifyInitBulkTransferResponseBody{
+			TotalAmount:            totalAmount,
+			BatchReference:         data.BatchReference,
+			BatchStatus:            "AWAITING_PROCESSING",
+			TotalTransactionsCount: len(transfers),
+			DateCreated:            "2022-11-03T14:11:12.659+0000",
+		},
+	}, nil
+}
+
+func MonnifyGetBulkTransfer(logger *utility.Logger, idata interface{}) (external_models.MonnifyGetBulkTransferResponseBody, error) {
+
+	var (
+		outBoundResponse external_models.MonnifyGetBulkTransferResponseBody
+	)
+
+	data, ok := idata.(external_models.MonnifyGetBulkTransferRequest)
+	if !ok {
+		logger.Error("monnify get bulk transfer", idata, "request data format error")
+		return outBoundResponse, fmt.Errorf("request data format error")
+	}
+
+	bulkTransferMutex.Lock()
+	defer bulkTransferMutex.Unlock()
+	transfers, ok := bulkTransfers[data.BatchReference]
+	if !ok {
+		return outBoundResponse, fmt.Errorf("bulk transfer %v not found", data.BatchReference)
+	}
+
+	outBoundResponse.Content = []external_models.MonnifyInitTransferResponseBody{}
+	for _, transfer := range transfers {
+		transfer.Status = BulkTransferStatus
+		outBoundResponse.Content = append(outBoundResponse.Content, transfer)
+	}
+	outBoundResponse.TotalElements = len(transfers)
+	outBoundResponse.TotalPages = 1
+	outBoundResponse.Last = true
+
+	return outBoundResponse, nil
+}
@@ -0,0 +1,49 @@
+package paystack_mocks
+
+import (
+	"fmt"
+
+	"github.com/vesicash/payment-ms/external/external_models"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+func ListBanksWithPaystack(logger *utility.Logger, idata interface{}) ([]external_models.PaystackBanksResponse, error) {
+
+	var (
+		outBoundResponse external_models.PaystackListBanksResponse
+	)
+
+	data, ok := idata.(string)
+	if !ok {
+		logger.Error("list banks with paystack", idata, "request data format error")
+		return outBoundResponse.Data, fmt.Errorf("request data format error")
+	}
+
+	logger.Info("list banks with paystack", outBoundResponse)
+
+	return []external_models.PaystackBanksResponse{
+		{
+			ID:       1,
+			Name:     "vesicash bank",
+			Slug:     "vesicash-bank",
+			Code:     "221",
+			Country:  data,
+			Currency: "NGN",
+			Type:     "nuban",
+			Active:   true,
+		},
+	}, nil
+}
+
+func PaystackResolveBankAccount(logger *utility.Logger, idata interface{}) (string, error) {
+
+	_, ok := idata.(external_models.ResolveAccountRequest)
+	if !ok {
+		logger.Error("paystack resolve bank account", idata, "request data format error")
+		return "", fmt.Errorf("request data format error")
+	}
+
+	logger.Info("paystack resolve bank account", "test user")
+
+	return "test user", nil
+}
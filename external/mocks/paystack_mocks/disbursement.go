@@ -0,0 +1,85 @@
+package paystack_mocks
+
+import (
+	"fmt"
+
+	"github.com/vesicash/payment-ms/external/external_models"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+func PaystackCreateTransferRecipient(logger *utility.Logger, idata interface{}) (external_models.PaystackCreateTransferRecipientResponseData, error) {
+
+	var (
+		outBoundResponse external_models.PaystackCreateTransferRecipientResponse
+	)
+
+	data, ok := idata.(external_models.PaystackCreateTransferRecipientRequest)
+	if !ok {
+		logger.Error("paystack create transfer recipient", idata, "request data format error")
+		return outBoundResponse.Data, fmt.Errorf("request data format error")
+	}
+
+	logger.Info("paystack create transfer recipient", outBoundResponse)
+
+	return external_models.PaystackCreateTransferRecipientResponseData{
+		ID:            6788170,
+		Type:          data.Type,
+		Name:          data.Name,
+		Currency:      data.Currency,
+		RecipientCode: "RCP_t0ya41mp35flk40",
+		Active:        true,
+	}, nil
+}
+
+func PaystackInitTransfer(logger *utility.Logger, idata interface{}) (external_models.PaystackInitTransferResponse, error) {
+
+	var (
+		outBoundResponse external_models.PaystackInitTransferResponse
+	)
+
+	data, ok := idata.(external_models.PaystackInitTransferRequest)
+	if !ok {
+		logger.Error("paystack init transfer", idata, "request data format error")
+		return outBoundResponse, fmt.Errorf("request data format error")
+	}
+
+	logger.Info("paystack init transfer", outBoundResponse)
+
+	return external_models.PaystackInitTransferResponse{
+		Status:  true,
+		Message: "Transfer has been queued",
+		Data: external_models.PaystackInitTransferResponseData{
+			ID:           57784109,
+			Reference:    data.Reference,
+			TransferCode: "TRF_1ptvuv321ahaa7q",
+			Amount:       data.Amount,
+			Currency:     data.Currency,
+			Reason:       data.Reason,
+			Status:       "pending",
+		},
+	}, nil
+}
+
+func PaystackVerifyTransfer(logger *utility.Logger, idata interface{}) (external_models.PaystackInitTransferResponseData, error) {
+
+	var (
+		outBoundResponse external_models.PaystackInitTransferResponse
+	)
+
+	data, ok := idata.(string)
+	if !ok {
+		logger.Error("paystack verify transfer", idata, "request data format error")
+		return outBoundResponse.Data, fmt.Errorf("request data format error")
+	}
+
+	logger.Info("paystack verify transfer", outBoundResponse)
+
+	return external_models.PaystackInitTransferResponseData{
+		ID:           57784109,
+		Reference:    data,
+		TransferCode: "TRF_1ptvuv321ahaa7q",
+		Amount:       20000,
+		Currency:     "NGN",
+		Status:       "success",
+	}, nil
+}
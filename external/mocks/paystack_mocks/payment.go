@@ -0,0 +1,70 @@
+package paystack_mocks
+
+import (
+	"fmt"
+
+	"github.com/vesicash/payment-ms/external/external_models"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+func PaystackInitPayment(logger *utility.Logger, idata interface{}) (external_models.PaystackInitPaymentResponse, error) {
+
+	var (
+		outBoundResponse external_models.PaystackInitPaymentResponse
+	)
+
+	data, ok := idata.(external_models.PaystackInitPaymentRequest)
+	if !ok {
+		logger.Error("init payment paystack", idata, "request data format error")
+		return outBoundResponse, fmt.Errorf("request data format error")
+	}
+
+	logger.Info("init payment paystack", outBoundResponse, data)
+
+	return external_models.PaystackInitPaymentResponse{
+		Status:  true,
+		Message: "Authorization URL created",
+		Data: external_models.PaystackInitPaymentResponseData{
+			AuthorizationUrl: "https://checkout.paystack.com/0peioxfhpn",
+			AccessCode:       "0peioxfhpn",
+			Reference:        data.Reference,
+		},
+	}, nil
+}
+
+func PaystackVerifyTransaction(logger *utility.Logger, idata interface{}) (external_models.PaystackVerifyTransactionResponseData, error) {
+
+	var (
+		outBoundResponse external_models.PaystackVerifyTransactionResponse
+	)
+
+	data, ok := idata.(string)
+	if !ok {
+		logger.Error("paystack verify transaction", idata, "request data format error")
+		return outBoundResponse.Data, fmt.Errorf("request data format error")
+	}
+
+	logger.Info("paystack verify transaction", outBoundResponse)
+
+	return external_models.PaystackVerifyTransactionResponseData{
+		ID:              4099260516,
+		Status:          "success",
+		Reference:       data,
+		Amount:          20000,
+		GatewayResponse: "Successful",
+		Channel:         "card",
+		Currency:        "NGN",
+		Authorization: &external_models.PaystackAuthorization{
+			AuthorizationCode: "AUTH_ahisucjkru",
+			Bin:               "408408",
+			Last4:             "4081",
+			ExpMonth:          "12",
+			ExpYear:           "2030",
+			Channel:           "card",
+			CardType:          "visa",
+			CountryCode:       "NG",
+			Brand:             "visa",
+			Reusable:          true,
+		},
+	}, nil
+}
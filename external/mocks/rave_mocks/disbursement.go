@@ -2,11 +2,29 @@ package rave_mocks
 
 import (
 	"fmt"
+	"strconv"
+	"sync"
 
 	"github.com/vesicash/payment-ms/external/external_models"
 	"github.com/vesicash/payment-ms/utility"
 )
 
+var (
+	// BulkTransferStatus is the status every transfer of a mocked bulk transfer reports
+	BulkTransferStatus = "SUCCESSFUL"
+
+	// InitTransferError, when set, is returned by every mocked transfer request without creating the transfer
+	InitTransferError error
+
+	// InitBulkTransferError, when set, is returned by mocked bulk transfer requests after the bulk transfer is created,
+	// like a response lost on its way back
+	InitBulkTransferError error
+
+	bulkTransferMutex = &sync.Mutex{}
+	bulkTransfers     = map[string][]external_models.RaveInitTransferResponseData{}
+	transfers         = map[string]external_models.RaveInitTransferResponseData{}
+)
+
 func RaveInitTransfer(logger *utility.Logger, idata interface{}) (external_models.RaveInitTransferResponse, error) {
 
 	var (
@@ -19,15 +37,60 @@ func RaveInitTransfer(logger *utility.Logger, idata interface{}) (external_model
 		return outBoundResponse, fmt.Errorf("request data format error")
 	}
 
+	if InitTransferError != nil {
+		logger.Error("rave init transfer", idata, InitTransferError.Error())
+		return outBoundResponse, InitTransferError
+	}
+
 	logger.Info("rave init transfer", outBoundResponse)
 
+	transfer := external_models.RaveInitTransferResponseData{
+		ID:            262251,
+		AccountNumber: "0690000040",
+		BankCode:      "221",
+		FullName:      data.BeneficiaryName,
+		Currency:      data.Currency,
+		DebitCurrency: data.DebitCurrency,
+		Amount:        data.Amount,
+		Fee:           0,
+		Status:        "NEW",
+		Reference:     data.Reference,
+		IsApproved:    1,
+		BankName:      "vesicash bank",
+	}
+
+	bulkTransferMutex.Lock()
+	transfers[data.Reference] = transfer
+	bulkTransferMutex.Unlock()
+
+	return external_models.RaveInitTransferResponse{
+		Status:  "success",
+		Message: "Transfer Queued Successfully",
+		Data:    transfer,
+	}, nil
+}
+
+func RaveInitMomoTransfer(logger *utility.Logger, idata interface{}) (external_models.RaveInitTransferResponse, error) {
+
+	var (
+		outBoundResponse external_models.RaveInitTransferResponse
+	)
+
+	data, ok := idata.(external_models.RaveInitMomoTransferRequest)
+	if !ok {
+		logger.Error("rave init momo transfer", idata, "request data format error")
+		return outBoundResponse, fmt.Errorf("request data format error")
+	}
+
+	logger.Info("rave init momo transfer", outBoundResponse)
+
 	return external_models.RaveInitTransferResponse{
 		Status:  "success",
 		Message: "Transfer Queued Successfully",
 		Data: external_models.RaveInitTransferResponseData{
-			ID:            262251,
-			AccountNumber: "0690000040",
-			BankCode:      "221",
+			ID:            262252,
+			AccountNumber: data.AccountNumber,
+			BankCode:      data.AccountBank,
 			FullName:      data.BeneficiaryName,
 			Currency:      data.Currency,
 			DebitCurrency: data.DebitCurrency,
@@ -36,7 +99,143 @@ func RaveInitTransfer(logger *utility.Logger, idata interface{}) (external_model
 			Status:        "NEW",
 			Reference:     data.Reference,
 			IsApproved:    1,
-			BankName:      "vesicash bank",
+			BankName:      data.AccountBank,
+		},
+	}, nil
+}
+
+func RaveGetTransfer(logger *utility.Logger, idata interface{}) (external_models.RaveInitTransferResponseData, error) {
+
+	var (
+		outBoundResponse external_models.RaveGetTransferResponse
+	)
+
+	data, ok := idata.(string)
+	if !ok {
+		logger.Error("rave get transfer", idata, "request data format error")
+		return outBoundResponse.Data, fmt.Errorf("request data format error")
+	}
+
+	logger.Info("rave get transfer", outBoundResponse)
+
+	return external_models.RaveInitTransferResponseData{
+		ID:            262252,
+		AccountNumber: "233244000000",
+		BankCode:      "MTN",
+		Currency:      "GHS",
+		DebitCurrency: "GHS",
+		Amount:        200,
+		Status:        "SUCCESSFUL",
+		Reference:     data,
+		IsApproved:    1,
+	}, nil
+}
+
+func RaveListTransfers(logger *utility.Logger, idata interface{}) ([]external_models.RaveInitTransferResponseData, error) {
+
+	data, ok := idata.(string)
+	if !ok {
+		logger.Error("rave list transfers", idata, "request data format error")
+		return []external_models.RaveInitTransferResponseData{}, fmt.Errorf("request data format error")
+	}
+
+	bulkTransferMutex.Lock()
+	defer bulkTransferMutex.Unlock()
+
+	list := []external_models.RaveInitTransferResponseData{}
+	if transfer, ok := transfers[data]; ok {
+		list = append(list, transfer)
+	}
+
+	logger.Info("rave list transfers", list)
+	return list, nil
+}
+
+func RaveInitBulkTransfer(logger *utility.Logger, idata interface{}) (external_models.RaveInitBulkTransferResponse, error) {
+
+	var (
+		outBoundResponse external_models.RaveInitBulkTransferResponse
+	)
+
+	data, ok := idata.(external_models.RaveInitBulkTransferRequest)
+	if !ok {
+		logger.Error("rave init bulk transfer", idata, "request data format error")
+		return outBoundResponse, fmt.Errorf("request data format error")
+	}
+
+	bulkTransferMutex.Lock()
+	defer bulkTransferMutex.Unlock()
+	id := uint(1480 + len(bulkTransfers))
+	items := []external_models.RaveInitTransferResponseData{}
+	for _, item := range data.BulkData {
+		items = append(items, external_models.RaveInitTransferResponseData{
+			AccountNumber: item.AccountNumber,
+			BankCode:      item.BankCode,
+			Currency:      item.Currency,
+			DebitCurrency: item.Currency,
+			Amount:        item.Amount,
+			Narration:     item.Narration,
+			Reference:     item.Reference,
+			IsApproved:    1,
+		})
+	}
+	bulkTransfers[strconv.Itoa(int(id))] = items
+	for _, transfer := range items {
+		// transfers of a bulk transfer are listed like any other
+		transfers[transfer.Reference] = transfer
+	}
+
+	if InitBulkTransferError != nil {
+		logger.Error("rave init bulk transfer", idata, InitBulkTransferError.Error())
+		return outBoundResponse, InitBulkTransferError
+	}
+
+	logger.Info("rave init bulk transfer", data)
+
+	return external_models.RaveInitBulkTransferResponse{
+		Status:  "success",
+		Message: "Bulk transfer queued",
+		Data: external_models.RaveInitBulkTransferResponseData{
+			ID:        id,
+			CreatedAt: "2022-11-03T14:11:12.000Z",
+			Approver:  "N/A",
 		},
 	}, nil
 }
+
+func RaveGetBulkTransfer(logger *utility.Logger, idata interface{}) (external_models.RaveGetBulkTransferResponse, error) {
+
+	var (
+		outBoundResponse external_models.RaveGetBulkTransferResponse
+	)
+
+	data, ok := idata.(external_models.RaveGetBulkTransferRequest)
+	if !ok {
+		logger.Error("rave get bulk transfer", idata, "request data format error")
+		return outBoundResponse, fmt.Errorf("request data format error")
+	}
+
+	bulkTransferMutex.Lock()
+	defer bulkTransferMutex.Unlock()
+	transfers, ok := bulkTransfers[data.BatchID]
+	if !ok {
+		return outBoundResponse, fmt.Errorf("bulk transfer %v not found", data.BatchID)
+	}
+
+	outBoundResponse = external_models.RaveGetBulkTransferResponse{
+		Status:  "success",
+		Message: "Transfers fetched",
+		Data:    []external_models.RaveInitTransferResponseData{},
+	}
+	for _, transfer := range transfers {
+		transfer.Status = BulkTransferStatus
+		outBoundResponse.Data = append(outBoundResponse.Data, transfer)
+	}
+	outBoundResponse.Meta.PageInfo.Total = len(transfers)
+	outBoundResponse.Meta.PageInfo.CurrentPage = 1
+	outBoundResponse.Meta.PageInfo.TotalPages = 1
+
+	logger.Info("rave get bulk transfer", outBoundResponse)
+
+	return outBoundResponse, nil
+}
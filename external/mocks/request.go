@@ -5,10 +5,12 @@ import (
 
 	"github.com/vesicash/payment-ms/external/mocks/appruve_mocks"
 	"github.com/vesicash/payment-ms/external/mocks/auth_mocks"
+	"github.com/vesicash/payment-ms/external/mocks/campay_mocks"
 	"github.com/vesicash/payment-ms/external/mocks/ip_api_mocks"
 	"github.com/vesicash/payment-ms/external/mocks/ipstack_mocks"
 	"github.com/vesicash/payment-ms/external/mocks/monnify_mocks"
 	"github.com/vesicash/payment-ms/external/mocks/notification_mocks"
+	"github.com/vesicash/payment-ms/external/mocks/paystack_mocks"
 	"github.com/vesicash/payment-ms/external/mocks/rave_mocks"
 	"github.com/vesicash/payment-ms/external/mocks/transactions_mocks"
 	"github.com/vesicash/payment-ms/external/mocks/upload_mocks"
@@ -166,8 +168,24 @@ func (er ExternalRequest) SendExternalRequest(name string, data interface{}) (in
 		return auth_mocks.GetBank(er.Logger, data)
 	case "rave_init_transfer":
 		return rave_mocks.RaveInitTransfer(er.Logger, data)
+	case "rave_init_momo_transfer":
+		return rave_mocks.RaveInitMomoTransfer(er.Logger, data)
+	case "rave_get_transfer":
+		return rave_mocks.RaveGetTransfer(er.Logger, data)
+	case "rave_list_transfers":
+		return rave_mocks.RaveListTransfers(er.Logger, data)
 	case "monnify_init_transfer":
 		return monnify_mocks.MonnifyInitTransfer(er.Logger, data)
+	case "monnify_get_transfer_summary":
+		return monnify_mocks.MonnifyGetTransferSummary(er.Logger, data)
+	case "rave_init_bulk_transfer":
+		return rave_mocks.RaveInitBulkTransfer(er.Logger, data)
+	case "rave_get_bulk_transfer":
+		return rave_mocks.RaveGetBulkTransfer(er.Logger, data)
+	case "monnify_init_bulk_transfer":
+		return monnify_mocks.MonnifyInitBulkTransfer(er.Logger, data)
+	case "monnify_get_bulk_transfer":
+		return monnify_mocks.MonnifyGetBulkTransfer(er.Logger, data)
 	case "transaction_paid_notification":
 		return notification_mocks.TransactionPaidNotification(er.Logger, data)
 	case "successful_refund_notification":
@@ -186,6 +204,24 @@ func (er ExternalRequest) SendExternalRequest(name string, data interface{}) (in
 		return verification_mocks.CheckVerification(er.Logger, data)
 	case "list_transactions":
 		return transactions_mocks.ListTransactions(er.Logger, data)
+	case "paystack_init_payment":
+		return paystack_mocks.PaystackInitPayment(er.Logger, data)
+	case "paystack_verify_transaction":
+		return paystack_mocks.PaystackVerifyTransaction(er.Logger, data)
+	case "paystack_create_transfer_recipient":
+		return paystack_mocks.PaystackCreateTransferRecipient(er.Logger, data)
+	case "paystack_init_transfer":
+		return paystack_mocks.PaystackInitTransfer(er.Logger, data)
+	case "paystack_verify_transfer":
+		return paystack_mocks.PaystackVerifyTransfer(er.Logger, data)
+	case "list_banks_with_paystack":
+		return paystack_mocks.ListBanksWithPaystack(er.Logger, data)
+	case "paystack_resolve_bank_account":
+		return paystack_mocks.PaystackResolveBankAccount(er.Logger, data)
+	case "campay_init_payment":
+		return campay_mocks.CampayInitPayment(er.Logger, data)
+	case "campay_transaction_status":
+		return campay_mocks.CampayTransactionStatus(er.Logger, data)
 	default:
 		return nil, fmt.Errorf("request not found")
 	}
@@ -2,7 +2,9 @@ package request
 
 import (
 	"fmt"
+	"time"
 
+	"github.com/vesicash/payment-ms/external"
 	"github.com/vesicash/payment-ms/external/microservice/auth"
 	"github.com/vesicash/payment-ms/external/microservice/notification"
 	"github.com/vesicash/payment-ms/external/microservice/transactions"
@@ -11,9 +13,11 @@ import (
 	"github.com/vesicash/payment-ms/external/mocks"
 	rave "github.com/vesicash/payment-ms/external/thirdparty/Rave"
 	"github.com/vesicash/payment-ms/external/thirdparty/appruve"
+	"github.com/vesicash/payment-ms/external/thirdparty/campay"
 	"github.com/vesicash/payment-ms/external/thirdparty/ip_api"
 	"github.com/vesicash/payment-ms/external/thirdparty/ipstack"
 	"github.com/vesicash/payment-ms/external/thirdparty/monnify"
+	"github.com/vesicash/payment-ms/external/thirdparty/paystack"
 	"github.com/vesicash/payment-ms/internal/config"
 	"github.com/vesicash/payment-ms/utility"
 )
@@ -21,6 +25,8 @@ import (
 type ExternalRequest struct {
 	Logger *utility.Logger
 	Test   bool
+	// Gateway is set by a payment gateway on the requests it sends, so they are reported to its gateway observers
+	Gateway string
 }
 
 var (
@@ -106,7 +112,15 @@ var (
 	GetBank                   string = "get_bank"
 
 	RaveInitTransfer            string = "rave_init_transfer"
+	RaveInitMomoTransfer        string = "rave_init_momo_transfer"
+	RaveGetTransfer             string = "rave_get_transfer"
+	RaveListTransfers           string = "rave_list_transfers"
 	MonnifyInitTransfer         string = "monnify_init_transfer"
+	MonnifyGetTransferSummary   string = "monnify_get_transfer_summary"
+	RaveInitBulkTransfer        string = "rave_init_bulk_transfer"
+	RaveGetBulkTransfer         string = "rave_get_bulk_transfer"
+	MonnifyInitBulkTransfer     string = "monnify_init_bulk_transfer"
+	MonnifyGetBulkTransfer      string = "monnify_get_bulk_transfer"
 	TransactionPaidNotification string = "transaction_paid_notification"
 
 	SuccessfulRefundNotification        string = "successful_refund_notification"
@@ -117,9 +131,31 @@ var (
 	GetAccessTokenByBusinessID          string = "get_access_token_by_busines_id"
 	CheckVerification                   string = "check_verification"
 	ListTransactions                    string = "list_transactions"
+
+	PaystackInitPayment             string = "paystack_init_payment"
+	PaystackVerifyTransaction       string = "paystack_verify_transaction"
+	PaystackCreateTransferRecipient string = "paystack_create_transfer_recipient"
+	PaystackInitTransfer            string = "paystack_init_transfer"
+	PaystackVerifyTransfer          string = "paystack_verify_transfer"
+	ListBanksWithPaystack           string = "list_banks_with_paystack"
+	PaystackResolveBankAccount      string = "paystack_resolve_bank_account"
+
+	CampayInitPayment       string = "campay_init_payment"
+	CampayTransactionStatus string = "campay_transaction_status"
 )
 
 func (er ExternalRequest) SendExternalRequest(name string, data interface{}) (interface{}, error) {
+	if er.Gateway == "" {
+		return er.sendExternalRequest(name, data)
+	}
+
+	start := time.Now()
+	response, err := er.sendExternalRequest(name, data)
+	external.ObserveGatewayRequest(er.Gateway, name, time.Since(start), err)
+	return response, err
+}
+
+func (er ExternalRequest) sendExternalRequest(name string, data interface{}) (interface{}, error) {
 	var (
 		config = config.GetConfig()
 	)
@@ -818,6 +854,39 @@ func (er ExternalRequest) SendExternalRequest(name string, data interface{}) (in
 				Logger:       er.Logger,
 			}
 			return obj.RaveInitTransfer()
+		case "rave_init_momo_transfer":
+			obj := rave.RequestObj{
+				Name:         name,
+				Path:         fmt.Sprintf("%v/v3/transfers", config.Rave.BaseUrl),
+				Method:       "POST",
+				SuccessCode:  200,
+				DecodeMethod: JsonDecodeMethod,
+				RequestData:  data,
+				Logger:       er.Logger,
+			}
+			return obj.RaveInitMomoTransfer()
+		case "rave_get_transfer":
+			obj := rave.RequestObj{
+				Name:         name,
+				Path:         fmt.Sprintf("%v/v3/transfers", config.Rave.BaseUrl),
+				Method:       "GET",
+				SuccessCode:  200,
+				DecodeMethod: JsonDecodeMethod,
+				RequestData:  data,
+				Logger:       er.Logger,
+			}
+			return obj.RaveGetTransfer()
+		case "rave_list_transfers":
+			obj := rave.RequestObj{
+				Name:         name,
+				Path:         fmt.Sprintf("%v/v3/transfers?reference=", config.Rave.BaseUrl),
+				Method:       "GET",
+				SuccessCode:  200,
+				DecodeMethod: JsonDecodeMethod,
+				RequestData:  data,
+				Logger:       er.Logger,
+			}
+			return obj.RaveListTransfers()
 		case "monnify_init_transfer":
 			obj := monnify.RequestObj{
 				Name:         name,
@@ -829,6 +898,61 @@ func (er ExternalRequest) SendExternalRequest(name string, data interface{}) (in
 				Logger:       er.Logger,
 			}
 			return obj.MonnifyInitTransfer()
+		case "monnify_get_transfer_summary":
+			obj := monnify.RequestObj{
+				Name:         name,
+				Path:         fmt.Sprintf("%v/v2/disbursements/single/summary?reference=", config.Monnify.MonnifyEndpoint),
+				Method:       "GET",
+				SuccessCode:  200,
+				DecodeMethod: JsonDecodeMethod,
+				RequestData:  data,
+				Logger:       er.Logger,
+			}
+			return obj.MonnifyGetTransferSummary()
+		case "rave_init_bulk_transfer":
+			obj := rave.RequestObj{
+				Name:         name,
+				Path:         fmt.Sprintf("%v/v3/bulk-transfers", config.Rave.BaseUrl),
+				Method:       "POST",
+				SuccessCode:  200,
+				DecodeMethod: JsonDecodeMethod,
+				RequestData:  data,
+				Logger:       er.Logger,
+			}
+			return obj.RaveInitBulkTransfer()
+		case "rave_get_bulk_transfer":
+			obj := rave.RequestObj{
+				Name:         name,
+				Path:         fmt.Sprintf("%v/v3/transfers", config.Rave.BaseUrl),
+				Method:       "GET",
+				SuccessCode:  200,
+				DecodeMethod: JsonDecodeMethod,
+				RequestData:  data,
+				Logger:       er.Logger,
+			}
+			return obj.RaveGetBulkTransfer()
+		case "monnify_init_bulk_transfer":
+			obj := monnify.RequestObj{
+				Name:         name,
+				Path:         fmt.Sprintf("%v/v2/disbursements/batch", config.Monnify.MonnifyEndpoint),
+				Method:       "POST",
+				SuccessCode:  200,
+				DecodeMethod: JsonDecodeMethod,
+				RequestData:  data,
+				Logger:       er.Logger,
+			}
+			return obj.MonnifyInitBulkTransfer()
+		case "monnify_get_bulk_transfer":
+			obj := monnify.RequestObj{
+				Name:         name,
+				Path:         fmt.Sprintf("%v/v2/disbursements/bulk/", config.Monnify.MonnifyEndpoint),
+				Method:       "GET",
+				SuccessCode:  200,
+				DecodeMethod: JsonDecodeMethod,
+				RequestData:  data,
+				Logger:       er.Logger,
+			}
+			return obj.MonnifyGetBulkTransfer()
 		case "transaction_paid_notification":
 			obj := notification.RequestObj{
 				Name:         name,
@@ -928,6 +1052,105 @@ func (er ExternalRequest) SendExternalRequest(name string, data interface{}) (in
 				Logger:       er.Logger,
 			}
 			return obj.ListTransactions()
+		case "paystack_init_payment":
+			obj := paystack.RequestObj{
+				Name:         name,
+				Path:         fmt.Sprintf("%v/transaction/initialize", config.Paystack.BaseUrl),
+				Method:       "POST",
+				SuccessCode:  200,
+				DecodeMethod: JsonDecodeMethod,
+				RequestData:  data,
+				Logger:       er.Logger,
+			}
+			return obj.PaystackInitPayment()
+		case "paystack_verify_transaction":
+			obj := paystack.RequestObj{
+				Name:         name,
+				Path:         fmt.Sprintf("%v/transaction/verify", config.Paystack.BaseUrl),
+				Method:       "GET",
+				SuccessCode:  200,
+				DecodeMethod: JsonDecodeMethod,
+				RequestData:  data,
+				Logger:       er.Logger,
+			}
+			return obj.PaystackVerifyTransaction()
+		case "paystack_create_transfer_recipient":
+			obj := paystack.RequestObj{
+				Name:         name,
+				Path:         fmt.Sprintf("%v/transferrecipient", config.Paystack.BaseUrl),
+				Method:       "POST",
+				SuccessCode:  200,
+				DecodeMethod: JsonDecodeMethod,
+				RequestData:  data,
+				Logger:       er.Logger,
+			}
+			return obj.PaystackCreateTransferRecipient()
+		case "paystack_init_transfer":
+			obj := paystack.RequestObj{
+				Name:         name,
+				Path:         fmt.Sprintf("%v/transfer", config.Paystack.BaseUrl),
+				Method:       "POST",
+				SuccessCode:  200,
+				DecodeMethod: JsonDecodeMethod,
+				RequestData:  data,
+				Logger:       er.Logger,
+			}
+			return obj.PaystackInitTransfer()
+		case "paystack_verify_transfer":
+			obj := paystack.RequestObj{
+				Name:         name,
+				Path:         fmt.Sprintf("%v/transfer/verify", config.Paystack.BaseUrl),
+				Method:       "GET",
+				SuccessCode:  200,
+				DecodeMethod: JsonDecodeMethod,
+				RequestData:  data,
+				Logger:       er.Logger,
+			}
+			return obj.PaystackVerifyTransfer()
+		case "list_banks_with_paystack":
+			obj := paystack.RequestObj{
+				Name:         name,
+				Path:         fmt.Sprintf("%v/bank", config.Paystack.BaseUrl),
+				Method:       "GET",
+				SuccessCode:  200,
+				DecodeMethod: JsonDecodeMethod,
+				RequestData:  data,
+				Logger:       er.Logger,
+			}
+			return obj.ListBanksWithPaystack()
+		case "paystack_resolve_bank_account":
+			obj := paystack.RequestObj{
+				Name:         name,
+				Path:         fmt.Sprintf("%v/bank/resolve", config.Paystack.BaseUrl),
+				Method:       "GET",
+				SuccessCode:  200,
+				DecodeMethod: JsonDecodeMethod,
+				RequestData:  data,
+				Logger:       er.Logger,
+			}
+			return obj.PaystackResolveBankAccount()
+		case "campay_init_payment":
+			obj := campay.RequestObj{
+				Name:         name,
+				Path:         fmt.Sprintf("%v/get_payment_link/", config.Campay.BaseUrl),
+				Method:       "POST",
+				SuccessCode:  200,
+				DecodeMethod: JsonDecodeMethod,
+				RequestData:  data,
+				Logger:       er.Logger,
+			}
+			return obj.CampayInitPayment()
+		case "campay_transaction_status":
+			obj := campay.RequestObj{
+				Name:         name,
+				Path:         fmt.Sprintf("%v/transaction", config.Campay.BaseUrl),
+				Method:       "GET",
+				SuccessCode:  200,
+				DecodeMethod: JsonDecodeMethod,
+				RequestData:  data,
+				Logger:       er.Logger,
+			}
+			return obj.CampayTransactionStatus()
 		default:
 			return nil, fmt.Errorf("request not found")
 		}
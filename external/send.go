@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/elliotchance/phpserialize"
 	"github.com/vesicash/payment-ms/utility"
@@ -49,6 +50,15 @@ var (
 	ResponseBody string
 )
 
+type RequestError struct {
+	Name string
+	Code int
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("external requests error for request %v, code %v", e.Name, strconv.Itoa(e.Code))
+}
+
 var (
 	JsonDecodeMethod    string = "json"
 	PhpSerializerMethod string = "phpserializer"
@@ -56,11 +66,16 @@ var (
 
 func (r *SendRequestObject) SendRequest(response interface{}) error {
 	var (
-		data   = r.Data
-		logger = r.Logger
-		name   = r.Name
-		err    error
+		data       = r.Data
+		logger     = r.Logger
+		name       = r.Name
+		err        error
+		start      = time.Now()
+		statusCode int
 	)
+	defer func() {
+		observeRequest(name, statusCode, time.Since(start))
+	}()
 
 	buf := new(bytes.Buffer)
 
@@ -125,13 +140,14 @@ func (r *SendRequestObject) SendRequest(response interface{}) error {
 
 	defer res.Body.Close()
 	ResponseCode = res.StatusCode
+	statusCode = res.StatusCode
 
 	if res.StatusCode == r.SuccessCode {
 		return nil
 	}
 
 	if res.StatusCode < 200 || res.StatusCode > 299 {
-		return fmt.Errorf("external requests error for request %v, code %v", name, strconv.Itoa(res.StatusCode))
+		return &RequestError{Name: name, Code: res.StatusCode}
 	}
 
 	return nil
@@ -2,6 +2,7 @@ package rave
 
 import (
 	"fmt"
+	"net/url"
 
 	"github.com/vesicash/payment-ms/external/external_models"
 	"github.com/vesicash/payment-ms/internal/config"
@@ -35,3 +36,148 @@ func (r *RequestObj) RaveInitTransfer() (external_models.RaveInitTransferRespons
 
 	return outBoundResponse, nil
 }
+
+func (r *RequestObj) RaveInitMomoTransfer() (external_models.RaveInitTransferResponse, error) {
+
+	var (
+		outBoundResponse external_models.RaveInitTransferResponse
+		logger           = r.Logger
+		idata            = r.RequestData
+	)
+
+	headers := map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": "Bearer " + config.GetConfig().Rave.SecretKey,
+	}
+
+	data, ok := idata.(external_models.RaveInitMomoTransferRequest)
+	if !ok {
+		logger.Error("rave init momo transfer", idata, "request data format error")
+		return outBoundResponse, fmt.Errorf("request data format error")
+	}
+
+	err := r.getNewSendRequestObject(data, headers, "").SendRequest(&outBoundResponse)
+	if err != nil {
+		logger.Error("rave init momo transfer", outBoundResponse, err.Error())
+		return outBoundResponse, err
+	}
+	logger.Info("rave init momo transfer", outBoundResponse)
+
+	return outBoundResponse, nil
+}
+
+func (r *RequestObj) RaveGetTransfer() (external_models.RaveInitTransferResponseData, error) {
+
+	var (
+		outBoundResponse external_models.RaveGetTransferResponse
+		logger           = r.Logger
+		idata            = r.RequestData
+	)
+
+	headers := map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": "Bearer " + config.GetConfig().Rave.SecretKey,
+	}
+
+	data, ok := idata.(string)
+	if !ok {
+		logger.Error("rave get transfer", idata, "request data format error")
+		return outBoundResponse.Data, fmt.Errorf("request data format error")
+	}
+
+	err := r.getNewSendRequestObject(nil, headers, "/"+data).SendRequest(&outBoundResponse)
+	if err != nil {
+		logger.Error("rave get transfer", outBoundResponse, err.Error())
+		return outBoundResponse.Data, err
+	}
+	logger.Info("rave get transfer", outBoundResponse)
+
+	return outBoundResponse.Data, nil
+}
+
+func (r *RequestObj) RaveListTransfers() ([]external_models.RaveInitTransferResponseData, error) {
+
+	var (
+		outBoundResponse external_models.RaveListTransfersResponse
+		logger           = r.Logger
+		idata            = r.RequestData
+	)
+
+	headers := map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": "Bearer " + config.GetConfig().Rave.SecretKey,
+	}
+
+	data, ok := idata.(string)
+	if !ok {
+		logger.Error("rave list transfers", idata, "request data format error")
+		return outBoundResponse.Data, fmt.Errorf("request data format error")
+	}
+
+	err := r.getNewSendRequestObject(nil, headers, url.QueryEscape(data)).SendRequest(&outBoundResponse)
+	if err != nil {
+		logger.Error("rave list transfers", outBoundResponse, err.Error())
+		return outBoundResponse.Data, err
+	}
+	logger.Info("rave list transfers", outBoundResponse)
+
+	return outBoundResponse.Data, nil
+}
+
+func (r *RequestObj) RaveInitBulkTransfer() (external_models.RaveInitBulkTransferResponse, error) {
+
+	var (
+		outBoundResponse external_models.RaveInitBulkTransferResponse
+		logger           = r.Logger
+		idata            = r.RequestData
+	)
+
+	headers := map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": "Bearer " + config.GetConfig().Rave.SecretKey,
+	}
+
+	data, ok := idata.(external_models.RaveInitBulkTransferRequest)
+	if !ok {
+		logger.Error("rave init bulk transfer", idata, "request data format error")
+		return outBoundResponse, fmt.Errorf("request data format error")
+	}
+
+	err := r.getNewSendRequestObject(data, headers, "").SendRequest(&outBoundResponse)
+	if err != nil {
+		logger.Error("rave init bulk transfer", outBoundResponse, err.Error())
+		return outBoundResponse, err
+	}
+	logger.Info("rave init bulk transfer", outBoundResponse)
+
+	return outBoundResponse, nil
+}
+
+func (r *RequestObj) RaveGetBulkTransfer() (external_models.RaveGetBulkTransferResponse, error) {
+
+	var (
+		outBoundResponse external_models.RaveGetBulkTransferResponse
+		logger           = r.Logger
+		idata            = r.RequestData
+	)
+
+	headers := map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": "Bearer " + config.GetConfig().Rave.SecretKey,
+	}
+
+	data, ok := idata.(external_models.RaveGetBulkTransferRequest)
+	if !ok {
+		logger.Error("rave get bulk transfer", idata, "request data format error")
+		return outBoundResponse, fmt.Errorf("request data format error")
+	}
+
+	err := r.getNewSendRequestObject(nil, headers, fmt.Sprintf("?batch_id=%v&page=%v", data.BatchID, data.Page)).SendRequest(&outBoundResponse)
+	if err != nil {
+		logger.Error("rave get bulk transfer", outBoundResponse, err.Error())
+		return outBoundResponse, err
+	}
+	logger.Info("rave get bulk transfer", outBoundResponse)
+
+	return outBoundResponse, nil
+}
@@ -0,0 +1,54 @@
+package campay
+
+import (
+	"fmt"
+
+	"github.com/vesicash/payment-ms/external"
+	"github.com/vesicash/payment-ms/external/external_models"
+	"github.com/vesicash/payment-ms/internal/config"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+type RequestObj struct {
+	Name         string
+	Path         string
+	Method       string
+	SuccessCode  int
+	RequestData  interface{}
+	DecodeMethod string
+	Logger       *utility.Logger
+}
+
+var (
+	JsonDecodeMethod    string = "json"
+	PhpSerializerMethod string = "phpserializer"
+)
+
+func (r *RequestObj) getNewSendRequestObject(data interface{}, headers map[string]string, urlprefix string) *external.SendRequestObject {
+	return external.GetNewSendRequestObject(r.Logger, r.Name, r.Path, r.Method, urlprefix, r.DecodeMethod, headers, r.SuccessCode, data)
+}
+
+func (r *RequestObj) getCampayTokenObject() *RequestObj {
+	var (
+		config = config.GetConfig()
+	)
+	return &RequestObj{
+		Name:         "campay_get_token",
+		Path:         fmt.Sprintf("%v/token/", config.Campay.BaseUrl),
+		Method:       "POST",
+		SuccessCode:  200,
+		DecodeMethod: JsonDecodeMethod,
+		RequestData: external_models.CampayGetTokenRequest{
+			Username: config.Campay.Username,
+			Password: config.Campay.Password,
+		},
+		Logger: r.Logger,
+	}
+}
+
+func (r *RequestObj) getCampayToken() (string, error) {
+	if token := config.GetConfig().Campay.AccessToken; token != "" {
+		return token, nil
+	}
+	return r.getCampayTokenObject().CampayGetToken()
+}
@@ -0,0 +1,104 @@
+package campay
+
+import (
+	"fmt"
+
+	"github.com/vesicash/payment-ms/external/external_models"
+)
+
+func (r *RequestObj) CampayGetToken() (string, error) {
+
+	var (
+		outBoundResponse external_models.CampayGetTokenResponse
+		logger           = r.Logger
+		idata            = r.RequestData
+	)
+
+	headers := map[string]string{
+		"Content-Type": "application/json",
+	}
+
+	data, ok := idata.(external_models.CampayGetTokenRequest)
+	if !ok {
+		logger.Error("campay get token", idata, "request data format error")
+		return "", fmt.Errorf("request data format error")
+	}
+
+	err := r.getNewSendRequestObject(data, headers, "").SendRequest(&outBoundResponse)
+	if err != nil {
+		logger.Error("campay get token", err.Error())
+		return "", err
+	}
+
+	return outBoundResponse.Token, nil
+}
+
+func (r *RequestObj) CampayInitPayment() (external_models.CampayInitPaymentResponse, error) {
+
+	var (
+		outBoundResponse external_models.CampayInitPaymentResponse
+		logger           = r.Logger
+		idata            = r.RequestData
+	)
+
+	token, err := r.getCampayToken()
+	if err != nil {
+		logger.Error("init payment campay", outBoundResponse, err.Error())
+		return outBoundResponse, err
+	}
+
+	headers := map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": "Token " + token,
+	}
+
+	data, ok := idata.(external_models.CampayInitPaymentRequest)
+	if !ok {
+		logger.Error("init payment campay", idata, "request data format error")
+		return outBoundResponse, fmt.Errorf("request data format error")
+	}
+
+	err = r.getNewSendRequestObject(data, headers, "").SendRequest(&outBoundResponse)
+	if err != nil {
+		logger.Error("init payment campay", outBoundResponse, err.Error())
+		return outBoundResponse, err
+	}
+	logger.Info("init payment campay", outBoundResponse)
+
+	return outBoundResponse, nil
+}
+
+func (r *RequestObj) CampayTransactionStatus() (external_models.CampayTransactionStatusResponse, error) {
+
+	var (
+		outBoundResponse external_models.CampayTransactionStatusResponse
+		logger           = r.Logger
+		idata            = r.RequestData
+	)
+
+	token, err := r.getCampayToken()
+	if err != nil {
+		logger.Error("campay transaction status", outBoundResponse, err.Error())
+		return outBoundResponse, err
+	}
+
+	headers := map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": "Token " + token,
+	}
+
+	data, ok := idata.(string)
+	if !ok {
+		logger.Error("campay transaction status", idata, "request data format error")
+		return outBoundResponse, fmt.Errorf("request data format error")
+	}
+
+	err = r.getNewSendRequestObject(data, headers, "/"+data+"/").SendRequest(&outBoundResponse)
+	if err != nil {
+		logger.Error("campay transaction status", outBoundResponse, err.Error())
+		return outBoundResponse, err
+	}
+	logger.Info("campay transaction status", outBoundResponse)
+
+	return outBoundResponse, nil
+}
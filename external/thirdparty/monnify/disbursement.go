@@ -40,3 +40,108 @@ func (r *RequestObj) MonnifyInitTransfer() (external_models.MonnifyInitTransferR
 
 	return outBoundResponse, nil
 }
+
+func (r *RequestObj) MonnifyGetTransferSummary() (external_models.MonnifyInitTransferResponseBody, error) {
+
+	var (
+		outBoundResponse external_models.MonnifyInitTransferResponse
+		logger           = r.Logger
+		idata            = r.RequestData
+	)
+
+	data, ok := idata.(string)
+	if !ok {
+		logger.Error("monnify get transfer summary", idata, "request data format error")
+		return outBoundResponse.ResponseBody, fmt.Errorf("request data format error")
+	}
+
+	token, err := r.getMonnifyLoginObject(false).MonnifyLogin()
+	if err != nil {
+		logger.Error("monnify get transfer summary", outBoundResponse, err.Error())
+		return outBoundResponse.ResponseBody, err
+	}
+
+	headers := map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": "Bearer " + token,
+	}
+
+	logger.Info("monnify get transfer summary", data)
+	err = r.getNewSendRequestObject(nil, headers, data).SendRequest(&outBoundResponse)
+	if err != nil {
+		logger.Error("monnify get transfer summary", outBoundResponse, err.Error())
+		return outBoundResponse.ResponseBody, err
+	}
+
+	return outBoundResponse.ResponseBody, nil
+}
+
+func (r *RequestObj) MonnifyInitBulkTransfer() (external_models.MonnifyInitBulkTransferResponse, error) {
+
+	var (
+		outBoundResponse external_models.MonnifyInitBulkTransferResponse
+		logger           = r.Logger
+		idata            = r.RequestData
+	)
+
+	data, ok := idata.(external_models.MonnifyInitBulkTransferRequest)
+	if !ok {
+		logger.Error("monnify init bulk transfer", idata, "request data format error")
+		return outBoundResponse, fmt.Errorf("request data format error")
+	}
+
+	token, err := r.getMonnifyLoginObject(false).MonnifyLogin()
+	if err != nil {
+		logger.Error("monnify init bulk transfer", outBoundResponse, err.Error())
+		return outBoundResponse, err
+	}
+
+	headers := map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": "Bearer " + token,
+	}
+
+	logger.Info("monnify init bulk transfer", data)
+	err = r.getNewSendRequestObject(data, headers, "").SendRequest(&outBoundResponse)
+	if err != nil {
+		logger.Error("monnify init bulk transfer", outBoundResponse, err.Error())
+		return outBoundResponse, err
+	}
+
+	return outBoundResponse, nil
+}
+
+func (r *RequestObj) MonnifyGetBulkTransfer() (external_models.MonnifyGetBulkTransferResponseBody, error) {
+
+	var (
+		outBoundResponse external_models.MonnifyGetBulkTransferResponse
+		logger           = r.Logger
+		idata            = r.RequestData
+	)
+
+	data, ok := idata.(external_models.MonnifyGetBulkTransferRequest)
+	if !ok {
+		logger.Error("monnify get bulk transfer", idata, "request data format error")
+		return outBoundResponse.ResponseBody, fmt.Errorf("request data format error")
+	}
+
+	token, err := r.getMonnifyLoginObject(false).MonnifyLogin()
+	if err != nil {
+		logger.Error("monnify get bulk transfer", outBoundResponse, err.Error())
+		return outBoundResponse.ResponseBody, err
+	}
+
+	headers := map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": "Bearer " + token,
+	}
+
+	logger.Info("monnify get bulk transfer", data)
+	err = r.getNewSendRequestObject(nil, headers, fmt.Sprintf("%v/transactions?pageNo=%v&pageSize=%v", data.BatchReference, data.PageNo, data.PageSize)).SendRequest(&outBoundResponse)
+	if err != nil {
+		logger.Error("monnify get bulk transfer", outBoundResponse, err.Error())
+		return outBoundResponse.ResponseBody, err
+	}
+
+	return outBoundResponse.ResponseBody, nil
+}
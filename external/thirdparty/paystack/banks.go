@@ -0,0 +1,65 @@
+package paystack
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/vesicash/payment-ms/external/external_models"
+	"github.com/vesicash/payment-ms/internal/config"
+)
+
+func (r *RequestObj) ListBanksWithPaystack() ([]external_models.PaystackBanksResponse, error) {
+
+	var (
+		outBoundResponse external_models.PaystackListBanksResponse
+		logger           = r.Logger
+		idata            = r.RequestData
+	)
+
+	headers := map[string]string{
+		"Authorization": "Bearer " + config.GetConfig().Paystack.SecretKey,
+	}
+
+	data, ok := idata.(string)
+	if !ok {
+		logger.Error("list banks with paystack", idata, "request data format error")
+		return outBoundResponse.Data, fmt.Errorf("request data format error")
+	}
+
+	err := r.getNewSendRequestObject(data, headers, "?country="+url.QueryEscape(data)).SendRequest(&outBoundResponse)
+	if err != nil {
+		logger.Error("list banks with paystack", outBoundResponse, err.Error())
+		return outBoundResponse.Data, err
+	}
+	logger.Info("list banks with paystack", outBoundResponse)
+
+	return outBoundResponse.Data, nil
+}
+
+func (r *RequestObj) PaystackResolveBankAccount() (string, error) {
+
+	var (
+		outBoundResponse external_models.PaystackResolveAccountResponse
+		logger           = r.Logger
+		idata            = r.RequestData
+	)
+
+	headers := map[string]string{
+		"Authorization": "Bearer " + config.GetConfig().Paystack.SecretKey,
+	}
+
+	data, ok := idata.(external_models.ResolveAccountRequest)
+	if !ok {
+		logger.Error("paystack resolve bank account", idata, "request data format error")
+		return "", fmt.Errorf("request data format error")
+	}
+
+	err := r.getNewSendRequestObject(data, headers, fmt.Sprintf("?account_number=%v&bank_code=%v", url.QueryEscape(data.AccountNumber), url.QueryEscape(data.AccountBank))).SendRequest(&outBoundResponse)
+	if err != nil {
+		logger.Error("paystack resolve bank account", outBoundResponse, err.Error())
+		return "", err
+	}
+	logger.Info("paystack resolve bank account", outBoundResponse)
+
+	return outBoundResponse.Data.AccountName, nil
+}
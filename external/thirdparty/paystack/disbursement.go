@@ -0,0 +1,94 @@
+package paystack
+
+import (
+	"fmt"
+
+	"github.com/vesicash/payment-ms/external/external_models"
+	"github.com/vesicash/payment-ms/internal/config"
+)
+
+func (r *RequestObj) PaystackCreateTransferRecipient() (external_models.PaystackCreateTransferRecipientResponseData, error) {
+
+	var (
+		outBoundResponse external_models.PaystackCreateTransferRecipientResponse
+		logger           = r.Logger
+		idata            = r.RequestData
+	)
+
+	headers := map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": "Bearer " + config.GetConfig().Paystack.SecretKey,
+	}
+
+	data, ok := idata.(external_models.PaystackCreateTransferRecipientRequest)
+	if !ok {
+		logger.Error("paystack create transfer recipient", idata, "request data format error")
+		return outBoundResponse.Data, fmt.Errorf("request data format error")
+	}
+
+	err := r.getNewSendRequestObject(data, headers, "").SendRequest(&outBoundResponse)
+	if err != nil {
+		logger.Error("paystack create transfer recipient", outBoundResponse, err.Error())
+		return outBoundResponse.Data, err
+	}
+	logger.Info("paystack create transfer recipient", outBoundResponse)
+
+	return outBoundResponse.Data, nil
+}
+
+func (r *RequestObj) PaystackInitTransfer() (external_models.PaystackInitTransferResponse, error) {
+
+	var (
+		outBoundResponse external_models.PaystackInitTransferResponse
+		logger           = r.Logger
+		idata            = r.RequestData
+	)
+
+	headers := map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": "Bearer " + config.GetConfig().Paystack.SecretKey,
+	}
+
+	data, ok := idata.(external_models.PaystackInitTransferRequest)
+	if !ok {
+		logger.Error("paystack init transfer", idata, "request data format error")
+		return outBoundResponse, fmt.Errorf("request data format error")
+	}
+
+	err := r.getNewSendRequestObject(data, headers, "").SendRequest(&outBoundResponse)
+	if err != nil {
+		logger.Error("paystack init transfer", outBoundResponse, err.Error())
+		return outBoundResponse, err
+	}
+	logger.Info("paystack init transfer", outBoundResponse)
+
+	return outBoundResponse, nil
+}
+
+func (r *RequestObj) PaystackVerifyTransfer() (external_models.PaystackInitTransferResponseData, error) {
+
+	var (
+		outBoundResponse external_models.PaystackInitTransferResponse
+		logger           = r.Logger
+		idata            = r.RequestData
+	)
+
+	headers := map[string]string{
+		"Authorization": "Bearer " + config.GetConfig().Paystack.SecretKey,
+	}
+
+	data, ok := idata.(string)
+	if !ok {
+		logger.Error("paystack verify transfer", idata, "request data format error")
+		return outBoundResponse.Data, fmt.Errorf("request data format error")
+	}
+
+	err := r.getNewSendRequestObject(data, headers, "/"+data).SendRequest(&outBoundResponse)
+	if err != nil {
+		logger.Error("paystack verify transfer", outBoundResponse, err.Error())
+		return outBoundResponse.Data, err
+	}
+	logger.Info("paystack verify transfer", outBoundResponse)
+
+	return outBoundResponse.Data, nil
+}
@@ -0,0 +1,65 @@
+package paystack
+
+import (
+	"fmt"
+
+	"github.com/vesicash/payment-ms/external/external_models"
+	"github.com/vesicash/payment-ms/internal/config"
+)
+
+func (r *RequestObj) PaystackInitPayment() (external_models.PaystackInitPaymentResponse, error) {
+
+	var (
+		outBoundResponse external_models.PaystackInitPaymentResponse
+		logger           = r.Logger
+		idata            = r.RequestData
+	)
+
+	headers := map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": "Bearer " + config.GetConfig().Paystack.SecretKey,
+	}
+
+	data, ok := idata.(external_models.PaystackInitPaymentRequest)
+	if !ok {
+		logger.Error("init payment paystack", idata, "request data format error")
+		return outBoundResponse, fmt.Errorf("request data format error")
+	}
+
+	err := r.getNewSendRequestObject(data, headers, "").SendRequest(&outBoundResponse)
+	if err != nil {
+		logger.Error("init payment paystack", outBoundResponse, err.Error())
+		return outBoundResponse, err
+	}
+	logger.Info("init payment paystack", outBoundResponse)
+
+	return outBoundResponse, nil
+}
+
+func (r *RequestObj) PaystackVerifyTransaction() (external_models.PaystackVerifyTransactionResponseData, error) {
+
+	var (
+		outBoundResponse external_models.PaystackVerifyTransactionResponse
+		logger           = r.Logger
+		idata            = r.RequestData
+	)
+
+	headers := map[string]string{
+		"Authorization": "Bearer " + config.GetConfig().Paystack.SecretKey,
+	}
+
+	data, ok := idata.(string)
+	if !ok {
+		logger.Error("paystack verify transaction", idata, "request data format error")
+		return outBoundResponse.Data, fmt.Errorf("request data format error")
+	}
+
+	err := r.getNewSendRequestObject(data, headers, "/"+data).SendRequest(&outBoundResponse)
+	if err != nil {
+		logger.Error("paystack verify transaction", outBoundResponse, err.Error())
+		return outBoundResponse.Data, err
+	}
+	logger.Info("paystack verify transaction", outBoundResponse)
+
+	return outBoundResponse.Data, nil
+}
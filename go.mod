@@ -12,7 +12,9 @@ require (
 	github.com/go-playground/universal-translator v0.18.0
 	github.com/go-playground/validator/v10 v10.11.1
 	github.com/jeanphorn/log4go v0.0.0-20190526082429-7dbb8deb9468
+	github.com/nyaruka/phonenumbers v1.1.6
 	github.com/sirupsen/logrus v1.9.0
+	github.com/slack-go/slack v0.12.1
 	github.com/spf13/viper v1.15.0
 	golang.org/x/crypto v0.5.0
 	gorm.io/driver/postgres v1.4.6
@@ -25,14 +27,12 @@ require (
 	github.com/golang/protobuf v1.5.2 // indirect
 	github.com/gorilla/websocket v1.4.2 // indirect
 	github.com/matttproud/golang_protobuf_extensions v1.0.1 // indirect
-	github.com/nyaruka/phonenumbers v1.1.6 // indirect
 	github.com/patrickmn/go-cache v2.1.0+incompatible // indirect
 	github.com/phpdave11/gofpdi v1.0.14-0.20211212211723-1f10f9844311 // indirect
 	github.com/pkg/errors v0.9.1 // indirect
 	github.com/prometheus/client_model v0.3.0 // indirect
 	github.com/prometheus/common v0.37.0 // indirect
 	github.com/prometheus/procfs v0.8.0 // indirect
-	github.com/slack-go/slack v0.12.1 // indirect
 	golang.org/x/time v0.1.0 // indirect
 )
 
@@ -0,0 +1,6 @@
+package config
+
+type BeneficiaryVerification struct {
+	MinMatchScore int
+	CacheHours    int
+}
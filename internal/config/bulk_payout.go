@@ -0,0 +1,6 @@
+package config
+
+type BulkPayout struct {
+	MaxRows     int
+	Concurrency int
+}
@@ -0,0 +1,9 @@
+package config
+
+type Campay struct {
+	Username    string
+	Password    string
+	AccessToken string
+	WebhookKey  string
+	BaseUrl     string
+}
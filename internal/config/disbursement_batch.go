@@ -0,0 +1,6 @@
+package config
+
+type DisbursementBatch struct {
+	MinSize int
+	MaxSize int
+}
@@ -0,0 +1,8 @@
+package config
+
+type DisbursementRetry struct {
+	MaxTries         int
+	BaseDelaySeconds int
+	MaxDelaySeconds  int
+	JitterPercent    int
+}
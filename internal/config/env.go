@@ -6,17 +6,25 @@ import (
 )
 
 type Configuration struct {
-	Server         ServerConfiguration
-	Databases      Databases
-	TestDatabases  Databases
-	Microservices  Microservices
-	App            App
-	Monnify        Monnify
-	Appruve        Appruve
-	Rave           Rave
-	IPStack        IPStack
-	ONLINE_PAYMENT OnlinePayment
-	Slack          Slack
+	Server                  ServerConfiguration
+	Databases               Databases
+	TestDatabases           Databases
+	Microservices           Microservices
+	App                     App
+	Monnify                 Monnify
+	Appruve                 Appruve
+	Rave                    Rave
+	Paystack                Paystack
+	Campay                  Campay
+	IPStack                 IPStack
+	ONLINE_PAYMENT          OnlinePayment
+	Slack                   Slack
+	GatewayHealth           GatewayHealth
+	DisbursementRetry       DisbursementRetry
+	BeneficiaryVerification BeneficiaryVerification
+	BulkPayout              BulkPayout
+	DisbursementBatch       DisbursementBatch
+	WebhookDispatcher       WebhookDispatcher
 }
 
 type BaseConfig struct {
@@ -112,6 +120,16 @@ type BaseConfig struct {
 	FLUTTERWAVE_MERCHANT_ID  string `mapstructure:"FLUTTERWAVE_MERCHANT_ID"`
 	FLUTTERWAVE_ACCOUNT_NAME string `mapstructure:"FLUTTERWAVE_ACCOUNT_NAME"`
 
+	PAYSTACK_SECRET_KEY string `mapstructure:"PAYSTACK_SECRET_KEY"`
+	PAYSTACK_PUBLIC_KEY string `mapstructure:"PAYSTACK_PUBLIC_KEY"`
+	PAYSTACK_BASE_URL   string `mapstructure:"PAYSTACK_BASE_URL"`
+
+	CAMPAY_USERNAME     string `mapstructure:"CAMPAY_USERNAME"`
+	CAMPAY_PASSWORD     string `mapstructure:"CAMPAY_PASSWORD"`
+	CAMPAY_ACCESS_TOKEN string `mapstructure:"CAMPAY_ACCESS_TOKEN"`
+	CAMPAY_WEBHOOK_KEY  string `mapstructure:"CAMPAY_WEBHOOK_KEY"`
+	CAMPAY_BASE_URL     string `mapstructure:"CAMPAY_BASE_URL"`
+
 	IPSTACK_KEY      string `mapstructure:"IPSTACK_KEY"`
 	IPSTACK_BASE_URL string `mapstructure:"IPSTACK_BASE_URL"`
 
@@ -122,6 +140,35 @@ type BaseConfig struct {
 	SLACK_OAUTH_TOKEN             string `mapstructure:"SLACK_OAUTH_TOKEN"`
 	SLACK_PAYMENT_CHANNELID       string `mapstructure:"SLACK_PAYMENT_CHANNELID"`
 	SLACK_DISBURSEMENTS_CHANNELID string `mapstructure:"SLACK_DISBURSEMENTS_CHANNELID"`
+
+	GATEWAY_HEALTH_WINDOW_SIZE               int     `mapstructure:"GATEWAY_HEALTH_WINDOW_SIZE"`
+	GATEWAY_HEALTH_MIN_CALLS                 int     `mapstructure:"GATEWAY_HEALTH_MIN_CALLS"`
+	GATEWAY_HEALTH_ERROR_RATE_THRESHOLD      float64 `mapstructure:"GATEWAY_HEALTH_ERROR_RATE_THRESHOLD"`
+	GATEWAY_HEALTH_LATENCY_THRESHOLD_MS      int64   `mapstructure:"GATEWAY_HEALTH_LATENCY_THRESHOLD_MS"`
+	GATEWAY_HEALTH_RECOVERY_COOLDOWN_SECONDS int     `mapstructure:"GATEWAY_HEALTH_RECOVERY_COOLDOWN_SECONDS"`
+
+	DISBURSEMENT_RETRY_MAX_TRIES          int `mapstructure:"DISBURSEMENT_RETRY_MAX_TRIES"`
+	DISBURSEMENT_RETRY_BASE_DELAY_SECONDS int `mapstructure:"DISBURSEMENT_RETRY_BASE_DELAY_SECONDS"`
+	DISBURSEMENT_RETRY_MAX_DELAY_SECONDS  int `mapstructure:"DISBURSEMENT_RETRY_MAX_DELAY_SECONDS"`
+	DISBURSEMENT_RETRY_JITTER_PERCENT     int `mapstructure:"DISBURSEMENT_RETRY_JITTER_PERCENT"`
+
+	BENEFICIARY_VERIFICATION_MIN_MATCH_SCORE int `mapstructure:"BENEFICIARY_VERIFICATION_MIN_MATCH_SCORE"`
+	BENEFICIARY_VERIFICATION_CACHE_HOURS     int `mapstructure:"BENEFICIARY_VERIFICATION_CACHE_HOURS"`
+
+	BULK_PAYOUT_MAX_ROWS    int `mapstructure:"BULK_PAYOUT_MAX_ROWS"`
+	BULK_PAYOUT_CONCURRENCY int `mapstructure:"BULK_PAYOUT_CONCURRENCY"`
+
+	DISBURSEMENT_BATCH_MIN_SIZE int `mapstructure:"DISBURSEMENT_BATCH_MIN_SIZE"`
+	DISBURSEMENT_BATCH_MAX_SIZE int `mapstructure:"DISBURSEMENT_BATCH_MAX_SIZE"`
+
+	WEBHOOK_DISPATCHER_WORKERS              int `mapstructure:"WEBHOOK_DISPATCHER_WORKERS"`
+	WEBHOOK_DISPATCHER_ENDPOINT_CONCURRENCY int `mapstructure:"WEBHOOK_DISPATCHER_ENDPOINT_CONCURRENCY"`
+	WEBHOOK_DISPATCHER_CLAIM_SIZE           int `mapstructure:"WEBHOOK_DISPATCHER_CLAIM_SIZE"`
+	WEBHOOK_DISPATCHER_TIMEOUT_SECONDS      int `mapstructure:"WEBHOOK_DISPATCHER_TIMEOUT_SECONDS"`
+	WEBHOOK_RETRY_MAX_TRIES                 int `mapstructure:"WEBHOOK_RETRY_MAX_TRIES"`
+	WEBHOOK_RETRY_BASE_DELAY_SECONDS        int `mapstructure:"WEBHOOK_RETRY_BASE_DELAY_SECONDS"`
+	WEBHOOK_RETRY_MAX_DELAY_SECONDS         int `mapstructure:"WEBHOOK_RETRY_MAX_DELAY_SECONDS"`
+	WEBHOOK_RETRY_JITTER_PERCENT            int `mapstructure:"WEBHOOK_RETRY_JITTER_PERCENT"`
 }
 
 func (config *BaseConfig) SetupConfigurationn() *Configuration {
@@ -233,6 +280,18 @@ func (config *BaseConfig) SetupConfigurationn() *Configuration {
 			AccountName:   config.FLUTTERWAVE_ACCOUNT_NAME,
 			WebhookSecret: config.RAVE_WEBHOOK_SECRET,
 		},
+		Paystack: Paystack{
+			SecretKey: config.PAYSTACK_SECRET_KEY,
+			PublicKey: config.PAYSTACK_PUBLIC_KEY,
+			BaseUrl:   config.PAYSTACK_BASE_URL,
+		},
+		Campay: Campay{
+			Username:    config.CAMPAY_USERNAME,
+			Password:    config.CAMPAY_PASSWORD,
+			AccessToken: config.CAMPAY_ACCESS_TOKEN,
+			WebhookKey:  config.CAMPAY_WEBHOOK_KEY,
+			BaseUrl:     config.CAMPAY_BASE_URL,
+		},
 
 		IPStack: IPStack{
 			Key:     config.IPSTACK_KEY,
@@ -249,5 +308,40 @@ func (config *BaseConfig) SetupConfigurationn() *Configuration {
 			PaymentChannelID:      config.SLACK_PAYMENT_CHANNELID,
 			DisbursementChannelID: config.SLACK_DISBURSEMENTS_CHANNELID,
 		},
+		GatewayHealth: GatewayHealth{
+			WindowSize:              config.GATEWAY_HEALTH_WINDOW_SIZE,
+			MinCalls:                config.GATEWAY_HEALTH_MIN_CALLS,
+			ErrorRateThreshold:      config.GATEWAY_HEALTH_ERROR_RATE_THRESHOLD,
+			LatencyThresholdMs:      config.GATEWAY_HEALTH_LATENCY_THRESHOLD_MS,
+			RecoveryCooldownSeconds: config.GATEWAY_HEALTH_RECOVERY_COOLDOWN_SECONDS,
+		},
+		DisbursementRetry: DisbursementRetry{
+			MaxTries:         config.DISBURSEMENT_RETRY_MAX_TRIES,
+			BaseDelaySeconds: config.DISBURSEMENT_RETRY_BASE_DELAY_SECONDS,
+			MaxDelaySeconds:  config.DISBURSEMENT_RETRY_MAX_DELAY_SECONDS,
+			JitterPercent:    config.DISBURSEMENT_RETRY_JITTER_PERCENT,
+		},
+		BeneficiaryVerification: BeneficiaryVerification{
+			MinMatchScore: config.BENEFICIARY_VERIFICATION_MIN_MATCH_SCORE,
+			CacheHours:    config.BENEFICIARY_VERIFICATION_CACHE_HOURS,
+		},
+		BulkPayout: BulkPayout{
+			MaxRows:     config.BULK_PAYOUT_MAX_ROWS,
+			Concurrency: config.BULK_PAYOUT_CONCURRENCY,
+		},
+		DisbursementBatch: DisbursementBatch{
+			MinSize: config.DISBURSEMENT_BATCH_MIN_SIZE,
+			MaxSize: config.DISBURSEMENT_BATCH_MAX_SIZE,
+		},
+		WebhookDispatcher: WebhookDispatcher{
+			Workers:             config.WEBHOOK_DISPATCHER_WORKERS,
+			EndpointConcurrency: config.WEBHOOK_DISPATCHER_ENDPOINT_CONCURRENCY,
+			ClaimSize:           config.WEBHOOK_DISPATCHER_CLAIM_SIZE,
+			TimeoutSeconds:      config.WEBHOOK_DISPATCHER_TIMEOUT_SECONDS,
+			MaxTries:            config.WEBHOOK_RETRY_MAX_TRIES,
+			BaseDelaySeconds:    config.WEBHOOK_RETRY_BASE_DELAY_SECONDS,
+			MaxDelaySeconds:     config.WEBHOOK_RETRY_MAX_DELAY_SECONDS,
+			JitterPercent:       config.WEBHOOK_RETRY_JITTER_PERCENT,
+		},
 	}
 }
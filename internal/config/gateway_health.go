@@ -0,0 +1,9 @@
+package config
+
+type GatewayHealth struct {
+	WindowSize              int
+	MinCalls                int
+	ErrorRateThreshold      float64
+	LatencyThresholdMs      int64
+	RecoveryCooldownSeconds int
+}
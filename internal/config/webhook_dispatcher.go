@@ -0,0 +1,12 @@
+package config
+
+type WebhookDispatcher struct {
+	Workers             int
+	EndpointConcurrency int
+	ClaimSize           int
+	TimeoutSeconds      int
+	MaxTries            int
+	BaseDelaySeconds    int
+	MaxDelaySeconds     int
+	JitterPercent       int
+}
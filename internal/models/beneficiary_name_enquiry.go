@@ -0,0 +1,46 @@
+package models
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"gorm.io/gorm"
+)
+
+// BeneficiaryNameEnquiry caches the account name a bank returned for an account number.
+type BeneficiaryNameEnquiry struct {
+	ID            uint      `gorm:"column:id; type:uint; not null; primaryKey; unique; autoIncrement" json:"id"`
+	AccountNumber string    `gorm:"column:account_number; type:varchar(255); not null; uniqueIndex:idx_beneficiary_name_enquiry_account" json:"account_number"`
+	BankCode      string    `gorm:"column:bank_code; type:varchar(255); not null; uniqueIndex:idx_beneficiary_name_enquiry_account" json:"bank_code"`
+	AccountName   string    `gorm:"column:account_name; type:varchar(255); not null" json:"account_name"`
+	Gateway       string    `gorm:"column:gateway; type:varchar(255)" json:"gateway"`
+	CreatedAt     time.Time `gorm:"column:created_at; autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time `gorm:"column:updated_at; autoUpdateTime" json:"updated_at"`
+}
+
+func (b *BeneficiaryNameEnquiry) CreateBeneficiaryNameEnquiry(db *gorm.DB) error {
+	err := postgresql.CreateOneRecord(db, &b)
+	if err != nil {
+		return fmt.Errorf("beneficiary name enquiry creation failed: %v", err.Error())
+	}
+	return nil
+}
+
+func (b *BeneficiaryNameEnquiry) GetBeneficiaryNameEnquiryByAccount(db *gorm.DB) (int, error) {
+	err, nilErr := postgresql.SelectOneFromDb(db, &b, "account_number = ? and bank_code = ?", b.AccountNumber, b.BankCode)
+	if nilErr != nil {
+		return http.StatusBadRequest, nilErr
+	}
+
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+func (b *BeneficiaryNameEnquiry) UpdateAllFields(db *gorm.DB) error {
+	_, err := postgresql.SaveAllFields(db, &b)
+	return err
+}
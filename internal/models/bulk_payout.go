@@ -0,0 +1,120 @@
+package models
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"gorm.io/gorm"
+)
+
+// BulkPayout pays many beneficiaries from one wallet debit, each row is sent as its own wallet disbursement.
+type BulkPayout struct {
+	ID           uint      `gorm:"column:id; type:uint; not null; primaryKey; unique; autoIncrement" json:"id"`
+	BulkPayoutID string    `gorm:"column:bulk_payout_id; type:varchar(255); not null; unique" json:"bulk_payout_id"`
+	AccountID    int       `gorm:"column:account_id; type:int; not null" json:"account_id"`
+	Currency     string    `gorm:"column:currency; type:varchar(255); not null" json:"currency"`
+	Gateway      string    `gorm:"column:gateway; type:varchar(255)" json:"gateway"`
+	TotalAmount  float64   `gorm:"column:total_amount; type:decimal(20,2); default: 0" json:"total_amount"`
+	TotalFee     float64   `gorm:"column:total_fee; type:decimal(20,2); default: 0" json:"total_fee"`
+	RowCount     int       `gorm:"column:row_count; type:int; default: 0" json:"row_count"`
+	Status       string    `gorm:"column:status; type:varchar(255); not null; default:processing; comment: rejected,processing,completed" json:"status"`
+	Reason       string    `gorm:"column:reason; type:text" json:"reason"`
+	CreatedAt    time.Time `gorm:"column:created_at; autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time `gorm:"column:updated_at; autoUpdateTime" json:"updated_at"`
+}
+
+type BulkPayoutItem struct {
+	ID                  uint      `gorm:"column:id; type:uint; not null; primaryKey; unique; autoIncrement" json:"id"`
+	BulkPayoutID        string    `gorm:"column:bulk_payout_id; type:varchar(255); not null; index" json:"bulk_payout_id"`
+	Row                 int       `gorm:"column:row_no; type:int" json:"row"`
+	BeneficiaryName     string    `gorm:"column:beneficiary_name; type:varchar(255)" json:"beneficiary_name"`
+	BankCode            string    `gorm:"column:bank_code; type:varchar(255)" json:"bank_code"`
+	BankName            string    `gorm:"column:bank_name; type:varchar(255)" json:"bank_name"`
+	BankAccountNumber   string    `gorm:"column:bank_account_number; type:varchar(255)" json:"bank_account_number"`
+	ResolvedAccountName string    `gorm:"column:resolved_account_name; type:varchar(255)" json:"resolved_account_name"`
+	Amount              float64   `gorm:"column:amount; type:decimal(20,2)" json:"amount"`
+	Narration           string    `gorm:"column:narration; type:varchar(255)" json:"narration"`
+	DisbursementID      int       `gorm:"column:disbursement_id; type:int; default: 0" json:"disbursement_id"`
+	Status              string    `gorm:"column:status; type:varchar(255); comment: invalid,valid or the status of the disbursement" json:"status"`
+	Error               string    `gorm:"column:error; type:text" json:"error"`
+	CreatedAt           time.Time `gorm:"column:created_at; autoCreateTime" json:"created_at"`
+	UpdatedAt           time.Time `gorm:"column:updated_at; autoUpdateTime" json:"updated_at"`
+}
+
+type BulkPayoutRow struct {
+	BeneficiaryName   string  `json:"beneficiary_name"`
+	BankCode          string  `json:"bank_code"`
+	BankAccountNumber string  `json:"bank_account_number"`
+	Amount            float64 `json:"amount"`
+	Narration         string  `json:"narration"`
+}
+
+type CreateBulkPayoutRequest struct {
+	Currency string          `json:"currency" form:"currency" validate:"required"`
+	Gateway  string          `json:"gateway" form:"gateway"`
+	Rows     []BulkPayoutRow `json:"rows"`
+}
+
+type BulkPayoutResponse struct {
+	BulkPayout BulkPayout       `json:"bulk_payout"`
+	Summary    map[string]int   `json:"summary"`
+	Items      []BulkPayoutItem `json:"items"`
+}
+
+func (b *BulkPayout) CreateBulkPayout(db *gorm.DB) error {
+	err := postgresql.CreateOneRecord(db, &b)
+	if err != nil {
+		return fmt.Errorf("bulk payout creation failed: %v", err.Error())
+	}
+	return nil
+}
+
+func (b *BulkPayout) GetBulkPayoutByBulkPayoutIDAndAccountID(db *gorm.DB) (int, error) {
+	err, nilErr := postgresql.SelectOneFromDb(db, &b, "bulk_payout_id = ? and account_id = ?", b.BulkPayoutID, b.AccountID)
+	if nilErr != nil {
+		return http.StatusBadRequest, nilErr
+	}
+
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+func (b *BulkPayout) GetBulkPayoutsByAccountID(db *gorm.DB, paginator postgresql.Pagination) ([]BulkPayout, postgresql.PaginationResponse, error) {
+	details := []BulkPayout{}
+	pagination, err := postgresql.SelectAllFromDbOrderByPaginated(db, "id", "desc", paginator, &details, "account_id = ?", b.AccountID)
+	if err != nil {
+		return details, pagination, err
+	}
+	return details, pagination, nil
+}
+
+func (b *BulkPayout) UpdateAllFields(db *gorm.DB) error {
+	_, err := postgresql.SaveAllFields(db, &b)
+	return err
+}
+
+func (b *BulkPayoutItem) CreateBulkPayoutItem(db *gorm.DB) error {
+	err := postgresql.CreateOneRecord(db, &b)
+	if err != nil {
+		return fmt.Errorf("bulk payout item creation failed: %v", err.Error())
+	}
+	return nil
+}
+
+func (b *BulkPayoutItem) GetBulkPayoutItemsByBulkPayoutID(db *gorm.DB) ([]BulkPayoutItem, error) {
+	details := []BulkPayoutItem{}
+	err := postgresql.SelectAllFromDbOrderBy(db, "row_no", "asc", &details, "bulk_payout_id = ?", b.BulkPayoutID)
+	if err != nil {
+		return details, err
+	}
+	return details, nil
+}
+
+func (b *BulkPayoutItem) UpdateAllFields(db *gorm.DB) error {
+	_, err := postgresql.SaveAllFields(db, &b)
+	return err
+}
@@ -11,32 +11,40 @@ import (
 )
 
 type Disbursement struct {
-	ID                    uint      `gorm:"column:id; type:uint; not null; primaryKey; unique; autoIncrement" json:"id"`
-	DisbursementID        int       `gorm:"column:disbursement_id; type:int; not null" json:"disbursement_id"`
-	RecipientID           int       `gorm:"column:recipient_id; type:int; not null" json:"recipient_id"`
-	PaymentID             string    `gorm:"column:payment_id; type:varchar(255); not null" json:"payment_id"`
-	BusinessID            int       `gorm:"column:business_id; type:int; not null" json:"business_id"`
-	Amount                string    `gorm:"column:amount; type:varchar(255); not null" json:"amount"`
-	Narration             string    `gorm:"column:narration; type:varchar(255)" json:"narration"`
-	Currency              string    `gorm:"column:currency; type:varchar(255); not null" json:"currency"`
-	Reference             string    `gorm:"column:reference; type:varchar(255); not null" json:"reference"`
-	CallbackUrl           string    `gorm:"column:callback_url; type:varchar(255)" json:"callback_url"`
-	BeneficiaryName       string    `gorm:"column:beneficiary_name; type:varchar(255)" json:"beneficiary_name"`
-	DestinationBranchCode string    `gorm:"column:destination_branch_code; type:varchar(255)" json:"destination_branch_code"`
-	DebitCurrency         string    `gorm:"column:debit_currency; type:varchar(255)" json:"debit_currency"`
-	Gateway               string    `gorm:"column:gateway; type:varchar(255)" json:"gateway"`
-	Type                  string    `gorm:"column:type; type:varchar(255)" json:"type"`
-	Status                string    `gorm:"column:status; type:varchar(255); default: new" json:"status"`
-	PaymentReleasedAt     string    `gorm:"column:payment_released_at; type:varchar(255)" json:"payment_released_at"`
-	DeletedAt             time.Time `gorm:"column:deleted_at" json:"deleted_at"`
-	CreatedAt             time.Time `gorm:"column:created_at; autoCreateTime" json:"created_at"`
-	UpdatedAt             time.Time `gorm:"column:updated_at; autoUpdateTime" json:"updated_at"`
-	Fee                   int       `gorm:"column:fee; type:int; default: 0" json:"fee"`
-	Tries                 int       `gorm:"column:tries; type:int; default: 0" json:"tries"`
-	TryAgainAt            time.Time `gorm:"column:try_again_at" json:"try_again_at"`
-	BankAccountNumber     string    `gorm:"column:bank_account_number; type:varchar(255)" json:"bank_account_number"`
-	BankName              string    `gorm:"column:bank_name; type:varchar(255)" json:"bank_name"`
-	Approved              string    `gorm:"column:approved; type:varchar(255); not null; default:pending; comment: yes,no,pending" json:"approved"`
+	ID                    uint       `gorm:"column:id; type:uint; not null; primaryKey; unique; autoIncrement" json:"id"`
+	DisbursementID        int        `gorm:"column:disbursement_id; type:int; not null" json:"disbursement_id"`
+	RecipientID           int        `gorm:"column:recipient_id; type:int; not null" json:"recipient_id"`
+	PaymentID             string     `gorm:"column:payment_id; type:varchar(255); not null" json:"payment_id"`
+	BusinessID            int        `gorm:"column:business_id; type:int; not null" json:"business_id"`
+	Amount                string     `gorm:"column:amount; type:varchar(255); not null" json:"amount"`
+	Narration             string     `gorm:"column:narration; type:varchar(255)" json:"narration"`
+	Currency              string     `gorm:"column:currency; type:varchar(255); not null" json:"currency"`
+	Reference             string     `gorm:"column:reference; type:varchar(255); not null" json:"reference"`
+	GatewayReference      string     `gorm:"column:gateway_reference; type:varchar(255)" json:"gateway_reference"`
+	CallbackUrl           string     `gorm:"column:callback_url; type:varchar(255)" json:"callback_url"`
+	BeneficiaryName       string     `gorm:"column:beneficiary_name; type:varchar(255)" json:"beneficiary_name"`
+	DestinationBranchCode string     `gorm:"column:destination_branch_code; type:varchar(255)" json:"destination_branch_code"`
+	DebitCurrency         string     `gorm:"column:debit_currency; type:varchar(255)" json:"debit_currency"`
+	Gateway               string     `gorm:"column:gateway; type:varchar(255)" json:"gateway"`
+	Type                  string     `gorm:"column:type; type:varchar(255)" json:"type"`
+	Status                string     `gorm:"column:status; type:varchar(255); default: new" json:"status"`
+	PaymentReleasedAt     string     `gorm:"column:payment_released_at; type:varchar(255)" json:"payment_released_at"`
+	DeletedAt             time.Time  `gorm:"column:deleted_at" json:"deleted_at"`
+	CreatedAt             time.Time  `gorm:"column:created_at; autoCreateTime" json:"created_at"`
+	UpdatedAt             time.Time  `gorm:"column:updated_at; autoUpdateTime" json:"updated_at"`
+	Fee                   int        `gorm:"column:fee; type:int; default: 0" json:"fee"`
+	Tries                 int        `gorm:"column:tries; type:int; default: 0" json:"tries"`
+	TryAgainAt            time.Time  `gorm:"column:try_again_at" json:"try_again_at"`
+	BankAccountNumber     string     `gorm:"column:bank_account_number; type:varchar(255)" json:"bank_account_number"`
+	BankName              string     `gorm:"column:bank_name; type:varchar(255)" json:"bank_name"`
+	BankCode              string     `gorm:"column:bank_code; type:varchar(255)" json:"bank_code"`
+	Approved              string     `gorm:"column:approved; type:varchar(255); not null; default:pending; comment: yes,no,pending" json:"approved"`
+	FailureReason         string     `gorm:"column:failure_reason; type:text" json:"failure_reason"`
+	DeadLetteredAt        *time.Time `gorm:"column:dead_lettered_at" json:"dead_lettered_at"`
+	ProofUrl              string     `gorm:"column:proof_url; type:varchar(255)" json:"proof_url"`
+	ResolvedAccountName   string     `gorm:"column:resolved_account_name; type:varchar(255)" json:"resolved_account_name"`
+	NameMatchScore        int        `gorm:"column:name_match_score; type:int; default: 0" json:"name_match_score"`
+	BatchReference        string     `gorm:"column:batch_reference; type:varchar(255); index" json:"batch_reference"`
 }
 
 type WalletTransferRequest struct {
@@ -68,9 +76,25 @@ type ManualDebitRequest struct {
 	DestinationBranchCode string  `json:"destination_branch_code"`
 	DebitCurrency         string  `json:"debit_currency" validate:"required"`
 	Status                string  `json:"status"`
-	Gateway               string  `json:"gateway" validate:"required,oneof=rave monnify paystack rave_banktransfer"`
+	Gateway               string  `json:"gateway" validate:"required,oneof=rave monnify paystack rave_banktransfer rave_momo"`
 	EscrowWallet          string  `json:"escrow_wallet" validate:"required,oneof=yes no"`
 }
+type CompleteManualDisbursementRequest struct {
+	DisbursementID int    `form:"disbursement_id" validate:"required"`
+	Comment        string `form:"comment"`
+}
+type FailManualDisbursementRequest struct {
+	DisbursementID int    `json:"disbursement_id" validate:"required"`
+	Reason         string `json:"reason" validate:"required"`
+}
+type RerouteManualDisbursementRequest struct {
+	DisbursementID int    `json:"disbursement_id" validate:"required"`
+	Gateway        string `json:"gateway" validate:"required"`
+}
+type ReviewDisbursementRequest struct {
+	DisbursementID int    `json:"disbursement_id" validate:"required"`
+	Comment        string `json:"comment" validate:"required"`
+}
 type ManualDebitResponse struct {
 	DisbursementID int         `json:"disbursement_id"`
 	Status         string      `json:"status"`
@@ -100,6 +124,17 @@ func (d *Disbursement) GetDisbursementByReference(db *gorm.DB) (int, error) {
 	}
 	return http.StatusOK, nil
 }
+func (d *Disbursement) GetDisbursementByGatewayReference(db *gorm.DB) (int, error) {
+	err, nilErr := postgresql.SelectOneFromDb(db, &d, "gateway_reference = ?", d.GatewayReference)
+	if nilErr != nil {
+		return http.StatusBadRequest, nilErr
+	}
+
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
 func (d *Disbursement) GetDisbursementByPaymentID(db *gorm.DB) (int, error) {
 	err, nilErr := postgresql.SelectOneFromDb(db, &d, "payment_id = ? ", d.PaymentID)
 	if nilErr != nil {
@@ -112,6 +147,41 @@ func (d *Disbursement) GetDisbursementByPaymentID(db *gorm.DB) (int, error) {
 	return http.StatusOK, nil
 }
 
+func (d *Disbursement) GetDisbursementByDisbursementID(db *gorm.DB) (int, error) {
+	err, nilErr := postgresql.SelectOneFromDb(db, &d, "disbursement_id = ?", d.DisbursementID)
+	if nilErr != nil {
+		return http.StatusBadRequest, nilErr
+	}
+
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// GetDisbursementStatusForUpdate reads the saved status of the disbursement and locks its row until the transaction ends.
+func (d *Disbursement) GetDisbursementStatusForUpdate(tx *gorm.DB) (string, error) {
+	current := Disbursement{}
+	err, _ := postgresql.SelectOneForUpdate(tx, &current, "id = ?", d.ID)
+	if err != nil {
+		return "", err
+	}
+	return current.Status, nil
+}
+
+func (d *Disbursement) CheckCompletedDisbursementToAccountExists(db *gorm.DB) bool {
+	return postgresql.CheckExists(db, &Disbursement{}, "recipient_id = ? and bank_account_number = ? and LOWER(status) = ?", d.RecipientID, d.BankAccountNumber, "completed")
+}
+
+func (d *Disbursement) GetDisbursementsByStatus(db *gorm.DB, paginator postgresql.Pagination) ([]Disbursement, postgresql.PaginationResponse, error) {
+	details := []Disbursement{}
+	pagination, err := postgresql.SelectAllFromDbOrderByPaginated(db, "id", "asc", paginator, &details, "LOWER(status) = ?", strings.ToLower(d.Status))
+	if err != nil {
+		return details, pagination, err
+	}
+	return details, pagination, nil
+}
+
 func (d *Disbursement) GetDisbursementsByRecipientID(db *gorm.DB, paginator postgresql.Pagination) ([]Disbursement, postgresql.PaginationResponse, error) {
 	details := []Disbursement{}
 	pagination, err := postgresql.SelectAllFromDbOrderByPaginated(db, "id", "desc", paginator, &details, "recipient_id = ?", d.RecipientID)
@@ -144,6 +214,15 @@ func (d *Disbursement) GetAllForStatuses(db *gorm.DB, statuses []string) ([]Disb
 	return details, nil
 }
 
+func (d *Disbursement) GetDisbursementsByBatchReference(db *gorm.DB) ([]Disbursement, error) {
+	details := []Disbursement{}
+	err := postgresql.SelectAllFromDbOrderBy(db, "id", "asc", &details, "batch_reference = ?", d.BatchReference)
+	if err != nil {
+		return details, err
+	}
+	return details, nil
+}
+
 func (d *Disbursement) CreateDisbursement(db *gorm.DB) error {
 	d.Currency = strings.ToUpper(d.Currency)
 	d.DebitCurrency = strings.ToUpper(d.DebitCurrency)
@@ -158,3 +237,9 @@ func (d *Disbursement) UpdateAllFields(db *gorm.DB) error {
 	_, err := postgresql.SaveAllFields(db, &d)
 	return err
 }
+
+// UpdateFields saves only the given columns, leaving the status to TransitionDisbursement.
+func (d *Disbursement) UpdateFields(db *gorm.DB, fields ...string) error {
+	_, err := postgresql.UpdateFields(db, d, fields...)
+	return err
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"gorm.io/gorm"
+)
+
+// DisbursementApprovalPolicy holds back disbursements for approval when all of its set criteria match.
+// Criteria left empty match every disbursement.
+type DisbursementApprovalPolicy struct {
+	ID             uint      `gorm:"column:id; type:uint; not null; primaryKey; unique; autoIncrement" json:"id"`
+	Name           string    `gorm:"column:name; type:varchar(255); not null" json:"name"`
+	BusinessID     int       `gorm:"column:business_id; type:int; default:0" json:"business_id"`
+	Currency       string    `gorm:"column:currency; type:varchar(255)" json:"currency"`
+	MinAmount      float64   `gorm:"column:min_amount; type:decimal(20,2); default:0" json:"min_amount"`
+	NewBeneficiary bool      `gorm:"column:new_beneficiary; type:bool; default:false" json:"new_beneficiary"`
+	Active         bool      `gorm:"column:active; type:bool; default:true" json:"active"`
+	CreatedAt      time.Time `gorm:"column:created_at; autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time `gorm:"column:updated_at; autoUpdateTime" json:"updated_at"`
+}
+
+type DisbursementApproval struct {
+	ID             uint      `gorm:"column:id; type:uint; not null; primaryKey; unique; autoIncrement" json:"id"`
+	DisbursementID int       `gorm:"column:disbursement_id; type:int; not null; index" json:"disbursement_id"`
+	PolicyID       uint      `gorm:"column:policy_id; type:uint; default:0" json:"policy_id"`
+	Action         string    `gorm:"column:action; type:varchar(255); not null; comment: requested,approve,reject" json:"action"`
+	ActorAccountID int       `gorm:"column:actor_account_id; type:int; default:0" json:"actor_account_id"`
+	Comment        string    `gorm:"column:comment; type:text" json:"comment"`
+	CreatedAt      time.Time `gorm:"column:created_at; autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time `gorm:"column:updated_at; autoUpdateTime" json:"updated_at"`
+}
+
+type CreateDisbursementApprovalPolicyRequest struct {
+	Name           string  `json:"name" validate:"required"`
+	BusinessID     int     `json:"business_id"`
+	Currency       string  `json:"currency"`
+	MinAmount      float64 `json:"min_amount" validate:"gte=0"`
+	NewBeneficiary bool    `json:"new_beneficiary"`
+}
+
+type DisbursementDecisionRequest struct {
+	DisbursementID int    `json:"disbursement_id" validate:"required"`
+	Comment        string `json:"comment" validate:"required"`
+}
+
+func (d *DisbursementApprovalPolicy) CreateDisbursementApprovalPolicy(db *gorm.DB) error {
+	err := postgresql.CreateOneRecord(db, &d)
+	if err != nil {
+		return fmt.Errorf("disbursement approval policy creation failed: %v", err.Error())
+	}
+	return nil
+}
+
+func (d *DisbursementApprovalPolicy) GetDisbursementApprovalPolicyByID(db *gorm.DB) (int, error) {
+	err, nilErr := postgresql.SelectOneFromDb(db, &d, "id = ?", d.ID)
+	if nilErr != nil {
+		return http.StatusBadRequest, nilErr
+	}
+
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+func (d *DisbursementApprovalPolicy) GetActiveDisbursementApprovalPolicies(db *gorm.DB) ([]DisbursementApprovalPolicy, error) {
+	details := []DisbursementApprovalPolicy{}
+	err := postgresql.SelectAllFromDb(db, "asc", &details, "active = ?", true)
+	if err != nil {
+		return details, err
+	}
+	return details, nil
+}
+
+func (d *DisbursementApprovalPolicy) UpdateAllFields(db *gorm.DB) error {
+	_, err := postgresql.SaveAllFields(db, &d)
+	return err
+}
+
+func (d *DisbursementApproval) CreateDisbursementApproval(db *gorm.DB) error {
+	err := postgresql.CreateOneRecord(db, &d)
+	if err != nil {
+		return fmt.Errorf("disbursement approval creation failed: %v", err.Error())
+	}
+	return nil
+}
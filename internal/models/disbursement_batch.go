@@ -0,0 +1,76 @@
+package models
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"gorm.io/gorm"
+)
+
+// DisbursementBatch is one bulk transfer request sent to a gateway, its disbursements carry the batch reference.
+type DisbursementBatch struct {
+	ID               uint       `gorm:"column:id; type:uint; not null; primaryKey; unique; autoIncrement" json:"id"`
+	BatchReference   string     `gorm:"column:batch_reference; type:varchar(255); not null; unique" json:"batch_reference"`
+	GatewayReference string     `gorm:"column:gateway_reference; type:varchar(255)" json:"gateway_reference"`
+	Gateway          string     `gorm:"column:gateway; type:varchar(255); not null" json:"gateway"`
+	Currency         string     `gorm:"column:currency; type:varchar(255); not null" json:"currency"`
+	TotalAmount      float64    `gorm:"column:total_amount; type:decimal(20,2); default: 0" json:"total_amount"`
+	ItemCount        int        `gorm:"column:item_count; type:int; default: 0" json:"item_count"`
+	CompletedCount   int        `gorm:"column:completed_count; type:int; default: 0" json:"completed_count"`
+	FailedCount      int        `gorm:"column:failed_count; type:int; default: 0" json:"failed_count"`
+	Status           string     `gorm:"column:status; type:varchar(255); not null; default:submitted; comment: submitted,completed,failed" json:"status"`
+	FailureReason    string     `gorm:"column:failure_reason; type:text" json:"failure_reason"`
+	ReconciledAt     *time.Time `gorm:"column:reconciled_at" json:"reconciled_at"`
+	CreatedAt        time.Time  `gorm:"column:created_at; autoCreateTime" json:"created_at"`
+	UpdatedAt        time.Time  `gorm:"column:updated_at; autoUpdateTime" json:"updated_at"`
+}
+
+type DisbursementBatchResponse struct {
+	Batch         DisbursementBatch `json:"batch"`
+	Disbursements []Disbursement    `json:"disbursements"`
+}
+
+func (d *DisbursementBatch) CreateDisbursementBatch(db *gorm.DB) error {
+	err := postgresql.CreateOneRecord(db, &d)
+	if err != nil {
+		return fmt.Errorf("disbursement batch creation failed: %v", err.Error())
+	}
+	return nil
+}
+
+func (d *DisbursementBatch) GetDisbursementBatchByBatchReference(db *gorm.DB) (int, error) {
+	err, nilErr := postgresql.SelectOneFromDb(db, &d, "batch_reference = ?", d.BatchReference)
+	if nilErr != nil {
+		return http.StatusBadRequest, nilErr
+	}
+
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+func (d *DisbursementBatch) GetDisbursementBatchesByStatus(db *gorm.DB) ([]DisbursementBatch, error) {
+	details := []DisbursementBatch{}
+	err := postgresql.SelectAllFromDbOrderBy(db, "id", "asc", &details, "status = ?", d.Status)
+	if err != nil {
+		return details, err
+	}
+	return details, nil
+}
+
+func (d *DisbursementBatch) GetDisbursementBatches(db *gorm.DB, paginator postgresql.Pagination) ([]DisbursementBatch, postgresql.PaginationResponse, error) {
+	details := []DisbursementBatch{}
+	pagination, err := postgresql.SelectAllFromDbOrderByPaginated(db, "id", "desc", paginator, &details, "batch_reference <> ?", "")
+	if err != nil {
+		return details, pagination, err
+	}
+	return details, pagination, nil
+}
+
+func (d *DisbursementBatch) UpdateAllFields(db *gorm.DB) error {
+	_, err := postgresql.SaveAllFields(db, &d)
+	return err
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"gorm.io/gorm"
+)
+
+// DisbursementRetrySetting overrides the default disbursement retry policy for a gateway, zero values keep the default.
+type DisbursementRetrySetting struct {
+	ID               uint      `gorm:"column:id; type:uint; not null; primaryKey; unique; autoIncrement" json:"id"`
+	Gateway          string    `gorm:"column:gateway; type:varchar(255); not null; unique" json:"gateway"`
+	MaxTries         int       `gorm:"column:max_tries; type:int; default: 0" json:"max_tries"`
+	BaseDelaySeconds int       `gorm:"column:base_delay_seconds; type:int; default: 0" json:"base_delay_seconds"`
+	MaxDelaySeconds  int       `gorm:"column:max_delay_seconds; type:int; default: 0" json:"max_delay_seconds"`
+	JitterPercent    int       `gorm:"column:jitter_percent; type:int; default: 0" json:"jitter_percent"`
+	UpdatedBy        string    `gorm:"column:updated_by; type:varchar(255)" json:"updated_by"`
+	CreatedAt        time.Time `gorm:"column:created_at; autoCreateTime" json:"created_at"`
+	UpdatedAt        time.Time `gorm:"column:updated_at; autoUpdateTime" json:"updated_at"`
+}
+
+type UpdateDisbursementRetrySettingRequest struct {
+	Gateway          string `json:"gateway" validate:"required"`
+	MaxTries         int    `json:"max_tries" validate:"gte=0"`
+	BaseDelaySeconds int    `json:"base_delay_seconds" validate:"gte=0"`
+	MaxDelaySeconds  int    `json:"max_delay_seconds" validate:"gte=0"`
+	JitterPercent    int    `json:"jitter_percent" validate:"gte=0,lte=100"`
+}
+
+func (d *DisbursementRetrySetting) CreateDisbursementRetrySetting(db *gorm.DB) error {
+	err := postgresql.CreateOneRecord(db, &d)
+	if err != nil {
+		return fmt.Errorf("disbursement retry setting creation failed: %v", err.Error())
+	}
+	return nil
+}
+
+func (d *DisbursementRetrySetting) GetDisbursementRetrySettingByGateway(db *gorm.DB) (int, error) {
+	err, nilErr := postgresql.SelectOneFromDb(db, &d, "gateway = ?", d.Gateway)
+	if nilErr != nil {
+		return http.StatusBadRequest, nilErr
+	}
+
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+func (d *DisbursementRetrySetting) GetAllDisbursementRetrySettings(db *gorm.DB) ([]DisbursementRetrySetting, error) {
+	details := []DisbursementRetrySetting{}
+	err := postgresql.SelectAllFromDb(db, "asc", &details, "gateway <> ?", "")
+	if err != nil {
+		return details, err
+	}
+	return details, nil
+}
+
+func (d *DisbursementRetrySetting) UpdateAllFields(db *gorm.DB) error {
+	_, err := postgresql.SaveAllFields(db, &d)
+	return err
+}
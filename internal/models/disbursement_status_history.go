@@ -0,0 +1,41 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"gorm.io/gorm"
+)
+
+type DisbursementStatusHistory struct {
+	ID             uint      `gorm:"column:id; type:uint; not null; primaryKey; unique; autoIncrement" json:"id"`
+	DisbursementID int       `gorm:"column:disbursement_id; type:int; not null; index" json:"disbursement_id"`
+	FromStatus     string    `gorm:"column:from_status; type:varchar(255)" json:"from_status"`
+	ToStatus       string    `gorm:"column:to_status; type:varchar(255); not null" json:"to_status"`
+	Actor          string    `gorm:"column:actor; type:varchar(255); not null" json:"actor"`
+	Payload        string    `gorm:"column:payload; type:text" json:"payload"`
+	CreatedAt      time.Time `gorm:"column:created_at; autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time `gorm:"column:updated_at; autoUpdateTime" json:"updated_at"`
+}
+
+func (DisbursementStatusHistory) TableName() string {
+	return "disbursement_status_history"
+}
+
+func (d *DisbursementStatusHistory) CreateDisbursementStatusHistory(db *gorm.DB) error {
+	err := postgresql.CreateOneRecord(db, &d)
+	if err != nil {
+		return fmt.Errorf("disbursement status history creation failed: %v", err.Error())
+	}
+	return nil
+}
+
+func (d *DisbursementStatusHistory) GetDisbursementStatusHistoryByDisbursementID(db *gorm.DB) ([]DisbursementStatusHistory, error) {
+	details := []DisbursementStatusHistory{}
+	err := postgresql.SelectAllFromDb(db, "asc", &details, "disbursement_id = ?", d.DisbursementID)
+	if err != nil {
+		return details, err
+	}
+	return details, nil
+}
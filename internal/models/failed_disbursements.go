@@ -3,6 +3,7 @@ package models
 import (
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
@@ -10,11 +11,41 @@ import (
 )
 
 type FailedDisbursement struct {
-	ID        uint      `gorm:"column:id; type:uint; not null; primaryKey; unique; autoIncrement" json:"id"`
-	PaymentID string    `gorm:"column:payment_id; type:varchar(255); not null" json:"payment_id"`
-	Reasons   string    `gorm:"column:reasons; type:text" json:"reasons"`
-	CreatedAt time.Time `gorm:"column:created_at; autoCreateTime" json:"created_at"`
-	UpdatedAt time.Time `gorm:"column:updated_at; autoUpdateTime" json:"updated_at"`
+	ID               uint       `gorm:"column:id; type:uint; not null; primaryKey; unique; autoIncrement" json:"id"`
+	PaymentID        string     `gorm:"column:payment_id; type:varchar(255); not null" json:"payment_id"`
+	TransactionID    string     `gorm:"column:transaction_id; type:varchar(255)" json:"transaction_id"`
+	BusinessID       int        `gorm:"column:business_id; type:int" json:"business_id"`
+	AccountID        int        `gorm:"column:account_id; type:int" json:"account_id"`
+	Currency         string     `gorm:"column:currency; type:varchar(255)" json:"currency"`
+	Amount           float64    `gorm:"column:amount; type:decimal(20,2)" json:"amount"`
+	Reasons          string     `gorm:"column:reasons; type:text" json:"reasons"`
+	ResolutionStatus string     `gorm:"column:resolution_status; type:varchar(255); not null; default:open; comment: open,cleared,resolved" json:"resolution_status"`
+	LastCheckedAt    *time.Time `gorm:"column:last_checked_at" json:"last_checked_at"`
+	ResolvedAt       *time.Time `gorm:"column:resolved_at" json:"resolved_at"`
+	CreatedAt        time.Time  `gorm:"column:created_at; autoCreateTime" json:"created_at"`
+	UpdatedAt        time.Time  `gorm:"column:updated_at; autoUpdateTime" json:"updated_at"`
+}
+
+type ListFailedDisbursementsRequest struct {
+	ResolutionStatus string `form:"resolution_status" validate:"omitempty,oneof=open cleared resolved"`
+	AccountID        int    `form:"account_id"`
+	BusinessID       int    `form:"business_id"`
+	TransactionID    string `form:"transaction_id"`
+	Reason           string `form:"reason"`
+	From             string `form:"from"`
+	To               string `form:"to"`
+}
+
+type RetryFailedDisbursementsRequest struct {
+	IDs []uint `json:"ids" validate:"required,min=1,max=50"`
+}
+
+type RetryFailedDisbursementResult struct {
+	ID               uint   `json:"id"`
+	PaymentID        string `json:"payment_id"`
+	ResolutionStatus string `json:"resolution_status"`
+	Reasons          string `json:"reasons"`
+	Error            string `json:"error,omitempty"`
 }
 
 func (f *FailedDisbursement) GetFailedDisbursementByPaymentID(db *gorm.DB) (int, error) {
@@ -29,6 +60,61 @@ func (f *FailedDisbursement) GetFailedDisbursementByPaymentID(db *gorm.DB) (int,
 	return http.StatusOK, nil
 }
 
+func (f *FailedDisbursement) GetFailedDisbursementByID(db *gorm.DB) (int, error) {
+	err, nilErr := postgresql.SelectOneFromDb(db, &f, "id = ?", f.ID)
+	if nilErr != nil {
+		return http.StatusBadRequest, nilErr
+	}
+
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+func (f *FailedDisbursement) GetFailedDisbursements(db *gorm.DB, req ListFailedDisbursementsRequest, paginator postgresql.Pagination) ([]FailedDisbursement, postgresql.PaginationResponse, error) {
+	var (
+		details    = []FailedDisbursement{}
+		conditions = []string{"payment_id <> ?"}
+		args       = []interface{}{""}
+	)
+
+	if req.ResolutionStatus != "" {
+		conditions = append(conditions, "resolution_status = ?")
+		args = append(args, req.ResolutionStatus)
+	}
+	if req.AccountID != 0 {
+		conditions = append(conditions, "account_id = ?")
+		args = append(args, req.AccountID)
+	}
+	if req.BusinessID != 0 {
+		conditions = append(conditions, "business_id = ?")
+		args = append(args, req.BusinessID)
+	}
+	if req.TransactionID != "" {
+		conditions = append(conditions, "transaction_id = ?")
+		args = append(args, req.TransactionID)
+	}
+	if req.Reason != "" {
+		conditions = append(conditions, "LOWER(reasons) LIKE ?")
+		args = append(args, "%"+strings.ToLower(req.Reason)+"%")
+	}
+	if req.From != "" {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, req.From)
+	}
+	if req.To != "" {
+		conditions = append(conditions, "created_at < (?::date + interval '1 day')")
+		args = append(args, req.To)
+	}
+
+	pagination, err := postgresql.SelectAllFromDbOrderByPaginated(db, "id", "desc", paginator, &details, strings.Join(conditions, " and "), args...)
+	if err != nil {
+		return details, pagination, err
+	}
+	return details, pagination, nil
+}
+
 func (f *FailedDisbursement) CreateFailedDisbursement(db *gorm.DB) error {
 	err := postgresql.CreateOneRecord(db, &f)
 	if err != nil {
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"gorm.io/gorm"
+)
+
+type GatewayHealth struct {
+	ID               uint      `gorm:"column:id; type:uint; not null; primaryKey; unique; autoIncrement" json:"id"`
+	Gateway          string    `gorm:"column:gateway; type:varchar(255); not null; unique" json:"gateway"`
+	Status           string    `gorm:"column:status; type:varchar(255); not null; default: 'enabled'" json:"status"`
+	Reason           string    `gorm:"column:reason; type:text" json:"reason"`
+	UpdatedBy        string    `gorm:"column:updated_by; type:varchar(255)" json:"updated_by"`
+	TotalCalls       int       `gorm:"column:total_calls; type:int" json:"total_calls"`
+	FailedCalls      int       `gorm:"column:failed_calls; type:int" json:"failed_calls"`
+	ErrorRate        float64   `gorm:"column:error_rate; type:decimal(20,2)" json:"error_rate"`
+	AverageLatencyMs int64     `gorm:"column:average_latency_ms; type:int" json:"average_latency_ms"`
+	LastError        string    `gorm:"column:last_error; type:text" json:"last_error"`
+	CreatedAt        time.Time `gorm:"column:created_at; autoCreateTime" json:"created_at"`
+	UpdatedAt        time.Time `gorm:"column:updated_at; autoUpdateTime" json:"updated_at"`
+}
+
+type UpdateGatewayHealthRequest struct {
+	Gateway string `json:"gateway" validate:"required"`
+	Status  string `json:"status" validate:"required,oneof=enabled degraded disabled"`
+	Reason  string `json:"reason"`
+}
+
+func (g *GatewayHealth) CreateGatewayHealth(db *gorm.DB) error {
+	err := postgresql.CreateOneRecord(db, &g)
+	if err != nil {
+		return fmt.Errorf("gateway health creation failed: %v", err.Error())
+	}
+	return nil
+}
+
+func (g *GatewayHealth) GetGatewayHealthByGateway(db *gorm.DB) (int, error) {
+	err, nilErr := postgresql.SelectOneFromDb(db, &g, "gateway = ?", g.Gateway)
+	if nilErr != nil {
+		return http.StatusBadRequest, nilErr
+	}
+
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+func (g *GatewayHealth) GetAllGatewayHealth(db *gorm.DB) ([]GatewayHealth, error) {
+	details := []GatewayHealth{}
+	err := postgresql.SelectAllFromDb(db, "asc", &details, "gateway <> ?", "")
+	if err != nil {
+		return details, err
+	}
+	return details, nil
+}
+
+func (g *GatewayHealth) UpdateAllFields(db *gorm.DB) error {
+	_, err := postgresql.SaveAllFields(db, &g)
+	return err
+}
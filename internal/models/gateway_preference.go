@@ -0,0 +1,77 @@
+package models
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"gorm.io/gorm"
+)
+
+type GatewayPreference struct {
+	ID                   uint      `gorm:"column:id; type:uint; not null; primaryKey; unique; autoIncrement" json:"id"`
+	BusinessID           int       `gorm:"column:business_id; type:int; not null" json:"business_id"`
+	Country              string    `gorm:"column:country; type:varchar(250); not null" json:"country"`
+	PaymentGateways      string    `gorm:"column:payment_gateways; type:varchar(255)" json:"payment_gateways"`
+	DisbursementGateways string    `gorm:"column:disbursement_gateways; type:varchar(255)" json:"disbursement_gateways"`
+	CreatedAt            time.Time `gorm:"column:created_at; autoCreateTime" json:"created_at"`
+	UpdatedAt            time.Time `gorm:"column:updated_at; autoUpdateTime" json:"updated_at"`
+}
+
+type SetGatewayPreferenceRequest struct {
+	BusinessID           int      `json:"business_id"`
+	Country              string   `json:"country" validate:"required"`
+	PaymentGateways      []string `json:"payment_gateways"`
+	DisbursementGateways []string `json:"disbursement_gateways"`
+}
+
+type GetGatewayPreferenceRequest struct {
+	BusinessID int    `json:"business_id" form:"business_id"`
+	Country    string `json:"country" form:"country" validate:"required"`
+}
+
+func (g *GatewayPreference) CreateGatewayPreference(db *gorm.DB) error {
+	err := postgresql.CreateOneRecord(db, &g)
+	if err != nil {
+		return fmt.Errorf("gateway preference creation failed: %v", err.Error())
+	}
+	return nil
+}
+
+func (g *GatewayPreference) GetGatewayPreferenceByBusinessIDAndCountry(db *gorm.DB) (int, error) {
+	err, nilErr := postgresql.SelectOneFromDb(db, &g, "business_id = ? and LOWER(country) = ?", g.BusinessID, strings.ToLower(g.Country))
+	if nilErr != nil {
+		return http.StatusBadRequest, nilErr
+	}
+
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+func (g *GatewayPreference) UpdateAllFields(db *gorm.DB) error {
+	_, err := postgresql.SaveAllFields(db, &g)
+	return err
+}
+
+func (g *GatewayPreference) PaymentGatewayList() []string {
+	return splitGatewayList(g.PaymentGateways)
+}
+
+func (g *GatewayPreference) DisbursementGatewayList() []string {
+	return splitGatewayList(g.DisbursementGateways)
+}
+
+func splitGatewayList(gateways string) []string {
+	list := []string{}
+	for _, gateway := range strings.Split(gateways, ",") {
+		gateway = strings.ToLower(strings.TrimSpace(gateway))
+		if gateway != "" {
+			list = append(list, gateway)
+		}
+	}
+	return list
+}
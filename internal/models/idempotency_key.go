@@ -0,0 +1,53 @@
+package models
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"gorm.io/gorm"
+)
+
+type IdempotencyKey struct {
+	ID           uint      `gorm:"column:id; type:uint; not null; primaryKey; unique; autoIncrement" json:"id"`
+	Key          string    `gorm:"column:key; type:varchar(255); not null; uniqueIndex:idx_idempotency_key_scope" json:"key"`
+	Scope        string    `gorm:"column:scope; type:varchar(255); not null; uniqueIndex:idx_idempotency_key_scope" json:"scope"`
+	Method       string    `gorm:"column:method; type:varchar(255)" json:"method"`
+	Path         string    `gorm:"column:path; type:varchar(255)" json:"path"`
+	RequestHash  string    `gorm:"column:request_hash; type:varchar(255); not null" json:"request_hash"`
+	Status       string    `gorm:"column:status; type:varchar(255); not null; default:processing; comment: processing,completed" json:"status"`
+	ResponseCode int       `gorm:"column:response_code; type:int" json:"response_code"`
+	ResponseBody string    `gorm:"column:response_body; type:text" json:"response_body"`
+	CreatedAt    time.Time `gorm:"column:created_at; autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time `gorm:"column:updated_at; autoUpdateTime" json:"updated_at"`
+}
+
+func (i *IdempotencyKey) CreateIdempotencyKey(db *gorm.DB) error {
+	err := postgresql.CreateOneRecord(db, &i)
+	if err != nil {
+		return fmt.Errorf("idempotency key creation failed: %v", err.Error())
+	}
+	return nil
+}
+
+func (i *IdempotencyKey) GetIdempotencyKeyByKeyAndScope(db *gorm.DB) (int, error) {
+	err, nilErr := postgresql.SelectOneFromDb(db, &i, "key = ? and scope = ?", i.Key, i.Scope)
+	if nilErr != nil {
+		return http.StatusBadRequest, nilErr
+	}
+
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+func (i *IdempotencyKey) UpdateAllFields(db *gorm.DB) error {
+	_, err := postgresql.SaveAllFields(db, &i)
+	return err
+}
+
+func (i *IdempotencyKey) Delete(db *gorm.DB) error {
+	return postgresql.DeleteRecordFromDb(db, i)
+}
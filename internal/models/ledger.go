@@ -0,0 +1,103 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"gorm.io/gorm"
+)
+
+type LedgerEntry struct {
+	ID            uint         `gorm:"column:id; type:uint; not null; primaryKey; unique; autoIncrement" json:"id"`
+	Type          string       `gorm:"column:type; type:varchar(255); not null" json:"type"`
+	Reference     string       `gorm:"column:reference; type:varchar(255)" json:"reference"`
+	TransactionID string       `gorm:"column:transaction_id; type:varchar(255)" json:"transaction_id"`
+	Description   string       `gorm:"column:description; type:text" json:"description"`
+	CreatedAt     time.Time    `gorm:"column:created_at; autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time    `gorm:"column:updated_at; autoUpdateTime" json:"updated_at"`
+	Lines         []LedgerLine `gorm:"-" json:"lines"`
+}
+
+type LedgerLine struct {
+	ID        uint      `gorm:"column:id; type:uint; not null; primaryKey; unique; autoIncrement" json:"id"`
+	EntryID   uint      `gorm:"column:entry_id; type:uint; not null; index" json:"entry_id"`
+	Account   string    `gorm:"column:account; type:varchar(255); not null" json:"account"`
+	AccountID int       `gorm:"column:account_id; type:int; not null; default:0" json:"account_id"`
+	Currency  string    `gorm:"column:currency; type:varchar(255); not null" json:"currency"`
+	Debit     float64   `gorm:"column:debit; type:decimal(20,2); not null; default:0" json:"debit"`
+	Credit    float64   `gorm:"column:credit; type:decimal(20,2); not null; default:0" json:"credit"`
+	CreatedAt time.Time `gorm:"column:created_at; autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at; autoUpdateTime" json:"updated_at"`
+}
+
+type GetLedgerBalanceRequest struct {
+	AccountID int    `json:"account_id" form:"account_id" validate:"required"`
+	Currency  string `json:"currency" form:"currency" validate:"required"`
+}
+
+type LedgerBalanceResponse struct {
+	AccountID     int     `json:"account_id"`
+	Currency      string  `json:"currency"`
+	LedgerBalance float64 `json:"ledger_balance"`
+	WalletBalance float64 `json:"wallet_balance"`
+	Difference    float64 `json:"difference"`
+	Balanced      bool    `json:"balanced"`
+}
+
+func (l *LedgerEntry) CreateLedgerEntry(db *gorm.DB) error {
+	err := postgresql.CreateOneRecord(db, &l)
+	if err != nil {
+		return fmt.Errorf("ledger entry creation failed: %v", err.Error())
+	}
+
+	for i := range l.Lines {
+		l.Lines[i].EntryID = l.ID
+		err := l.Lines[i].CreateLedgerLine(db)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *LedgerLine) CreateLedgerLine(db *gorm.DB) error {
+	err := postgresql.CreateOneRecord(db, &l)
+	if err != nil {
+		return fmt.Errorf("ledger line creation failed: %v", err.Error())
+	}
+	return nil
+}
+
+func (l *LedgerLine) GetLedgerLinesByEntryID(db *gorm.DB) ([]LedgerLine, error) {
+	details := []LedgerLine{}
+	err := postgresql.SelectAllFromDb(db, "asc", &details, "entry_id = ?", l.EntryID)
+	if err != nil {
+		return details, err
+	}
+	return details, nil
+}
+
+func (l *LedgerLine) CheckLedgerLineExistsForAccount(db *gorm.DB) bool {
+	return postgresql.CheckExists(db, &LedgerLine{}, "account = ? and account_id = ? and currency = ?", l.Account, l.AccountID, l.Currency)
+}
+
+// GetLedgerBalance returns credits minus debits posted to an account in a currency.
+func (l *LedgerLine) GetLedgerBalance(db *gorm.DB) (float64, error) {
+	var balance float64
+	err := postgresql.SumFromDb(db, &LedgerLine{}, "SUM(credit) - SUM(debit)", &balance, "account = ? and account_id = ? and currency = ?", l.Account, l.AccountID, l.Currency)
+	return balance, err
+}
+
+func (l *LedgerEntry) CheckLedgerEntryExists(db *gorm.DB) (bool, error) {
+	entry := LedgerEntry{}
+	err, nilErr := postgresql.SelectOneFromDb(db, &entry, "reference = ? and type = ?", l.Reference, l.Type)
+	if nilErr != nil {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
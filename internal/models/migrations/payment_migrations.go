@@ -5,11 +5,24 @@ import "github.com/vesicash/payment-ms/internal/models"
 // _ = db.AutoMigrate(MigrationModels()...)
 func AuthMigrationModels() []interface{} {
 	return []interface{}{
+		models.BeneficiaryNameEnquiry{},
+		models.BulkPayoutItem{},
+		models.BulkPayout{},
+		models.DisbursementApprovalPolicy{},
+		models.DisbursementApproval{},
+		models.DisbursementBatch{},
 		models.DisbursementLog{},
 		models.DisbursementRequestLog{},
+		models.DisbursementRetrySetting{},
+		models.DisbursementStatusHistory{},
 		models.Disbursement{},
 		models.FailedDisbursement{},
 		models.FundingAccount{},
+		models.GatewayHealth{},
+		models.GatewayPreference{},
+		models.IdempotencyKey{},
+		models.LedgerEntry{},
+		models.LedgerLine{},
 		models.PaymentAccount{},
 		models.PaymentCallback{},
 		models.PaymentCardInfo{},
@@ -17,9 +30,19 @@ func AuthMigrationModels() []interface{} {
 		models.PaymentLog{},
 		models.Payment{},
 		models.PendingTransferFunding{},
+		models.SettlementBatchItem{},
+		models.SettlementBatch{},
+		models.SettlementHoliday{},
+		models.SettlementSchedule{},
 		// models.WalletDebitLog{},
 		models.WalletEarningLog{},
+		models.WalletHold{},
+		models.WalletTransferApproval{},
+		models.WalletTransferStep{},
+		models.WalletTransfer{},
+		models.WebhookEndpoint{},
 		models.WebhookLog{},
+		models.WebhookSetting{},
 		models.Webhook{},
 	}
 }
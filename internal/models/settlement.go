@@ -0,0 +1,252 @@
+package models
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"gorm.io/gorm"
+)
+
+// SettlementSchedule decides when the payouts of a business are released, businesses without one are paid out instantly.
+type SettlementSchedule struct {
+	ID           uint      `gorm:"column:id; type:uint; not null; primaryKey; unique; autoIncrement" json:"id"`
+	BusinessID   int       `gorm:"column:business_id; type:int; not null; unique" json:"business_id"`
+	Frequency    string    `gorm:"column:frequency; type:varchar(255); not null; default:instant; comment: instant,daily,weekly,t_plus_n" json:"frequency"`
+	DayOfWeek    int       `gorm:"column:day_of_week; type:int; default: 1; comment: 0 is sunday" json:"day_of_week"`
+	DelayDays    int       `gorm:"column:delay_days; type:int; default: 0" json:"delay_days"`
+	Hour         int       `gorm:"column:hour; type:int; default: 0" json:"hour"`
+	Timezone     string    `gorm:"column:timezone; type:varchar(255); default:Africa/Lagos" json:"timezone"`
+	SkipWeekends bool      `gorm:"column:skip_weekends; type:bool; default:false" json:"skip_weekends"`
+	Country      string    `gorm:"column:country; type:varchar(255)" json:"country"`
+	CreatedAt    time.Time `gorm:"column:created_at; autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time `gorm:"column:updated_at; autoUpdateTime" json:"updated_at"`
+}
+
+// SettlementHoliday is a day on which no settlement window opens, an empty country applies to every country.
+type SettlementHoliday struct {
+	ID        uint      `gorm:"column:id; type:uint; not null; primaryKey; unique; autoIncrement" json:"id"`
+	Date      string    `gorm:"column:date; type:varchar(10); not null; uniqueIndex:idx_settlement_holiday_date_country" json:"date"`
+	Country   string    `gorm:"column:country; type:varchar(255); not null; default:''; uniqueIndex:idx_settlement_holiday_date_country" json:"country"`
+	Name      string    `gorm:"column:name; type:varchar(255)" json:"name"`
+	CreatedAt time.Time `gorm:"column:created_at; autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at; autoUpdateTime" json:"updated_at"`
+}
+
+// SettlementBatch groups the payments to one recipient that are paid out together when the window opens.
+type SettlementBatch struct {
+	ID             uint       `gorm:"column:id; type:uint; not null; primaryKey; unique; autoIncrement" json:"id"`
+	BatchID        string     `gorm:"column:batch_id; type:varchar(255); not null; unique" json:"batch_id"`
+	BusinessID     int        `gorm:"column:business_id; type:int; not null" json:"business_id"`
+	RecipientID    int        `gorm:"column:recipient_id; type:int; not null" json:"recipient_id"`
+	Currency       string     `gorm:"column:currency; type:varchar(255); not null" json:"currency"`
+	TotalAmount    float64    `gorm:"column:total_amount; type:decimal(20,2); default: 0" json:"total_amount"`
+	PaymentCount   int        `gorm:"column:payment_count; type:int; default: 0" json:"payment_count"`
+	Status         string     `gorm:"column:status; type:varchar(255); not null; default:open; comment: open,released,completed,failed" json:"status"`
+	WindowOpensAt  time.Time  `gorm:"column:window_opens_at" json:"window_opens_at"`
+	DisbursementID int        `gorm:"column:disbursement_id; type:int; default: 0" json:"disbursement_id"`
+	Note           string     `gorm:"column:note; type:text" json:"note"`
+	ReleasedAt     *time.Time `gorm:"column:released_at" json:"released_at"`
+	SettledAt      *time.Time `gorm:"column:settled_at" json:"settled_at"`
+	CreatedAt      time.Time  `gorm:"column:created_at; autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"column:updated_at; autoUpdateTime" json:"updated_at"`
+}
+
+type SettlementBatchItem struct {
+	ID            uint      `gorm:"column:id; type:uint; not null; primaryKey; unique; autoIncrement" json:"id"`
+	BatchID       string    `gorm:"column:batch_id; type:varchar(255); not null; index" json:"batch_id"`
+	PaymentID     string    `gorm:"column:payment_id; type:varchar(255); not null; unique" json:"payment_id"`
+	TransactionID string    `gorm:"column:transaction_id; type:varchar(255)" json:"transaction_id"`
+	Amount        float64   `gorm:"column:amount; type:decimal(20,2)" json:"amount"`
+	Currency      string    `gorm:"column:currency; type:varchar(255)" json:"currency"`
+	CreatedAt     time.Time `gorm:"column:created_at; autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time `gorm:"column:updated_at; autoUpdateTime" json:"updated_at"`
+}
+
+type SetSettlementScheduleRequest struct {
+	Frequency    string `json:"frequency" validate:"required,oneof=instant daily weekly t_plus_n"`
+	DayOfWeek    int    `json:"day_of_week" validate:"gte=0,lte=6"`
+	DelayDays    int    `json:"delay_days" validate:"gte=0,lte=30"`
+	Hour         int    `json:"hour" validate:"gte=0,lte=23"`
+	Timezone     string `json:"timezone"`
+	SkipWeekends bool   `json:"skip_weekends"`
+	Country      string `json:"country"`
+}
+
+type CreateSettlementHolidayRequest struct {
+	Date    string `json:"date" validate:"required,datetime=2006-01-02"`
+	Country string `json:"country"`
+	Name    string `json:"name" validate:"required"`
+}
+
+type SettlementReport struct {
+	Batch        SettlementBatch       `json:"batch"`
+	Disbursement *Disbursement         `json:"disbursement"`
+	Items        []SettlementBatchItem `json:"items"`
+}
+
+func (s *SettlementSchedule) CreateSettlementSchedule(db *gorm.DB) error {
+	err := postgresql.CreateOneRecord(db, &s)
+	if err != nil {
+		return fmt.Errorf("settlement schedule creation failed: %v", err.Error())
+	}
+	return nil
+}
+
+func (s *SettlementSchedule) GetSettlementScheduleByBusinessID(db *gorm.DB) (int, error) {
+	err, nilErr := postgresql.SelectOneFromDb(db, &s, "business_id = ?", s.BusinessID)
+	if nilErr != nil {
+		return http.StatusBadRequest, nilErr
+	}
+
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+func (s *SettlementSchedule) UpdateAllFields(db *gorm.DB) error {
+	_, err := postgresql.SaveAllFields(db, &s)
+	return err
+}
+
+func (s *SettlementHoliday) CreateSettlementHoliday(db *gorm.DB) error {
+	err := postgresql.CreateOneRecord(db, &s)
+	if err != nil {
+		return fmt.Errorf("settlement holiday creation failed: %v", err.Error())
+	}
+	return nil
+}
+
+func (s *SettlementHoliday) GetSettlementHolidayByID(db *gorm.DB) (int, error) {
+	err, nilErr := postgresql.SelectOneFromDb(db, &s, "id = ?", s.ID)
+	if nilErr != nil {
+		return http.StatusBadRequest, nilErr
+	}
+
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+func (s *SettlementHoliday) CheckSettlementHolidayExists(db *gorm.DB) bool {
+	return postgresql.CheckExists(db, &SettlementHoliday{}, "date = ? and country = ?", s.Date, s.Country)
+}
+
+func (s *SettlementHoliday) GetSettlementHolidays(db *gorm.DB) ([]SettlementHoliday, error) {
+	details := []SettlementHoliday{}
+	err := postgresql.SelectAllFromDbOrderBy(db, "date", "asc", &details, "date <> ?", "")
+	if err != nil {
+		return details, err
+	}
+	return details, nil
+}
+
+// GetSettlementHolidaysFrom returns the holidays of a country, and those of every country, from date on.
+func (s *SettlementHoliday) GetSettlementHolidaysFrom(db *gorm.DB, date string) ([]SettlementHoliday, error) {
+	details := []SettlementHoliday{}
+	err := postgresql.SelectAllFromDbOrderBy(db, "date", "asc", &details, "date >= ? and (country = ? or country = ?)", date, s.Country, "")
+	if err != nil {
+		return details, err
+	}
+	return details, nil
+}
+
+func (s *SettlementHoliday) Delete(db *gorm.DB) error {
+	return postgresql.DeleteRecordFromDb(db, &s)
+}
+
+func (s *SettlementBatch) CreateSettlementBatch(db *gorm.DB) error {
+	err := postgresql.CreateOneRecord(db, &s)
+	if err != nil {
+		return fmt.Errorf("settlement batch creation failed: %v", err.Error())
+	}
+	return nil
+}
+
+func (s *SettlementBatch) GetSettlementBatchByBatchID(db *gorm.DB) (int, error) {
+	err, nilErr := postgresql.SelectOneFromDb(db, &s, "batch_id = ?", s.BatchID)
+	if nilErr != nil {
+		return http.StatusBadRequest, nilErr
+	}
+
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+func (s *SettlementBatch) GetSettlementBatchByBatchIDAndBusinessID(db *gorm.DB) (int, error) {
+	err, nilErr := postgresql.SelectOneFromDb(db, &s, "batch_id = ? and business_id = ?", s.BatchID, s.BusinessID)
+	if nilErr != nil {
+		return http.StatusBadRequest, nilErr
+	}
+
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// GetOpenSettlementBatch finds the open batch of a recipient for the window.
+func (s *SettlementBatch) GetOpenSettlementBatch(db *gorm.DB) (int, error) {
+	err, nilErr := postgresql.SelectOneFromDb(db, &s, "business_id = ? and recipient_id = ? and currency = ? and window_opens_at = ? and status = ?", s.BusinessID, s.RecipientID, s.Currency, s.WindowOpensAt, "open")
+	if nilErr != nil {
+		return http.StatusBadRequest, nilErr
+	}
+
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+func (s *SettlementBatch) GetDueSettlementBatches(db *gorm.DB, now time.Time) ([]SettlementBatch, error) {
+	details := []SettlementBatch{}
+	err := postgresql.SelectAllFromDbOrderBy(db, "window_opens_at", "asc", &details, "status = ? and window_opens_at <= ?", "open", now)
+	if err != nil {
+		return details, err
+	}
+	return details, nil
+}
+
+func (s *SettlementBatch) GetSettlementBatchesByBusinessID(db *gorm.DB, paginator postgresql.Pagination) ([]SettlementBatch, postgresql.PaginationResponse, error) {
+	details := []SettlementBatch{}
+	pagination, err := postgresql.SelectAllFromDbOrderByPaginated(db, "id", "desc", paginator, &details, "business_id = ?", s.BusinessID)
+	if err != nil {
+		return details, pagination, err
+	}
+	return details, pagination, nil
+}
+
+func (s *SettlementBatch) UpdateAllFields(db *gorm.DB) error {
+	_, err := postgresql.SaveAllFields(db, &s)
+	return err
+}
+
+func (s *SettlementBatchItem) CreateSettlementBatchItem(db *gorm.DB) error {
+	err := postgresql.CreateOneRecord(db, &s)
+	if err != nil {
+		return fmt.Errorf("settlement batch item creation failed: %v", err.Error())
+	}
+	return nil
+}
+
+func (s *SettlementBatchItem) CheckSettlementBatchItemExists(db *gorm.DB) bool {
+	return postgresql.CheckExists(db, &SettlementBatchItem{}, "payment_id = ?", s.PaymentID)
+}
+
+func (s *SettlementBatchItem) GetSettlementBatchItemsByBatchID(db *gorm.DB) ([]SettlementBatchItem, error) {
+	details := []SettlementBatchItem{}
+	err := postgresql.SelectAllFromDbOrderBy(db, "id", "asc", &details, "batch_id = ?", s.BatchID)
+	if err != nil {
+		return details, err
+	}
+	return details, nil
+}
+
+func (s *SettlementBatchItem) Delete(db *gorm.DB) error {
+	return postgresql.DeleteRecordFromDb(db, &s)
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"gorm.io/gorm"
+)
+
+type WalletHold struct {
+	ID              uint       `gorm:"column:id; type:uint; not null; primaryKey; unique; autoIncrement" json:"id"`
+	Reference       string     `gorm:"column:reference; type:varchar(255); not null; unique" json:"reference"`
+	AccountID       int        `gorm:"column:account_id; type:int; not null; index" json:"account_id"`
+	Currency        string     `gorm:"column:currency; type:varchar(255); not null" json:"currency"`
+	Amount          float64    `gorm:"column:amount; type:decimal(20,2); not null" json:"amount"`
+	Type            string     `gorm:"column:type; type:varchar(255); not null; comment: transfer,disbursement,dispute" json:"type"`
+	SourceReference string     `gorm:"column:source_reference; type:varchar(255); index" json:"source_reference"`
+	Reason          string     `gorm:"column:reason; type:text" json:"reason"`
+	Status          string     `gorm:"column:status; type:varchar(255); not null; default:active; comment: active,releasing,captured,released,expired" json:"status"`
+	ExpiresAt       *time.Time `gorm:"column:expires_at" json:"expires_at"`
+	SettledAt       *time.Time `gorm:"column:settled_at" json:"settled_at"`
+	CreatedAt       time.Time  `gorm:"column:created_at; autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time  `gorm:"column:updated_at; autoUpdateTime" json:"updated_at"`
+}
+
+type PlaceWalletHoldRequest struct {
+	AccountID       int     `json:"account_id" validate:"required"`
+	Currency        string  `json:"currency" validate:"required"`
+	Amount          float64 `json:"amount" validate:"required,gt=0"`
+	Type            string  `json:"type" validate:"required,oneof=transfer disbursement dispute"`
+	SourceReference string  `json:"source_reference" validate:"required"`
+	Reason          string  `json:"reason"`
+	ExpiresInHours  int     `json:"expires_in_hours"`
+}
+
+type SettleWalletHoldRequest struct {
+	Reference string `json:"reference" validate:"required"`
+}
+
+type ListWalletHoldsRequest struct {
+	Currency string `json:"currency" form:"currency"`
+}
+
+type GetWalletBalanceRequest struct {
+	Currency string `json:"currency" form:"currency" validate:"required"`
+}
+
+type WalletBalanceResponse struct {
+	AccountID int     `json:"account_id"`
+	Currency  string  `json:"currency"`
+	Available float64 `json:"available"`
+	Pending   float64 `json:"pending"`
+	Total     float64 `json:"total"`
+}
+
+func (w *WalletHold) CreateWalletHold(db *gorm.DB) error {
+	err := postgresql.CreateOneRecord(db, &w)
+	if err != nil {
+		return fmt.Errorf("wallet hold creation failed: %v", err.Error())
+	}
+	return nil
+}
+
+func (w *WalletHold) GetWalletHoldByReference(db *gorm.DB) (int, error) {
+	err, nilErr := postgresql.SelectOneFromDb(db, &w, "reference = ?", w.Reference)
+	if nilErr != nil {
+		return http.StatusBadRequest, nilErr
+	}
+
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+func (w *WalletHold) GetLatestWalletHoldBySource(db *gorm.DB) (int, error) {
+	err, nilErr := postgresql.SelectLatestFromDb(db, &w, "type = ? and source_reference = ?", w.Type, w.SourceReference)
+	if nilErr != nil {
+		return http.StatusBadRequest, nilErr
+	}
+
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+func (w *WalletHold) GetActiveWalletHoldsByAccountID(db *gorm.DB) ([]WalletHold, error) {
+	details := []WalletHold{}
+	query, args := "account_id = ? and status = ?", []interface{}{w.AccountID, "active"}
+	if w.Currency != "" {
+		query += " and currency = ?"
+		args = append(args, strings.ToUpper(w.Currency))
+	}
+
+	err := postgresql.SelectAllFromDb(db, "desc", &details, query, args...)
+	if err != nil {
+		return details, err
+	}
+	return details, nil
+}
+
+func (w *WalletHold) GetExpiredWalletHolds(db *gorm.DB) ([]WalletHold, error) {
+	details := []WalletHold{}
+	err := postgresql.SelectAllFromDb(db, "asc", &details, "status = ? and expires_at IS NOT NULL and expires_at < ?", "active", time.Now())
+	if err != nil {
+		return details, err
+	}
+	return details, nil
+}
+
+func (w *WalletHold) UpdateAllFields(db *gorm.DB) error {
+	_, err := postgresql.SaveAllFields(db, &w)
+	return err
+}
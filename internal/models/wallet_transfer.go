@@ -0,0 +1,116 @@
+package models
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"gorm.io/gorm"
+)
+
+type WalletTransfer struct {
+	ID                 uint      `gorm:"column:id; type:uint; not null; primaryKey; unique; autoIncrement" json:"id"`
+	Reference          string    `gorm:"column:reference; type:varchar(255); not null; unique" json:"reference"`
+	SenderAccountID    int       `gorm:"column:sender_account_id; type:int; not null" json:"sender_account_id"`
+	RecipientAccountID int       `gorm:"column:recipient_account_id; type:int; not null" json:"recipient_account_id"`
+	SenderCurrency     string    `gorm:"column:sender_currency; type:varchar(255); not null" json:"sender_currency"`
+	RecipientCurrency  string    `gorm:"column:recipient_currency; type:varchar(255); not null" json:"recipient_currency"`
+	Amount             float64   `gorm:"column:amount; type:decimal(20,2); not null" json:"amount"`
+	RecipientAmount    float64   `gorm:"column:recipient_amount; type:decimal(20,2); not null" json:"recipient_amount"`
+	InitialAmount      float64   `gorm:"column:initial_amount; type:decimal(20,2)" json:"initial_amount"`
+	FinalAmount        float64   `gorm:"column:final_amount; type:decimal(20,2)" json:"final_amount"`
+	RateID             int       `gorm:"column:rate_id; type:int" json:"rate_id"`
+	TransactionID      string    `gorm:"column:transaction_id; type:varchar(255)" json:"transaction_id"`
+	Refund             bool      `gorm:"column:refund; type:bool; default:false" json:"refund"`
+	EscrowWallet       string    `gorm:"column:escrow_wallet; type:varchar(255); default:no" json:"escrow_wallet"`
+	Status             string    `gorm:"column:status; type:varchar(255); not null; default:pending; comment: pending,debited,exchanged,queued,approved,rejected,completed,compensating,compensated,failed,review" json:"status"`
+	LastError          string    `gorm:"column:last_error; type:text" json:"last_error"`
+	CreatedAt          time.Time `gorm:"column:created_at; autoCreateTime" json:"created_at"`
+	UpdatedAt          time.Time `gorm:"column:updated_at; autoUpdateTime" json:"updated_at"`
+}
+
+type WalletTransferStep struct {
+	ID               uint      `gorm:"column:id; type:uint; not null; primaryKey; unique; autoIncrement" json:"id"`
+	WalletTransferID uint      `gorm:"column:wallet_transfer_id; type:uint; not null; index" json:"wallet_transfer_id"`
+	Step             string    `gorm:"column:step; type:varchar(255); not null" json:"step"`
+	Status           string    `gorm:"column:status; type:varchar(255); not null; comment: completed,failed" json:"status"`
+	Error            string    `gorm:"column:error; type:text" json:"error"`
+	CreatedAt        time.Time `gorm:"column:created_at; autoCreateTime" json:"created_at"`
+	UpdatedAt        time.Time `gorm:"column:updated_at; autoUpdateTime" json:"updated_at"`
+}
+
+type WalletTransferApproval struct {
+	ID               uint      `gorm:"column:id; type:uint; not null; primaryKey; unique; autoIncrement" json:"id"`
+	WalletTransferID uint      `gorm:"column:wallet_transfer_id; type:uint; not null; index" json:"wallet_transfer_id"`
+	Reference        string    `gorm:"column:reference; type:varchar(255); not null; index" json:"reference"`
+	Action           string    `gorm:"column:action; type:varchar(255); not null; comment: approve,reject" json:"action"`
+	ActorAccountID   int       `gorm:"column:actor_account_id; type:int; not null" json:"actor_account_id"`
+	Reason           string    `gorm:"column:reason; type:text; not null" json:"reason"`
+	CreatedAt        time.Time `gorm:"column:created_at; autoCreateTime" json:"created_at"`
+	UpdatedAt        time.Time `gorm:"column:updated_at; autoUpdateTime" json:"updated_at"`
+}
+
+type WalletTransferDecisionRequest struct {
+	Reference string `json:"reference" validate:"required"`
+	Reason    string `json:"reason" validate:"required"`
+}
+
+func (w *WalletTransfer) CreateWalletTransfer(db *gorm.DB) error {
+	err := postgresql.CreateOneRecord(db, &w)
+	if err != nil {
+		return fmt.Errorf("wallet transfer creation failed: %v", err.Error())
+	}
+	return nil
+}
+
+func (w *WalletTransfer) GetWalletTransferByReference(db *gorm.DB) (int, error) {
+	err, nilErr := postgresql.SelectOneFromDb(db, &w, "reference = ?", w.Reference)
+	if nilErr != nil {
+		return http.StatusBadRequest, nilErr
+	}
+
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+func (w *WalletTransfer) GetStaleWalletTransfers(db *gorm.DB, statuses []string, updatedBefore time.Time) ([]WalletTransfer, error) {
+	details := []WalletTransfer{}
+	err := postgresql.SelectAllFromDb(db, "asc", &details, "status IN (?) and updated_at < ?", statuses, updatedBefore)
+	if err != nil {
+		return details, err
+	}
+	return details, nil
+}
+
+func (w *WalletTransfer) GetWalletTransfersByStatus(db *gorm.DB, paginator postgresql.Pagination) ([]WalletTransfer, postgresql.PaginationResponse, error) {
+	details := []WalletTransfer{}
+	pagination, err := postgresql.SelectAllFromDbOrderByPaginated(db, "id", "asc", paginator, &details, "status = ?", w.Status)
+	if err != nil {
+		return details, pagination, err
+	}
+	return details, pagination, nil
+}
+
+func (w *WalletTransfer) UpdateAllFields(db *gorm.DB) error {
+	_, err := postgresql.SaveAllFields(db, &w)
+	return err
+}
+
+func (w *WalletTransferStep) CreateWalletTransferStep(db *gorm.DB) error {
+	err := postgresql.CreateOneRecord(db, &w)
+	if err != nil {
+		return fmt.Errorf("wallet transfer step creation failed: %v", err.Error())
+	}
+	return nil
+}
+
+func (w *WalletTransferApproval) CreateWalletTransferApproval(db *gorm.DB) error {
+	err := postgresql.CreateOneRecord(db, &w)
+	if err != nil {
+		return fmt.Errorf("wallet transfer approval creation failed: %v", err.Error())
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
@@ -9,19 +10,41 @@ import (
 )
 
 type Webhook struct {
-	ID              uint      `gorm:"column:id; type:uint; not null; primaryKey; unique; autoIncrement" json:"id"`
-	Event           string    `gorm:"column:event; type:varchar(255); not null" json:"event"`
-	BusinessID      string    `gorm:"column:business_id; type:varchar(255); not null" json:"business_id"`
-	WebhookUri      string    `gorm:"column:webhook_uri; type:varchar(255)" json:"webhook_uri"`
-	RequestPayload  string    `gorm:"column:request_payload; type:text" json:"request_payload"`
-	IsReceived      bool      `gorm:"column:is_received; default: false" json:"is_received"`
-	ResponsePayload string    `gorm:"column:response_payload; type:text" json:"response_payload"`
-	ResponseCode    string    `gorm:"column:response_code; type:varchar(255)" json:"response_code"`
-	Tries           int       `gorm:"column:tries; type:int; not null; default: 0" json:"tries"`
-	IsAbandoned     bool      `gorm:"column:is_abandoned; default: false" json:"is_abandoned"`
-	CreatedAt       time.Time `gorm:"column:created_at; autoCreateTime" json:"created_at"`
-	UpdatedAt       time.Time `gorm:"column:updated_at; autoUpdateTime" json:"updated_at"`
-	RetryAt         string    `gorm:"column:retry_at; type:varchar(255)" json:"retry_at"`
+	ID              uint       `gorm:"column:id; type:uint; not null; primaryKey; unique; autoIncrement" json:"id"`
+	Event           string     `gorm:"column:event; type:varchar(255); not null" json:"event"`
+	BusinessID      string     `gorm:"column:business_id; type:varchar(255); not null" json:"business_id"`
+	WebhookUri      string     `gorm:"column:webhook_uri; type:varchar(255)" json:"webhook_uri"`
+	RequestPayload  string     `gorm:"column:request_payload; type:text" json:"request_payload"`
+	IsReceived      bool       `gorm:"column:is_received; default: false" json:"is_received"`
+	ResponsePayload string     `gorm:"column:response_payload; type:text" json:"response_payload"`
+	ResponseCode    string     `gorm:"column:response_code; type:varchar(255)" json:"response_code"`
+	Tries           int        `gorm:"column:tries; type:int; not null; default: 0" json:"tries"`
+	IsAbandoned     bool       `gorm:"column:is_abandoned; default: false" json:"is_abandoned"`
+	CreatedAt       time.Time  `gorm:"column:created_at; autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time  `gorm:"column:updated_at; autoUpdateTime" json:"updated_at"`
+	RetryAt         string     `gorm:"column:retry_at; type:varchar(255)" json:"retry_at"`
+	EndpointID      uint       `gorm:"column:endpoint_id; type:uint; default: 0" json:"endpoint_id"`
+	ClaimedUntil    *time.Time `gorm:"column:claimed_until" json:"claimed_until"`
+	RedeliveryOf    uint       `gorm:"column:redelivery_of; type:uint; default: 0" json:"redelivery_of"`
+	IsTest          bool       `gorm:"column:is_test; default: false" json:"is_test"`
+	EventID         string     `gorm:"column:event_id; type:varchar(255); index" json:"event_id"`
+	ApiVersion      string     `gorm:"column:api_version; type:varchar(255)" json:"api_version"`
+}
+
+type WebhookDelivery struct {
+	Webhook
+	Status string `json:"status"`
+}
+
+type ListWebhookDeliveriesRequest struct {
+	Event     string `form:"event" json:"event"`
+	Status    string `form:"status" json:"status" validate:"omitempty,oneof=pending delivered abandoned"`
+	StartDate string `form:"start_date" json:"start_date" validate:"omitempty,datetime=2006-01-02"`
+	EndDate   string `form:"end_date" json:"end_date" validate:"omitempty,datetime=2006-01-02"`
+}
+
+type SendTestWebhookRequest struct {
+	Event string `json:"event"`
 }
 
 func (w *Webhook) CreateWebhook(db *gorm.DB) error {
@@ -41,6 +64,70 @@ func (p *Webhook) GetAllByIsAbandonedAndIsReceived(db *gorm.DB) ([]Webhook, erro
 	return details, nil
 }
 
+// GetDueWebhooksForUpdate locks up to limit undelivered webhooks due for an attempt and not leased to a dispatcher,
+// it must run in a transaction and skips the rows another dispatcher has locked. Webhooks to disabled endpoints wait
+// until the endpoint is enabled again.
+func (w *Webhook) GetDueWebhooksForUpdate(tx *gorm.DB, now time.Time, limit int) ([]Webhook, error) {
+	details := []Webhook{}
+	err := postgresql.SelectForUpdateSkipLocked(tx, "id", "asc", limit, &details,
+		"is_abandoned = ? and is_received = ? and coalesce(nullif(retry_at, ''), '0')::bigint <= ? and (claimed_until is null or claimed_until <= ?) and endpoint_id not in (select id from webhook_endpoints where enabled = ?)",
+		false, false, now.Unix(), now, false)
+	if err != nil {
+		return details, err
+	}
+	return details, nil
+}
+
+func (w *Webhook) GetWebhookByIDAndBusinessID(db *gorm.DB) (int, error) {
+	err, nilErr := postgresql.SelectOneFromDb(db, &w, "id = ? and business_id = ?", w.ID, w.BusinessID)
+	if nilErr != nil {
+		return http.StatusBadRequest, nilErr
+	}
+
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+func (w *Webhook) GetWebhooksByBusinessID(db *gorm.DB, paginator postgresql.Pagination, req ListWebhookDeliveriesRequest) ([]Webhook, postgresql.PaginationResponse, error) {
+	var (
+		details = []Webhook{}
+		query   = "business_id = ?"
+		args    = []interface{}{w.BusinessID}
+	)
+
+	if req.Event != "" {
+		query += " and event = ?"
+		args = append(args, req.Event)
+	}
+	switch req.Status {
+	case "delivered":
+		query += " and is_received = ?"
+		args = append(args, true)
+	case "abandoned":
+		query += " and is_received = ? and is_abandoned = ?"
+		args = append(args, false, true)
+	case "pending":
+		query += " and is_received = ? and is_abandoned = ?"
+		args = append(args, false, false)
+	}
+	if req.StartDate != "" {
+		query += " and created_at >= ?"
+		args = append(args, req.StartDate)
+	}
+	if req.EndDate != "" {
+		query += " and created_at < (?::date + interval '1 day')"
+		args = append(args, req.EndDate)
+	}
+
+	pagination, err := postgresql.SelectAllFromDbOrderByPaginated(db, "id", "desc", paginator, &details, query, args...)
+	if err != nil {
+		return details, pagination, err
+	}
+	return details, pagination, nil
+}
+
 func (w *Webhook) UpdateAllFields(db *gorm.DB) error {
 	_, err := postgresql.SaveAllFields(db, &w)
 	return err
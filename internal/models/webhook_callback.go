@@ -156,3 +156,34 @@ type MonnifyWebhookRequestEventDataCustomer struct {
 	Name  *string `json:"name"`
 	Email *string `json:"email"`
 }
+
+// //////// Paystack ////////////////////////////////////////////////////////////////
+
+type PaystackWebhookRequest struct {
+	Event string                      `json:"event"`
+	Data  *PaystackWebhookRequestData `json:"data"`
+}
+type PaystackWebhookRequestData struct {
+	ID           *int64  `json:"id"`
+	Reference    *string `json:"reference"`
+	Amount       *int64  `json:"amount"`
+	Currency     *string `json:"currency"`
+	Status       *string `json:"status"`
+	TransferCode *string `json:"transfer_code"`
+	Reason       *string `json:"reason"`
+	PaidAt       *string `json:"paid_at"`
+}
+
+// //////// Campay ////////////////////////////////////////////////////////////////
+
+type CampayWebhookRequest struct {
+	Status            string `json:"status" form:"status"`
+	Reference         string `json:"reference" form:"reference"`
+	ExternalReference string `json:"external_reference" form:"external_reference"`
+	Amount            string `json:"amount" form:"amount"`
+	Currency          string `json:"currency" form:"currency"`
+	Operator          string `json:"operator" form:"operator"`
+	Code              string `json:"code" form:"code"`
+	OperatorReference string `json:"operator_reference" form:"operator_reference"`
+	Signature         string `json:"signature" form:"signature"`
+}
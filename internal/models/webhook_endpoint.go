@@ -0,0 +1,93 @@
+package models
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"gorm.io/gorm"
+)
+
+// WebhookEndpoint is a url a business has registered to receive webhooks on, Events is a comma separated list of the
+// events it receives, or * for every event.
+type WebhookEndpoint struct {
+	ID          uint      `gorm:"column:id; type:uint; not null; primaryKey; unique; autoIncrement" json:"id"`
+	BusinessID  int       `gorm:"column:business_id; type:int; not null; index" json:"business_id"`
+	Url         string    `gorm:"column:url; type:varchar(255); not null" json:"url"`
+	Description string    `gorm:"column:description; type:varchar(255)" json:"description"`
+	Events      string    `gorm:"column:events; type:text; not null; default:'*'" json:"events"`
+	Enabled     bool      `gorm:"column:enabled; type:bool; default:true" json:"enabled"`
+	Secret      string    `gorm:"column:secret; type:varchar(255); not null" json:"-"`
+	CreatedAt   time.Time `gorm:"column:created_at; autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"column:updated_at; autoUpdateTime" json:"updated_at"`
+
+	// while a secret is rotated webhooks are signed with the previous secret too, until it expires
+	PreviousSecret          string     `gorm:"column:previous_secret; type:varchar(255)" json:"-"`
+	PreviousSecretExpiresAt *time.Time `gorm:"column:previous_secret_expires_at" json:"previous_secret_expires_at"`
+}
+
+// WebhookEndpointWithSecret is returned when an endpoint is created or its secret rotated, the only times the secret is shown.
+type WebhookEndpointWithSecret struct {
+	WebhookEndpoint
+	Secret string `json:"secret"`
+}
+
+type CreateWebhookEndpointRequest struct {
+	Url         string   `json:"url" validate:"required,url"`
+	Description string   `json:"description"`
+	Events      []string `json:"events" validate:"required,min=1"`
+}
+
+type UpdateWebhookEndpointRequest struct {
+	Url         string   `json:"url" validate:"omitempty,url"`
+	Description *string  `json:"description"`
+	Events      []string `json:"events"`
+	Enabled     *bool    `json:"enabled"`
+}
+
+type RotateWebhookEndpointSecretRequest struct {
+	OverlapHours *int `json:"overlap_hours"`
+}
+
+func (w *WebhookEndpoint) CreateWebhookEndpoint(db *gorm.DB) error {
+	err := postgresql.CreateOneRecord(db, &w)
+	if err != nil {
+		return fmt.Errorf("webhook endpoint creation failed: %v", err.Error())
+	}
+	return nil
+}
+
+func (w *WebhookEndpoint) GetWebhookEndpointByIDAndBusinessID(db *gorm.DB) (int, error) {
+	err, nilErr := postgresql.SelectOneFromDb(db, &w, "id = ? and business_id = ?", w.ID, w.BusinessID)
+	if nilErr != nil {
+		return http.StatusBadRequest, nilErr
+	}
+
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+func (w *WebhookEndpoint) CheckWebhookEndpointExists(db *gorm.DB) bool {
+	return postgresql.CheckExists(db, &WebhookEndpoint{}, "business_id = ? and url = ?", w.BusinessID, w.Url)
+}
+
+func (w *WebhookEndpoint) GetWebhookEndpointsByBusinessID(db *gorm.DB) ([]WebhookEndpoint, error) {
+	details := []WebhookEndpoint{}
+	err := postgresql.SelectAllFromDbOrderBy(db, "id", "asc", &details, "business_id = ?", w.BusinessID)
+	if err != nil {
+		return details, err
+	}
+	return details, nil
+}
+
+func (w *WebhookEndpoint) UpdateAllFields(db *gorm.DB) error {
+	_, err := postgresql.SaveAllFields(db, &w)
+	return err
+}
+
+func (w *WebhookEndpoint) Delete(db *gorm.DB) error {
+	return postgresql.DeleteRecordFromDb(db, &w)
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"gorm.io/gorm"
+)
+
+// WebhookSetting pins the api version a business receives webhooks in, so payload changes do not break its integration.
+type WebhookSetting struct {
+	ID         uint      `gorm:"column:id; type:uint; not null; primaryKey; unique; autoIncrement" json:"id"`
+	BusinessID int       `gorm:"column:business_id; type:int; not null; unique" json:"business_id"`
+	ApiVersion string    `gorm:"column:api_version; type:varchar(255); not null" json:"api_version"`
+	CreatedAt  time.Time `gorm:"column:created_at; autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"column:updated_at; autoUpdateTime" json:"updated_at"`
+}
+
+type SetWebhookApiVersionRequest struct {
+	ApiVersion string `json:"api_version" validate:"required"`
+}
+
+type GetWebhookEventSchemaRequest struct {
+	Event      string `form:"event" json:"event"`
+	ApiVersion string `form:"api_version" json:"api_version"`
+}
+
+func (w *WebhookSetting) CreateWebhookSetting(db *gorm.DB) error {
+	err := postgresql.CreateOneRecord(db, &w)
+	if err != nil {
+		return fmt.Errorf("webhook setting creation failed: %v", err.Error())
+	}
+	return nil
+}
+
+func (w *WebhookSetting) GetWebhookSettingByBusinessID(db *gorm.DB) (int, error) {
+	err, nilErr := postgresql.SelectOneFromDb(db, &w, "business_id = ?", w.BusinessID)
+	if nilErr != nil {
+		return http.StatusBadRequest, nilErr
+	}
+
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+func (w *WebhookSetting) UpdateAllFields(db *gorm.DB) error {
+	_, err := postgresql.SaveAllFields(db, &w)
+	return err
+}
@@ -4,12 +4,14 @@ import (
 	"fmt"
 	"log"
 
+	"github.com/vesicash/payment-ms/external/request"
 	"github.com/vesicash/payment-ms/internal/config"
 	"github.com/vesicash/payment-ms/internal/models/migrations"
 	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/vesicash/payment-ms/pkg/router"
+	"github.com/vesicash/payment-ms/services/payment"
 	"github.com/vesicash/payment-ms/utility"
 )
 
@@ -26,6 +28,8 @@ func main() {
 		migrations.RunAllMigrations(db)
 	}
 
+	payment.StartGatewayHealthMonitor(request.ExternalRequest{Logger: logger, Test: false}, db)
+
 	r := router.Setup(logger, validatorRef, db, &configuration.App)
 	rM := router.SetupMetrics(&configuration.App)
 
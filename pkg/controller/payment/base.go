@@ -1,9 +1,13 @@
 package payment
 
 import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 	"github.com/vesicash/payment-ms/external/request"
 	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"github.com/vesicash/payment-ms/services/payment"
 	"github.com/vesicash/payment-ms/utility"
 )
 
@@ -13,3 +17,15 @@ type Controller struct {
 	Logger    *utility.Logger
 	ExtReq    request.ExternalRequest
 }
+
+// keyBusinessID returns the business whose keys authenticated the request, for routes where a business only ever
+// sees and changes its own records.
+func (base *Controller) keyBusinessID(c *gin.Context) (int, bool) {
+	accessToken, err := payment.GetAccessTokenByKeyFromRequest(base.ExtReq, c)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusUnauthorized, "error", "could not identify business", err, nil)
+		c.JSON(http.StatusUnauthorized, rd)
+		return 0, false
+	}
+	return accessToken.AccountID, true
+}
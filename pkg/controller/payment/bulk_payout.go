@@ -0,0 +1,139 @@
+package payment
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"github.com/vesicash/payment-ms/services/payment"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+// CreateBulkPayout takes the rows of a bulk payout as json, or as a csv file in the file field of a multipart form.
+func (base *Controller) CreateBulkPayout(c *gin.Context) {
+	var (
+		req models.CreateBulkPayoutRequest
+	)
+
+	err := c.ShouldBind(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Failed to parse request body", err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	if c.ContentType() == "multipart/form-data" {
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "csv file is required", err, nil)
+			c.JSON(http.StatusBadRequest, rd)
+			return
+		}
+
+		file, err := fileHeader.Open()
+		if err != nil {
+			rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "csv file could not be read", err, nil)
+			c.JSON(http.StatusBadRequest, rd)
+			return
+		}
+		defer file.Close()
+
+		req.Rows, err = payment.ParseBulkPayoutCSV(file)
+		if err != nil {
+			rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", err.Error(), err, nil)
+			c.JSON(http.StatusBadRequest, rd)
+			return
+		}
+	}
+
+	err = base.Validator.Struct(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", utility.ValidationResponse(err, base.Validator), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	accountID, ok := base.keyBusinessID(c)
+	if !ok {
+		return
+	}
+
+	bulkPayout, code, err := payment.CreateBulkPayoutService(base.ExtReq, base.Db, accountID, req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, bulkPayout)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", bulkPayout)
+	c.JSON(http.StatusOK, rd)
+
+}
+
+func (base *Controller) GetBulkPayout(c *gin.Context) {
+	var (
+		bulkPayoutID = c.Param("bulk_payout_id")
+	)
+
+	accountID, ok := base.keyBusinessID(c)
+	if !ok {
+		return
+	}
+
+	bulkPayout, code, err := payment.GetBulkPayoutService(base.Db, accountID, bulkPayoutID)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", bulkPayout)
+	c.JSON(http.StatusOK, rd)
+
+}
+
+func (base *Controller) ListBulkPayouts(c *gin.Context) {
+	var (
+		paginator = postgresql.GetPagination(c)
+	)
+
+	accountID, ok := base.keyBusinessID(c)
+	if !ok {
+		return
+	}
+
+	bulkPayouts, pagination, code, err := payment.ListBulkPayoutsService(base.Db, accountID, paginator)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", bulkPayouts, pagination)
+	c.JSON(http.StatusOK, rd)
+
+}
+
+func (base *Controller) DownloadBulkPayoutResults(c *gin.Context) {
+	var (
+		bulkPayoutID = c.Param("bulk_payout_id")
+	)
+
+	accountID, ok := base.keyBusinessID(c)
+	if !ok {
+		return
+	}
+
+	results, code, err := payment.BulkPayoutResultsService(base.Db, accountID, bulkPayoutID)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%v-results.csv", bulkPayoutID))
+	c.Data(http.StatusOK, "text/csv", results)
+
+}
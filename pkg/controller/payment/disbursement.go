@@ -154,3 +154,27 @@ func (base *Controller) ManualRefund(c *gin.Context) {
 	c.JSON(http.StatusOK, rd)
 
 }
+
+func (base *Controller) GetDisbursementStatusHistory(c *gin.Context) {
+	var (
+		disbursementID = c.Param("disbursement_id")
+	)
+
+	disbursementIDInt, err := strconv.Atoi(disbursementID)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "disbursement_id provided is not integer", err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	history, code, err := payment.GetDisbursementStatusHistoryService(base.Db, disbursementIDInt)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", history)
+	c.JSON(http.StatusOK, rd)
+
+}
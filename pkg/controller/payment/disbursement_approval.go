@@ -0,0 +1,136 @@
+package payment
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"github.com/vesicash/payment-ms/services/payment"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+func (base *Controller) CreateDisbursementApprovalPolicy(c *gin.Context) {
+	var (
+		req models.CreateDisbursementApprovalPolicyRequest
+	)
+
+	err := c.ShouldBind(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Failed to parse request body", err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	err = base.Validator.Struct(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", utility.ValidationResponse(err, base.Validator), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	policy, code, err := payment.CreateDisbursementApprovalPolicyService(base.Db, req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", policy)
+	c.JSON(http.StatusOK, rd)
+
+}
+
+func (base *Controller) ListDisbursementApprovalPolicies(c *gin.Context) {
+	policies, code, err := payment.ListDisbursementApprovalPoliciesService(base.Db)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", policies)
+	c.JSON(http.StatusOK, rd)
+
+}
+
+func (base *Controller) DeactivateDisbursementApprovalPolicy(c *gin.Context) {
+	var (
+		id = c.Param("id")
+	)
+
+	idInt, err := strconv.Atoi(id)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "id provided is not integer", err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	policy, code, err := payment.DeactivateDisbursementApprovalPolicyService(base.Db, idInt)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", policy)
+	c.JSON(http.StatusOK, rd)
+
+}
+
+func (base *Controller) ListDisbursementsAwaitingApproval(c *gin.Context) {
+	var (
+		paginator = postgresql.GetPagination(c)
+	)
+
+	disbursements, pagination, code, err := payment.ListDisbursementsAwaitingApprovalService(base.Db, paginator)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", disbursements, pagination)
+	c.JSON(http.StatusOK, rd)
+
+}
+
+func (base *Controller) ApproveDisbursement(c *gin.Context) {
+	base.decideDisbursement(c, payment.DisbursementApprove)
+}
+
+func (base *Controller) RejectDisbursement(c *gin.Context) {
+	base.decideDisbursement(c, payment.DisbursementReject)
+}
+
+func (base *Controller) decideDisbursement(c *gin.Context, decision payment.DisbursementDecision) {
+	var (
+		req models.DisbursementDecisionRequest
+	)
+
+	err := c.ShouldBind(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Failed to parse request body", err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	err = base.Validator.Struct(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", utility.ValidationResponse(err, base.Validator), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	disbursement, code, err := payment.DecideDisbursementService(c, base.ExtReq, base.Db, req, decision)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", disbursement)
+	c.JSON(http.StatusOK, rd)
+
+}
@@ -0,0 +1,44 @@
+package payment
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"github.com/vesicash/payment-ms/services/payment"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+func (base *Controller) ListDisbursementBatches(c *gin.Context) {
+	var (
+		paginator = postgresql.GetPagination(c)
+	)
+
+	batches, pagination, code, err := payment.ListDisbursementBatchesService(base.Db, paginator)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", batches, pagination)
+	c.JSON(http.StatusOK, rd)
+
+}
+
+func (base *Controller) GetDisbursementBatch(c *gin.Context) {
+	var (
+		batchReference = c.Param("batch_reference")
+	)
+
+	batch, code, err := payment.GetDisbursementBatchService(base.Db, batchReference)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", batch)
+	c.JSON(http.StatusOK, rd)
+
+}
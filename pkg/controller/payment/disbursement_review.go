@@ -0,0 +1,68 @@
+package payment
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vesicash/payment-ms/external/request"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"github.com/vesicash/payment-ms/services/payment"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+func (base *Controller) ListDisbursementsInReview(c *gin.Context) {
+	var (
+		paginator = postgresql.GetPagination(c)
+	)
+
+	disbursements, pagination, code, err := payment.ListDisbursementsInReviewService(base.Db, paginator)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", disbursements, pagination)
+	c.JSON(http.StatusOK, rd)
+
+}
+
+func (base *Controller) ReleaseDisbursement(c *gin.Context) {
+	base.reviewDisbursement(c, payment.ReleaseDisbursementService)
+}
+
+func (base *Controller) CancelDisbursement(c *gin.Context) {
+	base.reviewDisbursement(c, payment.CancelDisbursementService)
+}
+
+func (base *Controller) reviewDisbursement(c *gin.Context, service func(*gin.Context, request.ExternalRequest, postgresql.Databases, models.ReviewDisbursementRequest) (models.Disbursement, int, error)) {
+	var (
+		req models.ReviewDisbursementRequest
+	)
+
+	err := c.ShouldBind(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Failed to parse request body", err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	err = base.Validator.Struct(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", utility.ValidationResponse(err, base.Validator), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	disbursement, code, err := service(c, base.ExtReq, base.Db, req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", disbursement)
+	c.JSON(http.StatusOK, rd)
+
+}
@@ -0,0 +1,95 @@
+package payment
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vesicash/payment-ms/cronjobs"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"github.com/vesicash/payment-ms/services/payment"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+func (base *Controller) ListFailedDisbursements(c *gin.Context) {
+	var (
+		req       models.ListFailedDisbursementsRequest
+		paginator = postgresql.GetPagination(c)
+	)
+
+	err := c.ShouldBindQuery(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Failed to parse request query", err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	err = base.Validator.Struct(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", utility.ValidationResponse(err, base.Validator), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	failedDisbursements, pagination, code, err := payment.ListFailedDisbursementsService(base.Db, req, paginator)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", failedDisbursements, pagination)
+	c.JSON(http.StatusOK, rd)
+
+}
+
+func (base *Controller) ReEvaluateFailedDisbursement(c *gin.Context) {
+	var (
+		id = c.Param("id")
+	)
+
+	idInt, err := strconv.Atoi(id)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "id provided is not integer", err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	failedDisbursement, _, code, err := payment.ReEvaluateFailedDisbursementService(base.ExtReq, base.Db, uint(idInt))
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", failedDisbursement)
+	c.JSON(http.StatusOK, rd)
+
+}
+
+func (base *Controller) RetryFailedDisbursements(c *gin.Context) {
+	var (
+		req models.RetryFailedDisbursementsRequest
+	)
+
+	err := c.ShouldBind(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Failed to parse request body", err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	err = base.Validator.Struct(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", utility.ValidationResponse(err, base.Validator), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	results := cronjobs.RetryFailedDisbursements(base.ExtReq, base.Db, req.IDs)
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", results)
+	c.JSON(http.StatusOK, rd)
+
+}
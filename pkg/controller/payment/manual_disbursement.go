@@ -0,0 +1,151 @@
+package payment
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"github.com/vesicash/payment-ms/services/payment"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+func (base *Controller) ListManualDisbursements(c *gin.Context) {
+	var (
+		paginator = postgresql.GetPagination(c)
+	)
+
+	disbursements, pagination, code, err := payment.ListManualDisbursementsService(base.Db, paginator)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", disbursements, pagination)
+	c.JSON(http.StatusOK, rd)
+
+}
+
+func (base *Controller) CompleteManualDisbursement(c *gin.Context) {
+	var (
+		req models.CompleteManualDisbursementRequest
+	)
+
+	err := c.ShouldBind(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Failed to parse request body", err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	err = base.Validator.Struct(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", utility.ValidationResponse(err, base.Validator), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	fileHeader, err := c.FormFile("proof")
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "proof of payment file is required", err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "proof of payment could not be read", err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+	defer file.Close()
+
+	proof, err := io.ReadAll(file)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "proof of payment could not be read", err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+	if len(proof) == 0 {
+		err = fmt.Errorf("proof of payment file is empty")
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", err.Error(), err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	disbursement, code, err := payment.CompleteManualDisbursementService(c, base.ExtReq, base.Db, req, fileHeader.Filename, proof)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", disbursement)
+	c.JSON(http.StatusOK, rd)
+
+}
+
+func (base *Controller) FailManualDisbursement(c *gin.Context) {
+	var (
+		req models.FailManualDisbursementRequest
+	)
+
+	err := c.ShouldBind(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Failed to parse request body", err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	err = base.Validator.Struct(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", utility.ValidationResponse(err, base.Validator), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	disbursement, code, err := payment.FailManualDisbursementService(c, base.ExtReq, base.Db, req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", disbursement)
+	c.JSON(http.StatusOK, rd)
+
+}
+
+func (base *Controller) RerouteManualDisbursement(c *gin.Context) {
+	var (
+		req models.RerouteManualDisbursementRequest
+	)
+
+	err := c.ShouldBind(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Failed to parse request body", err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	err = base.Validator.Struct(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", utility.ValidationResponse(err, base.Validator), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	disbursement, code, err := payment.RerouteManualDisbursementService(c, base.ExtReq, base.Db, req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", disbursement)
+	c.JSON(http.StatusOK, rd)
+
+}
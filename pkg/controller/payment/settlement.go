@@ -0,0 +1,178 @@
+package payment
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"github.com/vesicash/payment-ms/services/payment"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+func (base *Controller) GetSettlementSchedule(c *gin.Context) {
+	businessID, ok := base.keyBusinessID(c)
+	if !ok {
+		return
+	}
+
+	schedule, code, err := payment.GetSettlementScheduleService(base.Db, businessID)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", schedule)
+	c.JSON(http.StatusOK, rd)
+
+}
+
+func (base *Controller) SetSettlementSchedule(c *gin.Context) {
+	var (
+		req models.SetSettlementScheduleRequest
+	)
+
+	err := c.ShouldBind(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Failed to parse request body", err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	err = base.Validator.Struct(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", utility.ValidationResponse(err, base.Validator), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	businessID, ok := base.keyBusinessID(c)
+	if !ok {
+		return
+	}
+
+	schedule, code, err := payment.SetSettlementScheduleService(base.Db, businessID, req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", schedule)
+	c.JSON(http.StatusOK, rd)
+
+}
+
+func (base *Controller) ListSettlementBatches(c *gin.Context) {
+	var (
+		paginator = postgresql.GetPagination(c)
+	)
+
+	businessID, ok := base.keyBusinessID(c)
+	if !ok {
+		return
+	}
+
+	batches, pagination, code, err := payment.ListSettlementBatchesService(base.Db, businessID, paginator)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", batches, pagination)
+	c.JSON(http.StatusOK, rd)
+
+}
+
+func (base *Controller) GetSettlementReport(c *gin.Context) {
+	var (
+		batchID = c.Param("batch_id")
+	)
+
+	businessID, ok := base.keyBusinessID(c)
+	if !ok {
+		return
+	}
+
+	report, code, err := payment.GetSettlementReportService(base.Db, businessID, batchID)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", report)
+	c.JSON(http.StatusOK, rd)
+
+}
+
+func (base *Controller) ListSettlementHolidays(c *gin.Context) {
+	holidays, code, err := payment.ListSettlementHolidaysService(base.Db)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", holidays)
+	c.JSON(http.StatusOK, rd)
+
+}
+
+func (base *Controller) CreateSettlementHoliday(c *gin.Context) {
+	var (
+		req models.CreateSettlementHolidayRequest
+	)
+
+	err := c.ShouldBind(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Failed to parse request body", err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	err = base.Validator.Struct(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", utility.ValidationResponse(err, base.Validator), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	holiday, code, err := payment.CreateSettlementHolidayService(base.Db, req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", holiday)
+	c.JSON(http.StatusOK, rd)
+
+}
+
+func (base *Controller) DeleteSettlementHoliday(c *gin.Context) {
+	var (
+		id = c.Param("id")
+	)
+
+	idInt, err := strconv.Atoi(id)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "id provided is not integer", err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	holiday, code, err := payment.DeleteSettlementHolidayService(base.Db, idInt)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", holiday)
+	c.JSON(http.StatusOK, rd)
+
+}
@@ -0,0 +1,175 @@
+package payment
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/services/payment"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+func (base *Controller) PlaceWalletHold(c *gin.Context) {
+	var (
+		req models.PlaceWalletHoldRequest
+	)
+
+	err := c.ShouldBind(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Failed to parse request body", err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	err = base.Validator.Struct(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", utility.ValidationResponse(err, base.Validator), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	hold, code, err := payment.PlaceWalletHoldService(base.ExtReq, base.Db, req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "wallet hold placed", hold)
+	c.JSON(http.StatusOK, rd)
+
+}
+
+func (base *Controller) CaptureWalletHold(c *gin.Context) {
+	var (
+		req models.SettleWalletHoldRequest
+	)
+
+	err := c.ShouldBind(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Failed to parse request body", err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	err = base.Validator.Struct(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", utility.ValidationResponse(err, base.Validator), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	hold, code, err := payment.CaptureWalletHold(base.ExtReq, base.Db, req.Reference)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "wallet hold captured", hold)
+	c.JSON(http.StatusOK, rd)
+
+}
+
+func (base *Controller) ReleaseWalletHold(c *gin.Context) {
+	var (
+		req models.SettleWalletHoldRequest
+	)
+
+	err := c.ShouldBind(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Failed to parse request body", err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	err = base.Validator.Struct(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", utility.ValidationResponse(err, base.Validator), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	hold, code, err := payment.ReleaseWalletHold(base.ExtReq, base.Db, req.Reference, payment.WalletHoldReleased)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "wallet hold released", hold)
+	c.JSON(http.StatusOK, rd)
+
+}
+
+func (base *Controller) ListWalletHolds(c *gin.Context) {
+	var (
+		accountID = c.Param("account_id")
+		req       models.ListWalletHoldsRequest
+	)
+
+	accountIDinT, err := strconv.Atoi(accountID)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "account_id provided is not integer", err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	err = c.ShouldBindQuery(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Failed to parse request query", err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	holds, code, err := payment.ListWalletHoldsService(base.Db, accountIDinT, req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", holds)
+	c.JSON(http.StatusOK, rd)
+
+}
+
+func (base *Controller) GetWalletBalance(c *gin.Context) {
+	var (
+		accountID = c.Param("account_id")
+		req       models.GetWalletBalanceRequest
+	)
+
+	accountIDinT, err := strconv.Atoi(accountID)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "account_id provided is not integer", err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	err = c.ShouldBindQuery(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Failed to parse request query", err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	err = base.Validator.Struct(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", utility.ValidationResponse(err, base.Validator), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	balance, code, err := payment.GetWalletBalanceService(base.ExtReq, base.Db, accountIDinT, req.Currency)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", balance)
+	c.JSON(http.StatusOK, rd)
+
+}
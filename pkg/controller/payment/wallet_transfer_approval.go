@@ -0,0 +1,67 @@
+package payment
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"github.com/vesicash/payment-ms/services/payment"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+func (base *Controller) ListQueuedWalletTransfers(c *gin.Context) {
+	var (
+		paginator = postgresql.GetPagination(c)
+	)
+
+	transfers, pagination, code, err := payment.ListQueuedWalletTransfersService(base.Db, paginator)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", transfers, pagination)
+	c.JSON(http.StatusOK, rd)
+
+}
+
+func (base *Controller) ApproveWalletTransfer(c *gin.Context) {
+	base.decideWalletTransfer(c, payment.WalletTransferApprove)
+}
+
+func (base *Controller) RejectWalletTransfer(c *gin.Context) {
+	base.decideWalletTransfer(c, payment.WalletTransferReject)
+}
+
+func (base *Controller) decideWalletTransfer(c *gin.Context, decision payment.WalletTransferDecision) {
+	var (
+		req models.WalletTransferDecisionRequest
+	)
+
+	err := c.ShouldBind(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Failed to parse request body", err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	err = base.Validator.Struct(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", utility.ValidationResponse(err, base.Validator), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	msg, transfer, code, err := payment.DecideWalletTransferService(c, base.ExtReq, base.Db, req, decision)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, msg, transfer)
+	c.JSON(http.StatusOK, rd)
+
+}
@@ -69,6 +69,61 @@ func (base *Controller) MonnifyWebhook(c *gin.Context) {
 
 }
 
+func (base *Controller) PaystackWebhook(c *gin.Context) {
+	var (
+		req models.PaystackWebhookRequest
+	)
+
+	requestBody, err := c.GetRawData()
+	if err != nil {
+		base.ExtReq.Logger.Error("paystack webhhook log error", "Failed to read request body", err.Error())
+	}
+
+	err = json.Unmarshal(requestBody, &req)
+	if err != nil {
+		base.Logger.Error("paystack webhhook log error", "Failed to parse request body", err.Error())
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Failed to parse request body", err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	code, err := payment.PaystackWebhookService(c, base.ExtReq, base.Db, req, requestBody)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "ok", nil)
+	c.JSON(http.StatusOK, rd)
+
+}
+
+func (base *Controller) CampayWebhook(c *gin.Context) {
+	var (
+		req models.CampayWebhookRequest
+	)
+
+	err := c.ShouldBind(&req)
+	if err != nil {
+		base.Logger.Error("campay webhhook log error", "Failed to parse request", err.Error())
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Failed to parse request", err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	code, err := payment.CampayWebhookService(c, base.ExtReq, base.Db, req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "ok", nil)
+	c.JSON(http.StatusOK, rd)
+
+}
+
 func (base *Controller) MonnifyDisbursementCallback(c *gin.Context) {
 	var (
 		req models.MonnifyWebhookRequest
@@ -0,0 +1,151 @@
+package payment
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"github.com/vesicash/payment-ms/services/payment"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+func (base *Controller) ListWebhookDeliveries(c *gin.Context) {
+	var (
+		req       models.ListWebhookDeliveriesRequest
+		paginator = postgresql.GetPagination(c)
+	)
+
+	err := c.ShouldBindQuery(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Failed to parse request query", err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	err = base.Validator.Struct(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", utility.ValidationResponse(err, base.Validator), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	businessID, ok := base.keyBusinessID(c)
+	if !ok {
+		return
+	}
+
+	deliveries, pagination, code, err := payment.ListWebhookDeliveriesService(base.Db, businessID, req, paginator)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", deliveries, pagination)
+	c.JSON(http.StatusOK, rd)
+
+}
+
+func (base *Controller) GetWebhookDelivery(c *gin.Context) {
+	var (
+		id = c.Param("id")
+	)
+
+	idInt, err := strconv.Atoi(id)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "id provided is not integer", err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	businessID, ok := base.keyBusinessID(c)
+	if !ok {
+		return
+	}
+
+	delivery, code, err := payment.GetWebhookDeliveryService(base.Db, businessID, idInt)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", delivery)
+	c.JSON(http.StatusOK, rd)
+
+}
+
+func (base *Controller) RedeliverWebhook(c *gin.Context) {
+	var (
+		id = c.Param("id")
+	)
+
+	idInt, err := strconv.Atoi(id)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "id provided is not integer", err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	businessID, ok := base.keyBusinessID(c)
+	if !ok {
+		return
+	}
+
+	delivery, code, err := payment.RedeliverWebhookService(base.ExtReq, base.Db, businessID, idInt)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", delivery)
+	c.JSON(http.StatusOK, rd)
+
+}
+
+func (base *Controller) SendTestWebhook(c *gin.Context) {
+	var (
+		id  = c.Param("id")
+		req models.SendTestWebhookRequest
+	)
+
+	idInt, err := strconv.Atoi(id)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "id provided is not integer", err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	err = c.ShouldBind(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Failed to parse request body", err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	err = base.Validator.Struct(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", utility.ValidationResponse(err, base.Validator), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	businessID, ok := base.keyBusinessID(c)
+	if !ok {
+		return
+	}
+
+	delivery, code, err := payment.SendTestWebhookService(base.ExtReq, base.Db, businessID, idInt, req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", delivery)
+	c.JSON(http.StatusOK, rd)
+
+}
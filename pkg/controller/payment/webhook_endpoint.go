@@ -0,0 +1,224 @@
+package payment
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/services/payment"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+func (base *Controller) ListWebhookEvents(c *gin.Context) {
+	events, code, err := payment.ListWebhookEventsService()
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", events)
+	c.JSON(http.StatusOK, rd)
+
+}
+
+func (base *Controller) CreateWebhookEndpoint(c *gin.Context) {
+	var (
+		req models.CreateWebhookEndpointRequest
+	)
+
+	err := c.ShouldBind(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Failed to parse request body", err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	err = base.Validator.Struct(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", utility.ValidationResponse(err, base.Validator), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	businessID, ok := base.keyBusinessID(c)
+	if !ok {
+		return
+	}
+
+	endpoint, code, err := payment.CreateWebhookEndpointService(base.Db, businessID, req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", models.WebhookEndpointWithSecret{WebhookEndpoint: endpoint, Secret: endpoint.Secret})
+	c.JSON(http.StatusOK, rd)
+
+}
+
+func (base *Controller) ListWebhookEndpoints(c *gin.Context) {
+	businessID, ok := base.keyBusinessID(c)
+	if !ok {
+		return
+	}
+
+	endpoints, code, err := payment.ListWebhookEndpointsService(base.Db, businessID)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", endpoints)
+	c.JSON(http.StatusOK, rd)
+
+}
+
+func (base *Controller) GetWebhookEndpoint(c *gin.Context) {
+	var (
+		id = c.Param("id")
+	)
+
+	idInt, err := strconv.Atoi(id)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "id provided is not integer", err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	businessID, ok := base.keyBusinessID(c)
+	if !ok {
+		return
+	}
+
+	endpoint, code, err := payment.GetWebhookEndpointService(base.Db, businessID, idInt)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", endpoint)
+	c.JSON(http.StatusOK, rd)
+
+}
+
+func (base *Controller) UpdateWebhookEndpoint(c *gin.Context) {
+	var (
+		id  = c.Param("id")
+		req models.UpdateWebhookEndpointRequest
+	)
+
+	idInt, err := strconv.Atoi(id)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "id provided is not integer", err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	err = c.ShouldBind(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Failed to parse request body", err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	err = base.Validator.Struct(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", utility.ValidationResponse(err, base.Validator), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	businessID, ok := base.keyBusinessID(c)
+	if !ok {
+		return
+	}
+
+	endpoint, code, err := payment.UpdateWebhookEndpointService(base.Db, businessID, idInt, req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", endpoint)
+	c.JSON(http.StatusOK, rd)
+
+}
+
+func (base *Controller) DeleteWebhookEndpoint(c *gin.Context) {
+	var (
+		id = c.Param("id")
+	)
+
+	idInt, err := strconv.Atoi(id)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "id provided is not integer", err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	businessID, ok := base.keyBusinessID(c)
+	if !ok {
+		return
+	}
+
+	endpoint, code, err := payment.DeleteWebhookEndpointService(base.Db, businessID, idInt)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", endpoint)
+	c.JSON(http.StatusOK, rd)
+
+}
+
+func (base *Controller) RotateWebhookEndpointSecret(c *gin.Context) {
+	var (
+		id  = c.Param("id")
+		req models.RotateWebhookEndpointSecretRequest
+	)
+
+	idInt, err := strconv.Atoi(id)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "id provided is not integer", err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	err = c.ShouldBind(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Failed to parse request body", err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	err = base.Validator.Struct(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", utility.ValidationResponse(err, base.Validator), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	businessID, ok := base.keyBusinessID(c)
+	if !ok {
+		return
+	}
+
+	endpoint, code, err := payment.RotateWebhookEndpointSecretService(base.Db, businessID, idInt, req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", models.WebhookEndpointWithSecret{WebhookEndpoint: endpoint, Secret: endpoint.Secret})
+	c.JSON(http.StatusOK, rd)
+
+}
@@ -0,0 +1,88 @@
+package payment
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/services/payment"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+func (base *Controller) GetWebhookEventSchema(c *gin.Context) {
+	var (
+		req models.GetWebhookEventSchemaRequest
+	)
+
+	err := c.ShouldBindQuery(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Failed to parse request query", err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	schemas, code, err := payment.GetWebhookEventSchemaService(req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", schemas)
+	c.JSON(http.StatusOK, rd)
+
+}
+
+func (base *Controller) GetWebhookSetting(c *gin.Context) {
+	businessID, ok := base.keyBusinessID(c)
+	if !ok {
+		return
+	}
+
+	setting, code, err := payment.GetWebhookSettingService(base.Db, businessID)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", setting)
+	c.JSON(http.StatusOK, rd)
+
+}
+
+func (base *Controller) SetWebhookApiVersion(c *gin.Context) {
+	var (
+		req models.SetWebhookApiVersionRequest
+	)
+
+	err := c.ShouldBind(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Failed to parse request body", err, nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	err = base.Validator.Struct(&req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", utility.ValidationResponse(err, base.Validator), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	businessID, ok := base.keyBusinessID(c)
+	if !ok {
+		return
+	}
+
+	setting, code, err := payment.SetWebhookApiVersionService(base.Db, businessID, req)
+	if err != nil {
+		rd := utility.BuildErrorResponse(code, "error", err.Error(), err, nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "successful", setting)
+	c.JSON(http.StatusOK, rd)
+
+}
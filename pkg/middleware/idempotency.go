@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+var (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// keys older than this can be used again for a new request
+	idempotencyKeyTTL = time.Hour * 24
+)
+
+type idempotencyResponseWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w idempotencyResponseWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w idempotencyResponseWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency replays the stored response when a request is retried with the same Idempotency-Key header.
+// Keys are scoped to the credentials that made the request, requests without the header are not affected.
+func Idempotency(db postgresql.Databases) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := GetHeader(c, IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, utility.BuildErrorResponse(http.StatusBadRequest, "error", "Failed to read request body", err, nil))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewBuffer(body))
+
+		record := models.IdempotencyKey{
+			Key:         key,
+			Scope:       idempotencyScope(c),
+			Method:      c.Request.Method,
+			Path:        c.FullPath(),
+			RequestHash: hashIdempotentRequest(c, body),
+			Status:      "processing",
+		}
+
+		err = record.CreateIdempotencyKey(db.Payment)
+		if err != nil {
+			existing := models.IdempotencyKey{Key: record.Key, Scope: record.Scope}
+			code, getErr := existing.GetIdempotencyKeyByKeyAndScope(db.Payment)
+			if getErr != nil {
+				if code != http.StatusInternalServerError {
+					getErr = err
+				}
+				c.AbortWithStatusJSON(http.StatusInternalServerError, utility.BuildErrorResponse(http.StatusInternalServerError, "error", "Failed to check idempotency key", getErr.Error(), nil))
+				return
+			}
+
+			if time.Since(existing.CreatedAt) > idempotencyKeyTTL {
+				existing.Delete(db.Payment)
+				err = record.CreateIdempotencyKey(db.Payment)
+			}
+
+			if err != nil {
+				replayIdempotentResponse(c, existing, record.RequestHash)
+				return
+			}
+		}
+
+		handled := false
+		defer func() {
+			// let the client retry when the request did not finish, e.g. after a panic
+			if !handled {
+				record.Delete(db.Payment)
+			}
+		}()
+
+		writer := idempotencyResponseWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = writer
+		c.Next()
+
+		// the request has run, so the key is kept even if the response cannot be saved:
+		// a retry is then refused as still processing instead of being executed again
+		handled = true
+		record.Status = "completed"
+		record.ResponseCode = c.Writer.Status()
+		record.ResponseBody = writer.body.String()
+		record.UpdateAllFields(db.Payment)
+	}
+}
+
+func replayIdempotentResponse(c *gin.Context, existing models.IdempotencyKey, requestHash string) {
+	if existing.RequestHash != requestHash {
+		msg := fmt.Sprintf("%v has already been used with a different request", IdempotencyKeyHeader)
+		c.AbortWithStatusJSON(http.StatusConflict, utility.BuildErrorResponse(http.StatusConflict, "error", msg, msg, nil))
+		return
+	}
+
+	if existing.Status != "completed" {
+		msg := fmt.Sprintf("a request with this %v is still being processed", IdempotencyKeyHeader)
+		c.AbortWithStatusJSON(http.StatusConflict, utility.BuildErrorResponse(http.StatusConflict, "error", msg, msg, nil))
+		return
+	}
+
+	c.Header("Idempotent-Replayed", "true")
+	c.Data(existing.ResponseCode, "application/json; charset=utf-8", []byte(existing.ResponseBody))
+	c.Abort()
+}
+
+// idempotencyScope identifies the caller by the secret that authenticates the request, without storing it.
+func idempotencyScope(c *gin.Context) string {
+	credentials := GetHeader(c, "v-private-key")
+	if credentials == "" {
+		credentials = GetHeader(c, "Authorization")
+	}
+	if credentials == "" {
+		credentials = GetHeader(c, "v-app")
+	}
+
+	hash := sha256.Sum256([]byte(credentials))
+	return hex.EncodeToString(hash[:])
+}
+
+func hashIdempotentRequest(c *gin.Context, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var (
@@ -150,6 +151,24 @@ func SelectOneFromDb(db *gorm.DB, receiver interface{}, query interface{}, args
 	}
 	return tx.Error, nil
 }
+
+// SelectOneForUpdate is SelectOneFromDb with a row lock held until the surrounding transaction ends.
+func SelectOneForUpdate(db *gorm.DB, receiver interface{}, query interface{}, args ...interface{}) (error, error) {
+
+	tx := db.Clauses(clause.Locking{Strength: "UPDATE"}).Where(query, args...).First(receiver)
+	if errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+		return tx.Error, tx.Error
+	}
+	return tx.Error, nil
+}
+
+// SelectForUpdateSkipLocked locks up to limit matching rows until the surrounding transaction ends, rows already
+// locked by another transaction are skipped instead of waited on.
+func SelectForUpdateSkipLocked(db *gorm.DB, orderBy, order string, limit int, receiver interface{}, query interface{}, args ...interface{}) error {
+	tx := db.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).Order(orderBy+" "+order).Where(query, args...).Limit(limit).Find(receiver)
+	return tx.Error
+}
+
 func SelectLatestFromDb(db *gorm.DB, receiver interface{}, query interface{}, args ...interface{}) (error, error) {
 
 	tx := db.Order("id desc").Where(query, args...).First(receiver)
@@ -199,3 +218,8 @@ func CheckExistsInTable(db *gorm.DB, table string, query interface{}, args ...in
 	tx := db.Table(table).Where(query, args...).Take(&result)
 	return tx.RowsAffected != 0
 }
+
+func SumFromDb(db *gorm.DB, model interface{}, expression string, result interface{}, query interface{}, args ...interface{}) error {
+	tx := db.Model(model).Select("COALESCE("+expression+", 0)").Where(query, args...).Scan(result)
+	return tx.Error
+}
@@ -0,0 +1,25 @@
+package postgresql
+
+import (
+	"hash/fnv"
+
+	"gorm.io/gorm"
+)
+
+// RunWithAdvisoryLock runs fn in a transaction holding a postgres advisory lock for key,
+// callers using the same key are serialized across every instance sharing the database.
+func RunWithAdvisoryLock(db *gorm.DB, key string, fn func(tx *gorm.DB) error) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec("SELECT pg_advisory_xact_lock(?)", advisoryLockID(key)).Error
+		if err != nil {
+			return err
+		}
+		return fn(tx)
+	})
+}
+
+func advisoryLockID(key string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return int64(h.Sum64())
+}
@@ -11,3 +11,11 @@ func SaveAllFields(db *gorm.DB, model interface{}) (*gorm.DB, error) {
 	}
 	return result, nil
 }
+
+func UpdateFields(db *gorm.DB, model interface{}, fields ...string) (*gorm.DB, error) {
+	result := db.Model(model).Select(fields).Updates(model)
+	if result.Error != nil {
+		return result, result.Error
+	}
+	return result, nil
+}
@@ -23,6 +23,9 @@ func Payment(r *gin.Engine, ApiVersion string, validator *validator.Validate, db
 
 		paymentUrl.POST("/webhook/rave", payment.RaveWebhook)
 		paymentUrl.POST("/webhook/monnify", payment.MonnifyWebhook)
+		paymentUrl.POST("/webhook/paystack", payment.PaystackWebhook)
+		paymentUrl.GET("/webhook/campay", payment.CampayWebhook)
+		paymentUrl.POST("/webhook/campay", payment.CampayWebhook)
 		paymentUrl.POST("/disbursement/callback", payment.MonnifyDisbursementCallback)
 		paymentUrl.GET("/disbursement/callback", payment.MonnifyDisbursementCallback)
 
@@ -55,7 +58,7 @@ func Payment(r *gin.Engine, ApiVersion string, validator *validator.Validate, db
 		// /pay
 		paymentApiUrl.POST("/pay", payment.InitiatePayment)
 		paymentApiUrl.POST("/pay/headless", payment.InitiatePaymentHeadless)
-		paymentApiUrl.POST("/pay/tokenized", payment.ChargeCardInit)
+		paymentApiUrl.POST("/pay/tokenized", middleware.Idempotency(db), payment.ChargeCardInit)
 		paymentApiUrl.POST("/pay/tokenized/headless", payment.ChargeCardHeadlessInit)
 		paymentApiUrl.DELETE("/pay/tokenized/delete", payment.DeleteStoredCard)
 
@@ -63,16 +66,79 @@ func Payment(r *gin.Engine, ApiVersion string, validator *validator.Validate, db
 		paymentApiUrl.POST("/payment_account/verify", payment.PaymentAccountMonnifyVerify)
 
 		paymentApiUrl.GET("/disbursement/user/:account_id", payment.ListDisbursementByAccountID)
-		paymentApiUrl.POST("/disbursement/wallet/wallet-transfer", payment.WalletTransfer)
-		paymentApiUrl.POST("/disbursement/wallet/withdraw", payment.ManualDebit)
-		paymentApiUrl.POST("/disbursement/process/refund", payment.ManualRefund)
+		paymentApiUrl.POST("/disbursement/wallet/wallet-transfer", middleware.Idempotency(db), payment.WalletTransfer)
+		paymentApiUrl.POST("/disbursement/wallet/withdraw", middleware.Idempotency(db), payment.ManualDebit)
+		paymentApiUrl.POST("/disbursement/process/refund", middleware.Idempotency(db), payment.ManualRefund)
+		paymentApiUrl.POST("/disbursement/bulk", middleware.Idempotency(db), payment.CreateBulkPayout)
+		paymentApiUrl.GET("/disbursement/bulk/user", payment.ListBulkPayouts)
+		paymentApiUrl.GET("/disbursement/bulk/details/:bulk_payout_id", payment.GetBulkPayout)
+		paymentApiUrl.GET("/disbursement/bulk/results/:bulk_payout_id", payment.DownloadBulkPayoutResults)
+		paymentApiUrl.GET("/wallet/holds/:account_id", payment.ListWalletHolds)
+		paymentApiUrl.GET("/wallet/balance/:account_id", payment.GetWalletBalance)
+		paymentApiUrl.GET("/settlement/schedule", payment.GetSettlementSchedule)
+		paymentApiUrl.POST("/settlement/schedule", payment.SetSettlementSchedule)
+		paymentApiUrl.GET("/settlement/batches", payment.ListSettlementBatches)
+		paymentApiUrl.GET("/settlement/report/:batch_id", payment.GetSettlementReport)
+		paymentApiUrl.GET("/webhooks/events", payment.ListWebhookEvents)
+		paymentApiUrl.GET("/webhooks/events/schema", payment.GetWebhookEventSchema)
+		paymentApiUrl.GET("/webhooks/version", payment.GetWebhookSetting)
+		paymentApiUrl.POST("/webhooks/version", payment.SetWebhookApiVersion)
+		paymentApiUrl.GET("/webhooks/endpoints", payment.ListWebhookEndpoints)
+		paymentApiUrl.POST("/webhooks/endpoints", payment.CreateWebhookEndpoint)
+		paymentApiUrl.GET("/webhooks/endpoints/:id", payment.GetWebhookEndpoint)
+		paymentApiUrl.PATCH("/webhooks/endpoints/:id", payment.UpdateWebhookEndpoint)
+		paymentApiUrl.DELETE("/webhooks/endpoints/:id", payment.DeleteWebhookEndpoint)
+		paymentApiUrl.POST("/webhooks/endpoints/:id/rotate-secret", payment.RotateWebhookEndpointSecret)
+		paymentApiUrl.POST("/webhooks/endpoints/:id/test", payment.SendTestWebhook)
+		paymentApiUrl.GET("/webhooks/deliveries", payment.ListWebhookDeliveries)
+		paymentApiUrl.GET("/webhooks/deliveries/:id", payment.GetWebhookDelivery)
+		paymentApiUrl.POST("/webhooks/deliveries/:id/redeliver", payment.RedeliverWebhook)
 
 	}
 
 	paymentAppUrl := r.Group(fmt.Sprintf("%v", ApiVersion), middleware.Authorize(db, extReq, middleware.AppType))
 	{
-		paymentAppUrl.POST("/wallet/debit", payment.DebitWallet)
-		paymentAppUrl.POST("/wallet/credit", payment.CreditWallet)
+		paymentAppUrl.POST("/wallet/debit", middleware.Idempotency(db), payment.DebitWallet)
+		paymentAppUrl.POST("/wallet/credit", middleware.Idempotency(db), payment.CreditWallet)
+		paymentAppUrl.POST("/wallet/holds", middleware.Idempotency(db), payment.PlaceWalletHold)
+		paymentAppUrl.POST("/wallet/holds/capture", payment.CaptureWalletHold)
+		paymentAppUrl.POST("/wallet/holds/release", payment.ReleaseWalletHold)
+		paymentAppUrl.GET("/gateway/preference", payment.GetGatewayPreference)
+		paymentAppUrl.GET("/gateway/health", payment.ListGatewayHealth)
+		paymentAppUrl.GET("/gateway/retry", payment.ListDisbursementRetrySettings)
+	}
+
+	paymentBusinessAdminUrl := r.Group(fmt.Sprintf("%v", ApiVersion), middleware.Authorize(db, extReq, middleware.BusinessAdmin))
+	{
+		paymentBusinessAdminUrl.POST("/gateway/preference", payment.SetGatewayPreference)
+		paymentBusinessAdminUrl.PATCH("/gateway/health", payment.UpdateGatewayHealth)
+		paymentBusinessAdminUrl.PATCH("/gateway/retry", payment.UpdateDisbursementRetrySetting)
+		paymentBusinessAdminUrl.GET("/ledger/balance", payment.GetLedgerBalance)
+		paymentBusinessAdminUrl.GET("/disbursement/wallet/wallet-transfer/queued", payment.ListQueuedWalletTransfers)
+		paymentBusinessAdminUrl.POST("/disbursement/wallet/wallet-transfer/approve", payment.ApproveWalletTransfer)
+		paymentBusinessAdminUrl.POST("/disbursement/wallet/wallet-transfer/reject", payment.RejectWalletTransfer)
+		paymentBusinessAdminUrl.GET("/disbursement/approval/policies", payment.ListDisbursementApprovalPolicies)
+		paymentBusinessAdminUrl.POST("/disbursement/approval/policies", payment.CreateDisbursementApprovalPolicy)
+		paymentBusinessAdminUrl.DELETE("/disbursement/approval/policies/:id", payment.DeactivateDisbursementApprovalPolicy)
+		paymentBusinessAdminUrl.GET("/disbursement/approval/pending", payment.ListDisbursementsAwaitingApproval)
+		paymentBusinessAdminUrl.POST("/disbursement/approval/approve", middleware.Idempotency(db), payment.ApproveDisbursement)
+		paymentBusinessAdminUrl.POST("/disbursement/approval/reject", payment.RejectDisbursement)
+		paymentBusinessAdminUrl.GET("/disbursement/history/:disbursement_id", payment.GetDisbursementStatusHistory)
+		paymentBusinessAdminUrl.GET("/disbursement/batches", payment.ListDisbursementBatches)
+		paymentBusinessAdminUrl.GET("/disbursement/batches/:batch_reference", payment.GetDisbursementBatch)
+		paymentBusinessAdminUrl.GET("/disbursement/failed", payment.ListFailedDisbursements)
+		paymentBusinessAdminUrl.POST("/disbursement/failed/:id/re-evaluate", payment.ReEvaluateFailedDisbursement)
+		paymentBusinessAdminUrl.POST("/disbursement/failed/retry", payment.RetryFailedDisbursements)
+		paymentBusinessAdminUrl.GET("/disbursement/manual", payment.ListManualDisbursements)
+		paymentBusinessAdminUrl.POST("/disbursement/manual/complete", middleware.Idempotency(db), payment.CompleteManualDisbursement)
+		paymentBusinessAdminUrl.POST("/disbursement/manual/fail", payment.FailManualDisbursement)
+		paymentBusinessAdminUrl.POST("/disbursement/manual/reroute", middleware.Idempotency(db), payment.RerouteManualDisbursement)
+		paymentBusinessAdminUrl.GET("/disbursement/review", payment.ListDisbursementsInReview)
+		paymentBusinessAdminUrl.POST("/disbursement/review/release", middleware.Idempotency(db), payment.ReleaseDisbursement)
+		paymentBusinessAdminUrl.POST("/disbursement/review/cancel", payment.CancelDisbursement)
+		paymentBusinessAdminUrl.GET("/settlement/holidays", payment.ListSettlementHolidays)
+		paymentBusinessAdminUrl.POST("/settlement/holidays", payment.CreateSettlementHoliday)
+		paymentBusinessAdminUrl.DELETE("/settlement/holidays/:id", payment.DeleteSettlementHoliday)
 	}
 
 	paymentjobsUrl := r.Group(fmt.Sprintf("%v/jobs", ApiVersion))
@@ -0,0 +1,121 @@
+package webhook
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	EventPaymentSuccess      = "payment.success"
+	EventPaymentFailed       = "payment.failed"
+	EventBankTransferSuccess = "bank-transfer.success"
+	EventBankTransferFailed  = "bank-transfer.failed"
+	EventDisbursementSuccess = "disbursement.success"
+	EventDisbursementFailed  = "disbursement.failed"
+
+	// APIVersionLegacy sends the bare event data, without the envelope, as webhooks were sent before versioning.
+	APIVersionLegacy = "2023-01-01"
+	APIVersion1      = "2026-10-18"
+	LatestAPIVersion = APIVersion1
+)
+
+var (
+	APIVersions = []string{APIVersionLegacy, APIVersion1}
+)
+
+// Event is the envelope every webhook is sent in from APIVersion1 on.
+type Event struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	APIVersion string          `json:"api_version"`
+	CreatedAt  time.Time       `json:"created_at"`
+	Test       bool            `json:"test,omitempty"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// EventData is the payload of an event type.
+type EventData interface {
+	// LegacyPayload is the data as it was sent before versioning, it is what APIVersionLegacy receives.
+	LegacyPayload() map[string]interface{}
+}
+
+// Payment is the data of payment.success and payment.failed. Payments confirmed for a transaction carry its id and
+// title, payments to a payment account carry the reference and amount.
+type Payment struct {
+	TransactionID    string  `json:"transaction_id,omitempty"`
+	TransactionTitle string  `json:"transaction_title,omitempty"`
+	Reference        string  `json:"reference,omitempty"`
+	Amount           float64 `json:"amount,omitempty"`
+	Status           string  `json:"status"`
+}
+
+func (p Payment) LegacyPayload() map[string]interface{} {
+	if p.Reference != "" {
+		return map[string]interface{}{"reference": p.Reference, "amount": p.Amount, "status": p.Status}
+	}
+	return map[string]interface{}{"transaction_title": p.TransactionTitle, "transaction_id": p.TransactionID, "payment_status": p.Status}
+}
+
+// BankTransfer is the data of bank-transfer.success and bank-transfer.failed. Transfers for a payment carry its id,
+// transfers to a reserved account carry the reference.
+type BankTransfer struct {
+	PaymentID string  `json:"payment_id,omitempty"`
+	Reference string  `json:"reference,omitempty"`
+	Amount    float64 `json:"amount"`
+	Status    string  `json:"status"`
+}
+
+func (b BankTransfer) LegacyPayload() map[string]interface{} {
+	if b.Reference != "" {
+		return map[string]interface{}{"reference": b.Reference, "amount": b.Amount, "status": b.Status}
+	}
+	return map[string]interface{}{"payment_id": b.PaymentID, "amount": b.Amount, "status": b.Status}
+}
+
+// Disbursement is the data of disbursement.success and disbursement.failed.
+type Disbursement struct {
+	DisbursementID int    `json:"disbursement_id"`
+	Reference      string `json:"reference"`
+	Status         string `json:"status"`
+}
+
+func (d Disbursement) LegacyPayload() map[string]interface{} {
+	return map[string]interface{}{"disbursement_id": d.DisbursementID, "reference": d.Reference, "status": d.Status}
+}
+
+// EventTypes maps every event type to the data it carries.
+var EventTypes = map[string]EventData{
+	EventPaymentSuccess:      Payment{},
+	EventPaymentFailed:       Payment{},
+	EventBankTransferSuccess: BankTransfer{},
+	EventBankTransferFailed:  BankTransfer{},
+	EventDisbursementSuccess: Disbursement{},
+	EventDisbursementFailed:  Disbursement{},
+}
+
+// Payload is the request body of event with data, in the shape of event.APIVersion.
+func Payload(event Event, data EventData) ([]byte, error) {
+	if event.APIVersion == APIVersionLegacy {
+		legacy := data.LegacyPayload()
+		if event.Test {
+			legacy["test"] = true
+		}
+		return json.Marshal(legacy)
+	}
+
+	dataByte, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	event.CreatedAt = event.CreatedAt.UTC()
+	event.Data = dataByte
+	return json.Marshal(event)
+}
+
+// ParseEvent reads a webhook body sent with APIVersion1 or later, Data can then be unmarshalled into the struct of
+// its Type.
+func ParseEvent(body []byte) (Event, error) {
+	event := Event{}
+	err := json.Unmarshal(body, &event)
+	return event, err
+}
@@ -0,0 +1,89 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	rawJSONType = reflect.TypeOf(json.RawMessage{})
+)
+
+// Schema is the JSON Schema of the webhook body of an event type for apiVersion.
+func Schema(eventType, apiVersion string) (map[string]interface{}, error) {
+	data, ok := EventTypes[eventType]
+	if !ok {
+		return nil, fmt.Errorf("event %v not supported", eventType)
+	}
+
+	// legacy bodies are the bare data, whose fields vary with where the event was raised
+	schema := map[string]interface{}{"type": "object"}
+	if apiVersion != APIVersionLegacy {
+		schema = schemaOf(reflect.TypeOf(Event{}))
+		properties := schema["properties"].(map[string]interface{})
+		properties["type"] = map[string]interface{}{"type": "string", "const": eventType}
+		properties["api_version"] = map[string]interface{}{"type": "string", "const": apiVersion}
+		properties["data"] = schemaOf(reflect.TypeOf(data))
+	}
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = eventType
+	return schema, nil
+}
+
+// schemaOf describes t from its json tags, fields without omitempty are required.
+func schemaOf(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t == rawJSONType:
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaOf(t.Elem())}
+	case reflect.Struct:
+		var (
+			properties = map[string]interface{}{}
+			required   = []string{}
+		)
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			properties[name] = schemaOf(field.Type)
+			if !strings.Contains(options, "omitempty") {
+				required = append(required, name)
+			}
+		}
+		return map[string]interface{}{"type": "object", "properties": properties, "required": required}
+	}
+	return map[string]interface{}{}
+}
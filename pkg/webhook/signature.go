@@ -0,0 +1,110 @@
+// Package webhook signs and verifies the webhooks Vesicash sends to businesses.
+//
+// Every webhook carries a X-Vesicash-Signature header of the form
+//
+//	t=1697650000,v1=5257a869e7ecebeda32affa62cdca3fa51cad7e77a0e56ff536d0ce8e108d8bd
+//
+// where v1 is the hex HMAC-SHA256 of "<t>.<raw request body>" keyed with the endpoint secret. While a secret is
+// being rotated the header carries one v1 per secret, a webhook is genuine if any of them matches. Businesses
+// verify a webhook with
+//
+//	err := webhook.Verify(body, r.Header.Get(webhook.SignatureHeader), secret, webhook.DefaultTolerance)
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SignatureHeader  = "X-Vesicash-Signature"
+	DefaultTolerance = 5 * time.Minute
+	schemeV1         = "v1"
+)
+
+var (
+	ErrInvalidHeader    = errors.New("webhook signature header is invalid")
+	ErrNoValidSignature = errors.New("no webhook signature matches the payload")
+	ErrTooOld           = errors.New("webhook timestamp is outside the tolerance")
+)
+
+// ComputeSignature is the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with secret.
+func ComputeSignature(timestamp time.Time, body []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Sign builds the signature header for body, with one v1 signature per secret.
+func Sign(timestamp time.Time, body []byte, secrets ...string) string {
+	parts := []string{fmt.Sprintf("t=%v", timestamp.Unix())}
+	for _, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%v=%v", schemeV1, ComputeSignature(timestamp, body, secret)))
+	}
+	return strings.Join(parts, ",")
+}
+
+// ParseHeader returns the timestamp and the v1 signatures of a signature header.
+func ParseHeader(header string) (time.Time, []string, error) {
+	var (
+		timestamp  time.Time
+		signatures = []string{}
+	)
+
+	for _, part := range strings.Split(header, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found {
+			return timestamp, signatures, ErrInvalidHeader
+		}
+		switch key {
+		case "t":
+			unix, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return timestamp, signatures, ErrInvalidHeader
+			}
+			timestamp = time.Unix(unix, 0)
+		case schemeV1:
+			signatures = append(signatures, value)
+		}
+	}
+
+	if timestamp.IsZero() || len(signatures) == 0 {
+		return timestamp, signatures, ErrInvalidHeader
+	}
+	return timestamp, signatures, nil
+}
+
+// Verify checks that header signs body with secret and was made within tolerance of now, a tolerance of 0 skips the
+// timestamp check. Rejecting old timestamps keeps a captured webhook from being replayed later.
+func Verify(body []byte, header, secret string, tolerance time.Duration) error {
+	timestamp, signatures, err := ParseHeader(header)
+	if err != nil {
+		return err
+	}
+
+	if tolerance > 0 {
+		age := time.Since(timestamp)
+		if age > tolerance || age < -tolerance {
+			return ErrTooOld
+		}
+	}
+
+	expected := ComputeSignature(timestamp, body, secret)
+	for _, signature := range signatures {
+		if hmac.Equal([]byte(signature), []byte(expected)) {
+			return nil
+		}
+	}
+	return ErrNoValidSignature
+}
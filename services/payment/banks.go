@@ -1,6 +1,7 @@
 package payment
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/vesicash/payment-ms/external/external_models"
@@ -11,15 +12,24 @@ import (
 
 func ListBanksService(extReq request.ExternalRequest, db postgresql.Databases, countryCode string) ([]external_models.BanksResponse, int, error) {
 	var (
-		rave = Rave{ExtReq: extReq}
+		banks = []external_models.BanksResponse{}
+		err   = fmt.Errorf("no bank listing gateway available")
 	)
 
-	banks, err := rave.ListBanks(countryCode)
-	if err != nil {
-		return banks, http.StatusInternalServerError, err
+	for _, name := range AvailableGateways("rave", "paystack") {
+		gateway, gatewayErr := FindGateway(extReq, name)
+		if gatewayErr != nil {
+			continue
+		}
+
+		banks, err = gateway.ListBanks(countryCode)
+		if err == nil {
+			return banks, http.StatusOK, nil
+		}
+		extReq.Logger.Error(fmt.Sprintf("listing banks with %v failed: %v", name, err.Error()))
 	}
 
-	return banks, http.StatusOK, nil
+	return banks, http.StatusInternalServerError, err
 }
 
 func ConvertCurrencyService(extReq request.ExternalRequest, db postgresql.Databases, amount float64, from, to string) (models.ConvertCurrencyResponse, int, error) {
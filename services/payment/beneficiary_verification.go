@@ -0,0 +1,313 @@
+package payment
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vesicash/payment-ms/external/request"
+	"github.com/vesicash/payment-ms/internal/config"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+)
+
+// AccountResolver is implemented by gateways that can look up the name on a bank account.
+type AccountResolver interface {
+	ResolveAccount(bankCode, accountNumber string) (string, error)
+}
+
+type BeneficiaryNameCheck struct {
+	ResolvedName string
+	MatchedName  string
+	Score        int
+	Matched      bool
+}
+
+var (
+	beneficiaryVerificationActor = "beneficiary-verification"
+
+	// gateways tried for a name enquiry when the disbursement gateway cannot do one
+	nameEnquiryGateways = []string{"rave", "paystack"}
+
+	// words that say nothing about who owns the account
+	nameNoiseWords = []string{"mr", "mrs", "ms", "miss", "dr", "chief", "alhaji", "ltd", "limited", "plc", "co", "and"}
+)
+
+func beneficiaryVerificationDefaults() config.BeneficiaryVerification {
+	settings := config.GetConfig().BeneficiaryVerification
+	if settings.MinMatchScore <= 0 {
+		settings.MinMatchScore = 80
+	}
+	if settings.CacheHours <= 0 {
+		settings.CacheHours = 24
+	}
+	return settings
+}
+
+// ResolveBeneficiaryName returns the name the bank holds for the account, a lookup is reused for the configured cache hours.
+// The disbursement gateway is asked first, then any available gateway that supports name enquiry.
+func ResolveBeneficiaryName(extReq request.ExternalRequest, db postgresql.Databases, gateway, bankCode, accountNumber string) (string, error) {
+	var (
+		settings = beneficiaryVerificationDefaults()
+		lastErr  = fmt.Errorf("no gateway available for name enquiry")
+	)
+
+	enquiry := models.BeneficiaryNameEnquiry{AccountNumber: accountNumber, BankCode: bankCode}
+	code, err := enquiry.GetBeneficiaryNameEnquiryByAccount(db.Payment)
+	if err != nil && code == http.StatusInternalServerError {
+		return "", err
+	}
+	cached := err == nil
+	if cached && time.Since(enquiry.UpdatedAt) < time.Duration(settings.CacheHours)*time.Hour {
+		return enquiry.AccountName, nil
+	}
+
+	for _, name := range append([]string{strings.ToLower(gateway)}, nameEnquiryGateways...) {
+		if name == "" || !IsGatewayAvailable(name) {
+			continue
+		}
+		resolver, ok := GetGateway(extReq, name).(AccountResolver)
+		if !ok {
+			continue
+		}
+
+		accountName, err := resolver.ResolveAccount(bankCode, accountNumber)
+		if err != nil {
+			lastErr = err
+			extReq.Logger.Error(fmt.Sprintf("name enquiry with %v failed for account %v, bank %v: %v", name, accountNumber, bankCode, err.Error()))
+			continue
+		}
+
+		enquiry.AccountName = accountName
+		enquiry.Gateway = name
+		if cached {
+			err = enquiry.UpdateAllFields(db.Payment)
+		} else {
+			err = enquiry.CreateBeneficiaryNameEnquiry(db.Payment)
+		}
+		if err != nil {
+			extReq.Logger.Error(fmt.Sprintf("error caching name enquiry for account %v: %v", accountNumber, err.Error()))
+		}
+		return accountName, nil
+	}
+
+	return "", lastErr
+}
+
+func beneficiaryNameTokens(name string) []string {
+	tokens := []string{}
+	fields := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, field := range fields {
+		noise := false
+		for _, word := range nameNoiseWords {
+			if field == word {
+				noise = true
+				break
+			}
+		}
+		if !noise {
+			tokens = append(tokens, field)
+		}
+	}
+	return tokens
+}
+
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
+
+func minInt(values ...int) int {
+	smallest := values[0]
+	for _, value := range values[1:] {
+		if value < smallest {
+			smallest = value
+		}
+	}
+	return smallest
+}
+
+func nameTokenSimilarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	ra, rb := []rune(a), []rune(b)
+	// an initial matches the name it stands for
+	if (len(ra) == 1 || len(rb) == 1) && ra[0] == rb[0] {
+		return 0.9
+	}
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+// averageBestTokenMatch is how well every token of from is found in to.
+func averageBestTokenMatch(from, to []string) float64 {
+	total := 0.0
+	for _, a := range from {
+		best := 0.0
+		for _, b := range to {
+			if similarity := nameTokenSimilarity(a, b); similarity > best {
+				best = similarity
+			}
+		}
+		total += best
+	}
+	return total / float64(len(from))
+}
+
+// BeneficiaryNameScore compares two names from 0 to 100 ignoring case, punctuation, titles and word order.
+// Names where one side has extra words, like a middle name, still score high, but less than an exact match.
+func BeneficiaryNameScore(a, b string) int {
+	ta, tb := beneficiaryNameTokens(a), beneficiaryNameTokens(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+
+	forward, backward := averageBestTokenMatch(ta, tb), averageBestTokenMatch(tb, ta)
+	high, low := forward, backward
+	if low > high {
+		high, low = low, high
+	}
+	return int(100*(0.6*high+0.4*low) + 0.5)
+}
+
+// CheckBeneficiaryName resolves the name on the account and scores it against each of the expected names,
+// like the saved beneficiary name and the KYC names of the recipient, keeping the best match.
+func CheckBeneficiaryName(extReq request.ExternalRequest, db postgresql.Databases, gateway, bankCode, accountNumber string, names ...string) (BeneficiaryNameCheck, error) {
+	check := BeneficiaryNameCheck{}
+
+	resolvedName, err := ResolveBeneficiaryName(extReq, db, gateway, bankCode, accountNumber)
+	if err != nil {
+		return check, err
+	}
+
+	check.ResolvedName = resolvedName
+	for _, name := range names {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		if score := BeneficiaryNameScore(resolvedName, name); score > check.Score {
+			check.Score, check.MatchedName = score, name
+		}
+	}
+	check.Matched = check.Score >= beneficiaryVerificationDefaults().MinMatchScore
+	return check, nil
+}
+
+// VerifyDisbursementBeneficiary runs a name enquiry before a disbursement is sent and holds it for review when the bank's
+// name does not match any of the expected names, or the name could not be looked up. It returns true when the disbursement
+// was held. Mobile money payouts have no bank account to look up and are not checked.
+func VerifyDisbursementBeneficiary(extReq request.ExternalRequest, db postgresql.Databases, disbursement *models.Disbursement, names ...string) (bool, error) {
+	if disbursement.Gateway == "rave_momo" || disbursement.BankCode == "" || disbursement.BankAccountNumber == "" {
+		return false, nil
+	}
+
+	check, err := CheckBeneficiaryName(extReq, db, disbursement.Gateway, disbursement.BankCode, disbursement.BankAccountNumber, append([]string{disbursement.BeneficiaryName}, names...)...)
+	if err == nil && check.Matched {
+		disbursement.ResolvedAccountName = check.ResolvedName
+		disbursement.NameMatchScore = check.Score
+		return false, disbursement.UpdateAllFields(db.Payment)
+	}
+
+	reason := fmt.Sprintf("beneficiary name %v scored %v against %v", check.ResolvedName, check.Score, check.MatchedName)
+	if err != nil {
+		reason = fmt.Sprintf("name enquiry failed: %v", err.Error())
+	}
+
+	disbursement.ResolvedAccountName = check.ResolvedName
+	disbursement.NameMatchScore = check.Score
+	disbursement.FailureReason = reason
+	err = TransitionDisbursement(db, disbursement, DisbursementReview, beneficiaryVerificationActor, map[string]interface{}{
+		"resolved_name": check.ResolvedName,
+		"matched_name":  check.MatchedName,
+		"score":         check.Score,
+		"reason":        reason,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	extReq.Logger.Info(fmt.Sprintf("disbursement %v held for review: %v", disbursement.DisbursementID, reason))
+	err = SlackNotify(extReq, config.GetConfig().Slack.DisbursementChannelID, `
+			Disbursement Held For Beneficiary Name Review
+			Environment: `+config.GetConfig().App.Name+`
+			Disbursement ID: `+fmt.Sprintf("%v", disbursement.DisbursementID)+`
+			Account ID: `+fmt.Sprintf("%v", disbursement.RecipientID)+`
+			Beneficiary Name: `+disbursement.BeneficiaryName+`
+			Bank Account: `+disbursement.BankAccountNumber+` (`+disbursement.BankName+`)
+			Amount: `+fmt.Sprintf("%v %v", disbursement.Currency, disbursement.Amount)+`
+			Reason: `+reason+`
+			`)
+	if err != nil && !extReq.Test {
+		extReq.Logger.Error("error sending notification to slack: ", err.Error())
+	}
+	return true, nil
+}
+
+func ListDisbursementsInReviewService(db postgresql.Databases, paginator postgresql.Pagination) ([]models.Disbursement, postgresql.PaginationResponse, int, error) {
+	disbursement := models.Disbursement{Status: string(DisbursementReview)}
+	disbursements, pagination, err := disbursement.GetDisbursementsByStatus(db.Payment, paginator)
+	if err != nil {
+		return disbursements, pagination, http.StatusInternalServerError, err
+	}
+	return disbursements, pagination, http.StatusOK, nil
+}
+
+// ReleaseDisbursementService sends a disbursement held for review to its gateway once an admin has confirmed the beneficiary.
+func ReleaseDisbursementService(c *gin.Context, extReq request.ExternalRequest, db postgresql.Databases, req models.ReviewDisbursementRequest) (models.Disbursement, int, error) {
+	return adminDisbursementAction(c, extReq, db, req.DisbursementID, DisbursementReview, func(disbursement *models.Disbursement, actor string) (int, error) {
+		if !IsGatewayAvailable(disbursement.Gateway) {
+			return http.StatusServiceUnavailable, fmt.Errorf("gateway %v is currently unavailable", disbursement.Gateway)
+		}
+
+		resData, err := InitDisbursementTransfer(extReq, db, disbursement)
+		if err != nil {
+			return http.StatusBadGateway, fmt.Errorf("disbursement could not be sent through %v: %v", disbursement.Gateway, err.Error())
+		}
+
+		err = TransitionDisbursement(db, disbursement, DisbursementPending, actor, map[string]interface{}{
+			"comment":  req.Comment,
+			"response": resData.Response,
+		})
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+		return http.StatusOK, nil
+	})
+}
+
+// CancelDisbursementService cancels a disbursement held for review, wallet withdrawals are returned to the wallet.
+func CancelDisbursementService(c *gin.Context, extReq request.ExternalRequest, db postgresql.Databases, req models.ReviewDisbursementRequest) (models.Disbursement, int, error) {
+	return adminDisbursementAction(c, extReq, db, req.DisbursementID, DisbursementReview, func(disbursement *models.Disbursement, actor string) (int, error) {
+		err := TransitionDisbursement(db, disbursement, DisbursementCancelled, actor, map[string]interface{}{"comment": req.Comment})
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+		returnWalletDisbursementFunds(extReq, db, *disbursement)
+		FailSettlementBatch(extReq, db, *disbursement, "payout cancelled: "+req.Comment)
+		return http.StatusOK, nil
+	})
+}
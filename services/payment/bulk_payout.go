@@ -0,0 +1,518 @@
+package payment
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vesicash/payment-ms/external/request"
+	"github.com/vesicash/payment-ms/internal/config"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+var (
+	bulkPayoutActor = "bulk-payout"
+
+	// bulk payouts go to bank accounts, mobile money is paid one recipient at a time
+	bulkPayoutGateways = []string{"monnify", "rave", "paystack"}
+
+	// disbursements are only checked by the disbursement-check job after they have been sent
+	bulkPayoutSendWindow = 30 * time.Minute
+
+	bulkPayoutResultsHeader = []string{"row", "beneficiary_name", "bank_code", "bank_account_number", "resolved_account_name", "amount", "narration", "disbursement_id", "status", "error"}
+)
+
+func bulkPayoutDefaults() config.BulkPayout {
+	settings := config.GetConfig().BulkPayout
+	if settings.MaxRows <= 0 {
+		settings.MaxRows = 1000
+	}
+	if settings.Concurrency <= 0 {
+		settings.Concurrency = 5
+	}
+	return settings
+}
+
+// ParseBulkPayoutCSV reads bulk payout rows from a csv file with a header row. The bank_code, bank_account_number
+// (or account_number) and amount columns are required, beneficiary_name and narration are optional.
+func ParseBulkPayoutCSV(file io.Reader) ([]models.BulkPayoutRow, error) {
+	rows := []models.BulkPayoutRow{}
+	reader := csv.NewReader(file)
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return rows, fmt.Errorf("invalid csv file: %v", err.Error())
+	}
+	if len(records) < 2 {
+		return rows, fmt.Errorf("csv file has no rows")
+	}
+
+	columns := map[string]int{}
+	for i, name := range records[0] {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "account_number" {
+			name = "bank_account_number"
+		}
+		columns[name] = i
+	}
+	for _, name := range []string{"bank_code", "bank_account_number", "amount"} {
+		if _, ok := columns[name]; !ok {
+			return rows, fmt.Errorf("csv file is missing the %v column", name)
+		}
+	}
+
+	value := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	for _, record := range records[1:] {
+		// rows with an amount that is not a number are kept with no amount and rejected by the validation
+		amount, _ := strconv.ParseFloat(strings.ReplaceAll(value(record, "amount"), ",", ""), 64)
+		rows = append(rows, models.BulkPayoutRow{
+			BeneficiaryName:   value(record, "beneficiary_name"),
+			BankCode:          value(record, "bank_code"),
+			BankAccountNumber: value(record, "bank_account_number"),
+			Amount:            amount,
+			Narration:         value(record, "narration"),
+		})
+	}
+	return rows, nil
+}
+
+func isAccountNumber(accountNumber string) bool {
+	if accountNumber == "" {
+		return false
+	}
+	for _, r := range accountNumber {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// validateBulkPayoutRows checks the bank, account and amount of every row and resolves the name on each account.
+// A row with a beneficiary name must match the resolved name. It returns the items and whether all of them are valid.
+func validateBulkPayoutRows(extReq request.ExternalRequest, db postgresql.Databases, gateway string, rows []models.BulkPayoutRow) ([]models.BulkPayoutItem, bool) {
+	var (
+		items = []models.BulkPayoutItem{}
+		banks = map[string]string{}
+		valid = true
+	)
+
+	for i, row := range rows {
+		item := models.BulkPayoutItem{
+			Row:               i + 1,
+			BeneficiaryName:   row.BeneficiaryName,
+			BankCode:          row.BankCode,
+			BankAccountNumber: row.BankAccountNumber,
+			Amount:            row.Amount,
+			Narration:         row.Narration,
+			Status:            "valid",
+		}
+		reasons := []string{}
+
+		if row.Amount <= 0 {
+			reasons = append(reasons, "amount must be greater than 0")
+		}
+		if !isAccountNumber(row.BankAccountNumber) {
+			reasons = append(reasons, "bank account number must be digits only")
+		}
+
+		bankName, ok := banks[row.BankCode]
+		if !ok && row.BankCode != "" {
+			bank, err := GetBank(extReq, 0, "", row.BankCode, "")
+			if err == nil {
+				bankName = bank.Name
+				banks[row.BankCode] = bankName
+				ok = true
+			}
+		}
+		if !ok {
+			reasons = append(reasons, fmt.Sprintf("bank code %v not found", row.BankCode))
+		}
+		item.BankName = bankName
+
+		if len(reasons) == 0 {
+			if row.BeneficiaryName == "" {
+				resolvedName, err := ResolveBeneficiaryName(extReq, db, gateway, row.BankCode, row.BankAccountNumber)
+				item.ResolvedAccountName = resolvedName
+				if err != nil {
+					reasons = append(reasons, fmt.Sprintf("account could not be resolved: %v", err.Error()))
+				}
+			} else {
+				check, err := CheckBeneficiaryName(extReq, db, gateway, row.BankCode, row.BankAccountNumber, row.BeneficiaryName)
+				item.ResolvedAccountName = check.ResolvedName
+				if err != nil {
+					reasons = append(reasons, fmt.Sprintf("account could not be resolved: %v", err.Error()))
+				} else if !check.Matched {
+					reasons = append(reasons, fmt.Sprintf("account name %v does not match %v", check.ResolvedName, row.BeneficiaryName))
+				}
+			}
+		}
+
+		if len(reasons) > 0 {
+			item.Status = "invalid"
+			item.Error = strings.Join(reasons, "; ")
+			valid = false
+		}
+		items = append(items, item)
+	}
+	return items, valid
+}
+
+// CreateBulkPayoutService validates every row of a bulk payout before anything is sent. When all rows are valid and the
+// wallet covers the total with fees, the wallet is debited once for the batch and each row is sent as a wallet disbursement,
+// a few at a time. A rejected bulk payout is saved with the reason of every invalid row and nothing is debited.
+func CreateBulkPayoutService(extReq request.ExternalRequest, db postgresql.Databases, accountID int, req models.CreateBulkPayoutRequest) (models.BulkPayoutResponse, int, error) {
+	var (
+		settings = bulkPayoutDefaults()
+		currency = strings.ToUpper(req.Currency)
+		gateway  = strings.ToLower(thisOrThatStr(req.Gateway, "monnify"))
+		fee      = float64(int(config.GetConfig().ONLINE_PAYMENT.DisbursementCharge))
+		data     = models.BulkPayoutResponse{}
+	)
+
+	if !utility.InStringSlice(gateway, bulkPayoutGateways) {
+		return data, http.StatusBadRequest, fmt.Errorf("gateway %v does not support bulk payouts", gateway)
+	}
+	if !IsGatewayAvailable(gateway) {
+		return data, http.StatusServiceUnavailable, fmt.Errorf("gateway %v is currently unavailable", gateway)
+	}
+	if len(req.Rows) == 0 {
+		return data, http.StatusBadRequest, fmt.Errorf("bulk payout has no rows")
+	}
+	if len(req.Rows) > settings.MaxRows {
+		return data, http.StatusBadRequest, fmt.Errorf("bulk payout has %v rows, the maximum is %v", len(req.Rows), settings.MaxRows)
+	}
+
+	if !HasBvn(extReq, uint(accountID)) {
+		return data, http.StatusBadRequest, fmt.Errorf("this account does not have bvn associated with it")
+	}
+
+	user, err := GetUserWithAccountID(extReq, accountID)
+	if err != nil {
+		return data, http.StatusInternalServerError, fmt.Errorf("could not retrieve user info")
+	}
+	if !user.CanMakeWithdrawal {
+		return data, http.StatusBadRequest, fmt.Errorf("user withdrawals not enabled, please contact customer care")
+	}
+
+	businessName := strconv.Itoa(accountID)
+	businessProfile, _ := GetBusinessProfileByAccountID(extReq, extReq.Logger, accountID)
+	if businessProfile.BusinessName != "" {
+		businessName = businessProfile.BusinessName
+	}
+
+	bulkPayout := models.BulkPayout{
+		BulkPayoutID: fmt.Sprintf("bp%v", utility.GetRandomNumbersInRange(1000000000, 9999999999)),
+		AccountID:    accountID,
+		Currency:     currency,
+		Gateway:      gateway,
+		RowCount:     len(req.Rows),
+		TotalFee:     fee * float64(len(req.Rows)),
+		Status:       "processing",
+	}
+	for _, row := range req.Rows {
+		bulkPayout.TotalAmount += row.Amount
+	}
+
+	items, valid := validateBulkPayoutRows(extReq, db, gateway, req.Rows)
+	code := http.StatusOK
+	if !valid {
+		bulkPayout.Status, bulkPayout.Reason = "rejected", "some rows are invalid"
+		code, err = http.StatusBadRequest, fmt.Errorf("bulk payout rejected: some rows are invalid")
+	} else {
+		_, debitErr := DebitWallet(extReq, db, bulkPayout.TotalAmount+bulkPayout.TotalFee, currency, accountID, DefaultWalletType, "", LedgerPosting{Account: LedgerPayoutAccount, Reference: bulkPayout.BulkPayoutID})
+		if debitErr != nil {
+			bulkPayout.Status, bulkPayout.Reason = "rejected", debitErr.Error()
+			code, err = http.StatusBadRequest, fmt.Errorf("bulk payout rejected: %v", debitErr.Error())
+		}
+	}
+
+	createErr := bulkPayout.CreateBulkPayout(db.Payment)
+	if createErr != nil {
+		if code == http.StatusOK {
+			// the wallet was debited, the bulk payout must not be lost
+			extReq.Logger.Error(fmt.Sprintf("error saving bulk payout %v of account %v after wallet debit: %v", bulkPayout.BulkPayoutID, accountID, createErr.Error()))
+		}
+		return data, http.StatusInternalServerError, createErr
+	}
+
+	callback := utility.GenerateGroupByURL(config.GetConfig().App.Url, "/disbursement/callback", map[string]string{})
+	for i := range items {
+		item := &items[i]
+		item.BulkPayoutID = bulkPayout.BulkPayoutID
+
+		if code == http.StatusOK {
+			disbursement := models.Disbursement{
+				RecipientID:         accountID,
+				DisbursementID:      utility.GetRandomNumbersInRange(1000000000, 9999999999),
+				Reference:           fmt.Sprintf("vc%v", utility.GetRandomNumbersInRange(1000000000, 9999999999)),
+				Currency:            currency,
+				BusinessID:          user.BusinessId,
+				Amount:              fmt.Sprintf("%v", item.Amount),
+				CallbackUrl:         callback,
+				BeneficiaryName:     item.ResolvedAccountName,
+				ResolvedAccountName: item.ResolvedAccountName,
+				BankAccountNumber:   item.BankAccountNumber,
+				BankName:            item.BankName,
+				BankCode:            item.BankCode,
+				Narration:           thisOrThatStr(item.Narration, fmt.Sprintf("%v/VES", businessName)),
+				DebitCurrency:       currency,
+				Gateway:             gateway,
+				Fee:                 int(fee),
+				Status:              "new",
+				Type:                "wallet",
+				Approved:            "pending",
+				TryAgainAt:          time.Now().Add(bulkPayoutSendWindow),
+			}
+			err := disbursement.CreateDisbursement(db.Payment)
+			if err != nil {
+				extReq.Logger.Error(fmt.Sprintf("error creating disbursement for row %v of bulk payout %v: %v", item.Row, bulkPayout.BulkPayoutID, err.Error()))
+				item.Status, item.Error = "failed", "disbursement could not be created"
+				refundBulkPayoutRow(extReq, db, bulkPayout, *item, fee)
+			} else {
+				item.DisbursementID = disbursement.DisbursementID
+				item.Status = disbursement.Status
+			}
+		}
+
+		err := item.CreateBulkPayoutItem(db.Payment)
+		if err != nil {
+			extReq.Logger.Error(fmt.Sprintf("error saving row %v of bulk payout %v: %v", item.Row, bulkPayout.BulkPayoutID, err.Error()))
+		}
+	}
+
+	data.BulkPayout, data.Items = bulkPayout, items
+	if code != http.StatusOK {
+		return data, code, err
+	}
+
+	extReq.Logger.Info(fmt.Sprintf("bulk payout %v of %v rows debited %v %v from account %v", bulkPayout.BulkPayoutID, bulkPayout.RowCount, currency, bulkPayout.TotalAmount+bulkPayout.TotalFee, accountID))
+	if extReq.Test {
+		SendBulkPayout(extReq, db, items)
+	} else {
+		go SendBulkPayout(extReq, db, items)
+	}
+
+	data.Summary = bulkPayoutSummary(items)
+	return data, http.StatusOK, nil
+}
+
+// refundBulkPayoutRow returns the amount and fee of a row that was debited but never became a disbursement.
+func refundBulkPayoutRow(extReq request.ExternalRequest, db postgresql.Databases, bulkPayout models.BulkPayout, item models.BulkPayoutItem, fee float64) {
+	_, err := CreditWallet(extReq, db, item.Amount+fee, bulkPayout.Currency, bulkPayout.AccountID, true, DefaultWalletType, "", LedgerPosting{Account: LedgerPayoutAccount, Reference: fmt.Sprintf("%v:%v", bulkPayout.BulkPayoutID, item.Row)})
+	if err != nil {
+		extReq.Logger.Error(fmt.Sprintf("error refunding row %v of bulk payout %v: %v", item.Row, bulkPayout.BulkPayoutID, err.Error()))
+	}
+}
+
+// SendBulkPayout sends the disbursements of a bulk payout to the gateway. Rows the gateway can take in one bulk transfer
+// are sent in batches, the rest no more than the configured number at a time.
+func SendBulkPayout(extReq request.ExternalRequest, db postgresql.Databases, items []models.BulkPayoutItem) {
+	var (
+		settings      = disbursementBatchDefaults()
+		wg            sync.WaitGroup
+		slots         = make(chan struct{}, bulkPayoutDefaults().Concurrency)
+		disbursements = []models.Disbursement{}
+		batchable     = []models.Disbursement{}
+		rows          = map[int]*models.BulkPayoutItem{}
+	)
+
+	for i := range items {
+		if items[i].DisbursementID == 0 {
+			continue
+		}
+		disbursement, ready := prepareBulkPayoutItem(extReq, db, &items[i])
+		if !ready {
+			saveBulkPayoutItem(extReq, db, &items[i])
+			continue
+		}
+		rows[disbursement.DisbursementID] = &items[i]
+		if SupportsBulkTransfer(extReq, disbursement.Gateway, disbursement.Currency) {
+			batchable = append(batchable, disbursement)
+		} else {
+			disbursements = append(disbursements, disbursement)
+		}
+	}
+
+	if len(batchable) < settings.MinSize {
+		disbursements = append(disbursements, batchable...)
+		batchable = []models.Disbursement{}
+	}
+	for _, group := range GroupDisbursementsForBatch(batchable, settings.MaxSize) {
+		_, err := SendDisbursementBatch(extReq, db, group[0].Gateway, bulkPayoutActor, group)
+		if err != nil {
+			extReq.Logger.Error(fmt.Sprintf("error sending batch of %v bulk payout rows: %v", len(group), err.Error()))
+		}
+		for _, disbursement := range group {
+			item := rows[disbursement.DisbursementID]
+			item.Status = disbursement.Status
+			if !strings.EqualFold(disbursement.Status, string(DisbursementPending)) {
+				item.Error = disbursement.FailureReason
+			}
+			saveBulkPayoutItem(extReq, db, item)
+		}
+	}
+
+	for i := range disbursements {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(disbursement *models.Disbursement) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			sendBulkPayoutItem(extReq, db, rows[disbursement.DisbursementID], disbursement)
+		}(&disbursements[i])
+	}
+	wg.Wait()
+}
+
+// prepareBulkPayoutItem gets the disbursement of a row ready to be sent, it returns false when the row cannot be sent yet.
+func prepareBulkPayoutItem(extReq request.ExternalRequest, db postgresql.Databases, item *models.BulkPayoutItem) (models.Disbursement, bool) {
+	disbursement := models.Disbursement{DisbursementID: item.DisbursementID}
+	_, err := disbursement.GetDisbursementByDisbursementID(db.Payment)
+	if err != nil {
+		item.Error = fmt.Sprintf("disbursement not found: %v", err.Error())
+		return disbursement, false
+	}
+
+	// from here the disbursement-check job follows the disbursement like any other
+	disbursement.TryAgainAt = time.Time{}
+	awaitingApproval, err := RequireDisbursementApproval(extReq, db, &disbursement, item.Amount, false, "")
+	if err != nil {
+		item.Error = err.Error()
+		return disbursement, false
+	}
+	if awaitingApproval {
+		item.Status = disbursement.Status
+		return disbursement, false
+	}
+	return disbursement, true
+}
+
+func saveBulkPayoutItem(extReq request.ExternalRequest, db postgresql.Databases, item *models.BulkPayoutItem) {
+	err := item.UpdateAllFields(db.Payment)
+	if err != nil {
+		extReq.Logger.Error(fmt.Sprintf("error updating row %v of bulk payout %v: %v", item.Row, item.BulkPayoutID, err.Error()))
+	}
+}
+
+func sendBulkPayoutItem(extReq request.ExternalRequest, db postgresql.Databases, item *models.BulkPayoutItem, disbursement *models.Disbursement) {
+	defer saveBulkPayoutItem(extReq, db, item)
+
+	sendDisbursementTransfer(extReq, db, disbursement, bulkPayoutActor)
+	item.Status = disbursement.Status
+	if !strings.EqualFold(disbursement.Status, string(DisbursementPending)) {
+		item.Error = disbursement.FailureReason
+	}
+}
+
+func bulkPayoutSummary(items []models.BulkPayoutItem) map[string]int {
+	summary := map[string]int{}
+	for _, item := range items {
+		summary[item.Status] += 1
+	}
+	return summary
+}
+
+// GetBulkPayoutService returns a bulk payout of the account with the current status of every row, taken from its disbursement.
+func GetBulkPayoutService(db postgresql.Databases, accountID int, bulkPayoutID string) (models.BulkPayoutResponse, int, error) {
+	data := models.BulkPayoutResponse{}
+
+	bulkPayout := models.BulkPayout{BulkPayoutID: bulkPayoutID, AccountID: accountID}
+	code, err := bulkPayout.GetBulkPayoutByBulkPayoutIDAndAccountID(db.Payment)
+	if err != nil {
+		if code == http.StatusBadRequest {
+			code = http.StatusNotFound
+		}
+		return data, code, fmt.Errorf("bulk payout not found: %v", err.Error())
+	}
+
+	item := models.BulkPayoutItem{BulkPayoutID: bulkPayoutID}
+	items, err := item.GetBulkPayoutItemsByBulkPayoutID(db.Payment)
+	if err != nil {
+		return data, http.StatusInternalServerError, err
+	}
+
+	done := true
+	for i := range items {
+		if items[i].DisbursementID != 0 {
+			disbursement := models.Disbursement{DisbursementID: items[i].DisbursementID}
+			_, err := disbursement.GetDisbursementByDisbursementID(db.Payment)
+			if err == nil && disbursement.Status != items[i].Status {
+				items[i].Status, items[i].Error = disbursement.Status, disbursement.FailureReason
+				items[i].UpdateAllFields(db.Payment)
+			}
+		}
+		if items[i].Status != "invalid" && !utility.InStringSlice(items[i].Status, disbursementTerminalStatuses) {
+			done = false
+		}
+	}
+
+	if done && bulkPayout.Status == "processing" {
+		bulkPayout.Status = "completed"
+		err = bulkPayout.UpdateAllFields(db.Payment)
+		if err != nil {
+			return data, http.StatusInternalServerError, err
+		}
+	}
+
+	data.BulkPayout, data.Items, data.Summary = bulkPayout, items, bulkPayoutSummary(items)
+	return data, http.StatusOK, nil
+}
+
+func ListBulkPayoutsService(db postgresql.Databases, accountID int, paginator postgresql.Pagination) ([]models.BulkPayout, postgresql.PaginationResponse, int, error) {
+	bulkPayout := models.BulkPayout{AccountID: accountID}
+	bulkPayouts, pagination, err := bulkPayout.GetBulkPayoutsByAccountID(db.Payment, paginator)
+	if err != nil {
+		return bulkPayouts, pagination, http.StatusInternalServerError, err
+	}
+	return bulkPayouts, pagination, http.StatusOK, nil
+}
+
+// BulkPayoutResultsService returns the rows of a bulk payout with their status as a csv file.
+func BulkPayoutResultsService(db postgresql.Databases, accountID int, bulkPayoutID string) ([]byte, int, error) {
+	data, code, err := GetBulkPayoutService(db, accountID, bulkPayoutID)
+	if err != nil {
+		return nil, code, err
+	}
+
+	buffer := new(bytes.Buffer)
+	writer := csv.NewWriter(buffer)
+	writer.Write(bulkPayoutResultsHeader)
+	for _, item := range data.Items {
+		writer.Write([]string{
+			strconv.Itoa(item.Row),
+			item.BeneficiaryName,
+			item.BankCode,
+			item.BankAccountNumber,
+			item.ResolvedAccountName,
+			fmt.Sprintf("%v", item.Amount),
+			item.Narration,
+			strconv.Itoa(item.DisbursementID),
+			item.Status,
+			item.Error,
+		})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	return buffer.Bytes(), http.StatusOK, nil
+}
@@ -0,0 +1,90 @@
+package payment
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/vesicash/payment-ms/external/external_models"
+	"github.com/vesicash/payment-ms/external/request"
+)
+
+type Campay struct {
+	ExtReq request.ExternalRequest
+}
+
+func (c *Campay) InitPayment(reference, description, currency, redirectUrl string, amount float64) (string, external_models.CampayInitPaymentRequest, error) {
+	data := external_models.CampayInitPaymentRequest{
+		Amount:             fmt.Sprintf("%v", math.Ceil(amount)),
+		Currency:           strings.ToUpper(thisOrThatStr(currency, "XAF")),
+		Description:        description,
+		ExternalReference:  reference,
+		RedirectUrl:        redirectUrl,
+		FailureRedirectUrl: redirectUrl,
+		PaymentOptions:     "MOMO",
+	}
+	paymentItf, err := c.ExtReq.SendExternalRequest(request.CampayInitPayment, data)
+	if err != nil {
+		return "", data, err
+	}
+
+	paymentData, ok := paymentItf.(external_models.CampayInitPaymentResponse)
+	if !ok {
+		return "", data, fmt.Errorf("response data format error")
+	}
+
+	if paymentData.Link == "" {
+		return "", data, fmt.Errorf("campay did not return a payment link")
+	}
+
+	return paymentData.Link, data, nil
+}
+
+func (c *Campay) Status(reference string) (external_models.CampayTransactionStatusResponse, bool, string, float64, error) {
+	paymentItf, err := c.ExtReq.SendExternalRequest(request.CampayTransactionStatus, reference)
+	if err != nil {
+		return external_models.CampayTransactionStatusResponse{}, false, "", 0, err
+	}
+
+	data, ok := paymentItf.(external_models.CampayTransactionStatusResponse)
+	if !ok {
+		return data, false, "", 0, fmt.Errorf("response data format error")
+	}
+
+	statusString := campayStatus(data.Status)
+	return data, statusString == "success", statusString, data.Amount, nil
+}
+
+func (c *Campay) VerifyTrans(reference string, amount float64, currency string) (bool, float64, error) {
+	data, status, statusString, paidAmount, err := c.Status(reference)
+	if err != nil {
+		return false, 0, err
+	}
+
+	if !status {
+		return false, paidAmount, fmt.Errorf("transaction %v", statusString)
+	}
+
+	if currency != "" && !strings.EqualFold(data.Currency, currency) {
+		return false, paidAmount, fmt.Errorf("different currencies")
+	}
+
+	if paidAmount < math.Floor(amount) {
+		return false, paidAmount, fmt.Errorf("incomplete payment")
+	}
+
+	return true, paidAmount, nil
+}
+
+func campayStatus(status string) string {
+	switch strings.ToUpper(status) {
+	case "SUCCESSFUL":
+		return "success"
+	case "FAILED":
+		return "failed"
+	case "PENDING":
+		return "pending"
+	default:
+		return strings.ToLower(status)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/vesicash/payment-ms/external/external_models"
@@ -50,8 +51,6 @@ func WalletTransferService(c *gin.Context, extReq request.ExternalRequest, db po
 		senderCurrency         = strings.ToUpper(req.SenderCurrency)
 		senderAvailableBalance float64
 		amount                 float64
-		initialCurrency        = strings.ReplaceAll(senderCurrency, "ESCROW_", "")
-		finalCurrency          = strings.ReplaceAll(recipientCurrency, "ESCROW_", "")
 		recipientAmount        float64
 		escrowWallet           = "no"
 	)
@@ -72,15 +71,6 @@ func WalletTransferService(c *gin.Context, extReq request.ExternalRequest, db po
 		return msg, http.StatusBadRequest, fmt.Errorf("insufficient balance")
 	}
 
-	if req.InitialAmount > 0 && req.RateID != 0 {
-		if initialCurrency != finalCurrency {
-			err := CreateExchangeTransaction(extReq, req.RecipientAccountID, req.RateID, req.InitialAmount, req.FinalAmount, ExchangeTransactionCompleted)
-			if err != nil {
-				return msg, http.StatusInternalServerError, err
-			}
-		}
-	}
-
 	if req.InitialAmount > 0 && req.RateID != 0 {
 		rate, err := GetRateByID(extReq, req.RateID)
 		if err != nil {
@@ -98,50 +88,28 @@ func WalletTransferService(c *gin.Context, extReq request.ExternalRequest, db po
 		recipientAmount = amount
 	}
 
-	senderWallet, err = DebitWallet(extReq, db, amount, req.SenderCurrency, req.SenderAccountID, GetWalletType(escrowWallet, ""), req.TransactionID)
+	transfer := models.WalletTransfer{
+		Reference:          NewWalletTransferReference(),
+		SenderAccountID:    req.SenderAccountID,
+		RecipientAccountID: req.RecipientAccountID,
+		SenderCurrency:     senderCurrency,
+		RecipientCurrency:  recipientCurrency,
+		Amount:             amount,
+		RecipientAmount:    recipientAmount,
+		InitialAmount:      req.InitialAmount,
+		FinalAmount:        req.FinalAmount,
+		RateID:             req.RateID,
+		TransactionID:      req.TransactionID,
+		Refund:             req.Refund,
+		EscrowWallet:       escrowWallet,
+		Status:             string(WalletTransferPending),
+	}
+	err = transfer.CreateWalletTransfer(db.Payment)
 	if err != nil {
 		return msg, http.StatusInternalServerError, err
 	}
 
-	if utility.InStringSlice(strings.ToUpper(req.SenderCurrency), []string{"NGN", "ESCROW_NGN", "USD", "GBP", "ESCROW_USD", "ESCROW_GBP"}) {
-		if utility.InStringSlice(strings.ToUpper(req.SenderCurrency), []string{"NGN", "ESCROW_NGN"}) && amount >= config.GetConfig().ONLINE_PAYMENT.NairaThreshold {
-			_, err := CreateWalletTransaction(extReq, req.SenderAccountID, req.RecipientAccountID, amount, recipientAmount, senderCurrency, recipientCurrency, WalletTransactionPending, false)
-			if err != nil {
-				return msg, http.StatusInternalServerError, err
-			}
-			return "Wallet transfer queued for approval by admin", http.StatusOK, nil
-		}
-	}
-
-	receiverWallet, err := CreditWallet(extReq, db, recipientAmount, recipientCurrency, req.RecipientAccountID, req.Refund, GetWalletType(escrowWallet, ""), req.TransactionID)
-	if err != nil {
-		return msg, http.StatusInternalServerError, err
-	}
-
-	err = SaveWalletHistory(extReq, req.SenderAccountID, req.RecipientAccountID, amount, recipientAmount, senderCurrency, recipientCurrency, senderWallet.Available, receiverWallet.Available)
-	if err != nil {
-		extReq.Logger.Error("error saving wallet history: ", err.Error())
-	}
-
-	if req.TransactionID != "" {
-		transaction, _ := ListTransactionsByID(extReq, req.TransactionID)
-		transactionTitle := strings.Split(transaction.Title, ";")[0]
-		action := "+"
-		description := fmt.Sprintf("A sum of %v %v has been paid for this transaction", recipientCurrency, recipientAmount)
-		if req.Refund {
-			action = "-"
-			description = fmt.Sprintf("A sum of %v %v has been deducted based on an excess payment being made on transaction %v", recipientCurrency, recipientAmount, transactionTitle)
-		}
-
-		extReq.SendExternalRequest(request.CreateActivityLog, external_models.CreateActivityLogRequest{
-			TransactionID: req.TransactionID,
-			Description:   description,
-		})
-
-		UpdateTransactionAmountPaid(extReq, req.TransactionID, amount, action)
-	}
-
-	return "Wallet transfer successful", http.StatusOK, nil
+	return runWalletTransfer(extReq, db, &transfer)
 }
 
 func ManualDebitService(c *gin.Context, extReq request.ExternalRequest, db postgresql.Databases, req models.ManualDebitRequest) (string, models.ManualDebitResponse, int, error) {
@@ -157,11 +125,13 @@ func ManualDebitService(c *gin.Context, extReq request.ExternalRequest, db postg
 		gateway              = thisOrThatStr(req.Gateway, "monnify")
 		beneficiaryName      = ""
 		email                = ""
-		rave                 = Rave{ExtReq: extReq}
-		monnify              = Monnify{ExtReq: extReq}
 		disbursementChannelD = config.GetConfig().Slack.DisbursementChannelID
 	)
 
+	if !IsGatewayAvailable(gateway) {
+		return "", data, http.StatusServiceUnavailable, fmt.Errorf("gateway %v is currently unavailable", gateway)
+	}
+
 	if !HasBvn(extReq, uint(req.AccountID)) {
 		return "", data, http.StatusBadRequest, fmt.Errorf("this account does not have bvn associated with it")
 	}
@@ -202,6 +172,15 @@ func ManualDebitService(c *gin.Context, extReq request.ExternalRequest, db postg
 
 	if req.BankAccountNumber != "" && req.BankCode != "" {
 		bankCode, bankAccountNumber, bankAccountName = req.BankCode, req.BankAccountNumber, req.BankAccountName
+	} else if gateway == "rave_momo" {
+		bankDetails, err := GetBankDetail(extReq, 0, req.AccountID, "", currency, true)
+		if err != nil {
+			return "", data, http.StatusBadRequest, fmt.Errorf("user has no %v mobile money details", currency)
+		}
+		bankCode = bankDetails.MobileMoneyOperator
+		bankName = bankDetails.MobileMoneyOperator
+		bankAccountNumber = thisOrThatStr(bankDetails.MobileMoneyNumber, bankDetails.AccountNo)
+		bankAccountName = bankDetails.AccountName
 	} else {
 		bankDetails, err := GetBankDetail(extReq, 0, req.AccountID, "", currency)
 		if err != nil {
@@ -238,10 +217,19 @@ func ManualDebitService(c *gin.Context, extReq request.ExternalRequest, db postg
 		return "", data, http.StatusBadRequest, fmt.Errorf("requested amount is greater than wallet balance")
 	}
 
-	walletBalance, err = DebitWallet(extReq, db, amount, currency, req.AccountID, GetWalletType(req.EscrowWallet, ""), "")
+	// the amount stays on hold until the disbursement completes or fails
+	_, holdCode, err := PlaceWalletHold(extReq, db, models.WalletHold{
+		AccountID:       req.AccountID,
+		Currency:        walletCurrency,
+		Amount:          amount,
+		Type:            string(WalletHoldDisbursement),
+		SourceReference: reference,
+		Reason:          "wallet withdrawal",
+	})
 	if err != nil {
-		return "", data, http.StatusInternalServerError, err
+		return "", data, holdCode, err
 	}
+	sendWalletDebitNotification(extReq, req.AccountID, amount, walletCurrency, "")
 
 	callback := utility.GenerateGroupByURL(config.GetConfig().App.Url, "/disbursement/callback", map[string]string{})
 	disbursement := models.Disbursement{Reference: reference, Status: "new"}
@@ -263,6 +251,7 @@ func ManualDebitService(c *gin.Context, extReq request.ExternalRequest, db postg
 
 		err = payment.CreatePayment(db.Payment)
 		if err != nil {
+			SettleDisbursementHold(extReq, db, reference, false)
 			return "", data, http.StatusInternalServerError, err
 		}
 
@@ -278,6 +267,8 @@ func ManualDebitService(c *gin.Context, extReq request.ExternalRequest, db postg
 			BeneficiaryName:       bankAccountName,
 			BankAccountNumber:     bankAccountNumber,
 			BankName:              bankName,
+			BankCode:              bankCode,
+			Narration:             fmt.Sprintf("%v/VES", businessName),
 			DestinationBranchCode: req.DestinationBranchCode,
 			DebitCurrency:         strings.ToUpper(req.DebitCurrency),
 			Gateway:               gateway,
@@ -288,13 +279,20 @@ func ManualDebitService(c *gin.Context, extReq request.ExternalRequest, db postg
 		}
 		err = disbursement.CreateDisbursement(db.Payment)
 		if err != nil {
+			SettleDisbursementHold(extReq, db, reference, false)
 			return "", data, http.StatusInternalServerError, err
 		}
 	}
 
-	if req.EscrowWallet != "yes" {
-		if (currency == "NGN" && amount >= config.GetConfig().ONLINE_PAYMENT.NairaThreshold) || currency == "USD" || currency == "GBP" {
-			err = SlackNotify(extReq, disbursementChannelD, `
+	// large withdrawals from the main wallet always need approval, on top of the configured policies
+	thresholdReached := req.EscrowWallet != "yes" && ((currency == "NGN" && amount >= config.GetConfig().ONLINE_PAYMENT.NairaThreshold) || currency == "USD" || currency == "GBP")
+	awaitingApproval, err := RequireDisbursementApproval(extReq, db, &disbursement, amount, thresholdReached, "wallet withdrawal threshold reached")
+	if err != nil {
+		return "", data, http.StatusInternalServerError, err
+	}
+
+	if awaitingApproval {
+		err = SlackNotify(extReq, disbursementChannelD, `
 				Wallet Debit To Bank Account #`+strconv.Itoa(req.AccountID)+`
                 Environment: `+config.GetConfig().App.Name+`
                 Disbursement ID: `+strconv.Itoa(disbursementID)+`
@@ -302,51 +300,32 @@ func ManualDebitService(c *gin.Context, extReq request.ExternalRequest, db postg
                 Amount: `+fmt.Sprintf("%v %v", currency, finalAmount)+`
                 Status: Pending Admin Approval
 			`)
-			if err != nil && !extReq.Test {
-				extReq.Logger.Error("error sending notification to slack: ", err.Error())
-			}
-			return "Wallet Disbursement Queued for approval by admin", models.ManualDebitResponse{DisbursementID: disbursementID, Status: disbursement.Status}, http.StatusOK, nil
+		if err != nil && !extReq.Test {
+			extReq.Logger.Error("error sending notification to slack: ", err.Error())
 		}
+		return "Wallet Disbursement Queued for approval by admin", models.ManualDebitResponse{DisbursementID: disbursementID, Status: disbursement.Status}, http.StatusOK, nil
 	}
 
 	data.DisbursementID = disbursementID
-	disbursement.Approved = "yes"
-	err = disbursement.UpdateAllFields(db.Payment)
+	data.Status = "new"
+
+	held, err := VerifyDisbursementBeneficiary(extReq, db, &disbursement, beneficiaryName, fmt.Sprintf("%v %v", user.Firstname, user.Lastname), businessProfile.BusinessName)
 	if err != nil {
 		return "", data, http.StatusInternalServerError, err
 	}
+	if held {
+		data.Status = disbursement.Status
+		return "Wallet Disbursement held for beneficiary name review", data, http.StatusOK, nil
+	}
 
-	data.Status = "new"
-	narration := fmt.Sprintf("%v/VES", businessName)
-	var gatewayData interface{}
-
-	switch strings.ToLower(gateway) {
-	case "rave":
-		resData, err := rave.InitTransfer(bankCode, bankAccountNumber, finalAmount, narration, currency, reference, "")
-		if err != nil {
-			return "", data, http.StatusInternalServerError, err
-		}
-		data.Msg = resData.Message
-		gatewayData = resData
-	case "monnify":
-		resData, err := monnify.InitTransfer(finalAmount, reference, narration, bankCode, bankAccountNumber, currency, bankAccountName)
-		if err != nil {
-			return "", data, http.StatusInternalServerError, err
-		}
-		data.Msg = resData.ResponseMessage
-		gatewayData = resData
-	default:
-		resData, err := rave.InitTransfer(bankCode, bankAccountNumber, finalAmount, narration, currency, reference, "")
-		if err != nil {
-			return "", data, http.StatusInternalServerError, err
-		}
-		data.Msg = resData.Message
-		gatewayData = resData
+	resData, err := InitDisbursementTransfer(extReq, db, &disbursement)
+	if err != nil {
+		return "", data, http.StatusInternalServerError, err
 	}
+	data.Msg = resData.Message
+	data.Response = resData.Response
 	fmt.Println(beneficiaryName, email)
 
-	LogDisbursement(db, disbursementID, gatewayData)
-	data.Response = gatewayData
 	err = SlackNotify(extReq, disbursementChannelD, `
 				Wallet Debit To Bank Account #`+strconv.Itoa(req.AccountID)+`
                 Environment: `+config.GetConfig().App.Name+`
@@ -364,7 +343,6 @@ func ManualDebitService(c *gin.Context, extReq request.ExternalRequest, db postg
 func ManualRefundService(c *gin.Context, extReq request.ExternalRequest, db postgresql.Databases, req models.ManualRefundRequest) (string, int, error) {
 	var (
 		response             string
-		rave                 = Rave{ExtReq: extReq}
 		disbursementChannelD = config.GetConfig().Slack.DisbursementChannelID
 	)
 
@@ -425,7 +403,7 @@ func ManualRefundService(c *gin.Context, extReq request.ExternalRequest, db post
 		return response, http.StatusBadRequest, fmt.Errorf("disbursement already exists")
 	}
 
-	// disbursementGateway := businessCharges.DisbursementGateway
+	disbursementGateway := businessCharges.DisbursementGateway
 	cancellationFee, _ := strconv.ParseFloat(businessCharges.CancellationFee, 64)
 
 	sellerInfo, err := GetUserWithAccountID(extReq, sellerParty.AccountID)
@@ -441,20 +419,22 @@ func ManualRefundService(c *gin.Context, extReq request.ExternalRequest, db post
 	reference := fmt.Sprintf("vc%v", utility.GetRandomNumbersInRange(1000000000, 9999999999))
 	currency := strings.ToUpper(payment.Currency)
 
-	bankDetails, err := GetBankDetail(extReq, 0, buyerParty.AccountID, "", currency)
+	isMomo := strings.EqualFold(disbursementGateway, "rave_momo")
+	bankDetails, err := GetBankDetail(extReq, 0, buyerParty.AccountID, "", currency, isMomo)
 	if err != nil {
 		return "", http.StatusBadRequest, fmt.Errorf("user has no %v bank account details", currency)
 	}
 
-	bank, err := GetBank(extReq, bankDetails.BankID, "", "", "")
-	if err != nil {
-		return "", http.StatusBadRequest, fmt.Errorf("bank with id %v not found", bankDetails.BankID)
+	bankCode := bankDetails.MobileMoneyOperator
+	accountNumber := thisOrThatStr(bankDetails.MobileMoneyNumber, bankDetails.AccountNo)
+	if !isMomo {
+		bank, err := GetBank(extReq, bankDetails.BankID, "", "", "")
+		if err != nil {
+			return "", http.StatusBadRequest, fmt.Errorf("bank with id %v not found", bankDetails.BankID)
+		}
+		bankCode = bank.Code
+		accountNumber = bankDetails.AccountNo
 	}
-	bankCode := bank.Code
-
-	// if strings.EqualFold(disbursementGateway, "rave_momo") {
-	// 	bankCode = bankDetails.MobileMoneyOperator
-	// }
 
 	businessProfile, err := GetBusinessProfileByAccountID(extReq, extReq.Logger, businessID)
 	if err != nil {
@@ -492,12 +472,12 @@ func ManualRefundService(c *gin.Context, extReq request.ExternalRequest, db post
 	}
 
 	if businessProfile.DisbursementSettings == "wallet" {
-		_, err := CreditWallet(extReq, db, payment.TotalAmount, currency, businessID, true, "no", transaction.TransactionID)
+		_, err := CreditWallet(extReq, db, payment.TotalAmount, currency, businessID, true, DefaultWalletType, transaction.TransactionID, LedgerPosting{Account: LedgerPayoutAccount, Reference: reference})
 		if err != nil {
 			return response, http.StatusInternalServerError, err
 		}
 		disbursement.Gateway = "wallet"
-		disbursement.Status = "completed"
+		disbursement.Status = string(DisbursementCompleted)
 		err = disbursement.CreateDisbursement(db.Payment)
 		if err != nil {
 			return response, http.StatusInternalServerError, err
@@ -519,19 +499,37 @@ func ManualRefundService(c *gin.Context, extReq request.ExternalRequest, db post
 	}
 
 	disbursement.Gateway = "rave"
+	if isMomo {
+		disbursement.Gateway = "rave_momo"
+	}
+	if !IsGatewayAvailable(disbursement.Gateway) {
+		return "", http.StatusServiceUnavailable, fmt.Errorf("gateway %v is currently unavailable", disbursement.Gateway)
+	}
+	disbursement.BankAccountNumber = accountNumber
 	err = disbursement.CreateDisbursement(db.Payment)
 	if err != nil {
 		return response, http.StatusInternalServerError, err
 	}
 
-	resData, err := rave.InitTransfer(bankCode, bankDetails.AccountNo, realAmount, "Vesicash Refund", currency, reference, "")
+	resData, err := GetGateway(extReq, disbursement.Gateway).InitTransfer(GatewayTransferRequest{
+		BankCode:      bankCode,
+		AccountNumber: accountNumber,
+		AccountName:   fmt.Sprintf("%v %v", buyerInfo.Firstname, buyerInfo.Lastname),
+		Narration:     "Vesicash Refund",
+		Currency:      currency,
+		Reference:     reference,
+		Amount:        realAmount,
+	})
 	if err != nil {
 		return "", http.StatusInternalServerError, err
 	}
 
-	disbursement.Status = strings.ToLower(resData.Status)
-	disbursement.UpdateAllFields(db.Payment)
-	LogDisbursement(db, disbursementID, resData)
+	disbursement.GatewayReference = resData.Reference
+	err = TransitionDisbursement(db, &disbursement, GatewayDisbursementStatus(resData.Status), "api:manual-refund", resData.Response)
+	if err != nil {
+		extReq.Logger.Error(fmt.Sprintf("error updating refund disbursement %v: %v", disbursementID, err.Error()))
+	}
+	LogDisbursement(db, disbursementID, resData.Response)
 
 	err = SlackNotify(extReq, disbursementChannelD, `
 				Bank Account Disbursement Re-Fund For Buyer #`+strconv.Itoa(buyerParty.AccountID)+`
@@ -590,3 +588,121 @@ func LogDisbursement(db postgresql.Databases, disbursementID int, logData interf
 	}
 	disbursementLog.CreateDisbursementLog(db.Payment)
 }
+
+// settleDisbursementFromWebhook finalizes wallet disbursements reported by a gateway webhook.
+// Transaction disbursements are left for the DisbursementCheck cron, which also closes the transaction.
+func settleDisbursementFromWebhook(extReq request.ExternalRequest, db postgresql.Databases, provider, reference string, successful bool, response interface{}) (int, error) {
+	var (
+		disbursementChannelD = config.GetConfig().Slack.DisbursementChannelID
+		actor                = "webhook:" + provider
+	)
+
+	disbursement, code, err := GetDisbursementByAnyReference(db, reference)
+	if err != nil {
+		return code, fmt.Errorf("disbursement with reference %v, not found. Error: %v", reference, err.Error())
+	}
+
+	if IsDisbursementTerminal(disbursement.Status) {
+		return http.StatusOK, nil
+	}
+
+	if disbursement.Type != "wallet" {
+		extReq.Logger.Info(fmt.Sprintf("%v webhook for disbursement %v, status will be confirmed by disbursement check", provider, disbursement.DisbursementID))
+		return http.StatusOK, nil
+	}
+
+	disbursement.PaymentReleasedAt = time.Now().Format("2006-01-02 15:04:05")
+	status := DisbursementCompleted
+	if successful {
+		err = TransitionDisbursement(db, &disbursement, status, actor, response)
+	} else {
+		status, err = HandleDisbursementFailure(extReq, db, &disbursement, actor, nil, response)
+	}
+	if err != nil {
+		extReq.Logger.Error(fmt.Sprintf("%v webhook: disbursement %v not updated: %v", provider, disbursement.DisbursementID, err.Error()))
+		return http.StatusOK, nil
+	}
+
+	RefreshDisbursementBatch(extReq, db, disbursement.BatchReference)
+
+	if status == DisbursementCompleted {
+		SettleDisbursementHold(extReq, db, disbursement.Reference, true)
+	} else if status == DisbursementFailed && !SettleDisbursementHold(extReq, db, disbursement.Reference, false) {
+		amount, _ := strconv.ParseFloat(disbursement.Amount, 64)
+		amount = amount + float64(disbursement.Fee)
+		_, err = CreditWallet(extReq, db, amount, disbursement.DebitCurrency, disbursement.RecipientID, true, DefaultWalletType, "", LedgerPosting{Account: LedgerPayoutAccount, Reference: disbursement.Reference})
+		if err != nil {
+			extReq.Logger.Error(fmt.Sprintf("%v webhook: error crediting wallet for user %v, amount: %v %v, Error: %v", provider, disbursement.RecipientID, disbursement.DebitCurrency, amount, err.Error()))
+		}
+	}
+
+	err = SlackNotify(extReq, disbursementChannelD, `
+			[WEBHOOK `+strings.ToUpper(provider)+`] Wallet Disbursement
+			Environment: `+config.GetConfig().App.Name+`
+			Reference: `+reference+`
+			Disbursement ID: `+fmt.Sprintf("%v", disbursement.DisbursementID)+`
+			Status: `+strings.ToUpper(disbursement.Status)+`
+			`)
+	if err != nil && !extReq.Test {
+		extReq.Logger.Error("error sending notification to slack: ", err.Error())
+	}
+
+	return http.StatusOK, nil
+}
+
+// CloseDisbursedTransaction finishes the transaction of a completed disbursement: the transaction is closed, the paid out
+// amount is taken from the escrow wallet and the buyer and seller are notified.
+func CloseDisbursedTransaction(extReq request.ExternalRequest, db postgresql.Databases, disbursement models.Disbursement, paymnt models.Payment, transaction external_models.TransactionByID) {
+	var (
+		amount, _            = strconv.ParseFloat(disbursement.Amount, 64)
+		disbursementChannelD = config.GetConfig().Slack.DisbursementChannelID
+	)
+
+	extReq.SendExternalRequest(request.TransactionUpdateStatus, external_models.UpdateTransactionStatusRequest{
+		AccountID:     int(paymnt.AccountID),
+		TransactionID: transaction.TransactionID,
+		MilestoneID:   transaction.MilestoneID,
+		Status:        "closed",
+	})
+
+	// wallet withdrawals were already taken from the wallet when the hold was placed
+	if !SettleDisbursementHold(extReq, db, disbursement.Reference, true) {
+		_, err := DebitWallet(extReq, db, amount, disbursement.DebitCurrency, disbursement.RecipientID, EscrowWalletType, transaction.TransactionID, LedgerPosting{Account: LedgerPayoutAccount, Reference: disbursement.Reference})
+		if err != nil {
+			extReq.Logger.Error(fmt.Sprintf("error debiting wallet for user %v, amount:%v; currency: %v; disbursementId:%v; error: %v", disbursement.RecipientID, amount, disbursement.DebitCurrency, disbursement.DisbursementID, err.Error()))
+		}
+	}
+
+	if strings.EqualFold(disbursement.Type, "refund") {
+		extReq.SendExternalRequest(request.SuccessfulRefundNotification, external_models.OnlyTransactionIDAndAccountIDRequest{
+			TransactionID: paymnt.TransactionID,
+			AccountID:     disbursement.RecipientID,
+		})
+	} else {
+		extReq.SendExternalRequest(request.EscrowDisbursedSellerNotification, external_models.OnlyTransactionIDRequiredRequest{
+			TransactionID: paymnt.TransactionID,
+		})
+		extReq.SendExternalRequest(request.EscrowDisbursedBuyerNotification, external_models.OnlyTransactionIDRequiredRequest{
+			TransactionID: paymnt.TransactionID,
+		})
+		extReq.SendExternalRequest(request.TransactionClosedBuyerNotification, external_models.OnlyTransactionIDRequiredRequest{
+			TransactionID: paymnt.TransactionID,
+		})
+		extReq.SendExternalRequest(request.TransactionClosedSellerNotification, external_models.OnlyTransactionIDRequiredRequest{
+			TransactionID: paymnt.TransactionID,
+		})
+	}
+
+	err := SlackNotify(extReq, disbursementChannelD, `
+		 	Payment Disbursement For Transaction #`+paymnt.TransactionID+` has been completed successfully.
+            Environment: `+config.GetConfig().App.Name+`
+            Account ID: `+fmt.Sprintf("%v", disbursement.RecipientID)+`
+            Beneficiary Name: `+disbursement.BeneficiaryName+`
+            Amount: `+fmt.Sprintf("%v %v", disbursement.DebitCurrency, disbursement.Amount)+`
+            Status: COMPLETED
+			`)
+	if err != nil && !extReq.Test {
+		extReq.Logger.Error("error sending notification to slack: ", err.Error())
+	}
+	extReq.Logger.Info(fmt.Sprintf("%v: Payment disbursement completed", disbursement.Reference))
+}
@@ -0,0 +1,339 @@
+package payment
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vesicash/payment-ms/external/request"
+	"github.com/vesicash/payment-ms/internal/config"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"gorm.io/gorm"
+)
+
+type DisbursementDecision string
+
+var (
+	DisbursementApprove DisbursementDecision = "approve"
+	DisbursementReject  DisbursementDecision = "reject"
+)
+
+func disbursementLockKey(disbursementID int) string {
+	return fmt.Sprintf("disbursement:%v", disbursementID)
+}
+
+// disbursementApprovalPolicyMatches checks every criteria set on the policy against the disbursement.
+func disbursementApprovalPolicyMatches(db postgresql.Databases, policy models.DisbursementApprovalPolicy, disbursement models.Disbursement, amount float64) bool {
+	if policy.BusinessID != 0 && policy.BusinessID != disbursement.BusinessID {
+		return false
+	}
+	if policy.Currency != "" && !strings.EqualFold(policy.Currency, disbursement.Currency) {
+		return false
+	}
+	if policy.MinAmount > 0 && amount < policy.MinAmount {
+		return false
+	}
+	if policy.NewBeneficiary && disbursement.CheckCompletedDisbursementToAccountExists(db.Payment) {
+		return false
+	}
+	return true
+}
+
+// RequireDisbursementApproval puts the disbursement in awaiting_approval when it matches an active approval policy,
+// or when required is set by the caller, otherwise it marks the disbursement as approved. It returns true when the
+// disbursement has to wait for an admin.
+func RequireDisbursementApproval(extReq request.ExternalRequest, db postgresql.Databases, disbursement *models.Disbursement, amount float64, required bool, reason string) (bool, error) {
+	var (
+		policy   = models.DisbursementApprovalPolicy{}
+		policyID uint
+	)
+
+	if !required {
+		policies, err := policy.GetActiveDisbursementApprovalPolicies(db.Payment)
+		if err != nil {
+			return false, err
+		}
+
+		for _, item := range policies {
+			if disbursementApprovalPolicyMatches(db, item, *disbursement, amount) {
+				required, policyID, reason = true, item.ID, fmt.Sprintf("matched approval policy %v", item.Name)
+				break
+			}
+		}
+	}
+
+	if !required {
+		disbursement.Approved = "yes"
+		return false, disbursement.UpdateFields(db.Payment, "approved")
+	}
+
+	disbursement.Approved = "pending"
+	err := TransitionDisbursement(db, disbursement, DisbursementAwaitingApproval, "approval_policy", nil)
+	if err != nil {
+		return true, err
+	}
+
+	approval := models.DisbursementApproval{
+		DisbursementID: disbursement.DisbursementID,
+		PolicyID:       policyID,
+		Action:         "requested",
+		Comment:        reason,
+	}
+	err = approval.CreateDisbursementApproval(db.Payment)
+	if err != nil {
+		extReq.Logger.Error(fmt.Sprintf("error saving approval request for disbursement %v: %v", disbursement.DisbursementID, err.Error()))
+	}
+
+	extReq.Logger.Info(fmt.Sprintf("disbursement %v is awaiting approval: %v", disbursement.DisbursementID, reason))
+	return true, nil
+}
+
+// InitDisbursementTransfer sends a saved disbursement to its gateway, a disbursement that is sent again gets a new reference per try.
+func InitDisbursementTransfer(extReq request.ExternalRequest, db postgresql.Databases, disbursement *models.Disbursement) (GatewayTransferResponse, error) {
+	var (
+		amount, _ = strconv.ParseFloat(disbursement.Amount, 64)
+		reference = disbursementTransferReference(*disbursement)
+	)
+	gateway, err := RecordedGateway(extReq, disbursement.Gateway)
+	if err != nil {
+		return GatewayTransferResponse{}, err
+	}
+	resData, err := gateway.InitTransfer(GatewayTransferRequest{
+		BankCode:      disbursement.BankCode,
+		AccountNumber: disbursement.BankAccountNumber,
+		AccountName:   disbursement.BeneficiaryName,
+		Narration:     disbursement.Narration,
+		Currency:      disbursement.Currency,
+		Reference:     reference,
+		Amount:        amount,
+	})
+	if err != nil {
+		return resData, err
+	}
+
+	if reference != disbursement.Reference {
+		disbursement.GatewayReference = reference
+	}
+	if resData.Reference != "" {
+		disbursement.GatewayReference = resData.Reference
+	}
+	if disbursement.GatewayReference != "" {
+		disbursement.UpdateFields(db.Payment, "gateway_reference")
+	}
+	LogDisbursement(db, disbursement.DisbursementID, resData.Response)
+	return resData, nil
+}
+
+func CreateDisbursementApprovalPolicyService(db postgresql.Databases, req models.CreateDisbursementApprovalPolicyRequest) (models.DisbursementApprovalPolicy, int, error) {
+	policy := models.DisbursementApprovalPolicy{
+		Name:           req.Name,
+		BusinessID:     req.BusinessID,
+		Currency:       strings.ToUpper(req.Currency),
+		MinAmount:      req.MinAmount,
+		NewBeneficiary: req.NewBeneficiary,
+		Active:         true,
+	}
+
+	err := policy.CreateDisbursementApprovalPolicy(db.Payment)
+	if err != nil {
+		return policy, http.StatusInternalServerError, err
+	}
+	return policy, http.StatusOK, nil
+}
+
+func ListDisbursementApprovalPoliciesService(db postgresql.Databases) ([]models.DisbursementApprovalPolicy, int, error) {
+	policy := models.DisbursementApprovalPolicy{}
+	policies, err := policy.GetActiveDisbursementApprovalPolicies(db.Payment)
+	if err != nil {
+		return policies, http.StatusInternalServerError, err
+	}
+	return policies, http.StatusOK, nil
+}
+
+func DeactivateDisbursementApprovalPolicyService(db postgresql.Databases, id int) (models.DisbursementApprovalPolicy, int, error) {
+	policy := models.DisbursementApprovalPolicy{ID: uint(id)}
+	code, err := policy.GetDisbursementApprovalPolicyByID(db.Payment)
+	if err != nil {
+		return policy, code, fmt.Errorf("disbursement approval policy not found: %v", err.Error())
+	}
+
+	policy.Active = false
+	err = policy.UpdateAllFields(db.Payment)
+	if err != nil {
+		return policy, http.StatusInternalServerError, err
+	}
+	return policy, http.StatusOK, nil
+}
+
+func ListDisbursementsAwaitingApprovalService(db postgresql.Databases, paginator postgresql.Pagination) ([]models.Disbursement, postgresql.PaginationResponse, int, error) {
+	disbursement := models.Disbursement{Status: string(DisbursementAwaitingApproval)}
+	disbursements, pagination, err := disbursement.GetDisbursementsByStatus(db.Payment, paginator)
+	if err != nil {
+		return disbursements, pagination, http.StatusInternalServerError, err
+	}
+	return disbursements, pagination, http.StatusOK, nil
+}
+
+// DecideDisbursementService approves or rejects a disbursement awaiting approval.
+// Approved wallet withdrawals are sent to the gateway right away, approved transaction disbursements are sent by the
+// disbursement job on its next run. Rejected wallet withdrawals are returned to the wallet.
+func DecideDisbursementService(c *gin.Context, extReq request.ExternalRequest, db postgresql.Databases, req models.DisbursementDecisionRequest, decision DisbursementDecision) (models.Disbursement, int, error) {
+	var (
+		disbursement = models.Disbursement{DisbursementID: req.DisbursementID}
+		code         = http.StatusInternalServerError
+	)
+
+	accessToken, err := GetAccessTokenByKeyFromRequest(extReq, c)
+	if err != nil {
+		return disbursement, http.StatusUnauthorized, fmt.Errorf("could not identify admin: %v", err.Error())
+	}
+
+	err = postgresql.RunWithAdvisoryLock(db.Payment, disbursementLockKey(req.DisbursementID), func(tx *gorm.DB) error {
+		var err error
+		code, err = disbursement.GetDisbursementByDisbursementID(db.Payment)
+		if err != nil {
+			return fmt.Errorf("disbursement not found: %v", err.Error())
+		}
+
+		if disbursement.Status != string(DisbursementAwaitingApproval) {
+			code = http.StatusBadRequest
+			return fmt.Errorf("disbursement is %v, only disbursements awaiting approval can be approved or rejected", disbursement.Status)
+		}
+
+		if accessToken.AccountID == disbursement.RecipientID {
+			code = http.StatusForbidden
+			return fmt.Errorf("disbursement cannot be approved or rejected by its recipient")
+		}
+
+		code = http.StatusInternalServerError
+		approval := models.DisbursementApproval{
+			DisbursementID: disbursement.DisbursementID,
+			Action:         string(decision),
+			ActorAccountID: accessToken.AccountID,
+			Comment:        req.Comment,
+		}
+		err = approval.CreateDisbursementApproval(db.Payment)
+		if err != nil {
+			return err
+		}
+
+		actor := fmt.Sprintf("admin:%v", accessToken.AccountID)
+		if decision == DisbursementReject {
+			return rejectDisbursement(extReq, db, &disbursement, actor)
+		}
+		return approveDisbursement(extReq, db, &disbursement, actor)
+	})
+	if err != nil {
+		return disbursement, code, err
+	}
+
+	notifyDisbursementDecision(extReq, disbursement, decision, accessToken.AccountID)
+	return disbursement, http.StatusOK, nil
+}
+
+func approveDisbursement(extReq request.ExternalRequest, db postgresql.Databases, disbursement *models.Disbursement, actor string) error {
+	disbursement.Approved = "yes"
+	if disbursement.Type == SettlementDisbursementType {
+		return sendApprovedSettlement(extReq, db, disbursement, actor)
+	}
+	if disbursement.Type != "wallet" {
+		return TransitionDisbursement(db, disbursement, DisbursementApproved, actor, nil)
+	}
+
+	err := TransitionDisbursement(db, disbursement, DisbursementNew, actor, nil)
+	if err != nil {
+		return err
+	}
+
+	held, err := VerifyDisbursementBeneficiary(extReq, db, disbursement)
+	if err != nil || held {
+		return err
+	}
+
+	resData, err := InitDisbursementTransfer(extReq, db, disbursement)
+	if err != nil {
+		// the transfer may still have been created, funds only go back to the wallet once it is failed for good
+		status, failureErr := HandleDisbursementFailure(extReq, db, disbursement, actor, err, resData.Response)
+		if failureErr != nil {
+			extReq.Logger.Error(fmt.Sprintf("error handling failure of disbursement %v: %v", disbursement.DisbursementID, failureErr.Error()))
+			return fmt.Errorf("disbursement approved but could not be sent: %v", err.Error())
+		}
+		if status == DisbursementFailed {
+			SettleDisbursementHold(extReq, db, disbursement.Reference, false)
+			return fmt.Errorf("disbursement approved but could not be sent, funds have been returned to the wallet: %v", err.Error())
+		}
+		return fmt.Errorf("disbursement approved but could not be sent, it is now %v: %v", status, err.Error())
+	}
+	return nil
+}
+
+// sendApprovedSettlement sends an approved settlement batch payout, settlements are not picked up again by the disbursement job.
+func sendApprovedSettlement(extReq request.ExternalRequest, db postgresql.Databases, disbursement *models.Disbursement, actor string) error {
+	err := TransitionDisbursement(db, disbursement, DisbursementApproved, actor, nil)
+	if err != nil {
+		return err
+	}
+
+	held, err := VerifyDisbursementBeneficiary(extReq, db, disbursement)
+	if err != nil || held {
+		return err
+	}
+
+	resData, err := InitDisbursementTransfer(extReq, db, disbursement)
+	if err != nil {
+		_, failureErr := HandleDisbursementFailure(extReq, db, disbursement, actor, err, resData.Response)
+		if failureErr != nil {
+			extReq.Logger.Error(fmt.Sprintf("error handling failure of disbursement %v: %v", disbursement.DisbursementID, failureErr.Error()))
+		}
+		return fmt.Errorf("disbursement approved but could not be sent: %v", err.Error())
+	}
+	return TransitionDisbursement(db, disbursement, DisbursementPending, actor, resData.Response)
+}
+
+func rejectDisbursement(extReq request.ExternalRequest, db postgresql.Databases, disbursement *models.Disbursement, actor string) error {
+	disbursement.Approved = "no"
+	err := TransitionDisbursement(db, disbursement, DisbursementRejected, actor, nil)
+	if err != nil {
+		return err
+	}
+
+	returnWalletDisbursementFunds(extReq, db, *disbursement)
+	FailSettlementBatch(extReq, db, *disbursement, "payout rejected")
+	return nil
+}
+
+// returnWalletDisbursementFunds gives the amount of a wallet withdrawal that will not be sent back to the wallet.
+func returnWalletDisbursementFunds(extReq request.ExternalRequest, db postgresql.Databases, disbursement models.Disbursement) {
+	if disbursement.Type != "wallet" || SettleDisbursementHold(extReq, db, disbursement.Reference, false) {
+		return
+	}
+
+	amount, _ := strconv.ParseFloat(disbursement.Amount, 64)
+	amount = amount + float64(disbursement.Fee)
+	_, err := CreditWallet(extReq, db, amount, disbursement.DebitCurrency, disbursement.RecipientID, true, DefaultWalletType, "", LedgerPosting{Account: LedgerPayoutAccount, Reference: disbursement.Reference})
+	if err != nil {
+		extReq.Logger.Error(fmt.Sprintf("error crediting wallet for disbursement %v: %v", disbursement.DisbursementID, err.Error()))
+	}
+}
+
+func notifyDisbursementDecision(extReq request.ExternalRequest, disbursement models.Disbursement, decision DisbursementDecision, actorAccountID int) {
+	outcome := "approved"
+	if decision == DisbursementReject {
+		outcome = "rejected"
+	}
+
+	err := SlackNotify(extReq, config.GetConfig().Slack.DisbursementChannelID, `
+			Disbursement `+strconv.Itoa(disbursement.DisbursementID)+` has been `+outcome+`.
+			Environment: `+config.GetConfig().App.Name+`
+			Account ID: `+fmt.Sprintf("%v", disbursement.RecipientID)+`
+			Beneficiary Name: `+disbursement.BeneficiaryName+`
+			Amount: `+fmt.Sprintf("%v %v", disbursement.Currency, disbursement.Amount)+`
+			Admin Account ID: `+fmt.Sprintf("%v", actorAccountID)+`
+			`)
+	if err != nil && !extReq.Test {
+		extReq.Logger.Error("error sending notification to slack: ", err.Error())
+	}
+}
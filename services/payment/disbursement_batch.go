@@ -0,0 +1,436 @@
+package payment
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vesicash/payment-ms/external/request"
+	"github.com/vesicash/payment-ms/internal/config"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"github.com/vesicash/payment-ms/utility"
+	"gorm.io/gorm"
+)
+
+// BulkTransferGateway is implemented by gateways that can send many transfers in one request.
+type BulkTransferGateway interface {
+	BulkTransferCurrencies() []string
+	InitBulkTransfer(req GatewayBulkTransferRequest) (GatewayBulkTransferResponse, error)
+	BulkTransferStatus(reference string) ([]GatewayBulkTransferItem, interface{}, error)
+}
+
+type GatewayBulkTransferRequest struct {
+	Reference string
+	Title     string
+	Narration string
+	Transfers []GatewayTransferRequest
+}
+
+type GatewayBulkTransferResponse struct {
+	Status    string
+	Message   string
+	Reference string
+	Response  interface{}
+}
+
+type GatewayBulkTransferItem struct {
+	Reference string
+	Status    string
+	Message   string
+	Response  interface{}
+}
+
+var (
+	disbursementBatchActor = "disbursement-batch"
+)
+
+func disbursementBatchDefaults() config.DisbursementBatch {
+	settings := config.GetConfig().DisbursementBatch
+	if settings.MinSize <= 0 {
+		settings.MinSize = 2
+	}
+	if settings.MaxSize <= 0 {
+		settings.MaxSize = 100
+	}
+	return settings
+}
+
+// SupportsBulkTransfer reports whether transfers in currency can go through the gateway in one bulk request.
+func SupportsBulkTransfer(extReq request.ExternalRequest, gateway, currency string) bool {
+	if gateway == "" || !IsGatewayAvailable(gateway) || IsGatewayDegraded(gateway) {
+		return false
+	}
+	bulkGateway, ok := GetGateway(extReq, gateway).(BulkTransferGateway)
+	if !ok {
+		return false
+	}
+	return utility.InStringSlice(strings.ToUpper(currency), bulkGateway.BulkTransferCurrencies())
+}
+
+// QueueDisbursementTransfer parks a disbursement that is ready to be sent until SendQueuedDisbursements groups it with the
+// others going through the same gateway. It returns false, and leaves the disbursement alone, when the first gateway cannot batch.
+func QueueDisbursementTransfer(extReq request.ExternalRequest, db postgresql.Databases, disbursement *models.Disbursement, gateways []string, actor string) (bool, error) {
+	if len(gateways) == 0 || !SupportsBulkTransfer(extReq, gateways[0], disbursement.Currency) {
+		return false, nil
+	}
+
+	disbursement.Gateway = gateways[0]
+	err := TransitionDisbursement(db, disbursement, DisbursementQueued, actor, map[string]interface{}{"gateway": disbursement.Gateway})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GroupDisbursementsForBatch groups disbursements by gateway and currency, in batches of no more than size.
+func GroupDisbursementsForBatch(disbursements []models.Disbursement, size int) [][]models.Disbursement {
+	var (
+		keys   = []string{}
+		groups = map[string][]models.Disbursement{}
+		chunks = [][]models.Disbursement{}
+	)
+
+	for _, disbursement := range disbursements {
+		key := strings.ToLower(disbursement.Gateway) + ":" + strings.ToUpper(disbursement.Currency)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], disbursement)
+	}
+
+	for _, key := range keys {
+		group := groups[key]
+		for len(group) > size {
+			chunks = append(chunks, group[:size])
+			group = group[size:]
+		}
+		chunks = append(chunks, group)
+	}
+	return chunks
+}
+
+// SendQueuedDisbursements sends every queued disbursement, in gateway batches where enough of them go through the same
+// gateway in the same currency and one at a time otherwise.
+func SendQueuedDisbursements(extReq request.ExternalRequest, db postgresql.Databases) {
+	settings := disbursementBatchDefaults()
+	err := postgresql.RunWithAdvisoryLock(db.Payment, "disbursement-batches", func(tx *gorm.DB) error {
+		disbursement := models.Disbursement{}
+		queued, err := disbursement.GetAllForStatuses(db.Payment, []string{string(DisbursementQueued)})
+		if err != nil {
+			return err
+		}
+
+		for _, group := range GroupDisbursementsForBatch(queued, settings.MaxSize) {
+			if len(group) < settings.MinSize || !SupportsBulkTransfer(extReq, group[0].Gateway, group[0].Currency) {
+				for i := range group {
+					sendDisbursementTransfer(extReq, db, &group[i], disbursementBatchActor)
+				}
+				continue
+			}
+
+			_, err := SendDisbursementBatch(extReq, db, group[0].Gateway, disbursementBatchActor, group)
+			if err != nil {
+				extReq.Logger.Error(fmt.Sprintf("error sending batch of %v %v disbursements: %v", len(group), group[0].Gateway, err.Error()))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		extReq.Logger.Error(fmt.Sprintf("error sending queued disbursements: %v", err.Error()))
+	}
+}
+
+// sendDisbursementTransfer sends one disbursement on its own, failures go through the retry policy.
+func sendDisbursementTransfer(extReq request.ExternalRequest, db postgresql.Databases, disbursement *models.Disbursement, actor string) {
+	resData, err := InitDisbursementTransfer(extReq, db, disbursement)
+	if err != nil {
+		extReq.Logger.Error(fmt.Sprintf("sending disbursement %v failed: %v", disbursement.DisbursementID, err.Error()))
+		failDisbursementTransfer(extReq, db, disbursement, actor, err, resData.Response)
+		return
+	}
+
+	err = TransitionDisbursement(db, disbursement, DisbursementPending, actor, resData.Response)
+	if err != nil {
+		extReq.Logger.Error(fmt.Sprintf("error updating disbursement %v: %v", disbursement.DisbursementID, err.Error()))
+	}
+}
+
+func failDisbursementTransfer(extReq request.ExternalRequest, db postgresql.Databases, disbursement *models.Disbursement, actor string, cause error, response interface{}) {
+	outcome, err := HandleDisbursementFailure(extReq, db, disbursement, actor, cause, response)
+	if err != nil {
+		extReq.Logger.Error(fmt.Sprintf("error handling failure of disbursement %v: %v", disbursement.DisbursementID, err.Error()))
+		return
+	}
+	if outcome == DisbursementFailed {
+		returnWalletDisbursementFunds(extReq, db, *disbursement)
+	}
+}
+
+// SendDisbursementBatch sends the disbursements to the gateway in one bulk transfer and moves them to pending, each
+// disbursement keeps the batch reference so its result can be read from the batch. When the gateway turns the batch
+// down every disbursement goes through the retry policy, retries are sent one at a time. When the request fails without
+// an answer the batch may still have been created, so it stays submitted and every disbursement is checked on its own.
+func SendDisbursementBatch(extReq request.ExternalRequest, db postgresql.Databases, gateway, actor string, disbursements []models.Disbursement) (models.DisbursementBatch, error) {
+	batch := models.DisbursementBatch{
+		BatchReference: fmt.Sprintf("db%v", utility.GetRandomNumbersInRange(1000000000, 9999999999)),
+		Gateway:        strings.ToLower(gateway),
+		ItemCount:      len(disbursements),
+		Status:         "submitted",
+	}
+	if len(disbursements) == 0 {
+		return batch, fmt.Errorf("no disbursements to send")
+	}
+	batch.Currency = strings.ToUpper(disbursements[0].Currency)
+
+	paymentGateway := GetGateway(extReq, gateway)
+	bulkGateway, ok := paymentGateway.(BulkTransferGateway)
+	if !ok {
+		return batch, fmt.Errorf("gateway %v cannot send bulk transfers", gateway)
+	}
+
+	req := GatewayBulkTransferRequest{
+		Reference: batch.BatchReference,
+		Title:     "Vesicash Payouts " + batch.BatchReference,
+		Narration: "Vesicash Payouts",
+	}
+	for i := range disbursements {
+		var (
+			amount, _ = strconv.ParseFloat(disbursements[i].Amount, 64)
+			reference = disbursementTransferReference(disbursements[i])
+		)
+		if reference != disbursements[i].Reference {
+			disbursements[i].GatewayReference = reference
+		}
+		disbursements[i].Gateway = batch.Gateway
+		disbursements[i].BatchReference = batch.BatchReference
+		disbursements[i].TryAgainAt = time.Time{}
+		batch.TotalAmount += amount
+
+		req.Transfers = append(req.Transfers, GatewayTransferRequest{
+			BankCode:      disbursements[i].BankCode,
+			AccountNumber: disbursements[i].BankAccountNumber,
+			AccountName:   disbursements[i].BeneficiaryName,
+			Narration:     disbursements[i].Narration,
+			Currency:      disbursements[i].Currency,
+			Reference:     reference,
+			CallbackUrl:   disbursements[i].CallbackUrl,
+			Amount:        amount,
+		})
+	}
+
+	err := batch.CreateDisbursementBatch(db.Payment)
+	if err != nil {
+		return batch, err
+	}
+
+	resData, err := bulkGateway.InitBulkTransfer(req)
+	if err != nil && ClassifyDisbursementError(err, resData.Response) != DisbursementErrorPermanent {
+		extReq.Logger.Error(fmt.Sprintf("disbursement batch %v may not have reached %v: %v", batch.BatchReference, batch.Gateway, err.Error()))
+		if checkUnconfirmedDisbursementBatch(extReq, db, paymentGateway, &batch, actor, disbursements, err) {
+			return batch, err
+		}
+	}
+	if err != nil {
+		batch.Status, batch.FailureReason = "failed", err.Error()
+		batch.FailedCount = batch.ItemCount
+		if updateErr := batch.UpdateAllFields(db.Payment); updateErr != nil {
+			extReq.Logger.Error(fmt.Sprintf("error updating disbursement batch %v: %v", batch.BatchReference, updateErr.Error()))
+		}
+		for i := range disbursements {
+			failDisbursementTransfer(extReq, db, &disbursements[i], actor, err, resData.Response)
+		}
+		return batch, err
+	}
+
+	batch.GatewayReference = resData.Reference
+	err = batch.UpdateAllFields(db.Payment)
+	if err != nil {
+		extReq.Logger.Error(fmt.Sprintf("error updating disbursement batch %v: %v", batch.BatchReference, err.Error()))
+	}
+
+	for i := range disbursements {
+		LogDisbursement(db, disbursements[i].DisbursementID, resData.Response)
+		err := TransitionDisbursement(db, &disbursements[i], DisbursementPending, actor, map[string]interface{}{
+			"batch_reference":   batch.BatchReference,
+			"gateway_reference": batch.GatewayReference,
+		})
+		if err != nil {
+			extReq.Logger.Error(fmt.Sprintf("error updating disbursement %v of batch %v: %v", disbursements[i].DisbursementID, batch.BatchReference, err.Error()))
+		}
+	}
+
+	extReq.Logger.Info(fmt.Sprintf("disbursement batch %v of %v transfers sent to %v as %v", batch.BatchReference, batch.ItemCount, batch.Gateway, batch.GatewayReference))
+	return batch, nil
+}
+
+// checkUnconfirmedDisbursementBatch asks the gateway about every transfer of a batch whose request failed without an
+// answer. Transfers that exist are pending, ones the gateway cannot tell about go to the dead-letter status and ones it
+// does not have go through the retry policy. It returns false, leaving the disbursements as they were, when none of
+// the transfers exist and the batch can be failed.
+func checkUnconfirmedDisbursementBatch(extReq request.ExternalRequest, db postgresql.Databases, gateway PaymentGateway, batch *models.DisbursementBatch, actor string, disbursements []models.Disbursement, cause error) bool {
+	var (
+		exists  = make([]bool, len(disbursements))
+		checked = make([]error, len(disbursements))
+		found   = false
+	)
+	for i := range disbursements {
+		exists[i], checked[i] = gateway.TransferExists(disbursementTransferReference(disbursements[i]))
+		if exists[i] || checked[i] != nil {
+			found = true
+		}
+	}
+	if !found {
+		return false
+	}
+
+	batch.FailureReason = cause.Error()
+	err := batch.UpdateAllFields(db.Payment)
+	if err != nil {
+		extReq.Logger.Error(fmt.Sprintf("error updating disbursement batch %v: %v", batch.BatchReference, err.Error()))
+	}
+
+	for i := range disbursements {
+		var (
+			disbursement = &disbursements[i]
+			reference    = disbursementTransferReference(*disbursement)
+		)
+		switch {
+		case checked[i] != nil:
+			disbursement.FailureReason = fmt.Sprintf("could not check if transfer %v reached %v: %v", reference, batch.Gateway, checked[i].Error())
+			_, err = deadLetterDisbursement(extReq, db, disbursement, actor, DisbursementErrorUnknown, map[string]interface{}{
+				"batch_reference": batch.BatchReference,
+				"reason":          disbursement.FailureReason,
+			})
+		case exists[i]:
+			err = TransitionDisbursement(db, disbursement, DisbursementPending, actor, map[string]interface{}{
+				"batch_reference": batch.BatchReference,
+				"exists":          true,
+			})
+		default:
+			failDisbursementTransfer(extReq, db, disbursement, actor, cause, nil)
+			err = nil
+		}
+		if err != nil {
+			extReq.Logger.Error(fmt.Sprintf("error updating disbursement %v of batch %v: %v", disbursement.DisbursementID, batch.BatchReference, err.Error()))
+		}
+	}
+
+	RefreshDisbursementBatch(extReq, db, batch.BatchReference)
+	return true
+}
+
+// DisbursementBatchResults asks the gateway for the transfers of a batch and returns the result of each, keyed by disbursement ID,
+// along with the disbursements of the batch. Disbursements retried on their own since have a new reference and no result,
+// and so do all disbursements of a batch whose gateway reference never came back.
+func DisbursementBatchResults(extReq request.ExternalRequest, db postgresql.Databases, batch models.DisbursementBatch) (map[int]GatewayBulkTransferItem, []models.Disbursement, error) {
+	results := map[int]GatewayBulkTransferItem{}
+
+	disbursement := models.Disbursement{BatchReference: batch.BatchReference}
+	disbursements, err := disbursement.GetDisbursementsByBatchReference(db.Payment)
+	if err != nil {
+		return results, disbursements, err
+	}
+
+	if batch.GatewayReference == "" {
+		return results, disbursements, nil
+	}
+
+	gateway, err := RecordedGateway(extReq, batch.Gateway)
+	if err != nil {
+		return results, disbursements, err
+	}
+	bulkGateway, ok := gateway.(BulkTransferGateway)
+	if !ok {
+		return results, disbursements, fmt.Errorf("gateway %v cannot send bulk transfers", batch.Gateway)
+	}
+
+	items, _, err := bulkGateway.BulkTransferStatus(batch.GatewayReference)
+	if err != nil {
+		return results, disbursements, err
+	}
+
+	byReference := map[string]GatewayBulkTransferItem{}
+	for _, item := range items {
+		byReference[item.Reference] = item
+	}
+	for _, disbursement := range disbursements {
+		if item, ok := byReference[disbursementTransferReference(disbursement)]; ok {
+			results[disbursement.DisbursementID] = item
+		}
+	}
+	return results, disbursements, nil
+}
+
+// RefreshDisbursementBatch recounts the disbursements of a batch and closes the batch once none of them is still in flight.
+func RefreshDisbursementBatch(extReq request.ExternalRequest, db postgresql.Databases, batchReference string) {
+	if batchReference == "" {
+		return
+	}
+
+	batch := models.DisbursementBatch{BatchReference: batchReference}
+	_, err := batch.GetDisbursementBatchByBatchReference(db.Payment)
+	if err != nil || batch.Status != "submitted" {
+		return
+	}
+
+	disbursement := models.Disbursement{BatchReference: batchReference}
+	disbursements, err := disbursement.GetDisbursementsByBatchReference(db.Payment)
+	if err != nil {
+		extReq.Logger.Error(fmt.Sprintf("error getting disbursements of batch %v: %v", batchReference, err.Error()))
+		return
+	}
+
+	open := 0
+	batch.CompletedCount, batch.FailedCount = 0, 0
+	for _, disbursement := range disbursements {
+		switch {
+		case strings.EqualFold(disbursement.Status, string(DisbursementCompleted)):
+			batch.CompletedCount += 1
+		case IsDisbursementTerminal(disbursement.Status) || strings.EqualFold(disbursement.Status, string(DisbursementDeadLetter)):
+			batch.FailedCount += 1
+		default:
+			open += 1
+		}
+	}
+
+	reconciledAt := time.Now()
+	batch.ReconciledAt = &reconciledAt
+	if open == 0 {
+		batch.Status = "completed"
+	}
+	err = batch.UpdateAllFields(db.Payment)
+	if err != nil {
+		extReq.Logger.Error(fmt.Sprintf("error updating disbursement batch %v: %v", batchReference, err.Error()))
+	}
+}
+
+func ListDisbursementBatchesService(db postgresql.Databases, paginator postgresql.Pagination) ([]models.DisbursementBatch, postgresql.PaginationResponse, int, error) {
+	batch := models.DisbursementBatch{}
+	batches, pagination, err := batch.GetDisbursementBatches(db.Payment, paginator)
+	if err != nil {
+		return batches, pagination, http.StatusInternalServerError, err
+	}
+	return batches, pagination, http.StatusOK, nil
+}
+
+func GetDisbursementBatchService(db postgresql.Databases, batchReference string) (models.DisbursementBatchResponse, int, error) {
+	data := models.DisbursementBatchResponse{}
+
+	batch := models.DisbursementBatch{BatchReference: batchReference}
+	code, err := batch.GetDisbursementBatchByBatchReference(db.Payment)
+	if err != nil {
+		return data, code, fmt.Errorf("disbursement batch not found: %v", err.Error())
+	}
+
+	disbursement := models.Disbursement{BatchReference: batchReference}
+	disbursements, err := disbursement.GetDisbursementsByBatchReference(db.Payment)
+	if err != nil {
+		return data, http.StatusInternalServerError, err
+	}
+
+	data.Batch, data.Disbursements = batch, disbursements
+	return data, http.StatusOK, nil
+}
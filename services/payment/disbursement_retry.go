@@ -0,0 +1,335 @@
+package payment
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/vesicash/payment-ms/external"
+	"github.com/vesicash/payment-ms/external/request"
+	"github.com/vesicash/payment-ms/internal/config"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+type DisbursementErrorClass string
+
+var (
+	DisbursementErrorTransient DisbursementErrorClass = "transient"
+	DisbursementErrorPermanent DisbursementErrorClass = "permanent"
+	// the transfer may have been created, the gateway could not confirm either way
+	DisbursementErrorUnknown DisbursementErrorClass = "unknown"
+
+	disbursementRetryActor = "cron:disbursement-retry"
+
+	// failure messages that will not go away by sending the transfer again
+	permanentDisbursementErrors = []string{
+		"invalid account", "invalid bank", "account not found", "no such account", "account does not exist",
+		"account closed", "closed account", "dormant", "blocked", "restricted", "insufficient", "unknown beneficiary",
+	}
+)
+
+func disbursementRetryDefaults() config.DisbursementRetry {
+	settings := config.GetConfig().DisbursementRetry
+	if settings.MaxTries <= 0 {
+		settings.MaxTries = 3
+	}
+	if settings.BaseDelaySeconds <= 0 {
+		settings.BaseDelaySeconds = 300
+	}
+	if settings.MaxDelaySeconds <= 0 {
+		settings.MaxDelaySeconds = 86400
+	}
+	if settings.JitterPercent <= 0 {
+		settings.JitterPercent = 20
+	}
+	return settings
+}
+
+// DisbursementRetrySettings returns the retry policy for a gateway, the stored gateway settings override the configured defaults.
+func DisbursementRetrySettings(db postgresql.Databases, gateway string) config.DisbursementRetry {
+	settings := disbursementRetryDefaults()
+
+	retrySetting := models.DisbursementRetrySetting{Gateway: strings.ToLower(gateway)}
+	_, err := retrySetting.GetDisbursementRetrySettingByGateway(db.Payment)
+	if err != nil {
+		return settings
+	}
+
+	if retrySetting.MaxTries > 0 {
+		settings.MaxTries = retrySetting.MaxTries
+	}
+	if retrySetting.BaseDelaySeconds > 0 {
+		settings.BaseDelaySeconds = retrySetting.BaseDelaySeconds
+	}
+	if retrySetting.MaxDelaySeconds > 0 {
+		settings.MaxDelaySeconds = retrySetting.MaxDelaySeconds
+	}
+	if retrySetting.JitterPercent > 0 {
+		settings.JitterPercent = retrySetting.JitterPercent
+	}
+	return settings
+}
+
+// DisbursementRetryDelay is the wait before attempt number tries, doubling from the base delay up to the max delay
+// and moved up or down by a random jitter so retries for many disbursements do not hit the gateway at once.
+func DisbursementRetryDelay(settings config.DisbursementRetry, tries int) time.Duration {
+	if tries < 1 {
+		tries = 1
+	}
+
+	delay := float64(settings.BaseDelaySeconds) * math.Pow(2, float64(tries-1))
+	if delay > float64(settings.MaxDelaySeconds) {
+		delay = float64(settings.MaxDelaySeconds)
+	}
+
+	jitter := delay * float64(settings.JitterPercent) / 100
+	delay = delay - jitter + rand.Float64()*2*jitter
+	return time.Duration(delay * float64(time.Second))
+}
+
+// ClassifyDisbursementError decides if a failed transfer or gateway call is worth retrying.
+// Timeouts, rate limits and 5xx responses are transient, other 4xx responses and failures
+// that point at the beneficiary account are permanent.
+func ClassifyDisbursementError(err error, response interface{}) DisbursementErrorClass {
+	if isPermanentDisbursementFailure(disbursementFailureReason(err, response)) {
+		return DisbursementErrorPermanent
+	}
+
+	if err == nil {
+		return DisbursementErrorTransient
+	}
+
+	// anything that did not come back as a gateway response, like a timeout, is treated as transient
+	var reqErr *external.RequestError
+	if errors.As(err, &reqErr) && reqErr.Code < 500 && reqErr.Code != http.StatusRequestTimeout && reqErr.Code != http.StatusTooManyRequests {
+		return DisbursementErrorPermanent
+	}
+	return DisbursementErrorTransient
+}
+
+func isPermanentDisbursementFailure(reason string) bool {
+	reason = strings.ToLower(reason)
+	for _, message := range permanentDisbursementErrors {
+		if strings.Contains(reason, message) {
+			return true
+		}
+	}
+	return false
+}
+
+func disbursementFailureReason(err error, response interface{}) string {
+	reasons := []string{}
+	if err != nil {
+		reasons = append(reasons, err.Error())
+	}
+	if response != nil {
+		responseByte, _ := json.Marshal(response)
+		reasons = append(reasons, string(responseByte))
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// HandleDisbursementFailure applies the retry policy to a disbursement whose transfer failed and returns the status it was moved to.
+// Transient failures are sent again after a backoff until the gateway's max tries is reached. Permanent failures of wallet
+// withdrawals are failed so the caller can return the funds, once the gateway confirms the transfer was not created.
+// Everything else goes to the dead-letter status for an admin to resolve.
+func HandleDisbursementFailure(extReq request.ExternalRequest, db postgresql.Databases, disbursement *models.Disbursement, actor string, cause error, response interface{}) (DisbursementStatus, error) {
+	var (
+		class    = ClassifyDisbursementError(cause, response)
+		settings = DisbursementRetrySettings(db, disbursement.Gateway)
+		status   DisbursementStatus
+	)
+
+	disbursement.FailureReason = disbursementFailureReason(cause, response)
+	switch {
+	case class == DisbursementErrorPermanent && disbursement.Type == "wallet" && !disbursementTransferMayExist(extReq, *disbursement, cause):
+		status = DisbursementFailed
+	case class == DisbursementErrorPermanent || disbursement.Tries >= settings.MaxTries:
+		status = DisbursementDeadLetter
+		deadLetteredAt := time.Now()
+		disbursement.DeadLetteredAt = &deadLetteredAt
+	default:
+		status = DisbursementRetryScheduled
+		disbursement.TryAgainAt = time.Now().Add(DisbursementRetryDelay(settings, disbursement.Tries+1))
+	}
+
+	err := TransitionDisbursement(db, disbursement, status, actor, map[string]interface{}{
+		"class":    class,
+		"tries":    disbursement.Tries,
+		"reason":   disbursement.FailureReason,
+		"response": response,
+	})
+	if err != nil {
+		return status, err
+	}
+
+	switch status {
+	case DisbursementRetryScheduled:
+		extReq.Logger.Info(fmt.Sprintf("disbursement %v failed (%v), retry %v scheduled for %v", disbursement.DisbursementID, class, disbursement.Tries+1, disbursement.TryAgainAt.Format(time.RFC3339)))
+	case DisbursementDeadLetter:
+		notifyDisbursementDeadLetter(extReq, *disbursement, class)
+	}
+	return status, nil
+}
+
+// RetryDisbursementTransfer sends a disbursement whose retry is due to its gateway again. The last try may have reached the
+// gateway even though it failed on our side, so it is only sent again once the gateway confirms it has no transfer for the
+// last reference. A transfer that exists is followed up as pending, one the gateway cannot tell about goes to the dead-letter status.
+func RetryDisbursementTransfer(extReq request.ExternalRequest, db postgresql.Databases, disbursement *models.Disbursement) (DisbursementStatus, error) {
+	reference := disbursementTransferReference(*disbursement)
+	exists, err := disbursementTransferExists(extReq, *disbursement)
+	if err != nil {
+		disbursement.FailureReason = fmt.Sprintf("could not check if transfer %v reached %v: %v", reference, disbursement.Gateway, err.Error())
+		return deadLetterDisbursement(extReq, db, disbursement, disbursementRetryActor, DisbursementErrorUnknown, map[string]interface{}{"reference": reference, "reason": disbursement.FailureReason})
+	}
+	if exists {
+		extReq.Logger.Info(fmt.Sprintf("transfer %v of disbursement %v reached %v, not sending it again", reference, disbursement.DisbursementID, disbursement.Gateway))
+		if reference != disbursement.Reference {
+			disbursement.GatewayReference = reference
+		}
+		return DisbursementPending, TransitionDisbursement(db, disbursement, DisbursementPending, disbursementRetryActor, map[string]interface{}{"reference": reference, "exists": true})
+	}
+
+	disbursement.Tries += 1
+	resData, err := InitDisbursementTransfer(extReq, db, disbursement)
+	if err != nil {
+		extReq.Logger.Error(fmt.Sprintf("retry %v of disbursement %v failed: %v", disbursement.Tries, disbursement.DisbursementID, err.Error()))
+		return HandleDisbursementFailure(extReq, db, disbursement, disbursementRetryActor, err, resData.Response)
+	}
+
+	return DisbursementPending, TransitionDisbursement(db, disbursement, DisbursementPending, disbursementRetryActor, resData.Response)
+}
+
+// DeferDisbursementCheck pushes the next status check of a disbursement back after the gateway could not be reached.
+func DeferDisbursementCheck(db postgresql.Databases, disbursement *models.Disbursement, cause error) error {
+	settings := DisbursementRetrySettings(db, disbursement.Gateway)
+	disbursement.FailureReason = disbursementFailureReason(cause, nil)
+	disbursement.TryAgainAt = time.Now().Add(DisbursementRetryDelay(settings, disbursement.Tries+1))
+	return disbursement.UpdateFields(db.Payment, "failure_reason", "try_again_at")
+}
+
+// disbursementTransferExists asks the gateway the disbursement was last sent through whether it has a transfer for the
+// last reference. An error means the gateway could not tell.
+func disbursementTransferExists(extReq request.ExternalRequest, disbursement models.Disbursement) (bool, error) {
+	gateway, err := RecordedGateway(extReq, disbursement.Gateway)
+	if err != nil {
+		return false, err
+	}
+	return gateway.TransferExists(disbursementTransferReference(disbursement))
+}
+
+// disbursementTransferMayExist reports whether a transfer that failed could still have been created. A transfer that
+// could not be sent is only taken as not created once the gateway confirms it, one the gateway reported as failed
+// (no cause) reached the gateway and failed there.
+func disbursementTransferMayExist(extReq request.ExternalRequest, disbursement models.Disbursement, cause error) bool {
+	if cause == nil {
+		return false
+	}
+	exists, err := disbursementTransferExists(extReq, disbursement)
+	if err != nil {
+		extReq.Logger.Error(fmt.Sprintf("could not check if transfer of disbursement %v reached %v: %v", disbursement.DisbursementID, disbursement.Gateway, err.Error()))
+		return true
+	}
+	return exists
+}
+
+// deadLetterDisbursement moves a disbursement to the dead-letter status for an admin to resolve.
+func deadLetterDisbursement(extReq request.ExternalRequest, db postgresql.Databases, disbursement *models.Disbursement, actor string, class DisbursementErrorClass, payload interface{}) (DisbursementStatus, error) {
+	deadLetteredAt := time.Now()
+	disbursement.DeadLetteredAt = &deadLetteredAt
+	err := TransitionDisbursement(db, disbursement, DisbursementDeadLetter, actor, payload)
+	if err != nil {
+		return DisbursementDeadLetter, err
+	}
+	notifyDisbursementDeadLetter(extReq, *disbursement, class)
+	return DisbursementDeadLetter, nil
+}
+
+func disbursementTransferReference(disbursement models.Disbursement) string {
+	if disbursement.Tries > 0 {
+		return fmt.Sprintf("%v-%v", disbursement.Reference, disbursement.Tries)
+	}
+	return disbursement.Reference
+}
+
+// GetDisbursementByAnyReference finds a disbursement by its own reference or, for disbursements that were sent again,
+// by the reference of the latest transfer.
+func GetDisbursementByAnyReference(db postgresql.Databases, reference string) (models.Disbursement, int, error) {
+	disbursement := models.Disbursement{Reference: reference}
+	code, err := disbursement.GetDisbursementByReference(db.Payment)
+	if err == nil || code == http.StatusInternalServerError {
+		return disbursement, code, err
+	}
+
+	disbursement = models.Disbursement{GatewayReference: reference}
+	code, err = disbursement.GetDisbursementByGatewayReference(db.Payment)
+	return disbursement, code, err
+}
+
+func notifyDisbursementDeadLetter(extReq request.ExternalRequest, disbursement models.Disbursement, class DisbursementErrorClass) {
+	extReq.Logger.Error(fmt.Sprintf("disbursement %v moved to dead letter after %v tries (%v): %v", disbursement.DisbursementID, disbursement.Tries, class, disbursement.FailureReason))
+	err := SlackNotify(extReq, config.GetConfig().Slack.DisbursementChannelID, `
+			Disbursement Moved To Dead Letter
+			Note: Requires manual resolution
+			Environment: `+config.GetConfig().App.Name+`
+			Disbursement ID: `+fmt.Sprintf("%v", disbursement.DisbursementID)+`
+			Payment ID: `+disbursement.PaymentID+`
+			Account ID: `+fmt.Sprintf("%v", disbursement.RecipientID)+`
+			Gateway: `+disbursement.Gateway+`
+			Amount: `+fmt.Sprintf("%v %v", disbursement.DebitCurrency, disbursement.Amount)+`
+			Tries: `+fmt.Sprintf("%v", disbursement.Tries)+`
+			Failure: `+string(class)+`
+			Reason: `+disbursement.FailureReason+`
+			`)
+	if err != nil && !extReq.Test {
+		extReq.Logger.Error("error sending notification to slack: ", err.Error())
+	}
+}
+
+func ListDisbursementRetrySettingsService(db postgresql.Databases) (map[string]interface{}, int, error) {
+	retrySetting := models.DisbursementRetrySetting{}
+	list, err := retrySetting.GetAllDisbursementRetrySettings(db.Payment)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	return map[string]interface{}{
+		"default":  disbursementRetryDefaults(),
+		"gateways": list,
+	}, http.StatusOK, nil
+}
+
+func UpdateDisbursementRetrySettingService(db postgresql.Databases, req models.UpdateDisbursementRetrySettingRequest) (models.DisbursementRetrySetting, int, error) {
+	gateway := strings.ToLower(req.Gateway)
+	if !utility.InStringSlice(gateway, GatewayNames()) {
+		return models.DisbursementRetrySetting{}, http.StatusBadRequest, fmt.Errorf("gateway %v not supported", req.Gateway)
+	}
+
+	retrySetting := models.DisbursementRetrySetting{Gateway: gateway}
+	code, err := retrySetting.GetDisbursementRetrySettingByGateway(db.Payment)
+	if err != nil && code == http.StatusInternalServerError {
+		return retrySetting, code, err
+	}
+
+	retrySetting.MaxTries = req.MaxTries
+	retrySetting.BaseDelaySeconds = req.BaseDelaySeconds
+	retrySetting.MaxDelaySeconds = req.MaxDelaySeconds
+	retrySetting.JitterPercent = req.JitterPercent
+	retrySetting.UpdatedBy = "admin"
+
+	if err != nil {
+		err = retrySetting.CreateDisbursementRetrySetting(db.Payment)
+	} else {
+		err = retrySetting.UpdateAllFields(db.Payment)
+	}
+	if err != nil {
+		return retrySetting, http.StatusInternalServerError, err
+	}
+	return retrySetting, http.StatusOK, nil
+}
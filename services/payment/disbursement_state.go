@@ -0,0 +1,140 @@
+package payment
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"github.com/vesicash/payment-ms/utility"
+	"gorm.io/gorm"
+)
+
+type DisbursementStatus string
+
+var (
+	DisbursementNew              DisbursementStatus = "new"
+	DisbursementPending          DisbursementStatus = "pending"
+	DisbursementQueued           DisbursementStatus = "queued"
+	DisbursementAwaitingApproval DisbursementStatus = "awaiting_approval"
+	DisbursementApproved         DisbursementStatus = "approved"
+	DisbursementManual           DisbursementStatus = "manual"
+	DisbursementReview           DisbursementStatus = "review"
+	DisbursementCompleted        DisbursementStatus = "completed"
+	DisbursementFailed           DisbursementStatus = "failed"
+	DisbursementCancelled        DisbursementStatus = "cancelled"
+	DisbursementRejected         DisbursementStatus = "rejected"
+	DisbursementRetryScheduled   DisbursementStatus = "retry_scheduled"
+	DisbursementDeadLetter       DisbursementStatus = "dead_letter"
+
+	// a disbursement in a terminal status can no longer change
+	disbursementTerminalStatuses = []string{string(DisbursementCompleted), string(DisbursementFailed), string(DisbursementCancelled), string(DisbursementRejected)}
+
+	disbursementTransitions = map[DisbursementStatus][]DisbursementStatus{
+		DisbursementNew:              {DisbursementPending, DisbursementQueued, DisbursementAwaitingApproval, DisbursementManual, DisbursementReview, DisbursementRetryScheduled, DisbursementDeadLetter, DisbursementCompleted, DisbursementFailed, DisbursementCancelled},
+		DisbursementPending:          {DisbursementNew, DisbursementQueued, DisbursementAwaitingApproval, DisbursementManual, DisbursementReview, DisbursementRetryScheduled, DisbursementDeadLetter, DisbursementCompleted, DisbursementFailed, DisbursementCancelled},
+		DisbursementQueued:           {DisbursementPending, DisbursementRetryScheduled, DisbursementDeadLetter, DisbursementFailed, DisbursementCancelled},
+		DisbursementAwaitingApproval: {DisbursementApproved, DisbursementNew, DisbursementRejected},
+		DisbursementApproved:         {DisbursementPending, DisbursementQueued, DisbursementManual, DisbursementReview, DisbursementRetryScheduled, DisbursementDeadLetter, DisbursementFailed},
+		DisbursementManual:           {DisbursementPending, DisbursementCompleted, DisbursementFailed, DisbursementCancelled},
+		DisbursementReview:           {DisbursementPending, DisbursementCompleted, DisbursementFailed, DisbursementCancelled},
+		DisbursementRetryScheduled:   {DisbursementPending, DisbursementDeadLetter, DisbursementCompleted, DisbursementFailed, DisbursementCancelled},
+		DisbursementDeadLetter:       {DisbursementPending, DisbursementRetryScheduled, DisbursementManual, DisbursementFailed, DisbursementCancelled},
+	}
+)
+
+// GatewayDisbursementStatus maps a transfer status returned by a gateway to a disbursement status,
+// statuses that mean nothing to us leave the disbursement pending.
+func GatewayDisbursementStatus(status string) DisbursementStatus {
+	switch strings.ToLower(status) {
+	case "new":
+		return DisbursementNew
+	case "completed", "success", "successful":
+		return DisbursementCompleted
+	case "failed":
+		return DisbursementFailed
+	case "cancelled":
+		return DisbursementCancelled
+	default:
+		return DisbursementPending
+	}
+}
+
+func IsDisbursementTerminal(status string) bool {
+	return utility.InStringSlice(strings.ToLower(status), disbursementTerminalStatuses)
+}
+
+func checkDisbursementTransition(disbursement models.Disbursement, from, to DisbursementStatus) error {
+	if IsDisbursementTerminal(string(from)) {
+		return fmt.Errorf("disbursement %v is %v and can no longer change", disbursement.DisbursementID, from)
+	}
+
+	allowed := false
+	for _, status := range disbursementTransitions[from] {
+		if status == to {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return fmt.Errorf("disbursement %v cannot move from %v to %v", disbursement.DisbursementID, from, to)
+	}
+
+	if from == DisbursementAwaitingApproval && to != DisbursementRejected && disbursement.Approved != "yes" {
+		return fmt.Errorf("disbursement %v has not been approved", disbursement.DisbursementID)
+	}
+	return nil
+}
+
+// TransitionDisbursement moves the disbursement to status and saves it, recording who or what moved it in the status history.
+// The transition is checked against the status saved in the database, not the one held in memory, so a late webhook or job run
+// cannot move a disbursement out of a terminal status. payload is usually the gateway response that caused the change.
+func TransitionDisbursement(db postgresql.Databases, disbursement *models.Disbursement, status DisbursementStatus, actor string, payload interface{}) error {
+	return db.Payment.Transaction(func(tx *gorm.DB) error {
+		current, err := disbursement.GetDisbursementStatusForUpdate(tx)
+		if err != nil {
+			return err
+		}
+
+		from := DisbursementStatus(strings.ToLower(current))
+		if from == status {
+			disbursement.Status = string(status)
+			return disbursement.UpdateAllFields(tx)
+		}
+
+		err = checkDisbursementTransition(*disbursement, from, status)
+		if err != nil {
+			disbursement.Status = current
+			return err
+		}
+
+		disbursement.Status = string(status)
+		err = disbursement.UpdateAllFields(tx)
+		if err != nil {
+			return err
+		}
+
+		history := models.DisbursementStatusHistory{
+			DisbursementID: disbursement.DisbursementID,
+			FromStatus:     current,
+			ToStatus:       string(status),
+			Actor:          actor,
+		}
+		if payload != nil {
+			payloadByte, _ := json.Marshal(payload)
+			history.Payload = string(payloadByte)
+		}
+		return history.CreateDisbursementStatusHistory(tx)
+	})
+}
+
+func GetDisbursementStatusHistoryService(db postgresql.Databases, disbursementID int) ([]models.DisbursementStatusHistory, int, error) {
+	history := models.DisbursementStatusHistory{DisbursementID: disbursementID}
+	list, err := history.GetDisbursementStatusHistoryByDisbursementID(db.Payment)
+	if err != nil {
+		return list, http.StatusInternalServerError, err
+	}
+	return list, http.StatusOK, nil
+}
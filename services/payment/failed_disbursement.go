@@ -0,0 +1,226 @@
+package payment
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/vesicash/payment-ms/external/external_models"
+	"github.com/vesicash/payment-ms/external/request"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+var (
+	FailedDisbursementOpen     = "open"
+	FailedDisbursementCleared  = "cleared"
+	FailedDisbursementResolved = "resolved"
+)
+
+// DisbursementRecipient is the seller of a transaction with the account a payout goes to,
+// Restricted lists what stops them from being paid.
+type DisbursementRecipient struct {
+	User        external_models.User
+	BankDetails external_models.BankDetail
+	IsMomo      bool
+	Restricted  []string
+}
+
+func GetDisbursementRecipient(extReq request.ExternalRequest, transaction external_models.TransactionByID, currency string) DisbursementRecipient {
+	var (
+		restricted  = []string{}
+		sellerParty = transaction.Parties["seller"]
+	)
+	user, _ := GetUserWithAccountID(extReq, sellerParty.AccountID)
+
+	businessCharge, _ := GetBusinessChargeWithBusinessIDAndCountry(extReq, transaction.BusinessID, transaction.Country.CountryCode)
+	isMomo := businessCharge.DisbursementGateway == "rave_momo"
+
+	bankDetails, err := GetBankDetail(extReq, 0, int(user.AccountID), "", currency, isMomo)
+	if err != nil {
+		if isMomo {
+			restricted = append(restricted, "Mobile Money Operator")
+		} else {
+			restricted = append(restricted, "Bank Account")
+		}
+	}
+
+	if user.AccountType == "" {
+		restricted = append(restricted, "Account Type")
+	}
+
+	if strings.EqualFold(user.AccountType, "individual") {
+		profile, _ := GetUserProfileByAccountID(extReq, extReq.Logger, int(user.AccountID))
+		if strings.EqualFold(profile.Country, "Nigeria") || strings.EqualFold(profile.Country, "NG") {
+			if !HasVerification(extReq, user.AccountID, "bvn") {
+				restricted = append(restricted, "Identity Card")
+			}
+		}
+	}
+
+	if strings.EqualFold(user.AccountType, "business") {
+		businessprofile, _ := GetBusinessProfileByAccountID(extReq, extReq.Logger, int(user.AccountID))
+		if strings.EqualFold(businessprofile.Country, "Nigeria") || strings.EqualFold(businessprofile.Country, "NG") {
+			if strings.EqualFold(businessprofile.BusinessType, "social_commerce") {
+				if !HasVerification(extReq, user.AccountID, "id") {
+					restricted = append(restricted, "Identity Card")
+				}
+			} else {
+				if !HasVerification(extReq, user.AccountID, "cac") {
+					restricted = append(restricted, "CAC")
+				}
+				if !HasVerification(extReq, user.AccountID, "utilitybill") {
+					restricted = append(restricted, "Utility Bill")
+				}
+			}
+
+		}
+	}
+
+	if isMomo {
+		if !utility.InStringSlice(bankDetails.MobileMoneyOperator, []string{"mps", "mtn", "tigo", "vodafone", "airtel"}) {
+			restricted = append(restricted, "Mobile Money Operator Not Supported")
+		}
+	}
+
+	businesscheck, _ := GetBusinessProfileByAccountID(extReq, extReq.Logger, user.BusinessId)
+	if businesscheck.IsVerificationWaved {
+		restricted = []string{}
+	}
+
+	return DisbursementRecipient{
+		User:        user,
+		BankDetails: bankDetails,
+		IsMomo:      isMomo,
+		Restricted:  restricted,
+	}
+}
+
+// RecordFailedDisbursement saves the restrictions that blocked the payout of a payment, an open record is updated rather than duplicated.
+func RecordFailedDisbursement(db postgresql.Databases, paymnt models.Payment, transaction external_models.TransactionByID, accountID int, restricted []string) (models.FailedDisbursement, bool, error) {
+	var (
+		reasons = strings.Join(restricted, ", ")
+		now     = time.Now()
+	)
+
+	failedDisbursement := models.FailedDisbursement{PaymentID: paymnt.PaymentID}
+	code, err := failedDisbursement.GetFailedDisbursementByPaymentID(db.Payment)
+	if err != nil && code == http.StatusInternalServerError {
+		return failedDisbursement, false, err
+	}
+
+	if err == nil {
+		if failedDisbursement.ResolutionStatus == FailedDisbursementResolved {
+			return failedDisbursement, false, nil
+		}
+		if failedDisbursement.Reasons == reasons && failedDisbursement.ResolutionStatus == FailedDisbursementOpen {
+			return failedDisbursement, false, nil
+		}
+		failedDisbursement.Reasons = reasons
+		failedDisbursement.ResolutionStatus = FailedDisbursementOpen
+		failedDisbursement.LastCheckedAt = &now
+		return failedDisbursement, false, failedDisbursement.UpdateAllFields(db.Payment)
+	}
+
+	failedDisbursement = models.FailedDisbursement{
+		PaymentID:        paymnt.PaymentID,
+		TransactionID:    transaction.TransactionID,
+		BusinessID:       transaction.BusinessID,
+		AccountID:        accountID,
+		Currency:         paymnt.Currency,
+		Amount:           paymnt.TotalAmount,
+		Reasons:          reasons,
+		ResolutionStatus: FailedDisbursementOpen,
+		LastCheckedAt:    &now,
+	}
+	return failedDisbursement, true, failedDisbursement.CreateFailedDisbursement(db.Payment)
+}
+
+// ResolveFailedDisbursement closes the failed disbursement of a payment once its payout has been started.
+func ResolveFailedDisbursement(db postgresql.Databases, paymentID string) error {
+	failedDisbursement := models.FailedDisbursement{PaymentID: paymentID}
+	code, err := failedDisbursement.GetFailedDisbursementByPaymentID(db.Payment)
+	if err != nil {
+		if code == http.StatusInternalServerError {
+			return err
+		}
+		return nil
+	}
+
+	if failedDisbursement.ResolutionStatus == FailedDisbursementResolved {
+		return nil
+	}
+
+	now := time.Now()
+	failedDisbursement.ResolutionStatus = FailedDisbursementResolved
+	failedDisbursement.ResolvedAt = &now
+	return failedDisbursement.UpdateAllFields(db.Payment)
+}
+
+func ListFailedDisbursementsService(db postgresql.Databases, req models.ListFailedDisbursementsRequest, paginator postgresql.Pagination) ([]models.FailedDisbursement, postgresql.PaginationResponse, int, error) {
+	if req.From != "" {
+		if _, err := time.Parse("2006-01-02", req.From); err != nil {
+			return nil, postgresql.PaginationResponse{}, http.StatusBadRequest, fmt.Errorf("from must be in the format YYYY-MM-DD")
+		}
+	}
+	if req.To != "" {
+		if _, err := time.Parse("2006-01-02", req.To); err != nil {
+			return nil, postgresql.PaginationResponse{}, http.StatusBadRequest, fmt.Errorf("to must be in the format YYYY-MM-DD")
+		}
+	}
+
+	failedDisbursement := models.FailedDisbursement{}
+	list, pagination, err := failedDisbursement.GetFailedDisbursements(db.Payment, req, paginator)
+	if err != nil {
+		return list, pagination, http.StatusInternalServerError, err
+	}
+	return list, pagination, http.StatusOK, nil
+}
+
+// ReEvaluateFailedDisbursementService runs the restriction checks of a failed disbursement again,
+// the record is cleared once nothing stops the seller from being paid.
+func ReEvaluateFailedDisbursementService(extReq request.ExternalRequest, db postgresql.Databases, id uint) (models.FailedDisbursement, external_models.TransactionByID, int, error) {
+	var (
+		transaction external_models.TransactionByID
+	)
+
+	failedDisbursement := models.FailedDisbursement{ID: id}
+	code, err := failedDisbursement.GetFailedDisbursementByID(db.Payment)
+	if err != nil {
+		return failedDisbursement, transaction, code, err
+	}
+
+	if failedDisbursement.ResolutionStatus == FailedDisbursementResolved {
+		return failedDisbursement, transaction, http.StatusBadRequest, fmt.Errorf("failed disbursement %v has already been resolved", id)
+	}
+
+	paymnt := models.Payment{PaymentID: failedDisbursement.PaymentID}
+	code, err = paymnt.GetPaymentByPaymentID(db.Payment)
+	if err != nil {
+		return failedDisbursement, transaction, code, fmt.Errorf("payment %v not found: %v", failedDisbursement.PaymentID, err.Error())
+	}
+
+	transaction, err = ListTransactionsByID(extReq, paymnt.TransactionID)
+	if err != nil {
+		return failedDisbursement, transaction, http.StatusInternalServerError, fmt.Errorf("transaction %v not found: %v", paymnt.TransactionID, err.Error())
+	}
+
+	recipient := GetDisbursementRecipient(extReq, transaction, paymnt.Currency)
+
+	// the reasons of a cleared record are kept to show what held the payout back
+	now := time.Now()
+	failedDisbursement.LastCheckedAt = &now
+	failedDisbursement.ResolutionStatus = FailedDisbursementCleared
+	if len(recipient.Restricted) > 0 {
+		failedDisbursement.ResolutionStatus = FailedDisbursementOpen
+		failedDisbursement.Reasons = strings.Join(recipient.Restricted, ", ")
+	}
+
+	err = failedDisbursement.UpdateAllFields(db.Payment)
+	if err != nil {
+		return failedDisbursement, transaction, http.StatusInternalServerError, err
+	}
+	return failedDisbursement, transaction, http.StatusOK, nil
+}
@@ -0,0 +1,562 @@
+package payment
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/vesicash/payment-ms/external/external_models"
+	"github.com/vesicash/payment-ms/external/request"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+)
+
+var (
+	DefaultGateway = "rave"
+
+	ErrGatewayOperationNotSupported = fmt.Errorf("operation not supported by gateway")
+
+	gatewayMutex     = &sync.RWMutex{}
+	disabledGateways = map[string]bool{}
+	degradedGateways = map[string]bool{}
+	gateways         = map[string]GatewayConstructor{
+		"rave":      NewRaveGateway,
+		"rave_momo": NewRaveMomoGateway,
+		"monnify":   NewMonnifyGateway,
+		"paystack":  NewPaystackGateway,
+		"campay":    NewCampayGateway,
+	}
+)
+
+type GatewayConstructor func(extReq request.ExternalRequest) PaymentGateway
+
+type PaymentGateway interface {
+	Name() string
+	InitPayment(req GatewayPaymentRequest) (string, interface{}, error)
+	Status(db postgresql.Databases, payment models.Payment, paymentInfo models.PaymentInfo, reference string) (interface{}, bool, string, float64, error)
+	TransferStatus(reference string) (interface{}, bool, string, float64, error)
+	Verify(reference string, amount float64, currency string) (bool, float64, error)
+	ReserveAccount(req GatewayReserveAccountRequest) (interface{}, error)
+	InitTransfer(req GatewayTransferRequest) (GatewayTransferResponse, error)
+	TransferExists(reference string) (bool, error)
+	ChargeCard(token, currency, email, reference string, amount float64) (string, error)
+	ListBanks(countryCode string) ([]external_models.BanksResponse, error)
+}
+
+type GatewayPaymentRequest struct {
+	Reference     string
+	CustomerName  string
+	CustomerEmail string
+	Description   string
+	Currency      string
+	RedirectUrl   string
+	Amount        float64
+}
+
+type GatewayReserveAccountRequest struct {
+	Reference   string
+	AccountName string
+	Narration   string
+	Email       string
+	Firstname   string
+	Lastname    string
+	Currency    string
+	Amount      float64
+}
+
+type GatewayTransferRequest struct {
+	BankCode      string
+	AccountNumber string
+	AccountName   string
+	Narration     string
+	Currency      string
+	Reference     string
+	CallbackUrl   string
+	Amount        float64
+}
+
+type GatewayTransferResponse struct {
+	Status    string
+	Message   string
+	Reference string
+	Response  interface{}
+}
+
+func RegisterGateway(name string, constructor GatewayConstructor) {
+	gatewayMutex.Lock()
+	defer gatewayMutex.Unlock()
+	gateways[strings.ToLower(name)] = constructor
+}
+
+func DisableGateway(name string) {
+	gatewayMutex.Lock()
+	defer gatewayMutex.Unlock()
+	disabledGateways[strings.ToLower(name)] = true
+}
+
+func EnableGateway(name string) {
+	gatewayMutex.Lock()
+	defer gatewayMutex.Unlock()
+	delete(disabledGateways, strings.ToLower(name))
+}
+
+func SetGatewayStatus(name, status string) {
+	gatewayMutex.Lock()
+	defer gatewayMutex.Unlock()
+	name = strings.ToLower(name)
+	disabledGateways[name] = status == "disabled"
+	degradedGateways[name] = status == "degraded"
+}
+
+func IsGatewayDegraded(name string) bool {
+	gatewayMutex.RLock()
+	defer gatewayMutex.RUnlock()
+	return degradedGateways[strings.ToLower(name)]
+}
+
+func GatewayNames() []string {
+	gatewayMutex.RLock()
+	defer gatewayMutex.RUnlock()
+	names := []string{}
+	for name := range gateways {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func IsGatewaySupported(name string) bool {
+	gatewayMutex.RLock()
+	defer gatewayMutex.RUnlock()
+	_, ok := gateways[strings.ToLower(name)]
+	return ok
+}
+
+func IsGatewayAvailable(name string) bool {
+	gatewayMutex.RLock()
+	defer gatewayMutex.RUnlock()
+	name = strings.ToLower(name)
+	_, ok := gateways[name]
+	return ok && !disabledGateways[name]
+}
+
+func FindGateway(extReq request.ExternalRequest, name string) (PaymentGateway, error) {
+	gateway, err := constructGateway(extReq, name)
+	if err != nil {
+		return nil, err
+	}
+	if !IsGatewayAvailable(name) {
+		return nil, fmt.Errorf("gateway %v is disabled", strings.ToLower(name))
+	}
+	return gateway, nil
+}
+
+// constructGateway builds the named gateway whether or not it is disabled.
+func constructGateway(extReq request.ExternalRequest, name string) (PaymentGateway, error) {
+	gatewayMutex.RLock()
+	defer gatewayMutex.RUnlock()
+	name = strings.ToLower(name)
+	constructor, ok := gateways[name]
+	if !ok {
+		return nil, fmt.Errorf("gateway %v not supported", name)
+	}
+	return constructor(extReq), nil
+}
+
+// RecordedGateway builds the gateway a payment or transfer was sent through, even when it has since been disabled, so an
+// existing reference is always checked on the gateway that created it. Records without a gateway were sent through the default.
+func RecordedGateway(extReq request.ExternalRequest, name string) (PaymentGateway, error) {
+	return constructGateway(extReq, thisOrThatStr(name, DefaultGateway))
+}
+
+// GetGateway returns the named gateway for a new payment or transfer, falling back to the default gateway when it is
+// unknown or disabled. Existing references are looked up with RecordedGateway.
+func GetGateway(extReq request.ExternalRequest, name string) PaymentGateway {
+	gateway, err := FindGateway(extReq, thisOrThatStr(name, DefaultGateway))
+	if err == nil {
+		return gateway
+	}
+
+	extReq.Logger.Warning(fmt.Sprintf("%v, falling back to %v", err.Error(), DefaultGateway))
+	gatewayMutex.RLock()
+	defer gatewayMutex.RUnlock()
+	return gateways[DefaultGateway](extReq)
+}
+
+type RaveGateway struct {
+	Rave Rave
+}
+
+func NewRaveGateway(extReq request.ExternalRequest) PaymentGateway {
+	extReq.Gateway = "rave"
+	return &RaveGateway{Rave: Rave{ExtReq: extReq}}
+}
+
+func (r *RaveGateway) Name() string {
+	return "rave"
+}
+
+func (r *RaveGateway) InitPayment(req GatewayPaymentRequest) (string, interface{}, error) {
+	return r.Rave.InitPayment(req.Reference, req.CustomerEmail, req.Currency, req.RedirectUrl, req.Amount)
+}
+
+func (r *RaveGateway) Status(db postgresql.Databases, payment models.Payment, paymentInfo models.PaymentInfo, reference string) (interface{}, bool, string, float64, error) {
+	return r.Rave.StatusV3(db, payment, paymentInfo, reference)
+}
+
+func (r *RaveGateway) TransferStatus(reference string) (interface{}, bool, string, float64, error) {
+	return r.Rave.Status(reference)
+}
+
+func (r *RaveGateway) Verify(reference string, amount float64, currency string) (bool, float64, error) {
+	status, err := r.Rave.VerifyTrans(reference, amount, currency)
+	if err != nil {
+		return false, 0, err
+	}
+	return status == "success", amount, nil
+}
+
+func (r *RaveGateway) ReserveAccount(req GatewayReserveAccountRequest) (interface{}, error) {
+	return r.Rave.ReserveAccount(req.Reference, req.Narration, req.Email, req.Firstname, req.Lastname, req.Amount)
+}
+
+func (r *RaveGateway) InitTransfer(req GatewayTransferRequest) (GatewayTransferResponse, error) {
+	resData, err := r.Rave.InitTransfer(req.BankCode, req.AccountNumber, req.Amount, req.Narration, req.Currency, req.Reference, req.CallbackUrl)
+	if err != nil {
+		return GatewayTransferResponse{}, err
+	}
+	return GatewayTransferResponse{Status: resData.Status, Message: resData.Message, Response: resData}, nil
+}
+
+func (r *RaveGateway) BulkTransferCurrencies() []string {
+	return []string{"NGN"}
+}
+
+func (r *RaveGateway) InitBulkTransfer(req GatewayBulkTransferRequest) (GatewayBulkTransferResponse, error) {
+	transfers := []external_models.RaveBulkTransferRequest{}
+	for _, transfer := range req.Transfers {
+		transfers = append(transfers, external_models.RaveBulkTransferRequest{
+			BankCode:      transfer.BankCode,
+			AccountNumber: transfer.AccountNumber,
+			Amount:        transfer.Amount,
+			Currency:      strings.ToUpper(transfer.Currency),
+			Narration:     transfer.Narration,
+			Reference:     transfer.Reference,
+			CallbackUrl:   transfer.CallbackUrl,
+		})
+	}
+
+	resData, err := r.Rave.InitBulkTransfer(req.Title, transfers)
+	if err != nil {
+		return GatewayBulkTransferResponse{}, err
+	}
+	if resData.Data.ID == 0 {
+		return GatewayBulkTransferResponse{Response: resData}, fmt.Errorf("bulk transfer not queued: %v", resData.Message)
+	}
+	return GatewayBulkTransferResponse{Status: resData.Status, Message: resData.Message, Reference: strconv.Itoa(int(resData.Data.ID)), Response: resData}, nil
+}
+
+func (r *RaveGateway) BulkTransferStatus(reference string) ([]GatewayBulkTransferItem, interface{}, error) {
+	transfers, err := r.Rave.BulkTransfers(reference)
+	if err != nil {
+		return nil, transfers, err
+	}
+
+	items := []GatewayBulkTransferItem{}
+	for _, transfer := range transfers {
+		items = append(items, GatewayBulkTransferItem{Reference: transfer.Reference, Status: transfer.Status, Message: transfer.CompleteMessage, Response: transfer})
+	}
+	return items, transfers, nil
+}
+
+func (r *RaveGateway) TransferExists(reference string) (bool, error) {
+	transfers, err := r.Rave.TransfersByReference(reference)
+	if err != nil {
+		return false, err
+	}
+	for _, transfer := range transfers {
+		if transfer.Reference == reference {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *RaveGateway) ResolveAccount(bankCode, accountNumber string) (string, error) {
+	return r.Rave.ResolveAccount(bankCode, accountNumber)
+}
+
+func (r *RaveGateway) ChargeCard(token, currency, email, reference string, amount float64) (string, error) {
+	return r.Rave.ChargeCard(token, currency, email, reference, amount)
+}
+
+func (r *RaveGateway) ListBanks(countryCode string) ([]external_models.BanksResponse, error) {
+	return r.Rave.ListBanks(countryCode)
+}
+
+func (r *RaveGateway) Probe() error {
+	_, err := r.Rave.ListBanks("NG")
+	return err
+}
+
+// RaveMomoGateway sends payouts to mobile money wallets through flutterwave.
+// Transfer requests carry the mobile money operator as the bank code and the wallet number as the account number.
+type RaveMomoGateway struct {
+	RaveGateway
+}
+
+func NewRaveMomoGateway(extReq request.ExternalRequest) PaymentGateway {
+	extReq.Gateway = "rave_momo"
+	return &RaveMomoGateway{RaveGateway: RaveGateway{Rave: Rave{ExtReq: extReq}}}
+}
+
+func (r *RaveMomoGateway) Name() string {
+	return "rave_momo"
+}
+
+func (r *RaveMomoGateway) TransferStatus(reference string) (interface{}, bool, string, float64, error) {
+	return r.Rave.TransferStatus(reference)
+}
+
+// BulkTransferCurrencies is empty, mobile money payouts are always sent one at a time.
+func (r *RaveMomoGateway) BulkTransferCurrencies() []string {
+	return []string{}
+}
+
+func (r *RaveMomoGateway) InitTransfer(req GatewayTransferRequest) (GatewayTransferResponse, error) {
+	resData, err := r.Rave.InitMomoTransfer(req.BankCode, req.AccountNumber, req.AccountName, req.Amount, req.Narration, req.Currency, req.Reference, req.CallbackUrl)
+	if err != nil {
+		return GatewayTransferResponse{}, err
+	}
+	return GatewayTransferResponse{Status: resData.Status, Message: resData.Message, Reference: strconv.Itoa(int(resData.Data.ID)), Response: resData}, nil
+}
+
+type MonnifyGateway struct {
+	Monnify Monnify
+}
+
+func NewMonnifyGateway(extReq request.ExternalRequest) PaymentGateway {
+	extReq.Gateway = "monnify"
+	return &MonnifyGateway{Monnify: Monnify{ExtReq: extReq}}
+}
+
+func (m *MonnifyGateway) Name() string {
+	return "monnify"
+}
+
+func (m *MonnifyGateway) InitPayment(req GatewayPaymentRequest) (string, interface{}, error) {
+	return m.Monnify.InitPayment(req.Amount, req.CustomerName, req.CustomerEmail, req.Reference, req.Description, req.Currency, req.RedirectUrl)
+}
+
+func (m *MonnifyGateway) Status(db postgresql.Databases, payment models.Payment, paymentInfo models.PaymentInfo, reference string) (interface{}, bool, string, float64, error) {
+	return m.Monnify.Status(reference)
+}
+
+func (m *MonnifyGateway) TransferStatus(reference string) (interface{}, bool, string, float64, error) {
+	return m.Monnify.Status(reference)
+}
+
+func (m *MonnifyGateway) Verify(reference string, amount float64, currency string) (bool, float64, error) {
+	return m.Monnify.VerifyTrans(reference, amount)
+}
+
+func (m *MonnifyGateway) ReserveAccount(req GatewayReserveAccountRequest) (interface{}, error) {
+	return m.Monnify.ReserveAccount(req.Reference, req.AccountName, req.Currency, req.Email)
+}
+
+func (m *MonnifyGateway) InitTransfer(req GatewayTransferRequest) (GatewayTransferResponse, error) {
+	resData, err := m.Monnify.InitTransfer(req.Amount, req.Reference, req.Narration, req.BankCode, req.AccountNumber, req.Currency, req.AccountName)
+	if err != nil {
+		return GatewayTransferResponse{}, err
+	}
+	return GatewayTransferResponse{Status: resData.ResponseBody.Status, Message: resData.ResponseMessage, Response: resData}, nil
+}
+
+func (m *MonnifyGateway) BulkTransferCurrencies() []string {
+	return []string{"NGN"}
+}
+
+func (m *MonnifyGateway) InitBulkTransfer(req GatewayBulkTransferRequest) (GatewayBulkTransferResponse, error) {
+	transfers := []external_models.MonnifyBulkTransferRequestItem{}
+	for _, transfer := range req.Transfers {
+		transfers = append(transfers, external_models.MonnifyBulkTransferRequestItem{
+			Amount:                   transfer.Amount,
+			Reference:                transfer.Reference,
+			Narration:                transfer.Narration,
+			DestinationBankCode:      transfer.BankCode,
+			DestinationAccountNumber: transfer.AccountNumber,
+			Currency:                 strings.ToUpper(transfer.Currency),
+		})
+	}
+
+	resData, err := m.Monnify.InitBulkTransfer(req.Title, req.Reference, req.Narration, transfers)
+	if err != nil {
+		return GatewayBulkTransferResponse{}, err
+	}
+	if !resData.RequestSuccessful {
+		return GatewayBulkTransferResponse{Response: resData}, fmt.Errorf("bulk transfer not queued: %v", resData.ResponseMessage)
+	}
+	return GatewayBulkTransferResponse{Status: resData.ResponseBody.BatchStatus, Message: resData.ResponseMessage, Reference: thisOrThatStr(resData.ResponseBody.BatchReference, req.Reference), Response: resData}, nil
+}
+
+func (m *MonnifyGateway) BulkTransferStatus(reference string) ([]GatewayBulkTransferItem, interface{}, error) {
+	transfers, err := m.Monnify.BulkTransfers(reference)
+	if err != nil {
+		return nil, transfers, err
+	}
+
+	items := []GatewayBulkTransferItem{}
+	for _, transfer := range transfers {
+		items = append(items, GatewayBulkTransferItem{Reference: transfer.Reference, Status: transfer.Status, Response: transfer})
+	}
+	return items, transfers, nil
+}
+
+func (m *MonnifyGateway) TransferExists(reference string) (bool, error) {
+	_, err := m.Monnify.TransferSummary(reference)
+	if err != nil {
+		if isExternalNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (m *MonnifyGateway) ChargeCard(token, currency, email, reference string, amount float64) (string, error) {
+	return "failed", ErrGatewayOperationNotSupported
+}
+
+func (m *MonnifyGateway) ListBanks(countryCode string) ([]external_models.BanksResponse, error) {
+	return []external_models.BanksResponse{}, ErrGatewayOperationNotSupported
+}
+
+func (m *MonnifyGateway) Probe() error {
+	_, err := m.Monnify.TransferSummary(gatewayProbeReference)
+	return err
+}
+
+type PaystackGateway struct {
+	Paystack Paystack
+}
+
+func NewPaystackGateway(extReq request.ExternalRequest) PaymentGateway {
+	extReq.Gateway = "paystack"
+	return &PaystackGateway{Paystack: Paystack{ExtReq: extReq}}
+}
+
+func (p *PaystackGateway) Name() string {
+	return "paystack"
+}
+
+func (p *PaystackGateway) InitPayment(req GatewayPaymentRequest) (string, interface{}, error) {
+	return p.Paystack.InitPayment(req.Reference, req.CustomerEmail, req.Currency, req.RedirectUrl, req.Amount)
+}
+
+func (p *PaystackGateway) Status(db postgresql.Databases, payment models.Payment, paymentInfo models.PaymentInfo, reference string) (interface{}, bool, string, float64, error) {
+	return p.Paystack.Status(reference)
+}
+
+func (p *PaystackGateway) TransferStatus(reference string) (interface{}, bool, string, float64, error) {
+	return p.Paystack.TransferStatus(reference)
+}
+
+func (p *PaystackGateway) Verify(reference string, amount float64, currency string) (bool, float64, error) {
+	return p.Paystack.VerifyTrans(reference, amount, currency)
+}
+
+func (p *PaystackGateway) ReserveAccount(req GatewayReserveAccountRequest) (interface{}, error) {
+	return nil, ErrGatewayOperationNotSupported
+}
+
+func (p *PaystackGateway) InitTransfer(req GatewayTransferRequest) (GatewayTransferResponse, error) {
+	resData, err := p.Paystack.InitTransfer(req.BankCode, req.AccountNumber, req.AccountName, req.Amount, req.Narration, req.Currency, req.Reference)
+	if err != nil {
+		return GatewayTransferResponse{}, err
+	}
+	return GatewayTransferResponse{Status: resData.Data.Status, Message: resData.Message, Response: resData}, nil
+}
+
+func (p *PaystackGateway) TransferExists(reference string) (bool, error) {
+	_, _, _, _, err := p.Paystack.TransferStatus(reference)
+	if err != nil {
+		if isExternalNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (p *PaystackGateway) ResolveAccount(bankCode, accountNumber string) (string, error) {
+	return p.Paystack.ResolveAccount(bankCode, accountNumber)
+}
+
+func (p *PaystackGateway) ChargeCard(token, currency, email, reference string, amount float64) (string, error) {
+	return "failed", ErrGatewayOperationNotSupported
+}
+
+func (p *PaystackGateway) ListBanks(countryCode string) ([]external_models.BanksResponse, error) {
+	return p.Paystack.ListBanks(countryCode)
+}
+
+func (p *PaystackGateway) Probe() error {
+	_, err := p.Paystack.ListBanks("NG")
+	return err
+}
+
+type CampayGateway struct {
+	Campay Campay
+}
+
+func NewCampayGateway(extReq request.ExternalRequest) PaymentGateway {
+	extReq.Gateway = "campay"
+	return &CampayGateway{Campay: Campay{ExtReq: extReq}}
+}
+
+func (c *CampayGateway) Name() string {
+	return "campay"
+}
+
+func (c *CampayGateway) InitPayment(req GatewayPaymentRequest) (string, interface{}, error) {
+	return c.Campay.InitPayment(req.Reference, req.Description, req.Currency, req.RedirectUrl, req.Amount)
+}
+
+func (c *CampayGateway) Status(db postgresql.Databases, payment models.Payment, paymentInfo models.PaymentInfo, reference string) (interface{}, bool, string, float64, error) {
+	return c.Campay.Status(reference)
+}
+
+func (c *CampayGateway) TransferStatus(reference string) (interface{}, bool, string, float64, error) {
+	return nil, false, "", 0, ErrGatewayOperationNotSupported
+}
+
+func (c *CampayGateway) Verify(reference string, amount float64, currency string) (bool, float64, error) {
+	return c.Campay.VerifyTrans(reference, amount, currency)
+}
+
+func (c *CampayGateway) ReserveAccount(req GatewayReserveAccountRequest) (interface{}, error) {
+	return nil, ErrGatewayOperationNotSupported
+}
+
+func (c *CampayGateway) InitTransfer(req GatewayTransferRequest) (GatewayTransferResponse, error) {
+	return GatewayTransferResponse{}, ErrGatewayOperationNotSupported
+}
+
+func (c *CampayGateway) TransferExists(reference string) (bool, error) {
+	return false, nil
+}
+
+func (c *CampayGateway) ChargeCard(token, currency, email, reference string, amount float64) (string, error) {
+	return "failed", ErrGatewayOperationNotSupported
+}
+
+func (c *CampayGateway) ListBanks(countryCode string) ([]external_models.BanksResponse, error) {
+	return []external_models.BanksResponse{}, ErrGatewayOperationNotSupported
+}
+
+func (c *CampayGateway) Probe() error {
+	_, _, _, _, err := c.Campay.Status(gatewayProbeReference)
+	return err
+}
@@ -0,0 +1,192 @@
+package payment
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gofrs/uuid"
+	"github.com/vesicash/payment-ms/external"
+	"github.com/vesicash/payment-ms/external/request"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+// GetGatewayPreference returns the gateway fallback list for a business and country,
+// falling back to the country wide list stored with business_id 0.
+func GetGatewayPreference(db postgresql.Databases, businessID int, country string) models.GatewayPreference {
+	preference := models.GatewayPreference{BusinessID: businessID, Country: country}
+	_, err := preference.GetGatewayPreferenceByBusinessIDAndCountry(db.Payment)
+	if err == nil || businessID == 0 {
+		return preference
+	}
+
+	preference = models.GatewayPreference{BusinessID: 0, Country: country}
+	preference.GetGatewayPreferenceByBusinessIDAndCountry(db.Payment)
+	return preference
+}
+
+func GetGatewayPreferenceService(db postgresql.Databases, req models.GetGatewayPreferenceRequest) (models.GatewayPreference, int, error) {
+	preference := models.GatewayPreference{BusinessID: req.BusinessID, Country: strings.ToUpper(req.Country)}
+	code, err := preference.GetGatewayPreferenceByBusinessIDAndCountry(db.Payment)
+	if err != nil {
+		return preference, code, fmt.Errorf("gateway preference not found: %v", err.Error())
+	}
+	return preference, http.StatusOK, nil
+}
+
+func SetGatewayPreferenceService(db postgresql.Databases, req models.SetGatewayPreferenceRequest) (models.GatewayPreference, int, error) {
+	for _, name := range append(req.PaymentGateways, req.DisbursementGateways...) {
+		if !IsGatewaySupported(strings.TrimSpace(name)) {
+			return models.GatewayPreference{}, http.StatusBadRequest, fmt.Errorf("gateway %v not supported", name)
+		}
+	}
+
+	preference := models.GatewayPreference{BusinessID: req.BusinessID, Country: strings.ToUpper(req.Country)}
+	code, err := preference.GetGatewayPreferenceByBusinessIDAndCountry(db.Payment)
+	if err != nil && code == http.StatusInternalServerError {
+		return preference, code, err
+	}
+
+	preference.PaymentGateways = strings.ToLower(strings.Join(req.PaymentGateways, ","))
+	preference.DisbursementGateways = strings.ToLower(strings.Join(req.DisbursementGateways, ","))
+
+	if preference.ID == 0 {
+		err = preference.CreateGatewayPreference(db.Payment)
+	} else {
+		err = preference.UpdateAllFields(db.Payment)
+	}
+	if err != nil {
+		return preference, http.StatusInternalServerError, err
+	}
+
+	return preference, http.StatusOK, nil
+}
+
+func PaymentGatewayOrder(db postgresql.Databases, businessID int, country, preferred string) []string {
+	preference := GetGatewayPreference(db, businessID, country)
+	return orderGateways(preferred, preference.PaymentGatewayList())
+}
+
+func DisbursementGatewayOrder(db postgresql.Databases, businessID int, country, preferred string) []string {
+	preference := GetGatewayPreference(db, businessID, country)
+	return orderGateways(preferred, preference.DisbursementGatewayList())
+}
+
+// AvailableGateways filters out disabled gateways and moves degraded ones behind the healthy ones, keeping the given order otherwise.
+func AvailableGateways(names ...string) []string {
+	var (
+		healthy  = []string{}
+		degraded = []string{}
+		seen     = map[string]bool{}
+	)
+
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] || !IsGatewayAvailable(name) {
+			continue
+		}
+		seen[name] = true
+		if IsGatewayDegraded(name) {
+			degraded = append(degraded, name)
+		} else {
+			healthy = append(healthy, name)
+		}
+	}
+
+	return append(healthy, degraded...)
+}
+
+func orderGateways(preferred string, fallbacks []string) []string {
+	ordered := AvailableGateways(append([]string{preferred}, fallbacks...)...)
+	if len(ordered) == 0 {
+		ordered = AvailableGateways(DefaultGateway)
+	}
+	return ordered
+}
+
+func NewPaymentReference(gateway string) string {
+	if gateway == "campay" {
+		id, _ := uuid.NewV4()
+		return id.String()
+	}
+	return fmt.Sprintf("VC%v", strconv.Itoa(utility.GetRandomNumbersInRange(1000000000, 9999999999)))
+}
+
+// InitPaymentWithFailover tries each gateway in order until one initiates the payment.
+// buildRequest is called once per attempt with a reference generated for that gateway.
+func InitPaymentWithFailover(extReq request.ExternalRequest, gateways []string, buildRequest func(gateway, reference string) GatewayPaymentRequest) (string, string, string, interface{}, error) {
+	var lastErr error = fmt.Errorf("no payment gateway available")
+
+	for _, name := range gateways {
+		gateway, err := FindGateway(extReq, name)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		reference := NewPaymentReference(gateway.Name())
+		paymentUrl, paymentRequest, err := gateway.InitPayment(buildRequest(gateway.Name(), reference))
+		if err == nil {
+			return gateway.Name(), reference, paymentUrl, paymentRequest, nil
+		}
+
+		extReq.Logger.Error(fmt.Sprintf("initiating payment with %v failed: %v", gateway.Name(), err.Error()))
+		lastErr = err
+	}
+
+	return "", "", "", nil, lastErr
+}
+
+// InitTransferWithFailover tries each gateway in order until one accepts the transfer.
+// It only moves on to the next gateway once the failed gateway confirms that no transfer was created
+// for the reference, otherwise it stops and returns the error so the payout is not sent twice.
+// beforeAttempt is called with each gateway name before the transfer is sent.
+func InitTransferWithFailover(extReq request.ExternalRequest, gateways []string, req GatewayTransferRequest, beforeAttempt func(gateway string) error) (string, GatewayTransferResponse, error) {
+	var lastErr error = fmt.Errorf("no disbursement gateway available")
+
+	for _, name := range gateways {
+		gateway, err := FindGateway(extReq, name)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		err = beforeAttempt(gateway.Name())
+		if err != nil {
+			return gateway.Name(), GatewayTransferResponse{}, err
+		}
+
+		resData, err := gateway.InitTransfer(req)
+		if err == nil {
+			return gateway.Name(), resData, nil
+		}
+		extReq.Logger.Error(fmt.Sprintf("initiating transfer %v with %v failed: %v", req.Reference, gateway.Name(), err.Error()))
+
+		if !errors.Is(err, ErrGatewayOperationNotSupported) {
+			exists, checkErr := gateway.TransferExists(req.Reference)
+			if checkErr != nil || exists {
+				extReq.Logger.Info(fmt.Sprintf("transfer %v may have been created on %v, not falling back", req.Reference, gateway.Name()))
+				return gateway.Name(), GatewayTransferResponse{}, err
+			}
+		}
+
+		lastErr = err
+	}
+
+	return "", GatewayTransferResponse{}, lastErr
+}
+
+func isExternalNotFound(err error) bool {
+	var reqErr *external.RequestError
+	return errors.As(err, &reqErr) && reqErr.Code == http.StatusNotFound
+}
+
+// isExternalClientError reports whether the gateway answered with a 4xx, which means it is up but refused the request.
+func isExternalClientError(err error) bool {
+	var reqErr *external.RequestError
+	return errors.As(err, &reqErr) && reqErr.Code >= http.StatusBadRequest && reqErr.Code < http.StatusInternalServerError
+}
@@ -0,0 +1,383 @@
+package payment
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vesicash/payment-ms/external"
+	"github.com/vesicash/payment-ms/external/request"
+	"github.com/vesicash/payment-ms/internal/config"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+type gatewayCall struct {
+	failed  bool
+	latency time.Duration
+}
+
+// gatewayWindow keeps the most recent calls made to a gateway, oldest calls are overwritten first.
+type gatewayWindow struct {
+	calls     []gatewayCall
+	next      int
+	lastError string
+}
+
+type GatewayStats struct {
+	Calls            int
+	FailedCalls      int
+	ErrorRate        float64
+	AverageLatencyMs int64
+	LastError        string
+}
+
+var (
+	healthMutex    = &sync.Mutex{}
+	gatewayWindows = map[string]*gatewayWindow{}
+	autoStatuses   = map[string]string{}
+)
+
+func gatewayHealthSettings() config.GatewayHealth {
+	settings := config.GetConfig().GatewayHealth
+	if settings.WindowSize <= 0 {
+		settings.WindowSize = 50
+	}
+	if settings.MinCalls <= 0 {
+		settings.MinCalls = 20
+	}
+	if settings.ErrorRateThreshold <= 0 {
+		settings.ErrorRateThreshold = 50
+	}
+	if settings.LatencyThresholdMs <= 0 {
+		settings.LatencyThresholdMs = 10000
+	}
+	if settings.RecoveryCooldownSeconds <= 0 {
+		settings.RecoveryCooldownSeconds = 300
+	}
+	return settings
+}
+
+// GatewayProber is implemented by gateways that can be checked with a single cheap request while they are disabled.
+type GatewayProber interface {
+	Probe() error
+}
+
+// gatewayProbeReference is looked up by probes that need a reference, a not found response still means the gateway is up.
+const gatewayProbeReference = "vesicash-health-probe"
+
+func recordGatewayCall(gateway string, failed bool, latency time.Duration, errMsg string) GatewayStats {
+	healthMutex.Lock()
+	defer healthMutex.Unlock()
+
+	window, ok := gatewayWindows[gateway]
+	if !ok {
+		window = &gatewayWindow{}
+		gatewayWindows[gateway] = window
+	}
+
+	size := gatewayHealthSettings().WindowSize
+	call := gatewayCall{failed: failed, latency: latency}
+	if len(window.calls) < size {
+		window.calls = append(window.calls, call)
+	} else {
+		window.calls[window.next%len(window.calls)] = call
+		window.next = (window.next + 1) % len(window.calls)
+	}
+	if failed {
+		window.lastError = errMsg
+	}
+
+	return window.stats()
+}
+
+func (w *gatewayWindow) stats() GatewayStats {
+	stats := GatewayStats{Calls: len(w.calls), LastError: w.lastError}
+	if stats.Calls == 0 {
+		return stats
+	}
+
+	var totalLatency time.Duration
+	for _, call := range w.calls {
+		if call.failed {
+			stats.FailedCalls++
+		}
+		totalLatency += call.latency
+	}
+	stats.ErrorRate = float64(stats.FailedCalls) * 100 / float64(stats.Calls)
+	stats.AverageLatencyMs = (totalLatency / time.Duration(stats.Calls)).Milliseconds()
+	return stats
+}
+
+func GetGatewayStats(gateway string) GatewayStats {
+	healthMutex.Lock()
+	defer healthMutex.Unlock()
+	window, ok := gatewayWindows[gateway]
+	if !ok {
+		return GatewayStats{}
+	}
+	return window.stats()
+}
+
+func resetGatewayStats(gateway string) {
+	healthMutex.Lock()
+	defer healthMutex.Unlock()
+	delete(gatewayWindows, gateway)
+	delete(autoStatuses, gateway)
+}
+
+func GatewayStatus(gateway string) string {
+	if !IsGatewayAvailable(gateway) {
+		return "disabled"
+	}
+	if IsGatewayDegraded(gateway) {
+		return "degraded"
+	}
+	return "enabled"
+}
+
+// StartGatewayHealthMonitor loads the stored gateway flags and records every request a gateway reports sending.
+func StartGatewayHealthMonitor(extReq request.ExternalRequest, db postgresql.Databases) {
+	LoadGatewayHealth(extReq, db)
+
+	external.AddGatewayObserver(func(gateway, name string, statusCode int, latency time.Duration, err error) {
+		// client errors are caused by the request, not the gateway
+		failed := err != nil && (statusCode == 0 || statusCode >= http.StatusInternalServerError)
+		errMsg := ""
+		if failed {
+			errMsg = err.Error()
+		}
+
+		stats := recordGatewayCall(gateway, failed, latency, errMsg)
+		evaluateGatewayHealth(extReq, db, gateway, stats)
+	})
+}
+
+func evaluateGatewayHealth(extReq request.ExternalRequest, db postgresql.Databases, gateway string, stats GatewayStats) {
+	var (
+		settings = gatewayHealthSettings()
+		status   = GatewayStatus(gateway)
+	)
+
+	if stats.Calls < settings.MinCalls || status == "disabled" {
+		return
+	}
+
+	healthMutex.Lock()
+	autoStatus := autoStatuses[gateway]
+	healthMutex.Unlock()
+
+	newStatus, reason := status, ""
+	if stats.ErrorRate >= settings.ErrorRateThreshold {
+		newStatus, reason = "disabled", fmt.Sprintf("error rate %.2f%% over the last %v calls", stats.ErrorRate, stats.Calls)
+	} else if stats.AverageLatencyMs >= settings.LatencyThresholdMs {
+		newStatus, reason = "degraded", fmt.Sprintf("average latency %vms over the last %v calls", stats.AverageLatencyMs, stats.Calls)
+	} else if status == "degraded" && autoStatus == "degraded" {
+		newStatus, reason = "enabled", "latency back within threshold"
+	}
+
+	if newStatus == status {
+		return
+	}
+
+	SetGatewayStatus(gateway, newStatus)
+	healthMutex.Lock()
+	autoStatuses[gateway] = newStatus
+	healthMutex.Unlock()
+
+	extReq.Logger.Info(fmt.Sprintf("gateway %v automatically marked %v: %v", gateway, newStatus, reason))
+	go func() {
+		_, err := saveGatewayHealth(db, gateway, newStatus, reason, "auto", stats)
+		if err != nil {
+			extReq.Logger.Error(fmt.Sprintf("error saving gateway health for %v: %v", gateway, err.Error()))
+		}
+		notifyGatewayHealth(extReq, gateway, newStatus, reason, "auto")
+	}()
+}
+
+func saveGatewayHealth(db postgresql.Databases, gateway, status, reason, updatedBy string, stats GatewayStats) (models.GatewayHealth, error) {
+	health := models.GatewayHealth{Gateway: gateway}
+	code, err := health.GetGatewayHealthByGateway(db.Payment)
+	if err != nil && code == http.StatusInternalServerError {
+		return health, err
+	}
+
+	if status != "" {
+		health.Status = status
+		health.Reason = reason
+		health.UpdatedBy = updatedBy
+	}
+	if health.Status == "" {
+		health.Status = "enabled"
+	}
+	health.TotalCalls = stats.Calls
+	health.FailedCalls = stats.FailedCalls
+	health.ErrorRate = stats.ErrorRate
+	health.AverageLatencyMs = stats.AverageLatencyMs
+	health.LastError = stats.LastError
+
+	if health.ID == 0 {
+		err = health.CreateGatewayHealth(db.Payment)
+	} else {
+		err = health.UpdateAllFields(db.Payment)
+	}
+	return health, err
+}
+
+func notifyGatewayHealth(extReq request.ExternalRequest, gateway, status, reason, updatedBy string) {
+	err := SlackNotify(extReq, config.GetConfig().Slack.PaymentChannelID, `
+			Gateway `+strings.ToUpper(gateway)+` has been marked `+strings.ToUpper(status)+`.
+			Environment: `+config.GetConfig().App.Name+`
+			Updated By: `+updatedBy+`
+			Reason: `+reason+`
+			`)
+	if err != nil && !extReq.Test {
+		extReq.Logger.Error("error sending notification to slack: ", err.Error())
+	}
+}
+
+// LoadGatewayHealth applies the flags stored in the database to the gateway registry,
+// so a gateway turned off on one instance is turned off on all of them.
+func LoadGatewayHealth(extReq request.ExternalRequest, db postgresql.Databases) {
+	health := models.GatewayHealth{}
+	healthList, err := health.GetAllGatewayHealth(db.Payment)
+	if err != nil {
+		extReq.Logger.Error(fmt.Sprintf("error loading gateway health: %v", err.Error()))
+		return
+	}
+
+	for _, item := range healthList {
+		SetGatewayStatus(item.Gateway, item.Status)
+	}
+}
+
+// SyncGatewayHealth reloads the stored flags, probes gateways that were disabled automatically
+// and saves the current call statistics for every gateway.
+func SyncGatewayHealth(extReq request.ExternalRequest, db postgresql.Databases) {
+	LoadGatewayHealth(extReq, db)
+	ProbeDisabledGateways(extReq, db)
+
+	for _, gateway := range GatewayNames() {
+		stats := GetGatewayStats(gateway)
+		if stats.Calls == 0 {
+			continue
+		}
+
+		_, err := saveGatewayHealth(db, gateway, "", "", "", stats)
+		if err != nil {
+			extReq.Logger.Error(fmt.Sprintf("error saving gateway health for %v: %v", gateway, err.Error()))
+		}
+	}
+}
+
+// ProbeDisabledGateways gives every gateway that was disabled automatically a half-open check once the cooldown has passed:
+// a single probe is sent and the gateway is enabled again if it succeeds, otherwise it stays disabled for another cooldown.
+// Gateways disabled by an admin are left for an admin to enable.
+func ProbeDisabledGateways(extReq request.ExternalRequest, db postgresql.Databases) {
+	var (
+		health   = models.GatewayHealth{}
+		cooldown = time.Duration(gatewayHealthSettings().RecoveryCooldownSeconds) * time.Second
+	)
+
+	healthList, err := health.GetAllGatewayHealth(db.Payment)
+	if err != nil {
+		extReq.Logger.Error(fmt.Sprintf("error loading gateway health: %v", err.Error()))
+		return
+	}
+
+	for _, item := range healthList {
+		if item.Status != "disabled" || item.UpdatedBy != "auto" || time.Since(item.UpdatedAt) < cooldown {
+			continue
+		}
+
+		gateway, err := constructGateway(extReq, item.Gateway)
+		if err != nil {
+			continue
+		}
+		prober, ok := gateway.(GatewayProber)
+		if !ok {
+			continue
+		}
+
+		err = prober.Probe()
+		if err != nil && !isExternalClientError(err) {
+			reason := fmt.Sprintf("recovery probe failed: %v", err.Error())
+			extReq.Logger.Info(fmt.Sprintf("gateway %v stays disabled: %v", item.Gateway, reason))
+			_, err = saveGatewayHealth(db, item.Gateway, "disabled", reason, "auto", GetGatewayStats(item.Gateway))
+			if err != nil {
+				extReq.Logger.Error(fmt.Sprintf("error saving gateway health for %v: %v", item.Gateway, err.Error()))
+			}
+			continue
+		}
+
+		reason := "recovery probe succeeded"
+		resetGatewayStats(item.Gateway)
+		SetGatewayStatus(item.Gateway, "enabled")
+		extReq.Logger.Info(fmt.Sprintf("gateway %v automatically marked enabled: %v", item.Gateway, reason))
+
+		_, err = saveGatewayHealth(db, item.Gateway, "enabled", reason, "auto", GatewayStats{})
+		if err != nil {
+			extReq.Logger.Error(fmt.Sprintf("error saving gateway health for %v: %v", item.Gateway, err.Error()))
+		}
+		notifyGatewayHealth(extReq, item.Gateway, "enabled", reason, "auto")
+	}
+}
+
+func ListGatewayHealthService(db postgresql.Databases) ([]models.GatewayHealth, int, error) {
+	var (
+		health     = models.GatewayHealth{}
+		healthList = []models.GatewayHealth{}
+	)
+
+	stored, err := health.GetAllGatewayHealth(db.Payment)
+	if err != nil {
+		return healthList, http.StatusInternalServerError, err
+	}
+
+	storedMap := map[string]models.GatewayHealth{}
+	for _, item := range stored {
+		storedMap[item.Gateway] = item
+	}
+
+	for _, gateway := range GatewayNames() {
+		item, ok := storedMap[gateway]
+		if !ok {
+			item = models.GatewayHealth{Gateway: gateway}
+		}
+
+		stats := GetGatewayStats(gateway)
+		item.Status = GatewayStatus(gateway)
+		if stats.Calls > 0 {
+			item.TotalCalls = stats.Calls
+			item.FailedCalls = stats.FailedCalls
+			item.ErrorRate = stats.ErrorRate
+			item.AverageLatencyMs = stats.AverageLatencyMs
+			item.LastError = stats.LastError
+		}
+		healthList = append(healthList, item)
+	}
+
+	return healthList, http.StatusOK, nil
+}
+
+func UpdateGatewayHealthService(extReq request.ExternalRequest, db postgresql.Databases, req models.UpdateGatewayHealthRequest) (models.GatewayHealth, int, error) {
+	gateway := strings.ToLower(req.Gateway)
+	if !utility.InStringSlice(gateway, GatewayNames()) {
+		return models.GatewayHealth{}, http.StatusBadRequest, fmt.Errorf("gateway %v not supported", req.Gateway)
+	}
+
+	// clear the window so errors from before the change do not disable the gateway again
+	resetGatewayStats(gateway)
+	SetGatewayStatus(gateway, req.Status)
+
+	health, err := saveGatewayHealth(db, gateway, req.Status, req.Reason, "admin", GatewayStats{})
+	if err != nil {
+		return health, http.StatusInternalServerError, err
+	}
+
+	notifyGatewayHealth(extReq, gateway, req.Status, req.Reason, "admin")
+	return health, http.StatusOK, nil
+}
@@ -0,0 +1,173 @@
+package payment
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/vesicash/payment-ms/external/request"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"gorm.io/gorm"
+)
+
+type LedgerAccount string
+type LedgerEntryType string
+
+// LedgerPosting names the account on the other side of a wallet movement and the reference it is recorded under.
+// AccountID is only set for accounts kept per user, like wallet holds.
+type LedgerPosting struct {
+	Account   LedgerAccount
+	AccountID int
+	Reference string
+}
+
+var (
+	LedgerWalletAccount         LedgerAccount = "wallet"
+	LedgerExternalAccount       LedgerAccount = "external"
+	LedgerPayoutAccount         LedgerAccount = "payout"
+	LedgerTransferAccount       LedgerAccount = "wallet_transfer"
+	LedgerOpeningBalanceAccount LedgerAccount = "opening_balance"
+	LedgerHoldAccount           LedgerAccount = "wallet_hold"
+	LedgerDisputeAccount        LedgerAccount = "dispute"
+
+	LedgerWalletCredit   LedgerEntryType = "wallet_credit"
+	LedgerWalletDebit    LedgerEntryType = "wallet_debit"
+	LedgerOpeningBalance LedgerEntryType = "opening_balance"
+	LedgerHoldCapture    LedgerEntryType = "hold_capture"
+)
+
+func walletLockKey(accountID int, currency string) string {
+	return fmt.Sprintf("wallet:%v:%v", accountID, strings.ToUpper(currency))
+}
+
+func getLedgerPosting(postings []LedgerPosting, defaultAccount LedgerAccount) LedgerPosting {
+	posting := LedgerPosting{Account: defaultAccount}
+	if len(postings) > 0 {
+		posting = postings[0]
+		if posting.Account == "" {
+			posting.Account = defaultAccount
+		}
+	}
+	return posting
+}
+
+func walletLedgerLine(accountID int, currency string, debit, credit float64) models.LedgerLine {
+	return models.LedgerLine{Account: string(LedgerWalletAccount), AccountID: accountID, Currency: currency, Debit: debit, Credit: credit}
+}
+
+func systemLedgerLine(account LedgerAccount, currency string, debit, credit float64) models.LedgerLine {
+	return models.LedgerLine{Account: string(account), Currency: currency, Debit: debit, Credit: credit}
+}
+
+func postingLedgerLine(posting LedgerPosting, currency string, debit, credit float64) models.LedgerLine {
+	return models.LedgerLine{Account: string(posting.Account), AccountID: posting.AccountID, Currency: currency, Debit: debit, Credit: credit}
+}
+
+// PostLedgerEntry saves a journal entry after checking that its debits and credits balance in every currency.
+func PostLedgerEntry(db *gorm.DB, entry models.LedgerEntry) error {
+	if len(entry.Lines) < 2 {
+		return fmt.Errorf("ledger entry needs at least two lines")
+	}
+
+	totals := map[string]float64{}
+	for _, line := range entry.Lines {
+		if line.Debit < 0 || line.Credit < 0 {
+			return fmt.Errorf("ledger line amounts cannot be negative")
+		}
+		totals[line.Currency] += line.Debit - line.Credit
+	}
+
+	for currency, total := range totals {
+		if math.Abs(total) >= 0.005 {
+			return fmt.Errorf("ledger entry is not balanced for %v, difference: %v", currency, total)
+		}
+	}
+
+	return entry.CreateLedgerEntry(db)
+}
+
+// postWalletLedgerEntry records a wallet movement, amount is positive for credits and negative for debits.
+// Wallets that moved before the ledger existed first get an opening balance entry for previousBalance.
+func postWalletLedgerEntry(db *gorm.DB, accountID int, currency string, previousBalance, amount float64, posting LedgerPosting, transactionID string) error {
+	walletLine := models.LedgerLine{Account: string(LedgerWalletAccount), AccountID: accountID, Currency: currency}
+	if previousBalance != 0 && !walletLine.CheckLedgerLineExistsForAccount(db) {
+		entry := models.LedgerEntry{
+			Type:        string(LedgerOpeningBalance),
+			Reference:   walletLockKey(accountID, currency),
+			Description: fmt.Sprintf("opening balance of %v %v", currency, previousBalance),
+		}
+		if previousBalance > 0 {
+			entry.Lines = []models.LedgerLine{
+				systemLedgerLine(LedgerOpeningBalanceAccount, currency, previousBalance, 0),
+				walletLedgerLine(accountID, currency, 0, previousBalance),
+			}
+		} else {
+			entry.Lines = []models.LedgerLine{
+				walletLedgerLine(accountID, currency, -previousBalance, 0),
+				systemLedgerLine(LedgerOpeningBalanceAccount, currency, 0, -previousBalance),
+			}
+		}
+
+		err := PostLedgerEntry(db, entry)
+		if err != nil {
+			return err
+		}
+	}
+
+	if amount == 0 {
+		return nil
+	}
+
+	entry := models.LedgerEntry{
+		Reference:     posting.Reference,
+		TransactionID: transactionID,
+	}
+	if entry.Reference == "" {
+		entry.Reference = transactionID
+	}
+
+	if amount > 0 {
+		entry.Type = string(LedgerWalletCredit)
+		entry.Description = fmt.Sprintf("credit of %v %v to wallet of account %v from %v", currency, amount, accountID, posting.Account)
+		entry.Lines = []models.LedgerLine{
+			postingLedgerLine(posting, currency, amount, 0),
+			walletLedgerLine(accountID, currency, 0, amount),
+		}
+	} else {
+		entry.Type = string(LedgerWalletDebit)
+		entry.Description = fmt.Sprintf("debit of %v %v from wallet of account %v to %v", currency, -amount, accountID, posting.Account)
+		entry.Lines = []models.LedgerLine{
+			walletLedgerLine(accountID, currency, -amount, 0),
+			postingLedgerLine(posting, currency, 0, -amount),
+		}
+	}
+
+	return PostLedgerEntry(db, entry)
+}
+
+// GetLedgerBalanceService rebuilds a wallet balance from the ledger and compares it with the balance held by the auth service.
+func GetLedgerBalanceService(extReq request.ExternalRequest, db postgresql.Databases, req models.GetLedgerBalanceRequest) (models.LedgerBalanceResponse, int, error) {
+	var (
+		currency = strings.ToUpper(req.Currency)
+		response = models.LedgerBalanceResponse{AccountID: req.AccountID, Currency: currency}
+		line     = models.LedgerLine{Account: string(LedgerWalletAccount), AccountID: req.AccountID, Currency: currency}
+	)
+
+	walletBalance, err := GetWalletBalanceByAccountIdAndCurrency(extReq, req.AccountID, currency)
+	if err != nil {
+		return response, http.StatusBadRequest, fmt.Errorf("wallet does not exist: %v", err.Error())
+	}
+
+	ledgerBalance, err := line.GetLedgerBalance(db.Payment)
+	if err != nil {
+		return response, http.StatusInternalServerError, err
+	}
+
+	response.LedgerBalance = ledgerBalance
+	response.WalletBalance = walletBalance.Available
+	response.Difference = math.Round((walletBalance.Available-ledgerBalance)*100) / 100
+	response.Balanced = response.Difference == 0
+	return response, http.StatusOK, nil
+}
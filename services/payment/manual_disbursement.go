@@ -0,0 +1,164 @@
+package payment
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vesicash/payment-ms/external/external_models"
+	"github.com/vesicash/payment-ms/external/request"
+	"github.com/vesicash/payment-ms/internal/config"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"github.com/vesicash/payment-ms/utility"
+	"gorm.io/gorm"
+)
+
+func ListManualDisbursementsService(db postgresql.Databases, paginator postgresql.Pagination) ([]models.Disbursement, postgresql.PaginationResponse, int, error) {
+	disbursement := models.Disbursement{Status: string(DisbursementManual)}
+	disbursements, pagination, err := disbursement.GetDisbursementsByStatus(db.Payment, paginator)
+	if err != nil {
+		return disbursements, pagination, http.StatusInternalServerError, err
+	}
+	return disbursements, pagination, http.StatusOK, nil
+}
+
+// adminDisbursementAction runs action under the disbursement lock when the disbursement is in status, action is passed the admin actor.
+func adminDisbursementAction(c *gin.Context, extReq request.ExternalRequest, db postgresql.Databases, disbursementID int, status DisbursementStatus, action func(disbursement *models.Disbursement, actor string) (int, error)) (models.Disbursement, int, error) {
+	var (
+		disbursement = models.Disbursement{DisbursementID: disbursementID}
+		code         = http.StatusInternalServerError
+	)
+
+	accessToken, err := GetAccessTokenByKeyFromRequest(extReq, c)
+	if err != nil {
+		return disbursement, http.StatusUnauthorized, fmt.Errorf("could not identify admin: %v", err.Error())
+	}
+
+	err = postgresql.RunWithAdvisoryLock(db.Payment, disbursementLockKey(disbursementID), func(tx *gorm.DB) error {
+		var err error
+		code, err = disbursement.GetDisbursementByDisbursementID(db.Payment)
+		if err != nil {
+			return fmt.Errorf("disbursement not found: %v", err.Error())
+		}
+
+		if disbursement.Status != string(status) {
+			code = http.StatusBadRequest
+			return fmt.Errorf("disbursement is %v, only %v disbursements can be processed", disbursement.Status, status)
+		}
+
+		code, err = action(&disbursement, fmt.Sprintf("admin:%v", accessToken.AccountID))
+		return err
+	})
+	if err != nil {
+		return disbursement, code, err
+	}
+	return disbursement, http.StatusOK, nil
+}
+
+// CompleteManualDisbursementService records a manual disbursement that was paid outside the platform.
+// The proof of payment is saved with the upload service and the transaction is closed like an automated payout.
+func CompleteManualDisbursementService(c *gin.Context, extReq request.ExternalRequest, db postgresql.Databases, req models.CompleteManualDisbursementRequest, proofName string, proof []byte) (models.Disbursement, int, error) {
+	return adminDisbursementAction(c, extReq, db, req.DisbursementID, DisbursementManual, func(disbursement *models.Disbursement, actor string) (int, error) {
+		fileItf, err := extReq.SendExternalRequest(request.UploadFile, external_models.UploadFileRequest{
+			PlaceHolderName: proofName,
+			File:            proof,
+		})
+		if err != nil {
+			return http.StatusInternalServerError, fmt.Errorf("proof of payment could not be uploaded: %v", err.Error())
+		}
+
+		fileData, ok := fileItf.(external_models.UploadFileResponseData)
+		if !ok {
+			return http.StatusInternalServerError, fmt.Errorf("response data format error")
+		}
+
+		disbursement.ProofUrl = fileData.FileUrl
+		disbursement.PaymentReleasedAt = time.Now().Format("2006-01-02 15:04:05")
+		err = TransitionDisbursement(db, disbursement, DisbursementCompleted, actor, map[string]interface{}{
+			"proof_url": disbursement.ProofUrl,
+			"comment":   req.Comment,
+		})
+		if err != nil {
+			return http.StatusBadRequest, err
+		}
+
+		if disbursement.Type == SettlementDisbursementType {
+			CompleteSettlementBatch(extReq, db, *disbursement)
+			return http.StatusOK, nil
+		}
+
+		if disbursement.PaymentID == "" || disbursement.PaymentID == "0" {
+			SettleDisbursementHold(extReq, db, disbursement.Reference, true)
+			return http.StatusOK, nil
+		}
+
+		paymnt := models.Payment{PaymentID: disbursement.PaymentID}
+		_, err = paymnt.GetPaymentByPaymentID(db.Payment)
+		if err != nil {
+			extReq.Logger.Error(fmt.Sprintf("error getting payment for payment id: %v, error: %v", disbursement.PaymentID, err.Error()))
+			return http.StatusOK, nil
+		}
+
+		transaction, _ := ListTransactionsByID(extReq, paymnt.TransactionID)
+		CloseDisbursedTransaction(extReq, db, *disbursement, paymnt, transaction)
+		return http.StatusOK, nil
+	})
+}
+
+// FailManualDisbursementService marks a manual disbursement that could not be paid as failed.
+func FailManualDisbursementService(c *gin.Context, extReq request.ExternalRequest, db postgresql.Databases, req models.FailManualDisbursementRequest) (models.Disbursement, int, error) {
+	return adminDisbursementAction(c, extReq, db, req.DisbursementID, DisbursementManual, func(disbursement *models.Disbursement, actor string) (int, error) {
+		disbursement.FailureReason = req.Reason
+		err := TransitionDisbursement(db, disbursement, DisbursementFailed, actor, map[string]interface{}{"reason": req.Reason})
+		if err != nil {
+			return http.StatusBadRequest, err
+		}
+		SettleDisbursementHold(extReq, db, disbursement.Reference, false)
+		FailSettlementBatch(extReq, db, *disbursement, req.Reason)
+
+		err = SlackNotify(extReq, config.GetConfig().Slack.DisbursementChannelID, `
+			Manual Disbursement `+fmt.Sprintf("%v", disbursement.DisbursementID)+` has been marked as failed.
+			Environment: `+config.GetConfig().App.Name+`
+			Account ID: `+fmt.Sprintf("%v", disbursement.RecipientID)+`
+			Beneficiary Name: `+disbursement.BeneficiaryName+`
+			Amount: `+fmt.Sprintf("%v %v", disbursement.DebitCurrency, disbursement.Amount)+`
+			Reason: `+req.Reason+`
+			Admin: `+actor+`
+			`)
+		if err != nil && !extReq.Test {
+			extReq.Logger.Error("error sending notification to slack: ", err.Error())
+		}
+		return http.StatusOK, nil
+	})
+}
+
+// RerouteManualDisbursementService sends a manual disbursement through the chosen gateway,
+// the disbursement job then follows it like any other pending disbursement.
+func RerouteManualDisbursementService(c *gin.Context, extReq request.ExternalRequest, db postgresql.Databases, req models.RerouteManualDisbursementRequest) (models.Disbursement, int, error) {
+	gateway := strings.ToLower(req.Gateway)
+	if !utility.InStringSlice(gateway, GatewayNames()) {
+		return models.Disbursement{}, http.StatusBadRequest, fmt.Errorf("gateway %v not supported", req.Gateway)
+	}
+	if !IsGatewayAvailable(gateway) {
+		return models.Disbursement{}, http.StatusBadRequest, fmt.Errorf("gateway %v is disabled", req.Gateway)
+	}
+
+	return adminDisbursementAction(c, extReq, db, req.DisbursementID, DisbursementManual, func(disbursement *models.Disbursement, actor string) (int, error) {
+		previousGateway := disbursement.Gateway
+		disbursement.Gateway = gateway
+		resData, err := InitDisbursementTransfer(extReq, db, disbursement)
+		if err != nil {
+			disbursement.Gateway = previousGateway
+			return http.StatusBadGateway, fmt.Errorf("disbursement could not be sent through %v: %v", gateway, err.Error())
+		}
+
+		err = TransitionDisbursement(db, disbursement, DisbursementPending, actor, resData.Response)
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+		return http.StatusOK, nil
+	})
+}
@@ -150,3 +150,61 @@ func (m *Monnify) InitTransfer(amount float64, reference, narration, destination
 
 	return data, nil
 }
+
+func (m *Monnify) InitBulkTransfer(title, batchReference, narration string, transfers []external_models.MonnifyBulkTransferRequestItem) (external_models.MonnifyInitBulkTransferResponse, error) {
+	reqData := external_models.MonnifyInitBulkTransferRequest{
+		Title:                title,
+		BatchReference:       batchReference,
+		Narration:            narration,
+		SourceAccountNumber:  config.GetConfig().Monnify.MonnifyDisbursementAccount,
+		OnValidationFailure:  "CONTINUE",
+		NotificationInterval: 25,
+		TransactionList:      transfers,
+	}
+	transferItf, err := m.ExtReq.SendExternalRequest(request.MonnifyInitBulkTransfer, reqData)
+	if err != nil {
+		return external_models.MonnifyInitBulkTransferResponse{}, err
+	}
+
+	data, ok := transferItf.(external_models.MonnifyInitBulkTransferResponse)
+	if !ok {
+		return external_models.MonnifyInitBulkTransferResponse{}, fmt.Errorf("response data format error")
+	}
+
+	return data, nil
+}
+
+// BulkTransfers returns every transfer of a batch disbursement, one page at a time.
+func (m *Monnify) BulkTransfers(batchReference string) ([]external_models.MonnifyInitTransferResponseBody, error) {
+	transfers := []external_models.MonnifyInitTransferResponseBody{}
+	for page := 0; ; page++ {
+		transferItf, err := m.ExtReq.SendExternalRequest(request.MonnifyGetBulkTransfer, external_models.MonnifyGetBulkTransferRequest{BatchReference: batchReference, PageNo: page, PageSize: 100})
+		if err != nil {
+			return transfers, err
+		}
+
+		data, ok := transferItf.(external_models.MonnifyGetBulkTransferResponseBody)
+		if !ok {
+			return transfers, fmt.Errorf("response data format error")
+		}
+
+		transfers = append(transfers, data.Content...)
+		if data.Last || page+1 >= data.TotalPages || len(data.Content) == 0 {
+			return transfers, nil
+		}
+	}
+}
+
+func (m *Monnify) TransferSummary(reference string) (external_models.MonnifyInitTransferResponseBody, error) {
+	transferItf, err := m.ExtReq.SendExternalRequest(request.MonnifyGetTransferSummary, reference)
+	if err != nil {
+		return external_models.MonnifyInitTransferResponseBody{}, err
+	}
+
+	data, ok := transferItf.(external_models.MonnifyInitTransferResponseBody)
+	if !ok {
+		return data, fmt.Errorf("response data format error")
+	}
+
+	return data, nil
+}
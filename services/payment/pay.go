@@ -8,7 +8,6 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/gofrs/uuid"
 	"github.com/vesicash/payment-ms/external/request"
 	"github.com/vesicash/payment-ms/internal/config"
 	"github.com/vesicash/payment-ms/internal/models"
@@ -20,9 +19,6 @@ func InitiatePaymentService(c *gin.Context, extReq request.ExternalRequest, db p
 	var (
 		payment                     = models.Payment{TransactionID: req.TransactionID}
 		paymentGateway              = req.PaymentGateway
-		reference                   = ""
-		rave                        = Rave{ExtReq: extReq}
-		monnify                     = Monnify{ExtReq: extReq}
 		response                    = models.InitiatePaymentResponse{}
 		maxUSDAmountNigeria float64 = 100
 		onlinePayment               = config.GetConfig().ONLINE_PAYMENT
@@ -102,13 +98,6 @@ func InitiatePaymentService(c *gin.Context, extReq request.ExternalRequest, db p
 		paymentGateway = businessCharge.PaymentGateway
 	}
 
-	if paymentGateway == "campay" {
-		id, _ := uuid.NewV4()
-		reference = id.String()
-	} else {
-		reference = fmt.Sprintf("VC%v", strconv.Itoa(utility.GetRandomNumbersInRange(1000000000, 9999999999)))
-	}
-
 	if payment.IsPaid {
 		return response, http.StatusBadRequest, fmt.Errorf("transaction has been paid for")
 	}
@@ -158,46 +147,37 @@ func InitiatePaymentService(c *gin.Context, extReq request.ExternalRequest, db p
 		buyerUserProfile.Country = "NG"
 	}
 
-	successPage, err := utility.URLDecode(req.SuccessPage)
+	decodedSuccessPage, err := utility.URLDecode(req.SuccessPage)
 	if err != nil {
 		return response, http.StatusBadRequest, fmt.Errorf("error decoding success_page: %v", err.Error())
 	}
-	utility.AddQueryParam(&successPage, "reference", reference)
 
 	paymentRef := ""
+	successPage := ""
 
-	if paymentGateway == "" {
-		paymentGateway = "rave"
-	}
-
+	gateways := PaymentGatewayOrder(db, transaction.BusinessID, countryCode, paymentGateway)
 	if paymentGateway != "wallet" {
 		if strings.ToUpper(transaction.Currency) == "USD" {
-			paymentGateway = "rave"
+			gateways = AvailableGateways("rave")
 		}
 	}
 
-	var (
-		paymentUrl     = ""
-		paymentRequest interface{}
-		callback       = utility.GenerateGroupByURL(config.GetConfig().App.Url, "/pay/status", map[string]string{"gateway": paymentGateway, "reference": reference, "success_page": successPage})
-	)
-
-	switch strings.ToLower(paymentGateway) {
-	case "rave":
-		paymentUrl, paymentRequest, err = rave.InitPayment(reference, buyer.EmailAddress, strings.ToUpper(transaction.Currency), callback, amount)
-		if err != nil {
-			return response, http.StatusInternalServerError, fmt.Errorf("initiating payment failed: %v", err.Error())
-		}
-	case "monnify":
-		paymentUrl, paymentRequest, err = monnify.InitPayment(amount, fmt.Sprintf("%v %v", buyer.Lastname, buyer.Firstname), buyer.EmailAddress, reference, "Payment For Vesicash", transaction.Currency, callback)
-		if err != nil {
-			return response, http.StatusInternalServerError, fmt.Errorf("initiating payment failed: %v", err.Error())
-		}
-	default:
-		paymentUrl, paymentRequest, err = rave.InitPayment(reference, buyer.EmailAddress, strings.ToUpper(transaction.Currency), callback, amount)
-		if err != nil {
-			return response, http.StatusInternalServerError, fmt.Errorf("initiating payment failed: %v", err.Error())
+	paymentGateway, reference, paymentUrl, paymentRequest, err := InitPaymentWithFailover(extReq, gateways, func(gateway, reference string) GatewayPaymentRequest {
+		successPage = decodedSuccessPage
+		utility.AddQueryParam(&successPage, "reference", reference)
+		callback := utility.GenerateGroupByURL(config.GetConfig().App.Url, "/pay/status", map[string]string{"gateway": gateway, "reference": reference, "success_page": successPage})
+		return GatewayPaymentRequest{
+			Reference:     reference,
+			CustomerName:  fmt.Sprintf("%v %v", buyer.Lastname, buyer.Firstname),
+			CustomerEmail: buyer.EmailAddress,
+			Description:   "Payment For Vesicash",
+			Currency:      strings.ToUpper(transaction.Currency),
+			RedirectUrl:   callback,
+			Amount:        amount,
 		}
+	})
+	if err != nil {
+		return response, http.StatusInternalServerError, fmt.Errorf("initiating payment failed: %v", err.Error())
 	}
 
 	// paymentUrl, paymentRequest, err := rave.InitPayment(reference, buyer.EmailAddress, transaction.Currency, successPage, amount)
@@ -254,8 +234,6 @@ func InitiatePaymentService(c *gin.Context, extReq request.ExternalRequest, db p
 func InitiatePaymentHeadlessService(c *gin.Context, extReq request.ExternalRequest, db postgresql.Databases, req models.InitiatePaymentHeadlessRequest) (models.InitiatePaymentResponse, int, error) {
 	var (
 		response                    = models.InitiatePaymentResponse{}
-		rave                        = Rave{ExtReq: extReq}
-		monnify                     = Monnify{ExtReq: extReq}
 		amount              float64 = 0
 		paymentGateway              = req.PaymentGateway
 		country                     = ""
@@ -302,13 +280,6 @@ func InitiatePaymentHeadlessService(c *gin.Context, extReq request.ExternalReque
 		amount = req.Amount + charge
 	}
 
-	if paymentGateway == "campay" {
-		id, _ := uuid.NewV4()
-		reference = id.String()
-	} else {
-		reference = fmt.Sprintf("VC%v", strconv.Itoa(utility.GetRandomNumbersInRange(1000000000, 9999999999)))
-	}
-
 	businessProfile, err := GetBusinessProfileByAccountID(extReq, extReq.Logger, accessToken.AccountID)
 	if err != nil {
 		return response, http.StatusInternalServerError, err
@@ -357,24 +328,22 @@ func InitiatePaymentHeadlessService(c *gin.Context, extReq request.ExternalReque
 		req.PaymentGateway = "rave"
 	}
 
-	callback := utility.GenerateGroupByURL(config.GetConfig().App.Url, "/pay/status", map[string]string{"gateway": paymentGateway, "reference": reference, "success_page": successPage, "failure_page": failPage, "fund_wallet": fmt.Sprintf("%v", req.FundWallet)})
+	gateways := PaymentGatewayOrder(db, businessProfile.AccountID, country, paymentGateway)
 
-	switch strings.ToLower(paymentGateway) {
-	case "rave":
-		paymentUrl, paymentRequest, err = rave.InitPayment(reference, user.EmailAddress, strings.ToUpper(req.Currency), callback, amount)
-		if err != nil {
-			return response, http.StatusInternalServerError, fmt.Errorf("initiating payment failed: %v", err.Error())
-		}
-	case "monnify":
-		paymentUrl, paymentRequest, err = monnify.InitPayment(amount, fmt.Sprintf("%v %v", user.Lastname, user.Firstname), user.EmailAddress, reference, "Payment For Vesicash", req.Currency, callback)
-		if err != nil {
-			return response, http.StatusInternalServerError, fmt.Errorf("initiating payment failed: %v", err.Error())
-		}
-	default:
-		paymentUrl, paymentRequest, err = rave.InitPayment(reference, user.EmailAddress, strings.ToUpper(req.Currency), callback, amount)
-		if err != nil {
-			return response, http.StatusInternalServerError, fmt.Errorf("initiating payment failed: %v", err.Error())
+	paymentGateway, reference, paymentUrl, paymentRequest, err = InitPaymentWithFailover(extReq, gateways, func(gateway, reference string) GatewayPaymentRequest {
+		callback := utility.GenerateGroupByURL(config.GetConfig().App.Url, "/pay/status", map[string]string{"gateway": gateway, "reference": reference, "success_page": successPage, "failure_page": failPage, "fund_wallet": fmt.Sprintf("%v", req.FundWallet)})
+		return GatewayPaymentRequest{
+			Reference:     reference,
+			CustomerName:  fmt.Sprintf("%v %v", user.Lastname, user.Firstname),
+			CustomerEmail: user.EmailAddress,
+			Description:   "Payment For Vesicash",
+			Currency:      strings.ToUpper(req.Currency),
+			RedirectUrl:   callback,
+			Amount:        amount,
 		}
+	})
+	if err != nil {
+		return response, http.StatusInternalServerError, fmt.Errorf("initiating payment failed: %v", err.Error())
 	}
 
 	payment := models.Payment{
@@ -579,7 +548,7 @@ func ChargeCardHeadlessInitService(c *gin.Context, extReq request.ExternalReques
 			}
 
 			amountTwo := utility.PercentageOf(paymentAmount, vesicashCharge)
-			_, err = CreditWallet(extReq, db, amountTwo, currency, 1, false, "no", transaction.TransactionID)
+			_, err = CreditWallet(extReq, db, amountTwo, currency, 1, false, DefaultWalletType, transaction.TransactionID)
 			if err != nil {
 				return data, http.StatusInternalServerError, err
 			}
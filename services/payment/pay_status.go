@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -14,6 +13,7 @@ import (
 	"github.com/vesicash/payment-ms/internal/config"
 	"github.com/vesicash/payment-ms/internal/models"
 	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	webhooksig "github.com/vesicash/payment-ms/pkg/webhook"
 	"github.com/vesicash/payment-ms/utility"
 )
 
@@ -25,9 +25,7 @@ func GetStatusService(c *gin.Context, extReq request.ExternalRequest, db postgre
 		successPage     = ""
 		msg             = ""
 		escrowWallet    = "no"
-		paymentGateway  = thisOrThatStr(req.Gateway, "rave")
-		rave            = Rave{ExtReq: extReq}
-		monnify         = Monnify{ExtReq: extReq}
+		paymentGateway  = thisOrThatStr(req.Gateway, DefaultGateway)
 		paymentChannelD = config.GetConfig().Slack.PaymentChannelID
 	)
 
@@ -79,15 +77,11 @@ func GetStatusService(c *gin.Context, extReq request.ExternalRequest, db postgre
 		transactionTitle string
 	)
 
-	switch strings.ToLower(paymentGateway) {
-	case "rave":
-		_, gatewayStatus, _, _, err = rave.StatusV3(db, payment, paymentInfo, req.Reference)
-	case "monnify":
-		_, gatewayStatus, _, _, err = monnify.Status(req.Reference)
-	default:
-		_, gatewayStatus, _, _, err = rave.StatusV3(db, payment, paymentInfo, req.Reference)
-
+	gateway, err := RecordedGateway(extReq, thisOrThatStr(paymentInfo.Gateway, paymentGateway))
+	if err != nil {
+		return "error", http.StatusBadRequest, err
 	}
+	_, gatewayStatus, statusString, _, err := gateway.Status(db, payment, paymentInfo, req.Reference)
 
 	if err != nil {
 		if !req.Headless {
@@ -98,7 +92,19 @@ func GetStatusService(c *gin.Context, extReq request.ExternalRequest, db postgre
 			c.Redirect(http.StatusMovedPermanently, uri)
 			return "Transaction payment failed", http.StatusBadRequest, nil
 		}
-		return "rave error", http.StatusInternalServerError, err
+		return fmt.Sprintf("%v error", gateway.Name()), http.StatusInternalServerError, err
+	}
+
+	if !gatewayStatus && statusString == "pending" {
+		if !req.Headless {
+			uri = failPage
+			if uri == "" {
+				uri = config.GetConfig().App.Url + "/v2/pay/failed"
+			}
+			utility.AddQueryParam(&uri, "status", "pending")
+			c.Redirect(http.StatusMovedPermanently, uri)
+		}
+		return "Transaction payment pending", http.StatusOK, nil
 	}
 
 	if gatewayStatus {
@@ -169,7 +175,7 @@ func GetStatusService(c *gin.Context, extReq request.ExternalRequest, db postgre
 				)
 
 				//credit vesicash
-				_, err = CreditWallet(extReq, db, amountTwo, transaction.Currency, 1, false, "no", transaction.TransactionID)
+				_, err = CreditWallet(extReq, db, amountTwo, transaction.Currency, 1, false, DefaultWalletType, transaction.TransactionID)
 				if err != nil {
 					return "error", http.StatusInternalServerError, err
 				}
@@ -248,13 +254,11 @@ func GetStatusService(c *gin.Context, extReq request.ExternalRequest, db postgre
 			utility.AddQueryParam(&uri, "transaction_id", transactionID)
 			utility.AddQueryParam(&uri, "reference", paymentInfo.Reference)
 
-			if businessProfileData.Webhook_uri != "" {
-				InitWebhook(extReq, db, businessProfileData.Webhook_uri, "payment.success", map[string]interface{}{
-					"transaction_title": transactionTitle,
-					"transaction_id":    transactionID,
-					"payment_status":    "success",
-				}, businessProfileData.AccountID)
-			}
+			InitWebhook(extReq, db, businessProfileData.Webhook_uri, webhooksig.EventPaymentSuccess, webhooksig.Payment{
+				TransactionID:    transactionID,
+				TransactionTitle: transactionTitle,
+				Status:           "success",
+			}, businessProfileData.AccountID)
 
 		}
 
@@ -279,13 +283,11 @@ func GetStatusService(c *gin.Context, extReq request.ExternalRequest, db postgre
 			transactionTitle = transaction.Title
 			businessID = transaction.BusinessID
 			businessProfileData, _ := GetBusinessProfileByAccountID(extReq, extReq.Logger, businessID)
-			if businessProfileData.Webhook_uri != "" {
-				InitWebhook(extReq, db, businessProfileData.Webhook_uri, "payment.failed", map[string]interface{}{
-					"transaction_title": transactionTitle,
-					"transaction_id":    transactionID,
-					"payment_status":    "failed",
-				}, businessProfileData.AccountID)
-			}
+			InitWebhook(extReq, db, businessProfileData.Webhook_uri, webhooksig.EventPaymentFailed, webhooksig.Payment{
+				TransactionID:    transactionID,
+				TransactionTitle: transactionTitle,
+				Status:           "failed",
+			}, businessProfileData.AccountID)
 
 			err = SlackNotify(extReq, paymentChannelD, `
  					Payment Status For Transaction #`+payment.TransactionID+`
@@ -330,9 +332,7 @@ func GetPaymentStatusService(c *gin.Context, extReq request.ExternalRequest, db
 		paymentInfo     = models.PaymentInfo{Reference: req.Reference}
 		uri             = ""
 		msg             = ""
-		paymentGateway  = thisOrThatStr(req.Gateway, "rave")
-		rave            = Rave{ExtReq: extReq}
-		monnify         = Monnify{ExtReq: extReq}
+		paymentGateway  = thisOrThatStr(req.Gateway, DefaultGateway)
 		paymentChannelD = config.GetConfig().Slack.PaymentChannelID
 	)
 
@@ -378,22 +378,17 @@ func GetPaymentStatusService(c *gin.Context, extReq request.ExternalRequest, db
 		transactionTitle string
 	)
 
-	switch strings.ToLower(paymentGateway) {
-	case "rave":
-		_, gatewayStatus, _, _, err = rave.StatusV3(db, payment, paymentInfo, req.Reference)
-		if err != nil {
-			return uri, "rave error", http.StatusInternalServerError, err
-		}
-	case "monnify":
-		_, gatewayStatus, _, _, err = monnify.Status(req.Reference)
-		if err != nil {
-			return uri, "monnify error", http.StatusInternalServerError, err
-		}
-	default:
-		_, gatewayStatus, _, _, err = rave.StatusV3(db, payment, paymentInfo, req.Reference)
-		if err != nil {
-			return uri, "rave error", http.StatusInternalServerError, err
-		}
+	gateway, err := RecordedGateway(extReq, thisOrThatStr(paymentInfo.Gateway, paymentGateway))
+	if err != nil {
+		return uri, "error", http.StatusBadRequest, err
+	}
+	_, gatewayStatus, statusString, _, err := gateway.Status(db, payment, paymentInfo, req.Reference)
+	if err != nil {
+		return uri, fmt.Sprintf("%v error", gateway.Name()), http.StatusInternalServerError, err
+	}
+
+	if !gatewayStatus && statusString == "pending" {
+		return uri, "Transaction payment pending", http.StatusOK, nil
 	}
 
 	if gatewayStatus {
@@ -417,7 +412,7 @@ func GetPaymentStatusService(c *gin.Context, extReq request.ExternalRequest, db
 			}
 
 			// credit vesicash
-			_, err = CreditWallet(extReq, db, escrowCharge, transaction.Currency, 1, false, "no", transaction.TransactionID)
+			_, err = CreditWallet(extReq, db, escrowCharge, transaction.Currency, 1, false, DefaultWalletType, transaction.TransactionID)
 			if err != nil {
 				return uri, "error", http.StatusInternalServerError, err
 			}
@@ -517,13 +512,11 @@ func GetPaymentStatusService(c *gin.Context, extReq request.ExternalRequest, db
 			utility.AddQueryParam(&uri, "transaction_id", transactionID)
 			utility.AddQueryParam(&uri, "reference", paymentInfo.Reference)
 
-			if businessProfileData.Webhook_uri != "" {
-				InitWebhook(extReq, db, businessProfileData.Webhook_uri, "payment.success", map[string]interface{}{
-					"transaction_title": transactionTitle,
-					"transaction_id":    transactionID,
-					"payment_status":    "success",
-				}, businessProfileData.AccountID)
-			}
+			InitWebhook(extReq, db, businessProfileData.Webhook_uri, webhooksig.EventPaymentSuccess, webhooksig.Payment{
+				TransactionID:    transactionID,
+				TransactionTitle: transactionTitle,
+				Status:           "success",
+			}, businessProfileData.AccountID)
 
 		}
 	}
@@ -14,6 +14,7 @@ import (
 	"github.com/vesicash/payment-ms/internal/config"
 	"github.com/vesicash/payment-ms/internal/models"
 	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	webhooksig "github.com/vesicash/payment-ms/pkg/webhook"
 	"github.com/vesicash/payment-ms/utility"
 )
 
@@ -278,13 +279,11 @@ func PaymentAccountMonnifyVerifyService(extReq request.ExternalRequest, db postg
 			}
 
 			businessProfileData, _ := GetBusinessProfileByAccountID(extReq, extReq.Logger, transaction.BusinessID)
-			if businessProfileData.Webhook_uri != "" {
-				InitWebhook(extReq, db, businessProfileData.Webhook_uri, "payment.success", map[string]interface{}{
-					"reference": req.Reference,
-					"amount":    payment.TotalAmount,
-					"status":    "success",
-				}, businessProfileData.AccountID)
-			}
+			InitWebhook(extReq, db, businessProfileData.Webhook_uri, webhooksig.EventPaymentSuccess, webhooksig.Payment{
+				Reference: req.Reference,
+				Amount:    payment.TotalAmount,
+				Status:    "success",
+			}, businessProfileData.AccountID)
 
 			return map[string]interface{}{"reference": req.Reference, "amount": payment.TotalAmount, "pdf_link": pdfLink, "status": verify}, "Bank Transfer Verified", http.StatusOK, nil
 		} else {
@@ -408,13 +407,11 @@ func PaymentAccountMonnifyVerifyService(extReq request.ExternalRequest, db postg
 		}
 
 		businessProfileData, _ := GetBusinessProfileByAccountID(extReq, extReq.Logger, transaction.BusinessID)
-		if businessProfileData.Webhook_uri != "" {
-			InitWebhook(extReq, db, businessProfileData.Webhook_uri, "payment.success", map[string]interface{}{
-				"reference": req.Reference,
-				"amount":    payment.TotalAmount,
-				"status":    "success",
-			}, businessProfileData.AccountID)
-		}
+		InitWebhook(extReq, db, businessProfileData.Webhook_uri, webhooksig.EventPaymentSuccess, webhooksig.Payment{
+			Reference: req.Reference,
+			Amount:    payment.TotalAmount,
+			Status:    "success",
+		}, businessProfileData.AccountID)
 
 		return map[string]interface{}{"reference": req.Reference, "amount": payment.TotalAmount, "pdf_link": pdfLink, "status": verify}, "Transfer Verified", http.StatusOK, nil
 	}
@@ -473,7 +470,7 @@ func sendTransactionConfirmed(extReq request.ExternalRequest, db postgresql.Data
 		businessPerc, _ := strconv.ParseFloat(businessEscrowCharge.BusinessCharge, 64)
 		vesicashCharge, _ := strconv.ParseFloat(businessEscrowCharge.VesicashCharge, 64)
 		// credit vesicash
-		_, err = CreditWallet(extReq, db, utility.PercentageOf(amount, vesicashCharge), transaction.Currency, 1, false, "no", transaction.TransactionID)
+		_, err = CreditWallet(extReq, db, utility.PercentageOf(amount, vesicashCharge), transaction.Currency, 1, false, DefaultWalletType, transaction.TransactionID)
 		if err != nil {
 			return transaction, err
 		}
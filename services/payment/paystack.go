@@ -0,0 +1,232 @@
+package payment
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/vesicash/payment-ms/external/external_models"
+	"github.com/vesicash/payment-ms/external/request"
+)
+
+var (
+	paystackCountries = map[string]string{
+		"NG": "nigeria",
+		"GH": "ghana",
+		"KE": "kenya",
+		"ZA": "south africa",
+	}
+	paystackMobileMoneyBanks = map[string]bool{
+		"MTN":   true,
+		"ATL":   true,
+		"VOD":   true,
+		"MPESA": true,
+	}
+)
+
+type Paystack struct {
+	ExtReq request.ExternalRequest
+}
+
+func toPaystackSubunit(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+func fromPaystackSubunit(amount int64) float64 {
+	return float64(amount) / 100
+}
+
+func (p *Paystack) ListBanks(countryCode string) ([]external_models.BanksResponse, error) {
+	country, ok := paystackCountries[strings.ToUpper(countryCode)]
+	if !ok {
+		return []external_models.BanksResponse{}, fmt.Errorf("country %v not supported by paystack", countryCode)
+	}
+
+	banksItf, err := p.ExtReq.SendExternalRequest(request.ListBanksWithPaystack, country)
+	if err != nil {
+		return []external_models.BanksResponse{}, err
+	}
+
+	paystackBanks, ok := banksItf.([]external_models.PaystackBanksResponse)
+	if !ok {
+		return []external_models.BanksResponse{}, fmt.Errorf("response data format error")
+	}
+
+	banks := []external_models.BanksResponse{}
+	for _, bank := range paystackBanks {
+		banks = append(banks, external_models.BanksResponse{ID: bank.ID, Code: bank.Code, Name: bank.Name})
+	}
+
+	return banks, nil
+}
+
+func (p *Paystack) ResolveAccount(bankCode, accountNumber string) (string, error) {
+	accountItf, err := p.ExtReq.SendExternalRequest(request.PaystackResolveBankAccount, external_models.ResolveAccountRequest{AccountBank: bankCode, AccountNumber: accountNumber})
+	if err != nil {
+		return "", err
+	}
+
+	accountName, ok := accountItf.(string)
+	if !ok {
+		return "", fmt.Errorf("response data format error")
+	}
+
+	return accountName, nil
+}
+
+func (p *Paystack) InitPayment(reference, email, currency, redirectUrl string, amount float64) (string, external_models.PaystackInitPaymentRequest, error) {
+	data := external_models.PaystackInitPaymentRequest{
+		Email:       email,
+		Amount:      toPaystackSubunit(amount),
+		Currency:    strings.ToUpper(currency),
+		Reference:   reference,
+		CallbackUrl: redirectUrl,
+	}
+	paymentItf, err := p.ExtReq.SendExternalRequest(request.PaystackInitPayment, data)
+	if err != nil {
+		return "", data, err
+	}
+
+	paymentData, ok := paymentItf.(external_models.PaystackInitPaymentResponse)
+	if !ok {
+		return "", data, fmt.Errorf("response data format error")
+	}
+	return paymentData.Data.AuthorizationUrl, data, nil
+}
+
+func (p *Paystack) Status(reference string) (external_models.PaystackVerifyTransactionResponseData, bool, string, float64, error) {
+	paymentItf, err := p.ExtReq.SendExternalRequest(request.PaystackVerifyTransaction, reference)
+	if err != nil {
+		return external_models.PaystackVerifyTransactionResponseData{}, false, "", 0, err
+	}
+
+	data, ok := paymentItf.(external_models.PaystackVerifyTransactionResponseData)
+	if !ok {
+		return data, false, "", 0, fmt.Errorf("response data format error")
+	}
+
+	return data, strings.EqualFold(data.Status, "success"), data.Status, fromPaystackSubunit(data.Amount), nil
+}
+
+func (p *Paystack) VerifyTrans(reference string, amount float64, currency string) (bool, float64, error) {
+	data, status, _, paidAmount, err := p.Status(reference)
+	if err != nil {
+		return false, 0, err
+	}
+
+	if !status {
+		return false, paidAmount, fmt.Errorf("transaction %v", data.Status)
+	}
+
+	if !strings.EqualFold(data.Currency, currency) {
+		return false, paidAmount, fmt.Errorf("different currencies")
+	}
+
+	if paidAmount < amount {
+		return false, paidAmount, fmt.Errorf("incomplete payment")
+	}
+
+	return true, paidAmount, nil
+}
+
+// paystackRecipientType returns the recipient type paystack expects for a payout in the currency to the bank code.
+func paystackRecipientType(bankCode, currency string) (string, error) {
+	switch strings.ToUpper(currency) {
+	case "NGN":
+		return "nuban", nil
+	case "GHS":
+		if paystackMobileMoneyBanks[strings.ToUpper(bankCode)] {
+			return "mobile_money", nil
+		}
+		return "ghipss", nil
+	case "KES":
+		return "mobile_money", nil
+	case "ZAR":
+		return "basa", nil
+	default:
+		return "", fmt.Errorf("currency %v not supported for paystack payouts", currency)
+	}
+}
+
+func (p *Paystack) CreateTransferRecipient(bankCode, accountNo, accountName, currency string) (string, error) {
+	recipientType, err := paystackRecipientType(bankCode, currency)
+	if err != nil {
+		return "", err
+	}
+
+	recipientItf, err := p.ExtReq.SendExternalRequest(request.PaystackCreateTransferRecipient, external_models.PaystackCreateTransferRecipientRequest{
+		Type:          recipientType,
+		Name:          accountName,
+		AccountNumber: accountNo,
+		BankCode:      bankCode,
+		Currency:      strings.ToUpper(currency),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	recipient, ok := recipientItf.(external_models.PaystackCreateTransferRecipientResponseData)
+	if !ok {
+		return "", fmt.Errorf("response data format error")
+	}
+
+	if recipient.RecipientCode == "" {
+		return "", fmt.Errorf("paystack did not return a recipient code")
+	}
+
+	return recipient.RecipientCode, nil
+}
+
+func (p *Paystack) InitTransfer(bankCode, accountNo, accountName string, amount float64, narration, currency, reference string) (external_models.PaystackInitTransferResponse, error) {
+	recipientCode, err := p.CreateTransferRecipient(bankCode, accountNo, accountName, currency)
+	if err != nil {
+		return external_models.PaystackInitTransferResponse{}, fmt.Errorf("creating transfer recipient failed: %v", err.Error())
+	}
+
+	transferItf, err := p.ExtReq.SendExternalRequest(request.PaystackInitTransfer, external_models.PaystackInitTransferRequest{
+		Source:    "balance",
+		Amount:    toPaystackSubunit(amount),
+		Recipient: recipientCode,
+		Reason:    narration,
+		Currency:  strings.ToUpper(currency),
+		Reference: reference,
+	})
+	if err != nil {
+		return external_models.PaystackInitTransferResponse{}, err
+	}
+
+	transferData, ok := transferItf.(external_models.PaystackInitTransferResponse)
+	if !ok {
+		return transferData, fmt.Errorf("response data format error")
+	}
+
+	return transferData, nil
+}
+
+func (p *Paystack) TransferStatus(reference string) (external_models.PaystackInitTransferResponseData, bool, string, float64, error) {
+	transferItf, err := p.ExtReq.SendExternalRequest(request.PaystackVerifyTransfer, reference)
+	if err != nil {
+		return external_models.PaystackInitTransferResponseData{}, false, "", 0, err
+	}
+
+	data, ok := transferItf.(external_models.PaystackInitTransferResponseData)
+	if !ok {
+		return data, false, "", 0, fmt.Errorf("response data format error")
+	}
+
+	statusString := paystackTransferStatus(data.Status)
+	return data, statusString == "completed", statusString, fromPaystackSubunit(data.Amount), nil
+}
+
+func paystackTransferStatus(status string) string {
+	switch strings.ToLower(status) {
+	case "success":
+		return "completed"
+	case "failed", "reversed", "abandoned", "rejected":
+		return "failed"
+	case "pending", "otp", "received", "queued":
+		return "pending"
+	default:
+		return strings.ToLower(status)
+	}
+}
@@ -314,3 +314,57 @@ func (r *Rave) InitTransfer(bank, accountNo string, amount float64, narration, c
 
 	return paymentData, nil
 }
+
+// TransfersByReference returns the transfers flutterwave has recorded for the reference.
+func (r *Rave) TransfersByReference(reference string) ([]external_models.RaveInitTransferResponseData, error) {
+	transferItf, err := r.ExtReq.SendExternalRequest(request.RaveListTransfers, reference)
+	if err != nil {
+		return []external_models.RaveInitTransferResponseData{}, err
+	}
+
+	transfers, ok := transferItf.([]external_models.RaveInitTransferResponseData)
+	if !ok {
+		return transfers, fmt.Errorf("response data format error")
+	}
+
+	return transfers, nil
+}
+
+func (r *Rave) InitBulkTransfer(title string, transfers []external_models.RaveBulkTransferRequest) (external_models.RaveInitBulkTransferResponse, error) {
+	data := external_models.RaveInitBulkTransferRequest{
+		Title:    title,
+		BulkData: transfers,
+	}
+	transferItf, err := r.ExtReq.SendExternalRequest(request.RaveInitBulkTransfer, data)
+	if err != nil {
+		return external_models.RaveInitBulkTransferResponse{}, err
+	}
+
+	transferData, ok := transferItf.(external_models.RaveInitBulkTransferResponse)
+	if !ok {
+		return transferData, fmt.Errorf("response data format error")
+	}
+
+	return transferData, nil
+}
+
+// BulkTransfers returns every transfer of a bulk transfer, one page at a time.
+func (r *Rave) BulkTransfers(batchID string) ([]external_models.RaveInitTransferResponseData, error) {
+	transfers := []external_models.RaveInitTransferResponseData{}
+	for page := 1; ; page++ {
+		transferItf, err := r.ExtReq.SendExternalRequest(request.RaveGetBulkTransfer, external_models.RaveGetBulkTransferRequest{BatchID: batchID, Page: page})
+		if err != nil {
+			return transfers, err
+		}
+
+		transferData, ok := transferItf.(external_models.RaveGetBulkTransferResponse)
+		if !ok {
+			return transfers, fmt.Errorf("response data format error")
+		}
+
+		transfers = append(transfers, transferData.Data...)
+		if page >= transferData.Meta.PageInfo.TotalPages || len(transferData.Data) == 0 {
+			return transfers, nil
+		}
+	}
+}
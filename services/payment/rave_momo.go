@@ -0,0 +1,118 @@
+package payment
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vesicash/payment-ms/external/external_models"
+	"github.com/vesicash/payment-ms/external/request"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+type raveMomoCountry struct {
+	CountryCode string
+	Operators   []string
+}
+
+var (
+	raveMomoCountries = map[string]raveMomoCountry{
+		"GHS": {CountryCode: "GH", Operators: []string{"mtn", "vodafone", "tigo", "airtel"}},
+		"KES": {CountryCode: "KE", Operators: []string{"mps"}},
+		"UGX": {CountryCode: "UG", Operators: []string{"mps", "mtn", "airtel"}},
+		"TZS": {CountryCode: "TZ", Operators: []string{"mps", "tigo", "airtel", "vodafone"}},
+		"RWF": {CountryCode: "RW", Operators: []string{"mps", "mtn", "airtel"}},
+	}
+)
+
+func IsRaveMomoCurrency(currency string) bool {
+	_, ok := raveMomoCountries[strings.ToUpper(currency)]
+	return ok
+}
+
+// raveMomoAccountBank maps a mobile money operator to the account_bank flutterwave expects for the currency.
+// Ghana transfers are routed by operator, every other supported country uses MPS.
+func raveMomoAccountBank(currency, operator string) (string, error) {
+	country, ok := raveMomoCountries[strings.ToUpper(currency)]
+	if !ok {
+		return "", fmt.Errorf("mobile money transfers not supported for currency %v", currency)
+	}
+
+	operator = strings.ToLower(operator)
+	if !utility.InStringSlice(operator, country.Operators) {
+		return "", fmt.Errorf("mobile money operator %v not supported for %v", operator, country.CountryCode)
+	}
+
+	if country.CountryCode == "GH" {
+		return strings.ToUpper(operator), nil
+	}
+	return "MPS", nil
+}
+
+func (r *Rave) InitMomoTransfer(operator, mobileNumber, beneficiaryName string, amount float64, narration, currency, reference, callback string) (external_models.RaveInitTransferResponse, error) {
+	accountBank, err := raveMomoAccountBank(currency, operator)
+	if err != nil {
+		return external_models.RaveInitTransferResponse{}, err
+	}
+
+	data := external_models.RaveInitMomoTransferRequest{
+		AccountBank:     accountBank,
+		AccountNumber:   strings.TrimPrefix(mobileNumber, "+"),
+		Amount:          amount,
+		Narration:       narration,
+		Currency:        strings.ToUpper(currency),
+		BeneficiaryName: beneficiaryName,
+		Reference:       reference,
+		DebitCurrency:   strings.ToUpper(currency),
+		CallbackUrl:     callback,
+	}
+
+	if raveMomoCountries[strings.ToUpper(currency)].CountryCode == "KE" {
+		data.Meta = &external_models.RaveInitMomoTransferRequestMeta{
+			Sender:        "Vesicash",
+			SenderCountry: "NG",
+			MobileNumber:  data.AccountNumber,
+		}
+	}
+
+	transferItf, err := r.ExtReq.SendExternalRequest(request.RaveInitMomoTransfer, data)
+	if err != nil {
+		return external_models.RaveInitTransferResponse{}, err
+	}
+
+	transferData, ok := transferItf.(external_models.RaveInitTransferResponse)
+	if !ok {
+		return transferData, fmt.Errorf("response data format error")
+	}
+
+	return transferData, nil
+}
+
+func (r *Rave) TransferStatus(transferID string) (external_models.RaveInitTransferResponseData, bool, string, float64, error) {
+	transferItf, err := r.ExtReq.SendExternalRequest(request.RaveGetTransfer, transferID)
+	if err != nil {
+		return external_models.RaveInitTransferResponseData{}, false, "", 0, err
+	}
+
+	data, ok := transferItf.(external_models.RaveInitTransferResponseData)
+	if !ok {
+		return data, false, "", 0, fmt.Errorf("response data format error")
+	}
+
+	statusString := raveTransferStatus(data.Status)
+	return data, statusString == "completed", statusString, data.Amount, nil
+}
+
+func raveTransferStatus(status string) string {
+	switch strings.ToUpper(status) {
+	case "SUCCESSFUL":
+		return "completed"
+	case "FAILED":
+		return "failed"
+	case "NEW":
+		return "new"
+	case "PENDING":
+		return "pending"
+	default:
+		return strings.ToLower(status)
+	}
+}
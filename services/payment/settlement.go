@@ -0,0 +1,402 @@
+package payment
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/vesicash/payment-ms/external/request"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"github.com/vesicash/payment-ms/utility"
+	"gorm.io/gorm"
+)
+
+type SettlementFrequency string
+
+var (
+	SettlementInstant SettlementFrequency = "instant"
+	SettlementDaily   SettlementFrequency = "daily"
+	SettlementWeekly  SettlementFrequency = "weekly"
+	SettlementTPlusN  SettlementFrequency = "t_plus_n"
+
+	// disbursements that pay out a settlement batch carry the batch id as their payment id
+	SettlementDisbursementType = "settlement"
+
+	defaultSettlementTimezone = "Africa/Lagos"
+)
+
+// GetSettlementSchedule returns the settlement schedule of a business, businesses without one are settled instantly.
+func GetSettlementSchedule(db postgresql.Databases, businessID int) (models.SettlementSchedule, error) {
+	schedule := models.SettlementSchedule{BusinessID: businessID}
+	code, err := schedule.GetSettlementScheduleByBusinessID(db.Payment)
+	if err != nil {
+		if code == http.StatusInternalServerError {
+			return schedule, err
+		}
+		return models.SettlementSchedule{BusinessID: businessID, Frequency: string(SettlementInstant), Timezone: defaultSettlementTimezone}, nil
+	}
+	return schedule, nil
+}
+
+func settlementLocation(schedule models.SettlementSchedule) *time.Location {
+	location, err := time.LoadLocation(schedule.Timezone)
+	if err != nil || schedule.Timezone == "" {
+		return time.UTC
+	}
+	return location
+}
+
+// SettlementWindow returns when a payment collected at from is released under the schedule, holidays are dates in 2006-01-02 format.
+// Windows never open on a holiday, or on a weekend when the schedule skips weekends, they move to the next open day at the same hour.
+// T+N counts open days only, so T+1 on a friday is the next monday when weekends are skipped.
+func SettlementWindow(schedule models.SettlementSchedule, holidays []string, from time.Time) time.Time {
+	if SettlementFrequency(schedule.Frequency) == SettlementInstant {
+		return from
+	}
+
+	var (
+		location = settlementLocation(schedule)
+		local    = from.In(location)
+		window   = time.Date(local.Year(), local.Month(), local.Day(), schedule.Hour, 0, 0, 0, location)
+		closed   = map[string]bool{}
+	)
+	for _, holiday := range holidays {
+		closed[holiday] = true
+	}
+	isOpen := func(day time.Time) bool {
+		if schedule.SkipWeekends && (day.Weekday() == time.Saturday || day.Weekday() == time.Sunday) {
+			return false
+		}
+		return !closed[day.Format("2006-01-02")]
+	}
+
+	switch SettlementFrequency(schedule.Frequency) {
+	case SettlementWeekly:
+		for window.Weekday() != time.Weekday(schedule.DayOfWeek) || !window.After(local) {
+			window = window.AddDate(0, 0, 1)
+		}
+	case SettlementTPlusN:
+		for days := schedule.DelayDays; days > 0; {
+			window = window.AddDate(0, 0, 1)
+			if isOpen(window) {
+				days--
+			}
+		}
+		if !window.After(local) {
+			window = window.AddDate(0, 0, 1)
+		}
+	default:
+		if !window.After(local) {
+			window = window.AddDate(0, 0, 1)
+		}
+	}
+
+	for !isOpen(window) {
+		window = window.AddDate(0, 0, 1)
+	}
+	return window
+}
+
+// NextSettlementWindow is SettlementWindow with the holidays saved for the country of the schedule.
+func NextSettlementWindow(db postgresql.Databases, schedule models.SettlementSchedule, from time.Time) (time.Time, error) {
+	holiday := models.SettlementHoliday{Country: strings.ToUpper(schedule.Country)}
+	holidays, err := holiday.GetSettlementHolidaysFrom(db.Payment, from.AddDate(0, 0, -1).Format("2006-01-02"))
+	if err != nil {
+		return from, err
+	}
+
+	dates := []string{}
+	for _, h := range holidays {
+		dates = append(dates, h.Date)
+	}
+	return SettlementWindow(schedule, dates, from), nil
+}
+
+// AddToSettlementBatch adds a payment to the open batch of the recipient for the next window, a batch is opened when there is none.
+// A payment is only ever added to one batch.
+func AddToSettlementBatch(db postgresql.Databases, schedule models.SettlementSchedule, recipientID int, item models.SettlementBatchItem) (models.SettlementBatch, error) {
+	batch := models.SettlementBatch{}
+	err := postgresql.RunWithAdvisoryLock(db.Payment, fmt.Sprintf("settlement-business:%v", schedule.BusinessID), func(tx *gorm.DB) error {
+		if item.CheckSettlementBatchItemExists(db.Payment) {
+			return nil
+		}
+
+		window, err := NextSettlementWindow(db, schedule, time.Now())
+		if err != nil {
+			return err
+		}
+
+		batch = models.SettlementBatch{BusinessID: schedule.BusinessID, RecipientID: recipientID, Currency: strings.ToUpper(item.Currency), WindowOpensAt: window}
+		code, err := batch.GetOpenSettlementBatch(db.Payment)
+		if err != nil {
+			if code == http.StatusInternalServerError {
+				return err
+			}
+			batch.BatchID = fmt.Sprintf("stl%v", utility.GetRandomNumbersInRange(1000000000, 9999999999))
+			batch.Status = "open"
+			err = batch.CreateSettlementBatch(db.Payment)
+			if err != nil {
+				return err
+			}
+		}
+
+		item.BatchID = batch.BatchID
+		item.Currency = batch.Currency
+		err = item.CreateSettlementBatchItem(db.Payment)
+		if err != nil {
+			return err
+		}
+
+		batch.TotalAmount += item.Amount
+		batch.PaymentCount += 1
+		return batch.UpdateAllFields(db.Payment)
+	})
+	return batch, err
+}
+
+// BatchForSettlement adds the payout of a payment to a settlement batch when the business is not settled instantly,
+// it returns true when the payout was batched and must not be sent now.
+func BatchForSettlement(extReq request.ExternalRequest, db postgresql.Databases, businessID, recipientID int, paymnt models.Payment, amount float64, currency string) (bool, error) {
+	schedule, err := GetSettlementSchedule(db, businessID)
+	if err != nil {
+		return false, err
+	}
+	if SettlementFrequency(schedule.Frequency) == SettlementInstant {
+		return false, nil
+	}
+
+	batch, err := AddToSettlementBatch(db, schedule, recipientID, models.SettlementBatchItem{
+		PaymentID:     paymnt.PaymentID,
+		TransactionID: paymnt.TransactionID,
+		Amount:        amount,
+		Currency:      currency,
+	})
+	if err != nil {
+		return false, fmt.Errorf("error adding payment %v to settlement batch: %v", paymnt.PaymentID, err.Error())
+	}
+
+	if batch.BatchID != "" {
+		extReq.Logger.Info(fmt.Sprintf("payment %v added to settlement batch %v, window opens at %v", paymnt.PaymentID, batch.BatchID, batch.WindowOpensAt.Format(time.RFC3339)))
+	}
+	return true, nil
+}
+
+// IsPaymentBatchedForSettlement reports whether the payout of a payment is waiting in, or was paid by, a settlement batch.
+func IsPaymentBatchedForSettlement(db postgresql.Databases, paymentID string) bool {
+	item := models.SettlementBatchItem{PaymentID: paymentID}
+	return item.CheckSettlementBatchItemExists(db.Payment)
+}
+
+// PostponeSettlementBatch moves a batch that cannot be paid out yet to the next window of its schedule.
+func PostponeSettlementBatch(db postgresql.Databases, batch *models.SettlementBatch, reason string) error {
+	schedule, err := GetSettlementSchedule(db, batch.BusinessID)
+	if err != nil {
+		return err
+	}
+	if SettlementFrequency(schedule.Frequency) == SettlementInstant {
+		schedule.Frequency = string(SettlementDaily)
+	}
+
+	window, err := NextSettlementWindow(db, schedule, time.Now())
+	if err != nil {
+		return err
+	}
+
+	batch.WindowOpensAt = window
+	batch.Note = reason
+	return batch.UpdateAllFields(db.Payment)
+}
+
+// CompleteSettlementBatch closes the transaction of every payment in the batch paid out by the disbursement.
+func CompleteSettlementBatch(extReq request.ExternalRequest, db postgresql.Databases, disbursement models.Disbursement) {
+	batch := models.SettlementBatch{BatchID: disbursement.PaymentID}
+	_, err := batch.GetSettlementBatchByBatchID(db.Payment)
+	if err != nil {
+		extReq.Logger.Error(fmt.Sprintf("error getting settlement batch %v: %v", disbursement.PaymentID, err.Error()))
+		return
+	}
+
+	item := models.SettlementBatchItem{BatchID: batch.BatchID}
+	items, err := item.GetSettlementBatchItemsByBatchID(db.Payment)
+	if err != nil {
+		extReq.Logger.Error(fmt.Sprintf("error getting items of settlement batch %v: %v", batch.BatchID, err.Error()))
+		return
+	}
+
+	for _, item := range items {
+		paymnt := models.Payment{PaymentID: item.PaymentID}
+		_, err := paymnt.GetPaymentByPaymentID(db.Payment)
+		if err != nil {
+			extReq.Logger.Error(fmt.Sprintf("error getting payment for payment id: %v, error: %v", item.PaymentID, err.Error()))
+			continue
+		}
+
+		transaction, _ := ListTransactionsByID(extReq, item.TransactionID)
+		itemDisbursement := disbursement
+		itemDisbursement.Amount = fmt.Sprintf("%v", item.Amount)
+		CloseDisbursedTransaction(extReq, db, itemDisbursement, paymnt, transaction)
+	}
+
+	settledAt := time.Now()
+	batch.Status = "completed"
+	batch.SettledAt = &settledAt
+	err = batch.UpdateAllFields(db.Payment)
+	if err != nil {
+		extReq.Logger.Error(fmt.Sprintf("error updating settlement batch %v: %v", batch.BatchID, err.Error()))
+	}
+}
+
+// FailSettlementBatch marks the batch of a settlement disbursement that will not be paid as failed and frees its payments,
+// the disbursement job then batches them again. Other disbursement types are left alone.
+func FailSettlementBatch(extReq request.ExternalRequest, db postgresql.Databases, disbursement models.Disbursement, reason string) {
+	if disbursement.Type != SettlementDisbursementType {
+		return
+	}
+
+	batch := models.SettlementBatch{BatchID: disbursement.PaymentID}
+	_, err := batch.GetSettlementBatchByBatchID(db.Payment)
+	if err != nil {
+		extReq.Logger.Error(fmt.Sprintf("error getting settlement batch %v: %v", disbursement.PaymentID, err.Error()))
+		return
+	}
+
+	item := models.SettlementBatchItem{BatchID: batch.BatchID}
+	items, err := item.GetSettlementBatchItemsByBatchID(db.Payment)
+	if err != nil {
+		extReq.Logger.Error(fmt.Sprintf("error getting items of settlement batch %v: %v", batch.BatchID, err.Error()))
+		return
+	}
+	for _, item := range items {
+		err = item.Delete(db.Payment)
+		if err != nil {
+			extReq.Logger.Error(fmt.Sprintf("error freeing payment %v of settlement batch %v: %v", item.PaymentID, batch.BatchID, err.Error()))
+		}
+	}
+
+	batch.Status = "failed"
+	batch.Note = reason
+	err = batch.UpdateAllFields(db.Payment)
+	if err != nil {
+		extReq.Logger.Error(fmt.Sprintf("error updating settlement batch %v: %v", batch.BatchID, err.Error()))
+	}
+}
+
+func GetSettlementScheduleService(db postgresql.Databases, businessID int) (models.SettlementSchedule, int, error) {
+	schedule, err := GetSettlementSchedule(db, businessID)
+	if err != nil {
+		return schedule, http.StatusInternalServerError, err
+	}
+	return schedule, http.StatusOK, nil
+}
+
+func SetSettlementScheduleService(db postgresql.Databases, businessID int, req models.SetSettlementScheduleRequest) (models.SettlementSchedule, int, error) {
+	if req.Timezone == "" {
+		req.Timezone = defaultSettlementTimezone
+	}
+	if _, err := time.LoadLocation(req.Timezone); err != nil {
+		return models.SettlementSchedule{}, http.StatusBadRequest, fmt.Errorf("timezone %v not supported", req.Timezone)
+	}
+
+	schedule := models.SettlementSchedule{BusinessID: businessID}
+	code, err := schedule.GetSettlementScheduleByBusinessID(db.Payment)
+	if err != nil && code == http.StatusInternalServerError {
+		return schedule, code, err
+	}
+
+	schedule.Frequency = req.Frequency
+	schedule.DayOfWeek = req.DayOfWeek
+	schedule.DelayDays = req.DelayDays
+	schedule.Hour = req.Hour
+	schedule.Timezone = req.Timezone
+	schedule.SkipWeekends = req.SkipWeekends
+	schedule.Country = strings.ToUpper(req.Country)
+
+	if schedule.ID == 0 {
+		err = schedule.CreateSettlementSchedule(db.Payment)
+	} else {
+		err = schedule.UpdateAllFields(db.Payment)
+	}
+	if err != nil {
+		return schedule, http.StatusInternalServerError, err
+	}
+
+	return schedule, http.StatusOK, nil
+}
+
+func ListSettlementHolidaysService(db postgresql.Databases) ([]models.SettlementHoliday, int, error) {
+	holiday := models.SettlementHoliday{}
+	holidays, err := holiday.GetSettlementHolidays(db.Payment)
+	if err != nil {
+		return holidays, http.StatusInternalServerError, err
+	}
+	return holidays, http.StatusOK, nil
+}
+
+func CreateSettlementHolidayService(db postgresql.Databases, req models.CreateSettlementHolidayRequest) (models.SettlementHoliday, int, error) {
+	holiday := models.SettlementHoliday{Date: req.Date, Country: strings.ToUpper(req.Country), Name: req.Name}
+	if holiday.CheckSettlementHolidayExists(db.Payment) {
+		return holiday, http.StatusBadRequest, fmt.Errorf("holiday already exists for %v", req.Date)
+	}
+
+	err := holiday.CreateSettlementHoliday(db.Payment)
+	if err != nil {
+		return holiday, http.StatusInternalServerError, err
+	}
+	return holiday, http.StatusOK, nil
+}
+
+func DeleteSettlementHolidayService(db postgresql.Databases, id int) (models.SettlementHoliday, int, error) {
+	holiday := models.SettlementHoliday{ID: uint(id)}
+	code, err := holiday.GetSettlementHolidayByID(db.Payment)
+	if err != nil {
+		return holiday, code, fmt.Errorf("holiday not found: %v", err.Error())
+	}
+
+	err = holiday.Delete(db.Payment)
+	if err != nil {
+		return holiday, http.StatusInternalServerError, err
+	}
+	return holiday, http.StatusOK, nil
+}
+
+func ListSettlementBatchesService(db postgresql.Databases, businessID int, paginator postgresql.Pagination) ([]models.SettlementBatch, postgresql.PaginationResponse, int, error) {
+	batch := models.SettlementBatch{BusinessID: businessID}
+	batches, pagination, err := batch.GetSettlementBatchesByBusinessID(db.Payment, paginator)
+	if err != nil {
+		return batches, pagination, http.StatusInternalServerError, err
+	}
+	return batches, pagination, http.StatusOK, nil
+}
+
+// GetSettlementReportService returns a batch of the business with the payments it settled and the disbursement that paid it out.
+func GetSettlementReportService(db postgresql.Databases, businessID int, batchID string) (models.SettlementReport, int, error) {
+	report := models.SettlementReport{}
+
+	batch := models.SettlementBatch{BatchID: batchID, BusinessID: businessID}
+	code, err := batch.GetSettlementBatchByBatchIDAndBusinessID(db.Payment)
+	if err != nil {
+		if code == http.StatusBadRequest {
+			code = http.StatusNotFound
+		}
+		return report, code, fmt.Errorf("settlement batch not found: %v", err.Error())
+	}
+	report.Batch = batch
+
+	item := models.SettlementBatchItem{BatchID: batchID}
+	report.Items, err = item.GetSettlementBatchItemsByBatchID(db.Payment)
+	if err != nil {
+		return report, http.StatusInternalServerError, err
+	}
+
+	if batch.DisbursementID != 0 {
+		disbursement := models.Disbursement{DisbursementID: batch.DisbursementID}
+		_, err = disbursement.GetDisbursementByDisbursementID(db.Payment)
+		if err == nil {
+			report.Disbursement = &disbursement
+		}
+	}
+
+	return report, http.StatusOK, nil
+}
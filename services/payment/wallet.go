@@ -10,6 +10,7 @@ import (
 	"github.com/vesicash/payment-ms/internal/models"
 	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
 	"github.com/vesicash/payment-ms/utility"
+	"gorm.io/gorm"
 )
 
 type WalletType string
@@ -41,7 +42,7 @@ func GetWalletType(isEscrow, isMor string) WalletType {
 	}
 }
 
-func CreditWallet(extReq request.ExternalRequest, db postgresql.Databases, amount float64, currency string, businessID int, isRefund bool, walletType WalletType, transactionID string) (external_models.WalletBalance, error) {
+func CreditWallet(extReq request.ExternalRequest, db postgresql.Databases, amount float64, currency string, businessID int, isRefund bool, walletType WalletType, transactionID string, ledger ...LedgerPosting) (external_models.WalletBalance, error) {
 	if currency == "" {
 		currency = "NGN"
 	}
@@ -58,24 +59,14 @@ func CreditWallet(extReq request.ExternalRequest, db postgresql.Databases, amoun
 		}
 	}
 
-	walletBalance, err := GetWalletBalanceByAccountIdAndCurrency(extReq, businessID, currency)
-	if err != nil {
-		walletBalance, err = CreateWalletBalance(extReq, businessID, currency, amount)
-		if err != nil {
-			return walletBalance, err
-		}
-		extReq.Logger.Info("credit-wallet-c", "new balance:", fmt.Sprintf("%v %v", currency, amount))
-	} else {
-		availableBalance := walletBalance.Available + amount
-		if isRefund {
-			availableBalance += config.GetConfig().ONLINE_PAYMENT.DisbursementCharge
-		}
-		walletBalance, err = UpdateWalletBalance(extReq, walletBalance.ID, availableBalance)
-		if err != nil {
-			return walletBalance, err
-		}
+	var refundCharge float64
+	if isRefund {
+		refundCharge = config.GetConfig().ONLINE_PAYMENT.DisbursementCharge
+	}
 
-		extReq.Logger.Info("credit-wallet-u", "new balance:", fmt.Sprintf("%v %v", currency, availableBalance))
+	walletBalance, err := creditWalletBalance(extReq, db, amount, refundCharge, currency, businessID, getLedgerPosting(ledger, LedgerExternalAccount), transactionID)
+	if err != nil {
+		return walletBalance, err
 	}
 
 	if !isRefund {
@@ -99,7 +90,56 @@ func CreditWallet(extReq request.ExternalRequest, db postgresql.Databases, amoun
 	return walletBalance, nil
 }
 
-func DebitWallet(extReq request.ExternalRequest, db postgresql.Databases, amount float64, currency string, businessID int, walletType WalletType, transactionID string) (external_models.WalletBalance, error) {
+// creditWalletBalance adds amount to a wallet, creating the wallet when it does not exist yet.
+// extraOnUpdate is only added to wallets that already exist.
+// The ledger entry is written before the wallet is changed, so a wallet never moves without one.
+func creditWalletBalance(extReq request.ExternalRequest, db postgresql.Databases, amount, extraOnUpdate float64, currency string, businessID int, posting LedgerPosting, transactionID string) (external_models.WalletBalance, error) {
+	var (
+		walletBalance external_models.WalletBalance
+		moved         bool
+	)
+	err := postgresql.RunWithAdvisoryLock(db.Payment, walletLockKey(businessID, currency), func(tx *gorm.DB) error {
+		var err error
+
+		walletBalance, err = GetWalletBalanceByAccountIdAndCurrency(extReq, businessID, currency)
+		if err != nil {
+			err = postWalletLedgerEntry(tx, businessID, currency, 0, amount, posting, transactionID)
+			if err != nil {
+				return fmt.Errorf("error posting ledger entry for wallet credit of %v %v to account %v: %v", currency, amount, businessID, err.Error())
+			}
+
+			walletBalance, err = CreateWalletBalance(extReq, businessID, currency, amount)
+			if err != nil {
+				return err
+			}
+			extReq.Logger.Info("credit-wallet-c", "new balance:", fmt.Sprintf("%v %v", currency, amount))
+		} else {
+			previousBalance := walletBalance.Available
+			availableBalance := walletBalance.Available + amount + extraOnUpdate
+			err = postWalletLedgerEntry(tx, businessID, currency, previousBalance, availableBalance-previousBalance, posting, transactionID)
+			if err != nil {
+				return fmt.Errorf("error posting ledger entry for wallet credit of %v %v to account %v: %v", currency, amount, businessID, err.Error())
+			}
+
+			walletBalance, err = UpdateWalletBalance(extReq, walletBalance.ID, availableBalance)
+			if err != nil {
+				return err
+			}
+
+			extReq.Logger.Info("credit-wallet-u", "new balance:", fmt.Sprintf("%v %v", currency, availableBalance))
+		}
+
+		moved = true
+		return nil
+	})
+	if err != nil && moved {
+		notifyWalletLedgerDrift(extReq, businessID, currency, amount, transactionID, err)
+		return walletBalance, nil
+	}
+	return walletBalance, err
+}
+
+func DebitWallet(extReq request.ExternalRequest, db postgresql.Databases, amount float64, currency string, businessID int, walletType WalletType, transactionID string, ledger ...LedgerPosting) (external_models.WalletBalance, error) {
 	if currency == "" {
 		currency = "NGN"
 	}
@@ -110,25 +150,45 @@ func DebitWallet(extReq request.ExternalRequest, db postgresql.Databases, amount
 		extReq.Logger.Info("debit-wallet", "creditEscrow is yes", "currency = ", currency, fmt.Sprintf("transaction with id %v", transactionID))
 	}
 
-	walletBalance, err := GetWalletBalanceByAccountIdAndCurrency(extReq, businessID, currency)
-	if err != nil {
-		walletBalance, err = CreateWalletBalance(extReq, businessID, currency, 0)
+	var (
+		walletBalance external_models.WalletBalance
+		moved         bool
+	)
+	err := postgresql.RunWithAdvisoryLock(db.Payment, walletLockKey(businessID, currency), func(tx *gorm.DB) error {
+		var err error
+		walletBalance, err = GetWalletBalanceByAccountIdAndCurrency(extReq, businessID, currency)
 		if err != nil {
-			return walletBalance, err
+			walletBalance, err = CreateWalletBalance(extReq, businessID, currency, 0)
+			if err != nil {
+				return err
+			}
+			extReq.Logger.Info("debit-wallet-c", "new balance:", fmt.Sprintf("%v %v", currency, amount))
 		}
-		extReq.Logger.Info("debit-wallet-c", "new balance:", fmt.Sprintf("%v %v", currency, amount))
-	}
 
-	if amount > walletBalance.Available {
-		return walletBalance, fmt.Errorf("insufficient wallet  balance")
-	} else {
+		if amount > walletBalance.Available {
+			return fmt.Errorf("insufficient wallet  balance")
+		}
+
+		previousBalance := walletBalance.Available
 		availableBalance := walletBalance.Available - amount
-		walletBalance, err = UpdateWalletBalance(extReq, walletBalance.ID, availableBalance)
+		err = postWalletLedgerEntry(tx, businessID, currency, previousBalance, availableBalance-previousBalance, getLedgerPosting(ledger, LedgerExternalAccount), transactionID)
 		if err != nil {
-			return walletBalance, err
+			return fmt.Errorf("error posting ledger entry for wallet debit of %v %v from account %v: %v", currency, amount, businessID, err.Error())
 		}
 
+		walletBalance, err = UpdateWalletBalance(extReq, walletBalance.ID, availableBalance)
+		if err != nil {
+			return err
+		}
 		extReq.Logger.Info("debit-wallet-u", "new balance:", fmt.Sprintf("%v %v", currency, availableBalance))
+
+		moved = true
+		return nil
+	})
+	if err != nil && moved {
+		notifyWalletLedgerDrift(extReq, businessID, currency, -amount, transactionID, err)
+	} else if err != nil {
+		return walletBalance, err
 	}
 
 	// walletDebitLog := models.WalletDebitLog{
@@ -138,6 +198,29 @@ func DebitWallet(extReq request.ExternalRequest, db postgresql.Databases, amount
 	// }
 
 	// walletDebitLog.CreateWalletDebitLog(db.Payment)
+	sendWalletDebitNotification(extReq, businessID, amount, currency, transactionID)
+
+	return walletBalance, nil
+}
+
+// notifyWalletLedgerDrift reports a wallet that moved while its ledger entry could not be saved. The movement has happened,
+// so it is not reported to the caller as a failure, the ledger is corrected by hand instead.
+func notifyWalletLedgerDrift(extReq request.ExternalRequest, businessID int, currency string, amount float64, transactionID string, cause error) {
+	extReq.Logger.Error(fmt.Sprintf("wallet %v of account %v moved by %v but its ledger entry was not saved: %v", currency, businessID, amount, cause.Error()))
+	err := SlackNotify(extReq, config.GetConfig().Slack.PaymentChannelID, `
+			Wallet ledger out of sync.
+			Environment: `+config.GetConfig().App.Name+`
+			Account ID: `+fmt.Sprintf("%v", businessID)+`
+			Amount: `+fmt.Sprintf("%v %v", currency, amount)+`
+			Transaction ID: `+transactionID+`
+			Reason: `+cause.Error()+`
+			`)
+	if err != nil && !extReq.Test {
+		extReq.Logger.Error("error sending notification to slack: ", err.Error())
+	}
+}
+
+func sendWalletDebitNotification(extReq request.ExternalRequest, businessID int, amount float64, currency string, transactionID string) {
 	actualCurrency := strings.Replace(currency, string(EscrowWalletType), "", -1)
 	actualCurrency = strings.Replace(currency, string(MorWalletType), "", -1)
 	extReq.SendExternalRequest(request.WalletDebitNotification, external_models.WalletDebitNotificationRequest{
@@ -146,8 +229,6 @@ func DebitWallet(extReq request.ExternalRequest, db postgresql.Databases, amount
 		Currency:      actualCurrency,
 		TransactionID: transactionID,
 	})
-
-	return walletBalance, nil
 }
 
 func CreateWalletBalance(extReq request.ExternalRequest, accountID int, currency string, available float64) (external_models.WalletBalance, error) {
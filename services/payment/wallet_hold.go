@@ -0,0 +1,317 @@
+package payment
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/vesicash/payment-ms/external/request"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"gorm.io/gorm"
+)
+
+type WalletHoldType string
+type WalletHoldStatus string
+
+var (
+	WalletHoldTransfer     WalletHoldType = "transfer"
+	WalletHoldDisbursement WalletHoldType = "disbursement"
+	WalletHoldDispute      WalletHoldType = "dispute"
+
+	WalletHoldActive    WalletHoldStatus = "active"
+	WalletHoldReleasing WalletHoldStatus = "releasing"
+	WalletHoldCaptured  WalletHoldStatus = "captured"
+	WalletHoldReleased  WalletHoldStatus = "released"
+	WalletHoldExpired   WalletHoldStatus = "expired"
+)
+
+func NewWalletHoldReference() string {
+	id, _ := uuid.NewV4()
+	return fmt.Sprintf("hd_%v", id.String())
+}
+
+func walletHoldLockKey(reference string) string {
+	return fmt.Sprintf("wallet_hold:%v", reference)
+}
+
+// walletHoldCaptureAccount is where held funds go once the hold is captured.
+func walletHoldCaptureAccount(holdType string) LedgerAccount {
+	switch WalletHoldType(holdType) {
+	case WalletHoldTransfer:
+		return LedgerTransferAccount
+	case WalletHoldDisbursement:
+		return LedgerPayoutAccount
+	default:
+		return LedgerDisputeAccount
+	}
+}
+
+// PlaceWalletHold takes the hold amount out of the available balance of the wallet and keeps it in the
+// account's wallet_hold ledger account until the hold is captured, released or expires.
+// The hold and its ledger entry are written before the wallet is debited, and saved again if the debit went through
+// but they could not be committed, so debited funds always have a hold to be released from.
+func PlaceWalletHold(extReq request.ExternalRequest, db postgresql.Databases, hold models.WalletHold) (models.WalletHold, int, error) {
+	var (
+		code            = http.StatusInternalServerError
+		posted          = LedgerPosting{Account: LedgerHoldAccount, AccountID: hold.AccountID}
+		previousBalance float64
+		moved           bool
+	)
+
+	hold.Reference = NewWalletHoldReference()
+	hold.Currency = strings.ToUpper(hold.Currency)
+	hold.Status = string(WalletHoldActive)
+
+	err := postgresql.RunWithAdvisoryLock(db.Payment, walletLockKey(hold.AccountID, hold.Currency), func(tx *gorm.DB) error {
+		walletBalance, err := GetWalletBalanceByAccountIdAndCurrency(extReq, hold.AccountID, hold.Currency)
+		if err != nil {
+			code = http.StatusBadRequest
+			return fmt.Errorf("wallet does not exist")
+		}
+
+		if hold.Amount > walletBalance.Available {
+			code = http.StatusBadRequest
+			return fmt.Errorf("insufficient wallet  balance")
+		}
+
+		posted.Reference = hold.Reference
+		previousBalance = walletBalance.Available
+		err = hold.CreateWalletHold(tx)
+		if err != nil {
+			return err
+		}
+
+		err = postWalletLedgerEntry(tx, hold.AccountID, hold.Currency, previousBalance, -hold.Amount, posted, hold.SourceReference)
+		if err != nil {
+			return fmt.Errorf("error posting ledger entry for wallet hold %v: %v", hold.Reference, err.Error())
+		}
+
+		_, err = UpdateWalletBalance(extReq, walletBalance.ID, walletBalance.Available-hold.Amount)
+		if err != nil {
+			return err
+		}
+		extReq.Logger.Info("wallet-hold", "held:", fmt.Sprintf("%v %v from account %v", hold.Currency, hold.Amount, hold.AccountID))
+
+		moved = true
+		return nil
+	})
+	if err != nil && moved {
+		extReq.Logger.Error(fmt.Sprintf("wallet hold %v was debited but could not be saved, saving it again: %v", hold.Reference, err.Error()))
+		err = db.Payment.Transaction(func(tx *gorm.DB) error {
+			hold.ID = 0
+			err := hold.CreateWalletHold(tx)
+			if err != nil {
+				return err
+			}
+			return postWalletLedgerEntry(tx, hold.AccountID, hold.Currency, previousBalance, -hold.Amount, posted, hold.SourceReference)
+		})
+		if err != nil {
+			notifyWalletLedgerDrift(extReq, hold.AccountID, hold.Currency, -hold.Amount, hold.SourceReference, fmt.Errorf("wallet hold %v was not saved: %v", hold.Reference, err.Error()))
+			return hold, http.StatusInternalServerError, err
+		}
+	} else if err != nil {
+		return hold, code, err
+	}
+
+	return hold, http.StatusOK, nil
+}
+
+// CaptureWalletHold moves the held funds on to where the hold was for, the wallet balance does not change.
+func CaptureWalletHold(extReq request.ExternalRequest, db postgresql.Databases, reference string) (models.WalletHold, int, error) {
+	var (
+		hold = models.WalletHold{Reference: reference}
+		code = http.StatusInternalServerError
+	)
+
+	err := postgresql.RunWithAdvisoryLock(db.Payment, walletHoldLockKey(reference), func(tx *gorm.DB) error {
+		var err error
+		code, err = hold.GetWalletHoldByReference(tx)
+		if err != nil {
+			return fmt.Errorf("wallet hold not found: %v", err.Error())
+		}
+
+		if hold.Status != string(WalletHoldActive) {
+			code = http.StatusBadRequest
+			return fmt.Errorf("wallet hold is already %v", hold.Status)
+		}
+
+		code = http.StatusInternalServerError
+		captureAccount := walletHoldCaptureAccount(hold.Type)
+		err = PostLedgerEntry(tx, models.LedgerEntry{
+			Type:          string(LedgerHoldCapture),
+			Reference:     hold.Reference,
+			TransactionID: hold.SourceReference,
+			Description:   fmt.Sprintf("capture of %v %v held for account %v to %v", hold.Currency, hold.Amount, hold.AccountID, captureAccount),
+			Lines: []models.LedgerLine{
+				postingLedgerLine(LedgerPosting{Account: LedgerHoldAccount, AccountID: hold.AccountID}, hold.Currency, hold.Amount, 0),
+				systemLedgerLine(captureAccount, hold.Currency, 0, hold.Amount),
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		hold.Status = string(WalletHoldCaptured)
+		hold.SettledAt = &now
+		return hold.UpdateAllFields(tx)
+	})
+	if err != nil {
+		return hold, code, err
+	}
+
+	return hold, http.StatusOK, nil
+}
+
+// ReleaseWalletHold returns the held funds to the available balance of the wallet.
+// status is either released or expired. The hold is marked releasing before the wallet is credited, so a hold whose
+// final status could not be saved is never picked up and credited again.
+func ReleaseWalletHold(extReq request.ExternalRequest, db postgresql.Databases, reference string, status WalletHoldStatus) (models.WalletHold, int, error) {
+	var (
+		hold = models.WalletHold{Reference: reference}
+		code = http.StatusInternalServerError
+	)
+
+	err := postgresql.RunWithAdvisoryLock(db.Payment, walletHoldLockKey(reference), func(tx *gorm.DB) error {
+		var err error
+		code, err = hold.GetWalletHoldByReference(tx)
+		if err != nil {
+			return fmt.Errorf("wallet hold not found: %v", err.Error())
+		}
+
+		if hold.Status != string(WalletHoldActive) {
+			code = http.StatusBadRequest
+			return fmt.Errorf("wallet hold is already %v", hold.Status)
+		}
+
+		code = http.StatusInternalServerError
+		hold.Status = string(WalletHoldReleasing)
+		return hold.UpdateAllFields(tx)
+	})
+	if err != nil {
+		return hold, code, err
+	}
+
+	_, err = creditWalletBalance(extReq, db, hold.Amount, 0, hold.Currency, hold.AccountID, LedgerPosting{Account: LedgerHoldAccount, AccountID: hold.AccountID, Reference: hold.Reference}, hold.SourceReference)
+	if err != nil {
+		// the wallet was not credited, the hold can be released again
+		hold.Status = string(WalletHoldActive)
+		if updateErr := hold.UpdateAllFields(db.Payment); updateErr != nil {
+			extReq.Logger.Error(fmt.Sprintf("wallet hold %v could not be set back to active: %v", hold.Reference, updateErr.Error()))
+		}
+		return hold, http.StatusInternalServerError, err
+	}
+
+	now := time.Now()
+	hold.Status = string(status)
+	hold.SettledAt = &now
+	err = hold.UpdateAllFields(db.Payment)
+	if err != nil {
+		extReq.Logger.Error(fmt.Sprintf("wallet hold %v was released but is left %v: %v", hold.Reference, WalletHoldReleasing, err.Error()))
+		return hold, http.StatusInternalServerError, err
+	}
+
+	return hold, http.StatusOK, nil
+}
+
+// SettleWalletHoldBySource captures or releases the active hold placed for a transfer or disbursement.
+// It returns false when no hold was placed for it, e.g. for disbursements created before holds existed.
+func SettleWalletHoldBySource(extReq request.ExternalRequest, db postgresql.Databases, holdType WalletHoldType, sourceReference string, capture bool) (bool, error) {
+	hold := models.WalletHold{Type: string(holdType), SourceReference: sourceReference}
+	code, err := hold.GetLatestWalletHoldBySource(db.Payment)
+	if err != nil {
+		if code == http.StatusInternalServerError {
+			return false, err
+		}
+		return false, nil
+	}
+
+	if hold.Status != string(WalletHoldActive) {
+		return true, nil
+	}
+
+	if capture {
+		_, _, err = CaptureWalletHold(extReq, db, hold.Reference)
+	} else {
+		_, _, err = ReleaseWalletHold(extReq, db, hold.Reference, WalletHoldReleased)
+	}
+	return true, err
+}
+
+func PlaceWalletHoldService(extReq request.ExternalRequest, db postgresql.Databases, req models.PlaceWalletHoldRequest) (models.WalletHold, int, error) {
+	hold := models.WalletHold{
+		AccountID:       req.AccountID,
+		Currency:        req.Currency,
+		Amount:          req.Amount,
+		Type:            req.Type,
+		SourceReference: req.SourceReference,
+		Reason:          req.Reason,
+	}
+	if req.ExpiresInHours > 0 {
+		expiresAt := time.Now().Add(time.Hour * time.Duration(req.ExpiresInHours))
+		hold.ExpiresAt = &expiresAt
+	}
+
+	return PlaceWalletHold(extReq, db, hold)
+}
+
+func ListWalletHoldsService(db postgresql.Databases, accountID int, req models.ListWalletHoldsRequest) ([]models.WalletHold, int, error) {
+	hold := models.WalletHold{AccountID: accountID, Currency: req.Currency}
+	holds, err := hold.GetActiveWalletHoldsByAccountID(db.Payment)
+	if err != nil {
+		return holds, http.StatusInternalServerError, err
+	}
+	return holds, http.StatusOK, nil
+}
+
+// GetWalletBalanceService returns the available balance held by the auth service and the pending balance held in the ledger.
+func GetWalletBalanceService(extReq request.ExternalRequest, db postgresql.Databases, accountID int, currency string) (models.WalletBalanceResponse, int, error) {
+	var (
+		response = models.WalletBalanceResponse{AccountID: accountID, Currency: strings.ToUpper(currency)}
+		line     = models.LedgerLine{Account: string(LedgerHoldAccount), AccountID: accountID, Currency: strings.ToUpper(currency)}
+	)
+
+	walletBalance, err := GetWalletBalanceByAccountIdAndCurrency(extReq, accountID, response.Currency)
+	if err != nil {
+		return response, http.StatusBadRequest, fmt.Errorf("wallet does not exist: %v", err.Error())
+	}
+
+	pending, err := line.GetLedgerBalance(db.Payment)
+	if err != nil {
+		return response, http.StatusInternalServerError, err
+	}
+
+	response.Available = walletBalance.Available
+	response.Pending = pending
+	response.Total = walletBalance.Available + pending
+	return response, http.StatusOK, nil
+}
+
+func ExpireWalletHolds(extReq request.ExternalRequest, db postgresql.Databases) {
+	hold := models.WalletHold{}
+	holds, err := hold.GetExpiredWalletHolds(db.Payment)
+	if err != nil {
+		extReq.Logger.Error(fmt.Sprintf("error getting expired wallet holds: %v", err.Error()))
+		return
+	}
+
+	for _, item := range holds {
+		_, _, err := ReleaseWalletHold(extReq, db, item.Reference, WalletHoldExpired)
+		if err != nil {
+			extReq.Logger.Error(fmt.Sprintf("error expiring wallet hold %v: %v", item.Reference, err.Error()))
+		}
+	}
+}
+
+// SettleDisbursementHold captures the hold placed for a wallet disbursement once it completes, or releases it
+// back to the wallet when it fails. It returns false when the disbursement has no hold.
+func SettleDisbursementHold(extReq request.ExternalRequest, db postgresql.Databases, reference string, capture bool) bool {
+	found, err := SettleWalletHoldBySource(extReq, db, WalletHoldDisbursement, reference, capture)
+	if err != nil {
+		extReq.Logger.Error(fmt.Sprintf("error settling wallet hold for disbursement %v: %v", reference, err.Error()))
+	}
+	return found
+}
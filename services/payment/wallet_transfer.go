@@ -0,0 +1,375 @@
+package payment
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/vesicash/payment-ms/external/external_models"
+	"github.com/vesicash/payment-ms/external/request"
+	"github.com/vesicash/payment-ms/internal/config"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"github.com/vesicash/payment-ms/utility"
+	"gorm.io/gorm"
+)
+
+type WalletTransferStatus string
+
+var (
+	WalletTransferPending      WalletTransferStatus = "pending"
+	WalletTransferDebited      WalletTransferStatus = "debited"
+	WalletTransferExchanged    WalletTransferStatus = "exchanged"
+	WalletTransferQueued       WalletTransferStatus = "queued"
+	WalletTransferApproved     WalletTransferStatus = "approved"
+	WalletTransferRejected     WalletTransferStatus = "rejected"
+	WalletTransferCompleted    WalletTransferStatus = "completed"
+	WalletTransferCompensating WalletTransferStatus = "compensating"
+	WalletTransferCompensated  WalletTransferStatus = "compensated"
+	WalletTransferFailed       WalletTransferStatus = "failed"
+	WalletTransferReview       WalletTransferStatus = "review"
+
+	// transfers left in these states for longer than walletTransferRecoveryDelay are picked up by RecoverWalletTransfers
+	walletTransferRecoverableStatuses = []string{string(WalletTransferPending), string(WalletTransferDebited), string(WalletTransferExchanged), string(WalletTransferApproved), string(WalletTransferCompensating)}
+	walletTransferRecoveryDelay       = time.Minute * 5
+)
+
+func NewWalletTransferReference() string {
+	id, _ := uuid.NewV4()
+	return fmt.Sprintf("wt_%v", id.String())
+}
+
+func walletTransferLockKey(reference string) string {
+	return fmt.Sprintf("wallet_transfer:%v", reference)
+}
+
+func walletTransferReversalReference(reference string) string {
+	return fmt.Sprintf("%v-reversal", reference)
+}
+
+func walletTransferSenderWallet(transfer models.WalletTransfer) string {
+	return fmt.Sprintf("%v%v", GetWalletType(transfer.EscrowWallet, ""), strings.ToUpper(transfer.SenderCurrency))
+}
+
+func walletTransferNeedsExchange(transfer models.WalletTransfer) bool {
+	initialCurrency := strings.ReplaceAll(strings.ToUpper(transfer.SenderCurrency), "ESCROW_", "")
+	finalCurrency := strings.ReplaceAll(strings.ToUpper(transfer.RecipientCurrency), "ESCROW_", "")
+	return transfer.InitialAmount > 0 && transfer.RateID != 0 && initialCurrency != finalCurrency
+}
+
+func walletTransferNeedsApproval(transfer models.WalletTransfer) bool {
+	return utility.InStringSlice(strings.ToUpper(transfer.SenderCurrency), []string{"NGN", "ESCROW_NGN"}) && transfer.Amount >= config.GetConfig().ONLINE_PAYMENT.NairaThreshold
+}
+
+// setWalletTransferStatus moves a transfer to status and records the step that got it there.
+func setWalletTransferStatus(extReq request.ExternalRequest, db postgresql.Databases, transfer *models.WalletTransfer, status WalletTransferStatus, step string, stepErr error) {
+	walletTransferStep := models.WalletTransferStep{WalletTransferID: transfer.ID, Step: step, Status: "completed"}
+	if stepErr != nil {
+		walletTransferStep.Status = "failed"
+		walletTransferStep.Error = stepErr.Error()
+		transfer.LastError = stepErr.Error()
+	}
+
+	transfer.Status = string(status)
+	err := transfer.UpdateAllFields(db.Payment)
+	if err != nil {
+		extReq.Logger.Error(fmt.Sprintf("error updating wallet transfer %v to %v: %v", transfer.Reference, status, err.Error()))
+	}
+
+	err = walletTransferStep.CreateWalletTransferStep(db.Payment)
+	if err != nil {
+		extReq.Logger.Error(fmt.Sprintf("error saving step %v for wallet transfer %v: %v", step, transfer.Reference, err.Error()))
+	}
+}
+
+// runWalletTransfer continues a transfer from its current status while holding the transfer lock,
+// so a transfer is never worked on by a request and the recovery job at the same time.
+func runWalletTransfer(extReq request.ExternalRequest, db postgresql.Databases, transfer *models.WalletTransfer) (string, int, error) {
+	var (
+		msg  string
+		code int
+	)
+
+	err := postgresql.RunWithAdvisoryLock(db.Payment, walletTransferLockKey(transfer.Reference), func(tx *gorm.DB) error {
+		var err error
+		msg, code, err = processWalletTransfer(extReq, db, transfer)
+		return err
+	})
+	if err != nil && code == 0 {
+		code = http.StatusInternalServerError
+	}
+	return msg, code, err
+}
+
+func processWalletTransfer(extReq request.ExternalRequest, db postgresql.Databases, transfer *models.WalletTransfer) (string, int, error) {
+	if transfer.Status == string(WalletTransferPending) {
+		// transfers waiting for approval only hold the funds, they are captured once the transfer is approved
+		step := "debit_sender"
+		var err error
+		if walletTransferNeedsApproval(*transfer) {
+			step = "hold_sender"
+			_, _, err = PlaceWalletHold(extReq, db, models.WalletHold{
+				AccountID:       transfer.SenderAccountID,
+				Currency:        walletTransferSenderWallet(*transfer),
+				Amount:          transfer.Amount,
+				Type:            string(WalletHoldTransfer),
+				SourceReference: transfer.Reference,
+				Reason:          "wallet transfer awaiting approval",
+			})
+		} else {
+			_, err = DebitWallet(extReq, db, transfer.Amount, transfer.SenderCurrency, transfer.SenderAccountID, GetWalletType(transfer.EscrowWallet, ""), transfer.TransactionID, LedgerPosting{Account: LedgerTransferAccount, Reference: transfer.Reference})
+		}
+		if err != nil {
+			setWalletTransferStatus(extReq, db, transfer, WalletTransferFailed, step, err)
+			return "", http.StatusInternalServerError, err
+		}
+		setWalletTransferStatus(extReq, db, transfer, WalletTransferDebited, step, nil)
+	}
+
+	if transfer.Status == string(WalletTransferDebited) {
+		if walletTransferNeedsExchange(*transfer) {
+			err := CreateExchangeTransaction(extReq, transfer.RecipientAccountID, transfer.RateID, transfer.InitialAmount, transfer.FinalAmount, ExchangeTransactionCompleted)
+			if err != nil {
+				return compensateWalletTransfer(extReq, db, transfer, "create_exchange", err)
+			}
+		}
+		setWalletTransferStatus(extReq, db, transfer, WalletTransferExchanged, "create_exchange", nil)
+	}
+
+	if transfer.Status == string(WalletTransferExchanged) {
+		if walletTransferNeedsApproval(*transfer) {
+			_, err := CreateWalletTransaction(extReq, transfer.SenderAccountID, transfer.RecipientAccountID, transfer.Amount, transfer.RecipientAmount, strings.ToUpper(transfer.SenderCurrency), strings.ToUpper(transfer.RecipientCurrency), WalletTransactionPending, false)
+			if err != nil {
+				return compensateWalletTransfer(extReq, db, transfer, "queue_for_approval", err)
+			}
+			setWalletTransferStatus(extReq, db, transfer, WalletTransferQueued, "queue_for_approval", nil)
+			return "Wallet transfer queued for approval by admin", http.StatusOK, nil
+		}
+
+		receiverWallet, err := CreditWallet(extReq, db, transfer.RecipientAmount, transfer.RecipientCurrency, transfer.RecipientAccountID, transfer.Refund, GetWalletType(transfer.EscrowWallet, ""), transfer.TransactionID, LedgerPosting{Account: LedgerTransferAccount, Reference: transfer.Reference})
+		if err != nil {
+			return compensateWalletTransfer(extReq, db, transfer, "credit_recipient", err)
+		}
+		setWalletTransferStatus(extReq, db, transfer, WalletTransferCompleted, "credit_recipient", nil)
+		finishWalletTransfer(extReq, *transfer, receiverWallet.Available)
+		return "Wallet transfer successful", http.StatusOK, nil
+	}
+
+	if transfer.Status == string(WalletTransferApproved) {
+		// the hold was captured on approval, so a failed credit is retried instead of reversed
+		receiverWallet, err := CreditWallet(extReq, db, transfer.RecipientAmount, transfer.RecipientCurrency, transfer.RecipientAccountID, transfer.Refund, GetWalletType(transfer.EscrowWallet, ""), transfer.TransactionID, LedgerPosting{Account: LedgerTransferAccount, Reference: transfer.Reference})
+		if err != nil {
+			setWalletTransferStatus(extReq, db, transfer, WalletTransferApproved, "credit_recipient", err)
+			return "", http.StatusInternalServerError, err
+		}
+		setWalletTransferStatus(extReq, db, transfer, WalletTransferCompleted, "credit_recipient", nil)
+		finishWalletTransfer(extReq, *transfer, receiverWallet.Available)
+		return "Wallet transfer approved", http.StatusOK, nil
+	}
+
+	if transfer.Status == string(WalletTransferCompensating) {
+		return compensateWalletTransfer(extReq, db, transfer, "compensate_sender", fmt.Errorf("%v", transfer.LastError))
+	}
+
+	return "", http.StatusBadRequest, fmt.Errorf("wallet transfer %v is %v", transfer.Reference, transfer.Status)
+}
+
+// compensateWalletTransfer credits the sender back, or releases the hold on the sender's funds, after a step failed once the sender had been debited.
+// The transfer stays in compensating when the credit fails so the recovery job can try again.
+func compensateWalletTransfer(extReq request.ExternalRequest, db postgresql.Databases, transfer *models.WalletTransfer, step string, cause error) (string, int, error) {
+	if step != "compensate_sender" {
+		setWalletTransferStatus(extReq, db, transfer, WalletTransferCompensating, step, cause)
+	}
+
+	var err error
+	if walletTransferNeedsApproval(*transfer) {
+		_, err = SettleWalletHoldBySource(extReq, db, WalletHoldTransfer, transfer.Reference, false)
+	} else {
+		_, err = creditWalletBalance(extReq, db, transfer.Amount, 0, walletTransferSenderWallet(*transfer), transfer.SenderAccountID, LedgerPosting{Account: LedgerTransferAccount, Reference: walletTransferReversalReference(transfer.Reference)}, transfer.TransactionID)
+	}
+	if err != nil {
+		setWalletTransferStatus(extReq, db, transfer, WalletTransferCompensating, "compensate_sender", err)
+		notifyWalletTransfer(extReq, *transfer, "could not be reversed, the recovery job will retry")
+		return "", http.StatusInternalServerError, fmt.Errorf("wallet transfer failed and could not be reversed: %v", cause.Error())
+	}
+
+	transfer.LastError = cause.Error()
+	setWalletTransferStatus(extReq, db, transfer, WalletTransferCompensated, "compensate_sender", nil)
+	notifyWalletTransfer(extReq, *transfer, "has been reversed")
+	return "", http.StatusInternalServerError, fmt.Errorf("wallet transfer failed and has been reversed: %v", cause.Error())
+}
+
+func finishWalletTransfer(extReq request.ExternalRequest, transfer models.WalletTransfer, receiverAvailable float64) {
+	var (
+		senderCurrency    = strings.ToUpper(transfer.SenderCurrency)
+		recipientCurrency = strings.ToUpper(transfer.RecipientCurrency)
+	)
+
+	senderWallet, _ := GetWalletBalanceByAccountIdAndCurrency(extReq, transfer.SenderAccountID, walletTransferSenderWallet(transfer))
+	err := SaveWalletHistory(extReq, transfer.SenderAccountID, transfer.RecipientAccountID, transfer.Amount, transfer.RecipientAmount, senderCurrency, recipientCurrency, senderWallet.Available, receiverAvailable)
+	if err != nil {
+		extReq.Logger.Error("error saving wallet history: ", err.Error())
+	}
+
+	if transfer.TransactionID != "" {
+		transaction, _ := ListTransactionsByID(extReq, transfer.TransactionID)
+		transactionTitle := strings.Split(transaction.Title, ";")[0]
+		action := "+"
+		description := fmt.Sprintf("A sum of %v %v has been paid for this transaction", recipientCurrency, transfer.RecipientAmount)
+		if transfer.Refund {
+			action = "-"
+			description = fmt.Sprintf("A sum of %v %v has been deducted based on an excess payment being made on transaction %v", recipientCurrency, transfer.RecipientAmount, transactionTitle)
+		}
+
+		extReq.SendExternalRequest(request.CreateActivityLog, external_models.CreateActivityLogRequest{
+			TransactionID: transfer.TransactionID,
+			Description:   description,
+		})
+
+		UpdateTransactionAmountPaid(extReq, transfer.TransactionID, transfer.Amount, action)
+	}
+}
+
+func notifyWalletTransfer(extReq request.ExternalRequest, transfer models.WalletTransfer, outcome string) {
+	err := SlackNotify(extReq, config.GetConfig().Slack.DisbursementChannelID, `
+			Wallet Transfer `+transfer.Reference+` `+outcome+`.
+			Environment: `+config.GetConfig().App.Name+`
+			Sender Account ID: `+fmt.Sprintf("%v", transfer.SenderAccountID)+`
+			Recipient Account ID: `+fmt.Sprintf("%v", transfer.RecipientAccountID)+`
+			Amount: `+fmt.Sprintf("%v %v", transfer.SenderCurrency, transfer.Amount)+`
+			Error: `+transfer.LastError+`
+			`)
+	if err != nil && !extReq.Test {
+		extReq.Logger.Error("error sending notification to slack: ", err.Error())
+	}
+}
+
+// RecoverWalletTransfers finishes or rolls back transfers that stopped midway, e.g. after a crash.
+// The ledger entries posted under the transfer reference tell which wallet movements already happened.
+// A transfer is only finished when all that is left is crediting the recipient, anything else is rolled back.
+// Approved transfers are always finished since the held funds were already captured. A pending transfer without
+// a debit on record may still have debited the sender, it is left for review instead of being failed.
+func RecoverWalletTransfers(extReq request.ExternalRequest, db postgresql.Databases) {
+	walletTransfer := models.WalletTransfer{}
+	transfers, err := walletTransfer.GetStaleWalletTransfers(db.Payment, walletTransferRecoverableStatuses, time.Now().Add(-walletTransferRecoveryDelay))
+	if err != nil {
+		extReq.Logger.Error(fmt.Sprintf("error getting stale wallet transfers: %v", err.Error()))
+		return
+	}
+
+	for _, transfer := range transfers {
+		err := postgresql.RunWithAdvisoryLock(db.Payment, walletTransferLockKey(transfer.Reference), func(tx *gorm.DB) error {
+			// the transfer may have moved on while waiting for the lock
+			code, err := transfer.GetWalletTransferByReference(db.Payment)
+			if err != nil {
+				return fmt.Errorf("error getting wallet transfer, code %v: %v", code, err.Error())
+			}
+			return recoverWalletTransfer(extReq, db, &transfer)
+		})
+		if err != nil {
+			extReq.Logger.Error(fmt.Sprintf("error recovering wallet transfer %v: %v", transfer.Reference, err.Error()))
+		}
+	}
+}
+
+func recoverWalletTransfer(extReq request.ExternalRequest, db postgresql.Databases, transfer *models.WalletTransfer) error {
+	if !utility.InStringSlice(transfer.Status, walletTransferRecoverableStatuses) {
+		return nil
+	}
+
+	switch WalletTransferStatus(transfer.Status) {
+	case WalletTransferPending:
+		var (
+			debited bool
+			err     error
+		)
+		if walletTransferNeedsApproval(*transfer) {
+			hold := models.WalletHold{Type: string(WalletHoldTransfer), SourceReference: transfer.Reference}
+			code, holdErr := hold.GetLatestWalletHoldBySource(db.Payment)
+			if holdErr != nil && code == http.StatusInternalServerError {
+				return holdErr
+			}
+			debited = holdErr == nil
+		} else {
+			debited, err = walletTransferPosted(db, transfer.Reference, LedgerWalletDebit)
+			if err != nil {
+				return err
+			}
+		}
+		if !debited {
+			setWalletTransferStatus(extReq, db, transfer, WalletTransferReview, "recover", fmt.Errorf("no debit of the sender on record, check the sender wallet before failing or finishing the transfer"))
+			notifyWalletTransfer(extReq, *transfer, "needs review")
+			return nil
+		}
+		setWalletTransferStatus(extReq, db, transfer, WalletTransferDebited, "recover", nil)
+		fallthrough
+
+	case WalletTransferDebited:
+		_, _, err := compensateWalletTransfer(extReq, db, transfer, "recover", fmt.Errorf("transfer interrupted after debiting sender"))
+		if err != nil {
+			extReq.Logger.Info(fmt.Sprintf("wallet transfer %v rolled back: %v", transfer.Reference, err.Error()))
+		}
+		return nil
+
+	case WalletTransferExchanged:
+		credited, err := walletTransferPosted(db, transfer.Reference, LedgerWalletCredit)
+		if err != nil {
+			return err
+		}
+		if credited {
+			setWalletTransferStatus(extReq, db, transfer, WalletTransferCompleted, "recover", nil)
+			return nil
+		}
+		if walletTransferNeedsApproval(*transfer) {
+			_, _, err := compensateWalletTransfer(extReq, db, transfer, "recover", fmt.Errorf("transfer interrupted before queueing for approval"))
+			if err != nil {
+				extReq.Logger.Info(fmt.Sprintf("wallet transfer %v rolled back: %v", transfer.Reference, err.Error()))
+			}
+			return nil
+		}
+		_, _, err = processWalletTransfer(extReq, db, transfer)
+		return err
+
+	case WalletTransferApproved:
+		credited, err := walletTransferPosted(db, transfer.Reference, LedgerWalletCredit)
+		if err != nil {
+			return err
+		}
+		if credited {
+			setWalletTransferStatus(extReq, db, transfer, WalletTransferCompleted, "recover", nil)
+			return nil
+		}
+		_, _, err = processWalletTransfer(extReq, db, transfer)
+		return err
+
+	case WalletTransferCompensating:
+		var (
+			reversed bool
+			err      error
+		)
+		if !walletTransferNeedsApproval(*transfer) {
+			reversed, err = walletTransferPosted(db, walletTransferReversalReference(transfer.Reference), LedgerWalletCredit)
+			if err != nil {
+				return err
+			}
+		}
+		if reversed {
+			setWalletTransferStatus(extReq, db, transfer, WalletTransferCompensated, "recover", nil)
+			return nil
+		}
+		_, _, err = processWalletTransfer(extReq, db, transfer)
+		if err != nil {
+			extReq.Logger.Info(fmt.Sprintf("wallet transfer %v rolled back: %v", transfer.Reference, err.Error()))
+		}
+		return nil
+	}
+
+	return nil
+}
+
+func walletTransferPosted(db postgresql.Databases, reference string, entryType LedgerEntryType) (bool, error) {
+	entry := models.LedgerEntry{Reference: reference, Type: string(entryType)}
+	return entry.CheckLedgerEntryExists(db.Payment)
+}
@@ -0,0 +1,100 @@
+package payment
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vesicash/payment-ms/external/request"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"gorm.io/gorm"
+)
+
+type WalletTransferDecision string
+
+var (
+	WalletTransferApprove WalletTransferDecision = "approve"
+	WalletTransferReject  WalletTransferDecision = "reject"
+)
+
+func ListQueuedWalletTransfersService(db postgresql.Databases, paginator postgresql.Pagination) ([]models.WalletTransfer, postgresql.PaginationResponse, int, error) {
+	walletTransfer := models.WalletTransfer{Status: string(WalletTransferQueued)}
+	transfers, pagination, err := walletTransfer.GetWalletTransfersByStatus(db.Payment, paginator)
+	if err != nil {
+		return transfers, pagination, http.StatusInternalServerError, err
+	}
+	return transfers, pagination, http.StatusOK, nil
+}
+
+// DecideWalletTransferService approves or rejects a transfer queued for approval. Approving captures the hold on the
+// sender's funds and credits the recipient, rejecting releases the hold back to the sender.
+// The admin making the decision is identified by the api keys on the request and cannot be the sender.
+func DecideWalletTransferService(c *gin.Context, extReq request.ExternalRequest, db postgresql.Databases, req models.WalletTransferDecisionRequest, decision WalletTransferDecision) (string, models.WalletTransfer, int, error) {
+	var (
+		transfer = models.WalletTransfer{Reference: req.Reference}
+		msg      string
+		code     = http.StatusInternalServerError
+	)
+
+	accessToken, err := GetAccessTokenByKeyFromRequest(extReq, c)
+	if err != nil {
+		return "", transfer, http.StatusUnauthorized, fmt.Errorf("could not identify admin: %v", err.Error())
+	}
+
+	err = postgresql.RunWithAdvisoryLock(db.Payment, walletTransferLockKey(req.Reference), func(tx *gorm.DB) error {
+		var err error
+		code, err = transfer.GetWalletTransferByReference(db.Payment)
+		if err != nil {
+			return fmt.Errorf("wallet transfer not found: %v", err.Error())
+		}
+
+		if transfer.Status != string(WalletTransferQueued) {
+			code = http.StatusBadRequest
+			return fmt.Errorf("wallet transfer is %v, only queued transfers can be approved or rejected", transfer.Status)
+		}
+
+		if accessToken.AccountID == transfer.SenderAccountID {
+			code = http.StatusForbidden
+			return fmt.Errorf("wallet transfer cannot be approved or rejected by its sender")
+		}
+
+		code = http.StatusInternalServerError
+		found, err := SettleWalletHoldBySource(extReq, db, WalletHoldTransfer, transfer.Reference, decision == WalletTransferApprove)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("no hold found for wallet transfer %v", transfer.Reference)
+		}
+
+		approval := models.WalletTransferApproval{
+			WalletTransferID: transfer.ID,
+			Reference:        transfer.Reference,
+			Action:           string(decision),
+			ActorAccountID:   accessToken.AccountID,
+			Reason:           req.Reason,
+		}
+		err = approval.CreateWalletTransferApproval(db.Payment)
+		if err != nil {
+			extReq.Logger.Error(fmt.Sprintf("error saving %v decision for wallet transfer %v: %v", decision, transfer.Reference, err.Error()))
+		}
+
+		if decision == WalletTransferReject {
+			setWalletTransferStatus(extReq, db, &transfer, WalletTransferRejected, "reject", nil)
+			notifyWalletTransfer(extReq, transfer, fmt.Sprintf("has been rejected by account %v", accessToken.AccountID))
+			msg, code = "Wallet transfer rejected", http.StatusOK
+			return nil
+		}
+
+		setWalletTransferStatus(extReq, db, &transfer, WalletTransferApproved, "approve", nil)
+		notifyWalletTransfer(extReq, transfer, fmt.Sprintf("has been approved by account %v", accessToken.AccountID))
+		msg, code, err = processWalletTransfer(extReq, db, &transfer)
+		return err
+	})
+	if err != nil {
+		return "", transfer, code, err
+	}
+
+	return msg, transfer, http.StatusOK, nil
+}
@@ -2,7 +2,6 @@ package payment
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -11,34 +10,64 @@ import (
 	"time"
 
 	"github.com/vesicash/payment-ms/external/request"
+	"github.com/vesicash/payment-ms/internal/config"
 	"github.com/vesicash/payment-ms/internal/models"
 	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	webhooksig "github.com/vesicash/payment-ms/pkg/webhook"
 	"github.com/vesicash/payment-ms/utility"
 )
 
-func InitWebhook(extReq request.ExternalRequest, db postgresql.Databases, uri, event string, data map[string]interface{}, businessID int) error {
-	if event == "" {
-		event = "payment"
-	}
-
-	dataByte, err := json.Marshal(data)
+// InitWebhook sends the event to every enabled endpoint of the business subscribed to it, and to the webhook uri on
+// its profile unless that uri is registered as an endpoint.
+func InitWebhook(extReq request.ExternalRequest, db postgresql.Databases, uri, eventType string, data webhooksig.EventData, businessID int) error {
+	event, payload, err := webhookPayload(db, businessID, eventType, data, false)
 	if err != nil {
 		return err
 	}
 
-	webhook := models.Webhook{WebhookUri: strings.TrimSpace(uri), BusinessID: strconv.Itoa(businessID), Event: event, RequestPayload: string(dataByte), IsAbandoned: false, IsReceived: false}
-	err = webhook.CreateWebhook(db.Payment)
+	endpoints, registered, err := WebhookEndpointsForEvent(db, businessID, eventType)
 	if err != nil {
-		return err
+		extReq.Logger.Error(fmt.Sprintf("error getting webhook endpoints for business %v: %v", businessID, err.Error()))
 	}
 
-	FireWebhook(extReq, db, webhook)
+	webhooks := []models.Webhook{}
+	for _, endpoint := range endpoints {
+		webhooks = append(webhooks, models.Webhook{WebhookUri: endpoint.Url, EndpointID: endpoint.ID, BusinessID: strconv.Itoa(businessID), Event: eventType, EventID: event.ID, ApiVersion: event.APIVersion, RequestPayload: payload, IsAbandoned: false, IsReceived: false})
+	}
+
+	uri = strings.TrimSpace(uri)
+	if uri != "" {
+		isRegistered := false
+		for _, endpoint := range registered {
+			if endpoint.Url == uri {
+				isRegistered = true
+				break
+			}
+		}
+		if !isRegistered {
+			webhooks = append(webhooks, models.Webhook{WebhookUri: uri, BusinessID: strconv.Itoa(businessID), Event: eventType, EventID: event.ID, ApiVersion: event.APIVersion, RequestPayload: payload, IsAbandoned: false, IsReceived: false})
+		}
+	}
+
+	// the first attempt is made here, the lease keeps the dispatcher from sending them at the same time
+	settings := webhookDispatcherDefaults()
+	claimedUntil := time.Now().Add(time.Duration(len(webhooks)+1) * time.Duration(settings.TimeoutSeconds) * time.Second)
+	for _, webhook := range webhooks {
+		webhook.ClaimedUntil = &claimedUntil
+		err = webhook.CreateWebhook(db.Payment)
+		if err != nil {
+			return err
+		}
+
+		FireWebhook(extReq, db, webhook)
+	}
 	return nil
 }
 
 func FireWebhook(extReq request.ExternalRequest, db postgresql.Databases, webhook models.Webhook) error {
 	var (
-		method = "POST"
+		method   = "POST"
+		settings = webhookDispatcherDefaults()
 	)
 
 	if extReq.Test {
@@ -53,22 +82,32 @@ func FireWebhook(extReq request.ExternalRequest, db postgresql.Databases, webhoo
 		}
 	}
 	businessId, _ := strconv.Atoi(webhook.BusinessID)
-	apiKey, _ := GetAccessTokenByBusinessID(extReq, businessId)
-
-	data := map[string]interface{}{}
-
-	json.Unmarshal([]byte(webhook.RequestPayload), &data)
 
-	buf := new(bytes.Buffer)
-	err := json.NewEncoder(buf).Encode(data)
-	if err != nil {
-		extReq.Logger.Error("webhook error for id: ", webhook.ID, err.Error())
-		return err
+	var endpoint models.WebhookEndpoint
+	if webhook.EndpointID != 0 {
+		endpoint = models.WebhookEndpoint{ID: webhook.EndpointID, BusinessID: businessId}
+		code, err := endpoint.GetWebhookEndpointByIDAndBusinessID(db.Payment)
+		if err != nil {
+			if code == http.StatusBadRequest {
+				webhook.IsAbandoned = true
+				webhook.ResponsePayload = "webhook endpoint deleted"
+				webhook.ClaimedUntil = nil
+				return webhook.UpdateAllFields(db.Payment)
+			}
+			return err
+		}
+		if !endpoint.Enabled {
+			return nil
+		}
+		webhook.WebhookUri = endpoint.Url
 	}
 
-	extReq.Logger.Info("request for id: ", webhook.WebhookUri, data, webhook.RequestPayload)
+	// the payload is sent as stored, the signature covers these exact bytes
+	buf := bytes.NewBufferString(webhook.RequestPayload)
+
+	extReq.Logger.Info("request for id: ", webhook.WebhookUri, webhook.RequestPayload)
 
-	client := &http.Client{}
+	client := &http.Client{Timeout: time.Duration(settings.TimeoutSeconds) * time.Second}
 	req, err := http.NewRequest(method, webhook.WebhookUri, buf)
 	if err != nil {
 		extReq.Logger.Error("request creation error for id: ", webhook.ID, err.Error())
@@ -79,11 +118,20 @@ func FireWebhook(extReq request.ExternalRequest, db postgresql.Databases, webhoo
 		"Content-Type": "application/json",
 	}
 
-	if apiKey.PrivateKey != "" {
-		headerSignature := fmt.Sprintf("%v:%v", apiKey.PrivateKey, businessId)
-		hmacResult := utility.Sha256Hmac(apiKey.PrivateKey, []byte(headerSignature))
-		headers["X-Vesicash-Webhook-Secret"] = hmacResult
-		headers["User-Agent"] = "Vesicash Agent/2.0"
+	// registered endpoints are signed with their own secrets, the profile uri with the api private key
+	signedAt := time.Now()
+	headers["User-Agent"] = "Vesicash Agent/2.0"
+	if webhook.EndpointID != 0 {
+		headers[webhooksig.SignatureHeader] = webhooksig.Sign(signedAt, buf.Bytes(), WebhookEndpointSecrets(endpoint, signedAt)...)
+	} else {
+		apiKey, _ := GetAccessTokenByBusinessID(extReq, businessId)
+		if apiKey.PrivateKey != "" {
+			headers[webhooksig.SignatureHeader] = webhooksig.Sign(signedAt, buf.Bytes(), apiKey.PrivateKey)
+
+			// Deprecated: does not cover the payload, kept until businesses move to X-Vesicash-Signature
+			headerSignature := fmt.Sprintf("%v:%v", apiKey.PrivateKey, businessId)
+			headers["X-Vesicash-Webhook-Secret"] = utility.Sha256Hmac(apiKey.PrivateKey, []byte(headerSignature))
+		}
 	}
 
 	for key, value := range headers {
@@ -95,34 +143,16 @@ func FireWebhook(extReq request.ExternalRequest, db postgresql.Databases, webhoo
 	res, err := client.Do(req)
 	if err != nil {
 		extReq.Logger.Error("client do error for id: ", webhook.ID, err.Error())
-		return err
+		return recordWebhookAttempt(db, &webhook, settings, 0, err.Error())
 	}
+	defer res.Body.Close()
 
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
 		extReq.Logger.Error("reading body error for id: ", webhook.ID, err.Error())
-		return err
 	}
 
-	webhook.RetryAt = strconv.Itoa(int(time.Now().Add(24 * time.Hour).Unix()))
-	webhook.Tries = webhook.Tries + 1
-	webhook.ResponseCode = strconv.Itoa(res.StatusCode)
-	webhook.ResponsePayload = string(body)
-
-	if res.StatusCode >= 200 && res.StatusCode <= 299 {
-		webhook.IsReceived = true
-	} else {
-
-		webhook.IsReceived = false
-	}
-
-	defer res.Body.Close()
-
-	if webhook.Tries > 10 {
-		webhook.IsAbandoned = true
-	}
-
-	err = webhook.UpdateAllFields(db.Payment)
+	err = recordWebhookAttempt(db, &webhook, settings, res.StatusCode, string(body))
 	if err != nil {
 		return err
 	}
@@ -132,4 +162,23 @@ func FireWebhook(extReq request.ExternalRequest, db postgresql.Databases, webhoo
 	return nil
 }
 
-// TODO write cron job for webhooks
+// recordWebhookAttempt saves the outcome of a delivery, failed deliveries are retried with backoff until MaxTries.
+func recordWebhookAttempt(db postgresql.Databases, webhook *models.Webhook, settings config.WebhookDispatcher, statusCode int, response string) error {
+	webhook.Tries = webhook.Tries + 1
+	webhook.ResponsePayload = response
+	webhook.ResponseCode = ""
+	if statusCode != 0 {
+		webhook.ResponseCode = strconv.Itoa(statusCode)
+	}
+	webhook.IsReceived = statusCode >= 200 && statusCode <= 299
+	webhook.ClaimedUntil = nil
+
+	if !webhook.IsReceived {
+		webhook.RetryAt = strconv.Itoa(int(time.Now().Add(WebhookRetryDelay(settings, webhook.Tries)).Unix()))
+		if webhook.Tries >= settings.MaxTries {
+			webhook.IsAbandoned = true
+		}
+	}
+
+	return webhook.UpdateAllFields(db.Payment)
+}
@@ -0,0 +1,128 @@
+package payment
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vesicash/payment-ms/external/request"
+	"github.com/vesicash/payment-ms/internal/config"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+func CampayWebhookService(c *gin.Context, extReq request.ExternalRequest, db postgresql.Databases, req models.CampayWebhookRequest) (int, error) {
+	var (
+		paymentChannelD = config.GetConfig().Slack.PaymentChannelID
+	)
+
+	if !utility.VerifyHS256Jwt(req.Signature, config.GetConfig().Campay.WebhookKey) {
+		extReq.Logger.Error("campay webhhook log error", "Web Hook Denied, invalid signature", req)
+		return http.StatusUnauthorized, fmt.Errorf("web Hook Denied, invalid signature")
+	}
+
+	reqByte, _ := json.Marshal(req)
+	extReq.Logger.Info("campay webhhook log info", string(reqByte))
+	webhookLog := models.WebhookLog{
+		Log:      string(reqByte),
+		Provider: "campay",
+	}
+	err := webhookLog.CreateWebhookLog(db.Payment)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	if req.ExternalReference == "" {
+		extReq.Logger.Error("campay webhhook log error", "external reference not found")
+		return http.StatusBadRequest, fmt.Errorf("external reference not found")
+	}
+
+	err = SlackNotify(extReq, paymentChannelD, `
+					Web Hook Received Campay
+					Environment: `+config.GetConfig().App.Name+`
+					Event: `+req.Status+`
+					Reference: `+fmt.Sprintf("campay reference:%v, external reference: %v", req.Reference, req.ExternalReference)+`
+					Status: SUCCESSFUL
+			`)
+	if err != nil && !extReq.Test {
+		extReq.Logger.Error("error sending notification to slack: ", err.Error())
+	}
+
+	go func(extReq request.ExternalRequest, db postgresql.Databases, req models.CampayWebhookRequest) {
+		code, err := handleCampayWebhookRequest(extReq, db, req)
+		if err != nil {
+			extReq.Logger.Error("campay webhhook log error:", err.Error(), "code:", code)
+		}
+	}(extReq, db, req)
+
+	return http.StatusOK, nil
+}
+
+func handleCampayWebhookRequest(extReq request.ExternalRequest, db postgresql.Databases, req models.CampayWebhookRequest) (int, error) {
+	var (
+		paymentChannelD = config.GetConfig().Slack.PaymentChannelID
+		campay          = Campay{ExtReq: extReq}
+		currency        = strings.ToUpper(req.Currency)
+	)
+
+	paymentInfo := models.PaymentInfo{Reference: req.ExternalReference}
+	code, err := paymentInfo.GetPaymentInfoByReference(db.Payment)
+	if err != nil {
+		return code, fmt.Errorf("payment info not found: %v", err.Error())
+	}
+
+	payment := models.Payment{PaymentID: paymentInfo.PaymentID}
+	code, err = payment.GetPaymentByPaymentID(db.Payment)
+	if err != nil {
+		return code, fmt.Errorf("payment not found: %v", err.Error())
+	}
+
+	if payment.IsPaid || paymentInfo.Status == "paid" {
+		return http.StatusOK, nil
+	}
+
+	if campayStatus(req.Status) == "failed" {
+		paymentInfo.Status = "failed"
+		paymentInfo.UpdateAllFields(db.Payment)
+		return http.StatusOK, nil
+	}
+
+	verified, paidAmount, err := campay.VerifyTrans(req.ExternalReference, payment.TotalAmount, thisOrThatStr(payment.Currency, currency))
+	if err != nil || !verified {
+		return http.StatusBadRequest, fmt.Errorf("payment verification failed: %v", err)
+	}
+
+	paymentInfo.Status = "paid"
+	paymentInfo.UpdateAllFields(db.Payment)
+
+	if payment.TransactionID != "" {
+		transaction, _ := ListTransactionsByID(extReq, payment.TransactionID)
+		transactionPaid(extReq, db, &payment, &transaction, req.ExternalReference, currency, "mobile_money")
+	} else {
+		payment.IsPaid = true
+		payment.WalletFunded = currency
+		payment.PaymentMethod = "mobile_money"
+		payment.PaymentMadeAt = time.Now()
+		payment.UpdateAllFields(db.Payment)
+	}
+
+	err = SlackNotify(extReq, paymentChannelD, `
+			[WEBHOOK CAMPAY] Mobile Money Payment
+			Environment: `+config.GetConfig().App.Name+`
+			Reference: `+req.ExternalReference+`
+			Transaction ID: `+payment.TransactionID+`
+			Payment ID: `+payment.PaymentID+`
+			Operator: `+req.Operator+`
+			Amount: `+fmt.Sprintf("%v %v", currency, paidAmount)+`
+			Status: SUCCESSFUL
+			`)
+	if err != nil && !extReq.Test {
+		extReq.Logger.Error("error sending notification to slack: ", err.Error())
+	}
+
+	return http.StatusOK, nil
+}
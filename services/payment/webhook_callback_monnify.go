@@ -14,6 +14,7 @@ import (
 	"github.com/vesicash/payment-ms/internal/config"
 	"github.com/vesicash/payment-ms/internal/models"
 	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	webhooksig "github.com/vesicash/payment-ms/pkg/webhook"
 	"github.com/vesicash/payment-ms/utility"
 )
 
@@ -252,13 +253,11 @@ func handleMonnifyWebhookRequest(c *gin.Context, extReq request.ExternalRequest,
 				extReq.Logger.Error("error sending notification to slack: ", err.Error())
 			}
 			businessProfileData, _ := GetBusinessProfileByAccountID(extReq, extReq.Logger, int(transaction.BusinessID))
-			if businessProfileData.Webhook_uri != "" {
-				InitWebhook(extReq, db, businessProfileData.Webhook_uri, "bank-transfer.success", map[string]interface{}{
-					"payment_id": payment.PaymentID,
-					"amount":     amountPaid,
-					"status":     "success",
-				}, businessProfileData.AccountID)
-			}
+			InitWebhook(extReq, db, businessProfileData.Webhook_uri, webhooksig.EventBankTransferSuccess, webhooksig.BankTransfer{
+				PaymentID: payment.PaymentID,
+				Amount:    amountPaid,
+				Status:    "success",
+			}, businessProfileData.AccountID)
 			if tErr != nil {
 				return http.StatusBadRequest, fmt.Errorf("transaction with ID %v not found", payment.TransactionID)
 			}
@@ -284,13 +283,11 @@ func handleMonnifyWebhookRequest(c *gin.Context, extReq request.ExternalRequest,
 			payment.UpdateAllFields(db.Payment)
 
 			businessProfileData, _ := GetBusinessProfileByAccountID(extReq, extReq.Logger, int(transaction.BusinessID))
-			if businessProfileData.Webhook_uri != "" {
-				InitWebhook(extReq, db, businessProfileData.Webhook_uri, "bank-transfer.failed", map[string]interface{}{
-					"payment_id": payment.PaymentID,
-					"amount":     amountPaid,
-					"status":     "failed",
-				}, businessProfileData.AccountID)
-			}
+			InitWebhook(extReq, db, businessProfileData.Webhook_uri, webhooksig.EventBankTransferFailed, webhooksig.BankTransfer{
+				PaymentID: payment.PaymentID,
+				Amount:    amountPaid,
+				Status:    "failed",
+			}, businessProfileData.AccountID)
 		}
 
 	}
@@ -370,24 +367,25 @@ func MonnifyDisbursementCallbackService(c *gin.Context, extReq request.ExternalR
 	}
 
 	if strings.EqualFold(disbursementStatus, "FAILED") && strings.EqualFold(req.EventType, "FAILED_DISBURSEMENT") {
-		disbursement := models.Disbursement{Reference: generatedReference}
-		code, err := disbursement.GetDisbursementByReference(db.Payment)
+		disbursement, code, err := GetDisbursementByAnyReference(db, generatedReference)
 		if err != nil {
 			extReq.Logger.Error("monnify callback log error", fmt.Sprintf("disbursement with reference %v, not found. Error: %v", generatedReference, err.Error()))
 			return code, err
 		}
 
-		disbursement.Status = "failed"
-		err = disbursement.UpdateAllFields(db.Payment)
+		status, err := HandleDisbursementFailure(extReq, db, &disbursement, "webhook:monnify", nil, req)
 		if err != nil {
 			extReq.Logger.Error("monnify callback log error", fmt.Sprintf("err updating disbursement: %v", err.Error()))
-			return code, err
+			return code, nil
 		}
+		RefreshDisbursementBatch(extReq, db, disbursement.BatchReference)
 
 		// credit the wallet back
-		_, err = CreditWallet(extReq, db, amountPaid, strings.ToUpper(currency), disbursement.RecipientID, true, "no", "")
-		if err != nil {
-			extReq.Logger.Error("monnify callback log error", fmt.Sprintf("crediting wallet for business id: %v, amount: %v %v, Error: %v", disbursement.BusinessID, disbursement.Currency, amountPaid, err.Error()))
+		if status == DisbursementFailed && !SettleDisbursementHold(extReq, db, disbursement.Reference, false) {
+			_, err = CreditWallet(extReq, db, amountPaid, strings.ToUpper(currency), disbursement.RecipientID, true, DefaultWalletType, "", LedgerPosting{Account: LedgerPayoutAccount, Reference: disbursement.Reference})
+			if err != nil {
+				extReq.Logger.Error("monnify callback log error", fmt.Sprintf("crediting wallet for business id: %v, amount: %v %v, Error: %v", disbursement.BusinessID, disbursement.Currency, amountPaid, err.Error()))
+			}
 		}
 	}
 
@@ -466,25 +464,21 @@ func fundAccount(extReq request.ExternalRequest, db postgresql.Databases, amount
 		}
 
 		businessProfileData, _ := GetBusinessProfileByAccountID(extReq, extReq.Logger, int(user.AccountID))
-		if businessProfileData.Webhook_uri != "" {
-			InitWebhook(extReq, db, businessProfileData.Webhook_uri, "bank-transfer.success", map[string]interface{}{
-				"reference": generatedReference,
-				"amount":    amountPaid,
-				"status":    "success",
-			}, businessProfileData.AccountID)
-		}
+		InitWebhook(extReq, db, businessProfileData.Webhook_uri, webhooksig.EventBankTransferSuccess, webhooksig.BankTransfer{
+			Reference: generatedReference,
+			Amount:    amountPaid,
+			Status:    "success",
+		}, businessProfileData.AccountID)
 		paymentAccountByte, _ := json.Marshal(paymentAccount)
 		extReq.Logger.Info("Monnify Bank Transfer Confirmed", "data:", string(paymentAccountByte))
 
 	} else {
 		businessProfileData, _ := GetBusinessProfileByAccountID(extReq, extReq.Logger, int(user.AccountID))
-		if businessProfileData.Webhook_uri != "" {
-			InitWebhook(extReq, db, businessProfileData.Webhook_uri, "bank-transfer.failed", map[string]interface{}{
-				"reference": generatedReference,
-				"amount":    amountPaid,
-				"status":    "failed",
-			}, businessProfileData.AccountID)
-		}
+		InitWebhook(extReq, db, businessProfileData.Webhook_uri, webhooksig.EventBankTransferFailed, webhooksig.BankTransfer{
+			Reference: generatedReference,
+			Amount:    amountPaid,
+			Status:    "failed",
+		}, businessProfileData.AccountID)
 		paymentAccountByte, _ := json.Marshal(paymentAccount)
 		extReq.Logger.Error("Monnify Bank Transfer Not Confirmed", "data:", string(paymentAccountByte))
 
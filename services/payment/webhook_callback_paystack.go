@@ -0,0 +1,169 @@
+package payment
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vesicash/payment-ms/external/request"
+	"github.com/vesicash/payment-ms/internal/config"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+func PaystackWebhookService(c *gin.Context, extReq request.ExternalRequest, db postgresql.Databases, req models.PaystackWebhookRequest, requestBody []byte) (int, error) {
+	var (
+		secret            = config.GetConfig().Paystack.SecretKey
+		paystackSignature = utility.GetHeader(c, "x-paystack-signature")
+		paymentChannelD   = config.GetConfig().Slack.PaymentChannelID
+		reference         string
+	)
+
+	hash := utility.Sha512Hmac(secret, requestBody)
+	if hash != paystackSignature {
+		extReq.Logger.Error("paystack webhhook log error", "Web Hook Denied, Hash Mismatch", hash, paystackSignature, requestBody)
+		return http.StatusUnauthorized, fmt.Errorf("web Hook Denied, Hash Mismatch")
+	}
+
+	extReq.Logger.Info("paystack webhhook log info", string(requestBody))
+	webhookLog := models.WebhookLog{
+		Log:      string(requestBody),
+		Provider: "paystack",
+	}
+	err := webhookLog.CreateWebhookLog(db.Payment)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	if req.Event == "" {
+		extReq.Logger.Error("paystack webhhook log error", "no event type specified")
+		return http.StatusBadRequest, fmt.Errorf("no event type specified")
+	}
+
+	if req.Data == nil {
+		extReq.Logger.Error("paystack webhhook log error", "event data not found")
+		return http.StatusBadRequest, fmt.Errorf("event data not found")
+	}
+
+	if req.Data.Reference != nil {
+		reference = *req.Data.Reference
+	}
+
+	err = SlackNotify(extReq, paymentChannelD, `
+					Web Hook Received Paystack
+					Environment: `+config.GetConfig().App.Name+`
+					Event: `+req.Event+`
+					Reference: `+reference+`
+					Status: SUCCESSFUL
+			`)
+	if err != nil && !extReq.Test {
+		extReq.Logger.Error("error sending notification to slack: ", err.Error())
+	}
+
+	go func(c *gin.Context, extReq request.ExternalRequest, db postgresql.Databases, req models.PaystackWebhookRequest) {
+		var (
+			err     error
+			errCode = http.StatusOK
+		)
+		switch req.Event {
+		case "charge.success":
+			errCode, err = handlePaystackChargeSuccess(extReq, db, *req.Data)
+		case "transfer.success", "transfer.failed", "transfer.reversed":
+			errCode, err = handlePaystackTransfer(extReq, db, req.Event, *req.Data)
+		default:
+			err, errCode = fmt.Errorf("event type %v, not implemented", req.Event), http.StatusNotImplemented
+		}
+
+		if err != nil {
+			extReq.Logger.Error("paystack webhhook log error: ", err.Error(), "error code:", errCode)
+		}
+	}(c, extReq, db, req)
+
+	return http.StatusOK, nil
+}
+
+func handlePaystackChargeSuccess(extReq request.ExternalRequest, db postgresql.Databases, data models.PaystackWebhookRequestData) (int, error) {
+	var (
+		ref             string
+		amount          float64
+		currency        string
+		paymentChannelD = config.GetConfig().Slack.PaymentChannelID
+		paystack        = Paystack{ExtReq: extReq}
+	)
+
+	if data.Reference != nil {
+		ref = *data.Reference
+	} else {
+		return http.StatusBadRequest, fmt.Errorf("data reference not found")
+	}
+
+	if data.Amount != nil {
+		amount = fromPaystackSubunit(*data.Amount)
+	}
+
+	if data.Currency != nil {
+		currency = strings.ToUpper(*data.Currency)
+	}
+
+	paymentInfo := models.PaymentInfo{Reference: ref}
+	code, err := paymentInfo.GetPaymentInfoByReference(db.Payment)
+	if err != nil {
+		return code, fmt.Errorf("payment info not found: %v", err.Error())
+	}
+
+	payment := models.Payment{PaymentID: paymentInfo.PaymentID}
+	code, err = payment.GetPaymentByPaymentID(db.Payment)
+	if err != nil {
+		return code, fmt.Errorf("payment not found: %v", err.Error())
+	}
+
+	if payment.IsPaid {
+		return http.StatusOK, nil
+	}
+
+	verified, _, err := paystack.VerifyTrans(ref, payment.TotalAmount, thisOrThatStr(payment.Currency, currency))
+	if err != nil || !verified {
+		return http.StatusBadRequest, fmt.Errorf("payment verification failed: %v", err)
+	}
+
+	paymentInfo.Status = "paid"
+	paymentInfo.UpdateAllFields(db.Payment)
+
+	if payment.TransactionID != "" {
+		transaction, _ := ListTransactionsByID(extReq, payment.TransactionID)
+		transactionPaid(extReq, db, &payment, &transaction, ref, currency, "card_payment")
+	} else {
+		payment.IsPaid = true
+		payment.WalletFunded = currency
+		payment.PaymentMethod = "card_payment"
+		payment.PaymentMadeAt = time.Now()
+		payment.UpdateAllFields(db.Payment)
+	}
+
+	err = SlackNotify(extReq, paymentChannelD, `
+			[WEBHOOK PAYSTACK] Card Payment
+			Environment: `+config.GetConfig().App.Name+`
+			Reference: `+ref+`
+			Transaction ID: `+payment.TransactionID+`
+			Payment ID: `+payment.PaymentID+`
+			Amount: `+fmt.Sprintf("%v %v", currency, amount)+`
+			Escrow Charge: `+fmt.Sprintf("%v", payment.EscrowCharge)+`
+			Status: SUCCESSFUL
+			`)
+	if err != nil && !extReq.Test {
+		extReq.Logger.Error("error sending notification to slack: ", err.Error())
+	}
+
+	return http.StatusOK, nil
+}
+
+func handlePaystackTransfer(extReq request.ExternalRequest, db postgresql.Databases, event string, data models.PaystackWebhookRequestData) (int, error) {
+	if data.Reference == nil {
+		return http.StatusBadRequest, fmt.Errorf("data reference not found")
+	}
+
+	return settleDisbursementFromWebhook(extReq, db, "paystack", *data.Reference, event == "transfer.success", data)
+}
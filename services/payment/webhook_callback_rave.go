@@ -300,6 +300,11 @@ func handleTransferCompleted(c *gin.Context, extReq request.ExternalRequest, db
 		transferStatus = *data.Status
 	}
 
+	// only mobile money payouts are settled from the webhook, bank payouts are confirmed by the disbursement check
+	if disbursement, _, err := GetDisbursementByAnyReference(db, ref); err == nil && disbursement.Gateway == "rave_momo" {
+		return settleDisbursementFromWebhook(extReq, db, "rave", ref, strings.EqualFold(transferStatus, "SUCCESSFUL"), data)
+	}
+
 	fundingAccounts := models.FundingAccount{AccountNumber: accountNumber}
 	code, err := fundingAccounts.GetFundingAccountByAccountNumber(db.Payment)
 	if err != nil {
@@ -426,7 +431,7 @@ func transactionPaid(extReq request.ExternalRequest, db postgresql.Databases, pa
 	if err == nil {
 		vesicashCharge, _ := strconv.ParseFloat(businessEscrowCharge.VesicashCharge, 64)
 		businessPerc, _ := strconv.ParseFloat(businessEscrowCharge.BusinessCharge, 64)
-		_, err = CreditWallet(extReq, db, utility.PercentageOf(payment.TotalAmount, vesicashCharge), transaction.Currency, 1, false, "no", transaction.TransactionID)
+		_, err = CreditWallet(extReq, db, utility.PercentageOf(payment.TotalAmount, vesicashCharge), transaction.Currency, 1, false, DefaultWalletType, transaction.TransactionID)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,158 @@
+package payment
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vesicash/payment-ms/external/request"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	webhooksig "github.com/vesicash/payment-ms/pkg/webhook"
+)
+
+// sample data for test events
+var webhookTestEventData = map[string]webhooksig.EventData{
+	webhooksig.EventPaymentSuccess:      webhooksig.Payment{TransactionID: "test_transaction", TransactionTitle: "Test transaction", Status: "success"},
+	webhooksig.EventPaymentFailed:       webhooksig.Payment{TransactionID: "test_transaction", TransactionTitle: "Test transaction", Status: "failed"},
+	webhooksig.EventBankTransferSuccess: webhooksig.BankTransfer{PaymentID: "test_payment", Amount: 1000, Status: "success"},
+	webhooksig.EventBankTransferFailed:  webhooksig.BankTransfer{PaymentID: "test_payment", Amount: 1000, Status: "failed"},
+	webhooksig.EventDisbursementSuccess: webhooksig.Disbursement{DisbursementID: 1000000000, Reference: "test_reference", Status: "success"},
+	webhooksig.EventDisbursementFailed:  webhooksig.Disbursement{DisbursementID: 1000000000, Reference: "test_reference", Status: "failed"},
+}
+
+func WebhookDeliveryStatus(webhook models.Webhook) string {
+	switch {
+	case webhook.IsReceived:
+		return "delivered"
+	case webhook.IsAbandoned:
+		return "abandoned"
+	default:
+		return "pending"
+	}
+}
+
+func webhookDelivery(webhook models.Webhook) models.WebhookDelivery {
+	return models.WebhookDelivery{Webhook: webhook, Status: WebhookDeliveryStatus(webhook)}
+}
+
+// deliverWebhookNow saves a new webhook and makes its first attempt at once, returning the saved outcome.
+func deliverWebhookNow(extReq request.ExternalRequest, db postgresql.Databases, webhook models.Webhook) (models.WebhookDelivery, int, error) {
+	settings := webhookDispatcherDefaults()
+	claimedUntil := time.Now().Add(2 * time.Duration(settings.TimeoutSeconds) * time.Second)
+	webhook.ClaimedUntil = &claimedUntil
+
+	err := webhook.CreateWebhook(db.Payment)
+	if err != nil {
+		return models.WebhookDelivery{}, http.StatusInternalServerError, err
+	}
+
+	err = FireWebhook(extReq, db, webhook)
+	if err != nil {
+		extReq.Logger.Error(fmt.Sprintf("error delivering webhook %v: %v", webhook.ID, err.Error()))
+	}
+
+	_, err = webhook.GetWebhookByIDAndBusinessID(db.Payment)
+	if err != nil {
+		return webhookDelivery(webhook), http.StatusInternalServerError, err
+	}
+	return webhookDelivery(webhook), http.StatusOK, nil
+}
+
+func ListWebhookDeliveriesService(db postgresql.Databases, businessID int, req models.ListWebhookDeliveriesRequest, paginator postgresql.Pagination) ([]models.WebhookDelivery, postgresql.PaginationResponse, int, error) {
+	var (
+		webhook    = models.Webhook{BusinessID: strconv.Itoa(businessID)}
+		deliveries = []models.WebhookDelivery{}
+	)
+
+	webhooks, pagination, err := webhook.GetWebhooksByBusinessID(db.Payment, paginator, req)
+	if err != nil {
+		return deliveries, pagination, http.StatusInternalServerError, err
+	}
+
+	for _, w := range webhooks {
+		deliveries = append(deliveries, webhookDelivery(w))
+	}
+	return deliveries, pagination, http.StatusOK, nil
+}
+
+func GetWebhookDeliveryService(db postgresql.Databases, businessID, id int) (models.WebhookDelivery, int, error) {
+	webhook := models.Webhook{ID: uint(id), BusinessID: strconv.Itoa(businessID)}
+	code, err := webhook.GetWebhookByIDAndBusinessID(db.Payment)
+	if err != nil {
+		if code == http.StatusBadRequest {
+			code = http.StatusNotFound
+		}
+		return models.WebhookDelivery{}, code, fmt.Errorf("webhook delivery not found: %v", err.Error())
+	}
+	return webhookDelivery(webhook), http.StatusOK, nil
+}
+
+// RedeliverWebhookService sends the payload of a past delivery again as a new delivery, the original is kept in the
+// log as it was. Abandoned and delivered webhooks can be redelivered.
+func RedeliverWebhookService(extReq request.ExternalRequest, db postgresql.Databases, businessID, id int) (models.WebhookDelivery, int, error) {
+	original, code, err := GetWebhookDeliveryService(db, businessID, id)
+	if err != nil {
+		return original, code, err
+	}
+
+	if original.EndpointID != 0 {
+		endpoint, _, err := GetWebhookEndpointService(db, businessID, int(original.EndpointID))
+		if err != nil {
+			return original, http.StatusBadRequest, fmt.Errorf("webhook endpoint %v no longer exists", original.EndpointID)
+		}
+		if !endpoint.Enabled {
+			return original, http.StatusBadRequest, fmt.Errorf("webhook endpoint %v is disabled", endpoint.ID)
+		}
+	}
+
+	return deliverWebhookNow(extReq, db, models.Webhook{
+		Event:          original.Event,
+		BusinessID:     original.BusinessID,
+		WebhookUri:     original.WebhookUri,
+		EndpointID:     original.EndpointID,
+		EventID:        original.EventID,
+		ApiVersion:     original.ApiVersion,
+		RequestPayload: original.RequestPayload,
+		RedeliveryOf:   original.ID,
+		IsTest:         original.IsTest,
+	})
+}
+
+// SendTestWebhookService sends a sample event to an endpoint, so a business can check its integration without a real payment.
+func SendTestWebhookService(extReq request.ExternalRequest, db postgresql.Databases, businessID, endpointID int, req models.SendTestWebhookRequest) (models.WebhookDelivery, int, error) {
+	event := strings.ToLower(strings.TrimSpace(req.Event))
+	if event == "" {
+		event = WebhookEvents[0]
+	}
+	data, ok := webhookTestEventData[event]
+	if !ok {
+		return models.WebhookDelivery{}, http.StatusBadRequest, fmt.Errorf("event %v not supported", event)
+	}
+
+	endpoint, code, err := GetWebhookEndpointService(db, businessID, endpointID)
+	if err != nil {
+		return models.WebhookDelivery{}, code, err
+	}
+	if !endpoint.Enabled {
+		return models.WebhookDelivery{}, http.StatusBadRequest, fmt.Errorf("webhook endpoint %v is disabled", endpoint.ID)
+	}
+
+	webhookEvent, payload, err := webhookPayload(db, businessID, event, data, true)
+	if err != nil {
+		return models.WebhookDelivery{}, http.StatusInternalServerError, err
+	}
+
+	return deliverWebhookNow(extReq, db, models.Webhook{
+		Event:          event,
+		EventID:        webhookEvent.ID,
+		ApiVersion:     webhookEvent.APIVersion,
+		BusinessID:     strconv.Itoa(businessID),
+		WebhookUri:     endpoint.Url,
+		EndpointID:     endpoint.ID,
+		RequestPayload: payload,
+		IsTest:         true,
+	})
+}
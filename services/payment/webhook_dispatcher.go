@@ -0,0 +1,190 @@
+package payment
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/vesicash/payment-ms/external/request"
+	"github.com/vesicash/payment-ms/internal/config"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"gorm.io/gorm"
+)
+
+func webhookDispatcherDefaults() config.WebhookDispatcher {
+	settings := config.GetConfig().WebhookDispatcher
+	if settings.Workers <= 0 {
+		settings.Workers = 10
+	}
+	if settings.EndpointConcurrency <= 0 {
+		settings.EndpointConcurrency = 2
+	}
+	if settings.ClaimSize <= 0 {
+		settings.ClaimSize = 100
+	}
+	if settings.TimeoutSeconds <= 0 {
+		settings.TimeoutSeconds = 10
+	}
+	if settings.MaxTries <= 0 {
+		settings.MaxTries = 15
+	}
+	if settings.BaseDelaySeconds <= 0 {
+		settings.BaseDelaySeconds = 10
+	}
+	if settings.MaxDelaySeconds <= 0 {
+		settings.MaxDelaySeconds = 21600
+	}
+	if settings.JitterPercent <= 0 {
+		settings.JitterPercent = 20
+	}
+	return settings
+}
+
+// WebhookRetryDelay is the wait before the next delivery after tries failed attempts, doubling from the base delay
+// up to the max delay with a random jitter so an endpoint coming back up is not hit by every webhook at once.
+func WebhookRetryDelay(settings config.WebhookDispatcher, tries int) time.Duration {
+	if tries < 1 {
+		tries = 1
+	}
+
+	delay := float64(settings.BaseDelaySeconds) * math.Pow(2, float64(tries-1))
+	if delay > float64(settings.MaxDelaySeconds) {
+		delay = float64(settings.MaxDelaySeconds)
+	}
+
+	jitter := delay * float64(settings.JitterPercent) / 100
+	delay = delay - jitter + rand.Float64()*2*jitter
+	return time.Duration(delay * float64(time.Second))
+}
+
+// webhookClaimLease is how long claimed webhooks are kept from other dispatchers, long enough for the workers to
+// deliver all of them even when every delivery times out.
+func webhookClaimLease(settings config.WebhookDispatcher, webhooks ...models.Webhook) time.Duration {
+	rounds := int(math.Ceil(float64(len(webhooks)) / float64(settings.Workers)))
+
+	perEndpoint := map[string]int{}
+	for _, webhook := range webhooks {
+		key := webhookEndpointKey(webhook)
+		perEndpoint[key]++
+		if r := int(math.Ceil(float64(perEndpoint[key]) / float64(settings.EndpointConcurrency))); r > rounds {
+			rounds = r
+		}
+	}
+
+	return time.Duration(rounds+1) * time.Duration(settings.TimeoutSeconds) * time.Second
+}
+
+func webhookEndpointKey(webhook models.Webhook) string {
+	if webhook.EndpointID != 0 {
+		return fmt.Sprintf("endpoint:%v", webhook.EndpointID)
+	}
+	return "uri:" + webhook.WebhookUri
+}
+
+// InterleaveWebhooks orders webhooks round robin across endpoints, so a slow endpoint with a backlog does not keep
+// the workers from delivering to the others.
+func InterleaveWebhooks(webhooks []models.Webhook) []models.Webhook {
+	var (
+		keys   = []string{}
+		groups = map[string][]models.Webhook{}
+		list   = []models.Webhook{}
+	)
+	for _, webhook := range webhooks {
+		key := webhookEndpointKey(webhook)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], webhook)
+	}
+
+	for len(list) < len(webhooks) {
+		for _, key := range keys {
+			if len(groups[key]) > 0 {
+				list = append(list, groups[key][0])
+				groups[key] = groups[key][1:]
+			}
+		}
+	}
+	return list
+}
+
+// ClaimDueWebhooks leases the next due webhooks to this dispatcher, rows claimed by a dispatcher on another
+// instance are skipped.
+func ClaimDueWebhooks(db postgresql.Databases, settings config.WebhookDispatcher) ([]models.Webhook, error) {
+	var (
+		now      = time.Now()
+		webhooks = []models.Webhook{}
+	)
+
+	err := db.Payment.Transaction(func(tx *gorm.DB) error {
+		webhook := models.Webhook{}
+		due, err := webhook.GetDueWebhooksForUpdate(tx, now, settings.ClaimSize)
+		if err != nil {
+			return err
+		}
+
+		claimedUntil := now.Add(webhookClaimLease(settings, due...))
+		for _, w := range due {
+			w.ClaimedUntil = &claimedUntil
+			err := w.UpdateAllFields(tx)
+			if err != nil {
+				return err
+			}
+			webhooks = append(webhooks, w)
+		}
+		return nil
+	})
+	return webhooks, err
+}
+
+// DispatchWebhooks delivers the due webhooks on a pool of workers, no endpoint receives more than
+// EndpointConcurrency deliveries at once.
+func DispatchWebhooks(extReq request.ExternalRequest, db postgresql.Databases) {
+	settings := webhookDispatcherDefaults()
+
+	webhooks, err := ClaimDueWebhooks(db, settings)
+	if err != nil {
+		extReq.Logger.Error(fmt.Sprintf("error claiming webhooks: %v", err.Error()))
+		return
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+
+	limits := map[string]chan struct{}{}
+	for _, webhook := range webhooks {
+		key := webhookEndpointKey(webhook)
+		if _, ok := limits[key]; !ok {
+			limits[key] = make(chan struct{}, settings.EndpointConcurrency)
+		}
+	}
+
+	var (
+		jobs = make(chan models.Webhook)
+		wg   sync.WaitGroup
+	)
+	for i := 0; i < settings.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for webhook := range jobs {
+				limit := limits[webhookEndpointKey(webhook)]
+				limit <- struct{}{}
+				err := FireWebhook(extReq, db, webhook)
+				if err != nil {
+					extReq.Logger.Error(fmt.Sprintf("error delivering webhook %v: %v", webhook.ID, err.Error()))
+				}
+				<-limit
+			}
+		}()
+	}
+
+	for _, webhook := range InterleaveWebhooks(webhooks) {
+		jobs <- webhook
+	}
+	close(jobs)
+	wg.Wait()
+}
@@ -0,0 +1,216 @@
+package payment
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	webhooksig "github.com/vesicash/payment-ms/pkg/webhook"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+var (
+	defaultWebhookSecretOverlapHours = 24
+	maxWebhookSecretOverlapHours     = 168
+
+	AllWebhookEvents = "*"
+	WebhookEvents    = []string{
+		webhooksig.EventPaymentSuccess,
+		webhooksig.EventPaymentFailed,
+		webhooksig.EventBankTransferSuccess,
+		webhooksig.EventBankTransferFailed,
+		webhooksig.EventDisbursementSuccess,
+		webhooksig.EventDisbursementFailed,
+	}
+)
+
+func newWebhookEndpointSecret() string {
+	return "whsec_" + utility.RandomString(32)
+}
+
+func webhookEndpointEvents(events []string) (string, error) {
+	list := []string{}
+	for _, event := range events {
+		event = strings.ToLower(strings.TrimSpace(event))
+		if event == AllWebhookEvents {
+			return AllWebhookEvents, nil
+		}
+		if !utility.InStringSlice(event, WebhookEvents) {
+			return "", fmt.Errorf("event %v not supported", event)
+		}
+		if !utility.InStringSlice(event, list) {
+			list = append(list, event)
+		}
+	}
+	return strings.Join(list, ","), nil
+}
+
+// WebhookEndpointSecrets returns the secrets webhooks to the endpoint are signed with, the previous secret is kept
+// until its overlap window closes so receivers can switch over without dropping webhooks.
+func WebhookEndpointSecrets(endpoint models.WebhookEndpoint, now time.Time) []string {
+	secrets := []string{endpoint.Secret}
+	if endpoint.PreviousSecret != "" && endpoint.PreviousSecretExpiresAt != nil && endpoint.PreviousSecretExpiresAt.After(now) {
+		secrets = append(secrets, endpoint.PreviousSecret)
+	}
+	return secrets
+}
+
+// WebhookEndpointSubscribed reports whether the endpoint receives event.
+func WebhookEndpointSubscribed(endpoint models.WebhookEndpoint, event string) bool {
+	for _, e := range strings.Split(endpoint.Events, ",") {
+		e = strings.TrimSpace(e)
+		if e == AllWebhookEvents || strings.EqualFold(e, event) {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookEndpointsForEvent returns the enabled endpoints of a business subscribed to event.
+func WebhookEndpointsForEvent(db postgresql.Databases, businessID int, event string) ([]models.WebhookEndpoint, []models.WebhookEndpoint, error) {
+	endpoint := models.WebhookEndpoint{BusinessID: businessID}
+	endpoints, err := endpoint.GetWebhookEndpointsByBusinessID(db.Payment)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	matched := []models.WebhookEndpoint{}
+	for _, e := range endpoints {
+		if e.Enabled && WebhookEndpointSubscribed(e, event) {
+			matched = append(matched, e)
+		}
+	}
+	return matched, endpoints, nil
+}
+
+func ListWebhookEventsService() ([]string, int, error) {
+	return append([]string{AllWebhookEvents}, WebhookEvents...), http.StatusOK, nil
+}
+
+func CreateWebhookEndpointService(db postgresql.Databases, businessID int, req models.CreateWebhookEndpointRequest) (models.WebhookEndpoint, int, error) {
+	events, err := webhookEndpointEvents(req.Events)
+	if err != nil {
+		return models.WebhookEndpoint{}, http.StatusBadRequest, err
+	}
+
+	endpoint := models.WebhookEndpoint{
+		BusinessID:  businessID,
+		Url:         strings.TrimSpace(req.Url),
+		Description: req.Description,
+		Events:      events,
+		Enabled:     true,
+		Secret:      newWebhookEndpointSecret(),
+	}
+	if endpoint.CheckWebhookEndpointExists(db.Payment) {
+		return endpoint, http.StatusBadRequest, fmt.Errorf("webhook endpoint %v already exists", endpoint.Url)
+	}
+
+	err = endpoint.CreateWebhookEndpoint(db.Payment)
+	if err != nil {
+		return endpoint, http.StatusInternalServerError, err
+	}
+	return endpoint, http.StatusOK, nil
+}
+
+func ListWebhookEndpointsService(db postgresql.Databases, businessID int) ([]models.WebhookEndpoint, int, error) {
+	endpoint := models.WebhookEndpoint{BusinessID: businessID}
+	endpoints, err := endpoint.GetWebhookEndpointsByBusinessID(db.Payment)
+	if err != nil {
+		return endpoints, http.StatusInternalServerError, err
+	}
+	return endpoints, http.StatusOK, nil
+}
+
+func GetWebhookEndpointService(db postgresql.Databases, businessID, id int) (models.WebhookEndpoint, int, error) {
+	endpoint := models.WebhookEndpoint{ID: uint(id), BusinessID: businessID}
+	code, err := endpoint.GetWebhookEndpointByIDAndBusinessID(db.Payment)
+	if err != nil {
+		if code == http.StatusBadRequest {
+			code = http.StatusNotFound
+		}
+		return endpoint, code, fmt.Errorf("webhook endpoint not found: %v", err.Error())
+	}
+	return endpoint, http.StatusOK, nil
+}
+
+func UpdateWebhookEndpointService(db postgresql.Databases, businessID, id int, req models.UpdateWebhookEndpointRequest) (models.WebhookEndpoint, int, error) {
+	endpoint, code, err := GetWebhookEndpointService(db, businessID, id)
+	if err != nil {
+		return endpoint, code, err
+	}
+
+	if req.Url != "" && strings.TrimSpace(req.Url) != endpoint.Url {
+		endpoint.Url = strings.TrimSpace(req.Url)
+		if endpoint.CheckWebhookEndpointExists(db.Payment) {
+			return endpoint, http.StatusBadRequest, fmt.Errorf("webhook endpoint %v already exists", endpoint.Url)
+		}
+	}
+	if req.Description != nil {
+		endpoint.Description = *req.Description
+	}
+	if req.Events != nil {
+		events, err := webhookEndpointEvents(req.Events)
+		if err != nil {
+			return endpoint, http.StatusBadRequest, err
+		}
+		if events == "" {
+			return endpoint, http.StatusBadRequest, fmt.Errorf("an endpoint must be subscribed to at least one event")
+		}
+		endpoint.Events = events
+	}
+	if req.Enabled != nil {
+		endpoint.Enabled = *req.Enabled
+	}
+
+	err = endpoint.UpdateAllFields(db.Payment)
+	if err != nil {
+		return endpoint, http.StatusInternalServerError, err
+	}
+	return endpoint, http.StatusOK, nil
+}
+
+func DeleteWebhookEndpointService(db postgresql.Databases, businessID, id int) (models.WebhookEndpoint, int, error) {
+	endpoint, code, err := GetWebhookEndpointService(db, businessID, id)
+	if err != nil {
+		return endpoint, code, err
+	}
+
+	err = endpoint.Delete(db.Payment)
+	if err != nil {
+		return endpoint, http.StatusInternalServerError, err
+	}
+	return endpoint, http.StatusOK, nil
+}
+
+func RotateWebhookEndpointSecretService(db postgresql.Databases, businessID, id int, req models.RotateWebhookEndpointSecretRequest) (models.WebhookEndpoint, int, error) {
+	overlapHours := defaultWebhookSecretOverlapHours
+	if req.OverlapHours != nil {
+		overlapHours = *req.OverlapHours
+	}
+	if overlapHours < 0 || overlapHours > maxWebhookSecretOverlapHours {
+		return models.WebhookEndpoint{}, http.StatusBadRequest, fmt.Errorf("overlap_hours must be between 0 and %v", maxWebhookSecretOverlapHours)
+	}
+
+	endpoint, code, err := GetWebhookEndpointService(db, businessID, id)
+	if err != nil {
+		return endpoint, code, err
+	}
+
+	endpoint.PreviousSecret = ""
+	endpoint.PreviousSecretExpiresAt = nil
+	if overlapHours > 0 {
+		expiresAt := time.Now().Add(time.Duration(overlapHours) * time.Hour)
+		endpoint.PreviousSecret = endpoint.Secret
+		endpoint.PreviousSecretExpiresAt = &expiresAt
+	}
+	endpoint.Secret = newWebhookEndpointSecret()
+
+	err = endpoint.UpdateAllFields(db.Payment)
+	if err != nil {
+		return endpoint, http.StatusInternalServerError, err
+	}
+	return endpoint, http.StatusOK, nil
+}
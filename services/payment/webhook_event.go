@@ -0,0 +1,103 @@
+package payment
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	webhooksig "github.com/vesicash/payment-ms/pkg/webhook"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+// WebhookApiVersion is the api version a business receives webhooks in, businesses that have not pinned a version
+// keep the legacy payloads.
+func WebhookApiVersion(db postgresql.Databases, businessID int) string {
+	setting := models.WebhookSetting{BusinessID: businessID}
+	_, err := setting.GetWebhookSettingByBusinessID(db.Payment)
+	if err != nil || setting.ApiVersion == "" {
+		return webhooksig.APIVersionLegacy
+	}
+	return setting.ApiVersion
+}
+
+// webhookPayload builds the body of an event for a business in its pinned api version.
+func webhookPayload(db postgresql.Databases, businessID int, eventType string, data webhooksig.EventData, test bool) (webhooksig.Event, string, error) {
+	if _, ok := webhooksig.EventTypes[eventType]; !ok {
+		return webhooksig.Event{}, "", fmt.Errorf("event %v not supported", eventType)
+	}
+
+	event := webhooksig.Event{
+		ID:         "evt_" + utility.RandomString(24),
+		Type:       eventType,
+		APIVersion: WebhookApiVersion(db, businessID),
+		CreatedAt:  time.Now(),
+		Test:       test,
+	}
+	payload, err := webhooksig.Payload(event, data)
+	if err != nil {
+		return event, "", err
+	}
+	return event, string(payload), nil
+}
+
+func GetWebhookSettingService(db postgresql.Databases, businessID int) (models.WebhookSetting, int, error) {
+	setting := models.WebhookSetting{BusinessID: businessID}
+	code, err := setting.GetWebhookSettingByBusinessID(db.Payment)
+	if err != nil && code == http.StatusInternalServerError {
+		return setting, code, err
+	}
+	setting.ApiVersion = WebhookApiVersion(db, businessID)
+	return setting, http.StatusOK, nil
+}
+
+func SetWebhookApiVersionService(db postgresql.Databases, businessID int, req models.SetWebhookApiVersionRequest) (models.WebhookSetting, int, error) {
+	if !utility.InStringSlice(req.ApiVersion, webhooksig.APIVersions) {
+		return models.WebhookSetting{}, http.StatusBadRequest, fmt.Errorf("api version %v not supported", req.ApiVersion)
+	}
+
+	setting := models.WebhookSetting{BusinessID: businessID}
+	code, err := setting.GetWebhookSettingByBusinessID(db.Payment)
+	if err != nil && code == http.StatusInternalServerError {
+		return setting, code, err
+	}
+
+	setting.ApiVersion = req.ApiVersion
+	if setting.ID == 0 {
+		err = setting.CreateWebhookSetting(db.Payment)
+	} else {
+		err = setting.UpdateAllFields(db.Payment)
+	}
+	if err != nil {
+		return setting, http.StatusInternalServerError, err
+	}
+	return setting, http.StatusOK, nil
+}
+
+// GetWebhookEventSchemaService returns the JSON Schema of every event type, or of one, for an api version.
+func GetWebhookEventSchemaService(req models.GetWebhookEventSchemaRequest) (map[string]interface{}, int, error) {
+	var (
+		schemas = map[string]interface{}{}
+		events  = WebhookEvents
+	)
+
+	if req.ApiVersion == "" {
+		req.ApiVersion = webhooksig.LatestAPIVersion
+	}
+	if !utility.InStringSlice(req.ApiVersion, webhooksig.APIVersions) {
+		return schemas, http.StatusBadRequest, fmt.Errorf("api version %v not supported", req.ApiVersion)
+	}
+	if req.Event != "" {
+		events = []string{req.Event}
+	}
+
+	for _, event := range events {
+		schema, err := webhooksig.Schema(event, req.ApiVersion)
+		if err != nil {
+			return schemas, http.StatusBadRequest, err
+		}
+		schemas[event] = schema
+	}
+	return schemas, http.StatusOK, nil
+}
@@ -0,0 +1,154 @@
+package test_payment
+
+import (
+	"testing"
+
+	"github.com/vesicash/payment-ms/external/mocks/rave_mocks"
+	"github.com/vesicash/payment-ms/external/request"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	paymentService "github.com/vesicash/payment-ms/services/payment"
+	tst "github.com/vesicash/payment-ms/tests"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+func TestBeneficiaryNameScore(t *testing.T) {
+	tst.Setup()
+
+	tests := []struct {
+		Name     string
+		A        string
+		B        string
+		MinScore int
+		MaxScore int
+	}{
+		{
+			Name:     "same name",
+			A:        "ADEBAYO CHUKWUEMEKA",
+			B:        "Adebayo Chukwuemeka",
+			MinScore: 100,
+			MaxScore: 100,
+		}, {
+			Name:     "reversed with title",
+			A:        "MR CHUKWUEMEKA, ADEBAYO",
+			B:        "Adebayo Chukwuemeka",
+			MinScore: 100,
+			MaxScore: 100,
+		}, {
+			Name:     "extra middle name",
+			A:        "ADEBAYO OLUWASEUN CHUKWUEMEKA",
+			B:        "Adebayo Chukwuemeka",
+			MinScore: 80,
+			MaxScore: 99,
+		}, {
+			Name:     "misspelt",
+			A:        "ADEBAYO CHUKWUEMAKA",
+			B:        "Adebayo Chukwuemeka",
+			MinScore: 80,
+			MaxScore: 99,
+		}, {
+			Name:     "different person",
+			A:        "GRACE OKAFOR",
+			B:        "Adebayo Chukwuemeka",
+			MaxScore: 50,
+		}, {
+			Name:     "empty name",
+			A:        "",
+			B:        "Adebayo Chukwuemeka",
+			MaxScore: 0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			score := paymentService.BeneficiaryNameScore(test.A, test.B)
+			if score < test.MinScore || score > test.MaxScore {
+				t.Errorf("expected score between %v and %v, got %v", test.MinScore, test.MaxScore, score)
+			}
+		})
+	}
+}
+
+func TestVerifyDisbursementBeneficiary(t *testing.T) {
+	logger := tst.Setup()
+	db := postgresql.Connection()
+	extReq := request.ExternalRequest{
+		Logger: logger,
+		Test:   true,
+	}
+
+	rave_mocks.AccountName = "ADEBAYO CHUKWUEMEKA"
+	defer func() { rave_mocks.AccountName = "" }()
+
+	tests := []struct {
+		Name            string
+		BeneficiaryName string
+		Names           []string
+		Held            bool
+	}{
+		{
+			Name:            "saved beneficiary name matches",
+			BeneficiaryName: "Adebayo Chukwuemeka",
+		}, {
+			Name:            "kyc name matches",
+			BeneficiaryName: "Vesicash Ventures",
+			Names:           []string{"Chukwuemeka Adebayo"},
+		}, {
+			Name:            "no name matches",
+			BeneficiaryName: "Grace Okafor",
+			Names:           []string{"Grace Okafor"},
+			Held:            true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			disbursement := models.Disbursement{
+				DisbursementID:    utility.GetRandomNumbersInRange(1000000000, 9999999999),
+				RecipientID:       utility.GetRandomNumbersInRange(1000000000, 9999999999),
+				Reference:         utility.RandomString(10),
+				Currency:          "NGN",
+				Amount:            "1000",
+				Gateway:           "rave",
+				BeneficiaryName:   test.BeneficiaryName,
+				BankAccountNumber: utility.RandomString(10),
+				BankCode:          "058",
+				Status:            "new",
+				Type:              "wallet",
+			}
+			err := disbursement.CreateDisbursement(db.Payment)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			held, err := paymentService.VerifyDisbursementBeneficiary(extReq, db, &disbursement, test.Names...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if held != test.Held {
+				t.Fatalf("expected held to be %v, got %v", test.Held, held)
+			}
+
+			saved := models.Disbursement{DisbursementID: disbursement.DisbursementID}
+			_, err = saved.GetDisbursementByDisbursementID(db.Payment)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.Held && saved.Status != string(paymentService.DisbursementReview) {
+				t.Errorf("expected disbursement to be held for review, got %v", saved.Status)
+			}
+			if !test.Held && saved.Status != "new" {
+				t.Errorf("expected disbursement status to remain new, got %v", saved.Status)
+			}
+			if saved.ResolvedAccountName != rave_mocks.AccountName {
+				t.Errorf("expected resolved account name %v, got %v", rave_mocks.AccountName, saved.ResolvedAccountName)
+			}
+
+			enquiry := models.BeneficiaryNameEnquiry{AccountNumber: disbursement.BankAccountNumber, BankCode: disbursement.BankCode}
+			_, err = enquiry.GetBeneficiaryNameEnquiryByAccount(db.Payment)
+			if err != nil {
+				t.Errorf("expected name enquiry to be cached: %v", err)
+			}
+		})
+	}
+}
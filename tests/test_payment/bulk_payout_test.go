@@ -0,0 +1,220 @@
+package test_payment
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/vesicash/payment-ms/external/external_models"
+	"github.com/vesicash/payment-ms/external/mocks/auth_mocks"
+	"github.com/vesicash/payment-ms/external/request"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/controller/payment"
+	"github.com/vesicash/payment-ms/pkg/middleware"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	paymentService "github.com/vesicash/payment-ms/services/payment"
+	tst "github.com/vesicash/payment-ms/tests"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+func TestParseBulkPayoutCSV(t *testing.T) {
+	tst.Setup()
+
+	rows, err := paymentService.ParseBulkPayoutCSV(strings.NewReader("Bank_Code,Account_Number,Amount,Narration\n058,0123456789,\"1,500\",June payout\n044,0987654321,abc,\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %v", len(rows))
+	}
+	if rows[0].BankCode != "058" || rows[0].BankAccountNumber != "0123456789" || rows[0].Amount != 1500 || rows[0].Narration != "June payout" {
+		t.Errorf("unexpected first row %+v", rows[0])
+	}
+	if rows[1].Amount != 0 {
+		t.Errorf("expected amount that is not a number to be read as 0, got %v", rows[1].Amount)
+	}
+
+	_, err = paymentService.ParseBulkPayoutCSV(strings.NewReader("bank_code,amount\n058,100\n"))
+	if err == nil {
+		t.Errorf("expected csv without account number column to be rejected")
+	}
+}
+
+func TestCreateBulkPayout(t *testing.T) {
+	logger := tst.Setup()
+	gin.SetMode(gin.TestMode)
+	validatorRef := validator.New()
+	db := postgresql.Connection()
+
+	testUser := external_models.User{
+		ID:                uint(utility.GetRandomNumbersInRange(1000000000, 9999999999)),
+		AccountID:         uint(utility.GetRandomNumbersInRange(1000000000, 9999999999)),
+		Firstname:         "test",
+		Lastname:          "user",
+		CanMakeWithdrawal: true,
+	}
+	auth_mocks.User = &testUser
+	auth_mocks.BusinessProfile = &external_models.BusinessProfile{
+		ID:        uint(utility.GetRandomNumbersInRange(1000000000, 9999999999)),
+		AccountID: int(testUser.AccountID),
+		Country:   "NG",
+		Currency:  "NGN",
+	}
+	auth_mocks.ValidateAuthorizationRes = &external_models.ValidateAuthorizationDataModel{
+		Status:  true,
+		Message: "authorized",
+		Data:    testUser,
+	}
+	auth_mocks.AccessToken = external_models.AccessToken{AccountID: int(testUser.AccountID)}
+	auth_mocks.UsersCredential = &external_models.UsersCredential{
+		ID:                 uint(utility.GetRandomNumbersInRange(1000000000, 9999999999)),
+		AccountID:          int(testUser.AccountID),
+		Bvn:                "42536272823",
+		IdentificationType: "bvn",
+	}
+
+	paymnt := payment.Controller{Db: db, Validator: validatorRef, Logger: logger, ExtReq: request.ExternalRequest{
+		Logger: logger,
+		Test:   true,
+	}}
+	r := gin.Default()
+
+	paymentApiUrl := r.Group(fmt.Sprintf("%v", "v2"), middleware.Authorize(db, paymnt.ExtReq, middleware.ApiType))
+	{
+		paymentApiUrl.POST("/disbursement/bulk", paymnt.CreateBulkPayout)
+		paymentApiUrl.GET("/disbursement/bulk/results/:bulk_payout_id", paymnt.DownloadBulkPayoutResults)
+	}
+
+	jsonBody := func(rows []models.BulkPayoutRow) (*bytes.Buffer, string) {
+		body := new(bytes.Buffer)
+		json.NewEncoder(body).Encode(models.CreateBulkPayoutRequest{Currency: "NGN", Rows: rows})
+		return body, "application/json"
+	}
+
+	csvBody := func(content string) (*bytes.Buffer, string) {
+		body := new(bytes.Buffer)
+		writer := multipart.NewWriter(body)
+		writer.WriteField("currency", "NGN")
+		part, _ := writer.CreateFormFile("file", "payouts.csv")
+		part.Write([]byte(content))
+		writer.Close()
+		return body, writer.FormDataContentType()
+	}
+
+	tests := []struct {
+		Name         string
+		Body         func() (*bytes.Buffer, string)
+		ExpectedCode int
+		Status       string
+		RowStatuses  []string
+	}{
+		{
+			Name: "invalid rows reject the whole bulk payout",
+			Body: func() (*bytes.Buffer, string) {
+				return jsonBody([]models.BulkPayoutRow{
+					{BankCode: "058", BankAccountNumber: "0123456789", Amount: 1000},
+					{BankCode: "058", BankAccountNumber: "01234-6789", Amount: 0},
+				})
+			},
+			ExpectedCode: http.StatusBadRequest,
+			Status:       "rejected",
+			RowStatuses:  []string{"valid", "invalid"},
+		}, {
+			Name: "total above wallet balance",
+			Body: func() (*bytes.Buffer, string) {
+				return jsonBody([]models.BulkPayoutRow{
+					{BankCode: "058", BankAccountNumber: "0123456789", Amount: 15000},
+					{BankCode: "044", BankAccountNumber: "0987654321", Amount: 15000},
+				})
+			},
+			ExpectedCode: http.StatusBadRequest,
+			Status:       "rejected",
+			RowStatuses:  []string{"valid", "valid"},
+		}, {
+			Name: "OK csv upload",
+			Body: func() (*bytes.Buffer, string) {
+				return csvBody("beneficiary_name,bank_code,account_number,amount,narration\ntest user,058,0123456789,1000,supplier\n,044,0987654321,2500,\n")
+			},
+			ExpectedCode: http.StatusOK,
+			Status:       "processing",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			body, contentType := test.Body()
+			req, err := http.NewRequest(http.MethodPost, "/v2/disbursement/bulk", body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", contentType)
+			req.Header.Set("v-private-key", utility.RandomString(20))
+			req.Header.Set("v-public-key", utility.RandomString(20))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			tst.AssertStatusCode(t, rr.Code, test.ExpectedCode)
+
+			data := tst.ParseResponse(rr)
+			result, _ := data["data"].(map[string]interface{})
+			if result == nil {
+				t.Fatalf("expected bulk payout in response, got %v", data)
+			}
+
+			bulkPayout := result["bulk_payout"].(map[string]interface{})
+			if bulkPayout["status"] != test.Status {
+				t.Errorf("expected status %v, got %v", test.Status, bulkPayout["status"])
+			}
+
+			items := result["items"].([]interface{})
+			for i, status := range test.RowStatuses {
+				if items[i].(map[string]interface{})["status"] != status {
+					t.Errorf("expected row %v to be %v, got %v", i+1, status, items[i].(map[string]interface{})["status"])
+				}
+			}
+
+			if test.ExpectedCode != http.StatusOK {
+				return
+			}
+			for _, item := range items {
+				if item.(map[string]interface{})["disbursement_id"].(float64) == 0 {
+					t.Errorf("expected every row to have a disbursement")
+				}
+			}
+
+			req, _ = http.NewRequest(http.MethodGet, fmt.Sprintf("/v2/disbursement/bulk/results/%v", bulkPayout["bulk_payout_id"]), nil)
+			req.Header.Set("v-private-key", utility.RandomString(20))
+			req.Header.Set("v-public-key", utility.RandomString(20))
+			rr = httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+			tst.AssertStatusCode(t, rr.Code, http.StatusOK)
+
+			records, err := csv.NewReader(rr.Body).ReadAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(records) != len(items)+1 {
+				t.Errorf("expected %v result rows, got %v", len(items)+1, len(records))
+			}
+
+			auth_mocks.AccessToken = external_models.AccessToken{AccountID: utility.GetRandomNumbersInRange(1000000000, 9999999999)}
+			defer func() { auth_mocks.AccessToken = external_models.AccessToken{AccountID: int(testUser.AccountID)} }()
+
+			req, _ = http.NewRequest(http.MethodGet, fmt.Sprintf("/v2/disbursement/bulk/results/%v", bulkPayout["bulk_payout_id"]), nil)
+			req.Header.Set("v-private-key", utility.RandomString(20))
+			req.Header.Set("v-public-key", utility.RandomString(20))
+			rr = httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+			tst.AssertStatusCode(t, rr.Code, http.StatusNotFound)
+		})
+	}
+}
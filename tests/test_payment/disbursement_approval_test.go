@@ -0,0 +1,230 @@
+package test_payment
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/vesicash/payment-ms/external/external_models"
+	"github.com/vesicash/payment-ms/external/mocks/auth_mocks"
+	"github.com/vesicash/payment-ms/external/request"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/controller/payment"
+	"github.com/vesicash/payment-ms/pkg/middleware"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	paymentService "github.com/vesicash/payment-ms/services/payment"
+	tst "github.com/vesicash/payment-ms/tests"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+func TestRequireDisbursementApproval(t *testing.T) {
+	logger := tst.Setup()
+	db := postgresql.Connection()
+	extReq := request.ExternalRequest{
+		Logger: logger,
+		Test:   true,
+	}
+
+	businessID := utility.GetRandomNumbersInRange(1000000000, 9999999999)
+	_, _, err := paymentService.CreateDisbursementApprovalPolicyService(db, models.CreateDisbursementApprovalPolicyRequest{
+		Name:       "large ghs payouts",
+		BusinessID: businessID,
+		Currency:   "GHS",
+		MinAmount:  5000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		Name             string
+		Currency         string
+		Amount           float64
+		AwaitingApproval bool
+	}{
+		{
+			Name:             "matches policy",
+			Currency:         "GHS",
+			Amount:           6000,
+			AwaitingApproval: true,
+		}, {
+			Name:     "below policy amount",
+			Currency: "GHS",
+			Amount:   1000,
+		}, {
+			Name:     "other currency",
+			Currency: "NGN",
+			Amount:   6000,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			disbursement := models.Disbursement{
+				DisbursementID: utility.GetRandomNumbersInRange(1000000000, 9999999999),
+				RecipientID:    utility.GetRandomNumbersInRange(1000000000, 9999999999),
+				BusinessID:     businessID,
+				Reference:      utility.RandomString(10),
+				Currency:       test.Currency,
+				Amount:         fmt.Sprintf("%v", test.Amount),
+				Status:         "pending",
+				Type:           "credit",
+			}
+			err := disbursement.CreateDisbursement(db.Payment)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			awaitingApproval, err := paymentService.RequireDisbursementApproval(extReq, db, &disbursement, test.Amount, false, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if awaitingApproval != test.AwaitingApproval {
+				t.Errorf("awaiting approval: got %v, expected %v", awaitingApproval, test.AwaitingApproval)
+			}
+		})
+	}
+}
+
+func TestDecideDisbursement(t *testing.T) {
+	logger := tst.Setup()
+	gin.SetMode(gin.TestMode)
+	validatorRef := validator.New()
+	db := postgresql.Connection()
+
+	var (
+		adminAccountID     = utility.GetRandomNumbersInRange(1000000000, 9999999999)
+		recipientAccountID = utility.GetRandomNumbersInRange(1000000000, 9999999999)
+	)
+
+	auth_mocks.AccessToken = external_models.AccessToken{AccountID: adminAccountID}
+	auth_mocks.ValidateAuthorizationRes = &external_models.ValidateAuthorizationDataModel{
+		Status:  true,
+		Message: "authorized",
+	}
+
+	paymnt := payment.Controller{Db: db, Validator: validatorRef, Logger: logger, ExtReq: request.ExternalRequest{
+		Logger: logger,
+		Test:   true,
+	}}
+	r := gin.Default()
+
+	disbursements := []models.Disbursement{}
+	for _, item := range []struct {
+		recipientID int
+		dType       string
+	}{{recipientAccountID, "credit"}, {recipientAccountID, "wallet"}, {adminAccountID, "credit"}} {
+		disbursement := models.Disbursement{
+			DisbursementID: utility.GetRandomNumbersInRange(1000000000, 9999999999),
+			RecipientID:    item.recipientID,
+			Reference:      utility.RandomString(10),
+			Currency:       "NGN",
+			DebitCurrency:  "NGN",
+			Amount:         "1000",
+			Status:         "awaiting_approval",
+			Type:           item.dType,
+		}
+		err := disbursement.CreateDisbursement(db.Payment)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if item.dType == "wallet" {
+			_, _, err = paymentService.PlaceWalletHold(paymnt.ExtReq, db, models.WalletHold{
+				AccountID:       item.recipientID,
+				Currency:        "NGN",
+				Amount:          1000,
+				Type:            string(paymentService.WalletHoldDisbursement),
+				SourceReference: disbursement.Reference,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		disbursements = append(disbursements, disbursement)
+	}
+
+	tests := []struct {
+		Name         string
+		Path         string
+		RequestBody  models.DisbursementDecisionRequest
+		ExpectedCode int
+		Status       string
+	}{
+		{
+			Name:         "OK approve disbursement",
+			Path:         "/v2/disbursement/approval/approve",
+			RequestBody:  models.DisbursementDecisionRequest{DisbursementID: disbursements[0].DisbursementID, Comment: "beneficiary verified"},
+			ExpectedCode: http.StatusOK,
+			Status:       "approved",
+		}, {
+			Name:         "approve approved disbursement",
+			Path:         "/v2/disbursement/approval/approve",
+			RequestBody:  models.DisbursementDecisionRequest{DisbursementID: disbursements[0].DisbursementID, Comment: "again"},
+			ExpectedCode: http.StatusBadRequest,
+		}, {
+			Name:         "OK reject wallet withdrawal",
+			Path:         "/v2/disbursement/approval/reject",
+			RequestBody:  models.DisbursementDecisionRequest{DisbursementID: disbursements[1].DisbursementID, Comment: "account under review"},
+			ExpectedCode: http.StatusOK,
+			Status:       "rejected",
+		}, {
+			Name:         "approve own disbursement",
+			Path:         "/v2/disbursement/approval/approve",
+			RequestBody:  models.DisbursementDecisionRequest{DisbursementID: disbursements[2].DisbursementID, Comment: "mine"},
+			ExpectedCode: http.StatusForbidden,
+		}, {
+			Name:         "no comment",
+			Path:         "/v2/disbursement/approval/reject",
+			RequestBody:  models.DisbursementDecisionRequest{DisbursementID: disbursements[2].DisbursementID},
+			ExpectedCode: http.StatusBadRequest,
+		},
+	}
+
+	paymentBusinessAdminUrl := r.Group(fmt.Sprintf("%v", "v2"), middleware.Authorize(db, paymnt.ExtReq, middleware.BusinessAdmin))
+	{
+		paymentBusinessAdminUrl.POST("/disbursement/approval/approve", paymnt.ApproveDisbursement)
+		paymentBusinessAdminUrl.POST("/disbursement/approval/reject", paymnt.RejectDisbursement)
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+
+			var b bytes.Buffer
+			json.NewEncoder(&b).Encode(test.RequestBody)
+			URI := url.URL{Path: test.Path}
+
+			req, err := http.NewRequest(http.MethodPost, URI.String(), &b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("v-private-key", utility.RandomString(20))
+			req.Header.Set("v-public-key", utility.RandomString(20))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			tst.AssertStatusCode(t, rr.Code, test.ExpectedCode)
+
+			data := tst.ParseResponse(rr)
+
+			code := int(data["code"].(float64))
+			tst.AssertStatusCode(t, code, test.ExpectedCode)
+
+			if test.ExpectedCode == http.StatusOK {
+				disbursement := data["data"].(map[string]interface{})
+				tst.AssertResponseMessage(t, disbursement["status"].(string), test.Status)
+			}
+
+		})
+
+	}
+
+}
@@ -0,0 +1,170 @@
+package test_payment
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/vesicash/payment-ms/external/mocks/rave_mocks"
+	"github.com/vesicash/payment-ms/external/request"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	paymentService "github.com/vesicash/payment-ms/services/payment"
+	tst "github.com/vesicash/payment-ms/tests"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+func TestGroupDisbursementsForBatch(t *testing.T) {
+	tst.Setup()
+
+	disbursements := []models.Disbursement{
+		{DisbursementID: 1, Gateway: "monnify", Currency: "NGN"},
+		{DisbursementID: 2, Gateway: "rave", Currency: "NGN"},
+		{DisbursementID: 3, Gateway: "Monnify", Currency: "ngn"},
+		{DisbursementID: 4, Gateway: "monnify", Currency: "NGN"},
+		{DisbursementID: 5, Gateway: "monnify", Currency: "USD"},
+	}
+
+	groups := paymentService.GroupDisbursementsForBatch(disbursements, 2)
+	expected := [][]int{{1, 3}, {4}, {2}, {5}}
+	if len(groups) != len(expected) {
+		t.Fatalf("expected %v groups, got %v", len(expected), len(groups))
+	}
+	for i, group := range groups {
+		if len(group) != len(expected[i]) {
+			t.Fatalf("expected group %v to have %v disbursements, got %v", i+1, len(expected[i]), len(group))
+		}
+		for j, disbursement := range group {
+			if disbursement.DisbursementID != expected[i][j] {
+				t.Errorf("expected disbursement %v in group %v, got %v", expected[i][j], i+1, disbursement.DisbursementID)
+			}
+		}
+	}
+}
+
+func TestSendDisbursementBatch(t *testing.T) {
+	logger := tst.Setup()
+	db := postgresql.Connection()
+	extReq := request.ExternalRequest{
+		Logger: logger,
+		Test:   true,
+	}
+
+	queueDisbursements := func(t *testing.T, gateway string) []models.Disbursement {
+		disbursements := []models.Disbursement{}
+		for i := 0; i < 3; i++ {
+			disbursement := models.Disbursement{
+				DisbursementID:    utility.GetRandomNumbersInRange(1000000000, 9999999999),
+				RecipientID:       utility.GetRandomNumbersInRange(1000000000, 9999999999),
+				PaymentID:         utility.RandomString(10),
+				Reference:         utility.RandomString(10),
+				Currency:          "NGN",
+				Amount:            "1000",
+				Gateway:           gateway,
+				BeneficiaryName:   "test user",
+				BankAccountNumber: "0123456789",
+				BankCode:          "058",
+				Status:            "new",
+				Type:              "credit",
+			}
+			err := disbursement.CreateDisbursement(db.Payment)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			queued, err := paymentService.QueueDisbursementTransfer(extReq, db, &disbursement, []string{gateway}, "test")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !queued {
+				t.Fatalf("expected disbursement to be queued for %v", gateway)
+			}
+			disbursements = append(disbursements, disbursement)
+		}
+		return disbursements
+	}
+
+	for _, gateway := range []string{"monnify", "rave"} {
+		t.Run(gateway, func(t *testing.T) {
+			disbursements := queueDisbursements(t, gateway)
+
+			batch, err := paymentService.SendDisbursementBatch(extReq, db, gateway, "test", disbursements)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if batch.Status != "submitted" || batch.GatewayReference == "" || batch.ItemCount != 3 || batch.TotalAmount != 3000 {
+				t.Fatalf("unexpected batch %+v", batch)
+			}
+
+			results, saved, err := paymentService.DisbursementBatchResults(extReq, db, batch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(saved) != 3 || len(results) != 3 {
+				t.Fatalf("expected 3 disbursements with results, got %v and %v", len(saved), len(results))
+			}
+
+			for _, disbursement := range saved {
+				if disbursement.Status != string(paymentService.DisbursementPending) {
+					t.Errorf("expected disbursement %v to be pending, got %v", disbursement.DisbursementID, disbursement.Status)
+				}
+				if paymentService.GatewayDisbursementStatus(results[disbursement.DisbursementID].Status) != paymentService.DisbursementCompleted {
+					t.Errorf("expected disbursement %v to be reported completed, got %v", disbursement.DisbursementID, results[disbursement.DisbursementID].Status)
+				}
+
+				err := paymentService.TransitionDisbursement(db, &disbursement, paymentService.DisbursementCompleted, "test", nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			paymentService.RefreshDisbursementBatch(extReq, db, batch.BatchReference)
+			_, err = batch.GetDisbursementBatchByBatchReference(db.Payment)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if batch.Status != "completed" || batch.CompletedCount != 3 {
+				t.Errorf("expected batch to be completed with 3 transfers, got %v with %v", batch.Status, batch.CompletedCount)
+			}
+		})
+	}
+
+	t.Run("rave response lost after the batch is created", func(t *testing.T) {
+		rave_mocks.InitBulkTransferError = fmt.Errorf("rave timed out")
+		defer func() { rave_mocks.InitBulkTransferError = nil }()
+
+		batch, err := paymentService.SendDisbursementBatch(extReq, db, "rave", "test", queueDisbursements(t, "rave"))
+		if err == nil {
+			t.Fatalf("expected the batch request to fail")
+		}
+
+		_, err = batch.GetDisbursementBatchByBatchReference(db.Payment)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if batch.Status != "submitted" {
+			t.Errorf("expected batch to stay submitted, got %v", batch.Status)
+		}
+
+		disbursement := models.Disbursement{BatchReference: batch.BatchReference}
+		saved, err := disbursement.GetDisbursementsByBatchReference(db.Payment)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, disbursement := range saved {
+			if disbursement.Status != string(paymentService.DisbursementPending) {
+				t.Errorf("expected disbursement %v to be pending, got %v", disbursement.DisbursementID, disbursement.Status)
+			}
+		}
+	})
+
+	t.Run("mobile money is not batched", func(t *testing.T) {
+		disbursement := models.Disbursement{Currency: "GHS"}
+		queued, err := paymentService.QueueDisbursementTransfer(extReq, db, &disbursement, []string{"rave_momo"}, "test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if queued {
+			t.Errorf("expected mobile money disbursement not to be queued")
+		}
+	})
+}
@@ -0,0 +1,308 @@
+package test_payment
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/vesicash/payment-ms/external"
+	"github.com/vesicash/payment-ms/external/request"
+	"github.com/vesicash/payment-ms/internal/config"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	paymentService "github.com/vesicash/payment-ms/services/payment"
+	tst "github.com/vesicash/payment-ms/tests"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+func TestDisbursementRetryPolicy(t *testing.T) {
+	tst.Setup()
+
+	settings := config.DisbursementRetry{MaxTries: 3, BaseDelaySeconds: 60, MaxDelaySeconds: 300, JitterPercent: 10}
+	delays := []struct {
+		Tries int
+		Base  time.Duration
+	}{
+		{Tries: 1, Base: 60 * time.Second},
+		{Tries: 2, Base: 120 * time.Second},
+		{Tries: 3, Base: 240 * time.Second},
+		{Tries: 6, Base: 300 * time.Second},
+	}
+	for _, test := range delays {
+		delay := paymentService.DisbursementRetryDelay(settings, test.Tries)
+		if delay < test.Base*9/10 || delay > test.Base*11/10 {
+			t.Errorf("try %v: expected delay within 10%% of %v, got %v", test.Tries, test.Base, delay)
+		}
+	}
+
+	classes := []struct {
+		Name     string
+		Err      error
+		Response interface{}
+		Class    paymentService.DisbursementErrorClass
+	}{
+		{
+			Name:  "gateway timeout",
+			Err:   &external.RequestError{Name: request.RaveInitTransfer, Code: http.StatusGatewayTimeout},
+			Class: paymentService.DisbursementErrorTransient,
+		}, {
+			Name:  "rate limited",
+			Err:   &external.RequestError{Name: request.RaveInitTransfer, Code: http.StatusTooManyRequests},
+			Class: paymentService.DisbursementErrorTransient,
+		}, {
+			Name:  "bad request",
+			Err:   &external.RequestError{Name: request.RaveInitTransfer, Code: http.StatusBadRequest},
+			Class: paymentService.DisbursementErrorPermanent,
+		}, {
+			Name:  "connection error",
+			Err:   fmt.Errorf("dial tcp: i/o timeout"),
+			Class: paymentService.DisbursementErrorTransient,
+		}, {
+			Name:     "invalid account",
+			Response: map[string]interface{}{"complete_message": "Invalid account number"},
+			Class:    paymentService.DisbursementErrorPermanent,
+		}, {
+			Name:     "insufficient funds",
+			Response: map[string]interface{}{"complete_message": "Insufficient funds in customer wallet"},
+			Class:    paymentService.DisbursementErrorPermanent,
+		}, {
+			Name:     "failed without reason",
+			Response: map[string]interface{}{"status": "FAILED"},
+			Class:    paymentService.DisbursementErrorTransient,
+		},
+	}
+	for _, test := range classes {
+		t.Run(test.Name, func(t *testing.T) {
+			class := paymentService.ClassifyDisbursementError(test.Err, test.Response)
+			if class != test.Class {
+				t.Errorf("expected %v, got %v", test.Class, class)
+			}
+		})
+	}
+}
+
+func TestHandleDisbursementFailure(t *testing.T) {
+	logger := tst.Setup()
+	db := postgresql.Connection()
+	extReq := request.ExternalRequest{
+		Logger: logger,
+		Test:   true,
+	}
+
+	gateway := "paystack"
+	_, _, err := paymentService.UpdateDisbursementRetrySettingService(db, models.UpdateDisbursementRetrySettingRequest{
+		Gateway:  gateway,
+		MaxTries: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		Name     string
+		Type     string
+		Tries    int
+		Response interface{}
+		Status   paymentService.DisbursementStatus
+	}{
+		{
+			Name:     "transient failure is retried",
+			Type:     "credit",
+			Tries:    1,
+			Response: map[string]interface{}{"status": "failed"},
+			Status:   paymentService.DisbursementRetryScheduled,
+		}, {
+			Name:     "out of retries",
+			Type:     "credit",
+			Tries:    2,
+			Response: map[string]interface{}{"status": "failed"},
+			Status:   paymentService.DisbursementDeadLetter,
+		}, {
+			Name:     "permanent failure of transaction disbursement",
+			Type:     "credit",
+			Response: map[string]interface{}{"message": "account not found"},
+			Status:   paymentService.DisbursementDeadLetter,
+		}, {
+			Name:     "permanent failure of wallet withdrawal",
+			Type:     "wallet",
+			Response: map[string]interface{}{"message": "account not found"},
+			Status:   paymentService.DisbursementFailed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			disbursement := models.Disbursement{
+				DisbursementID: utility.GetRandomNumbersInRange(1000000000, 9999999999),
+				RecipientID:    utility.GetRandomNumbersInRange(1000000000, 9999999999),
+				Reference:      utility.RandomString(10),
+				Currency:       "NGN",
+				Amount:         "1000",
+				Gateway:        gateway,
+				Status:         "pending",
+				Type:           test.Type,
+				Tries:          test.Tries,
+			}
+			err := disbursement.CreateDisbursement(db.Payment)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			status, err := paymentService.HandleDisbursementFailure(extReq, db, &disbursement, "test", nil, test.Response)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status != test.Status {
+				t.Fatalf("expected %v, got %v", test.Status, status)
+			}
+
+			saved := models.Disbursement{DisbursementID: disbursement.DisbursementID}
+			_, err = saved.GetDisbursementByDisbursementID(db.Payment)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if saved.Status != string(test.Status) {
+				t.Errorf("expected saved status %v, got %v", test.Status, saved.Status)
+			}
+			if test.Status == paymentService.DisbursementRetryScheduled && !saved.TryAgainAt.After(time.Now()) {
+				t.Errorf("expected retry to be scheduled in the future, got %v", saved.TryAgainAt)
+			}
+			if test.Status == paymentService.DisbursementDeadLetter && saved.DeadLetteredAt == nil {
+				t.Errorf("expected dead lettered at to be set")
+			}
+		})
+	}
+}
+
+func TestRetryDisbursementTransfer(t *testing.T) {
+	logger := tst.Setup()
+	db := postgresql.Connection()
+	extReq := request.ExternalRequest{
+		Logger: logger,
+		Test:   true,
+	}
+
+	disbursement := models.Disbursement{
+		DisbursementID:    utility.GetRandomNumbersInRange(1000000000, 9999999999),
+		RecipientID:       utility.GetRandomNumbersInRange(1000000000, 9999999999),
+		Reference:         utility.RandomString(10),
+		Currency:          "NGN",
+		Amount:            "1000",
+		BankCode:          "044",
+		BankAccountNumber: "0690000040",
+		Gateway:           "rave",
+		Status:            string(paymentService.DisbursementRetryScheduled),
+		Type:              "credit",
+		Tries:             1,
+	}
+	err := disbursement.CreateDisbursement(db.Payment)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("last transfer did not reach the gateway", func(t *testing.T) {
+		status, err := paymentService.RetryDisbursementTransfer(extReq, db, &disbursement)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status != paymentService.DisbursementPending {
+			t.Fatalf("expected %v, got %v", paymentService.DisbursementPending, status)
+		}
+		if disbursement.Tries != 2 {
+			t.Errorf("expected the transfer to be sent again, got %v tries", disbursement.Tries)
+		}
+	})
+
+	t.Run("last transfer reached the gateway", func(t *testing.T) {
+		err := paymentService.TransitionDisbursement(db, &disbursement, paymentService.DisbursementRetryScheduled, "test", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		status, err := paymentService.RetryDisbursementTransfer(extReq, db, &disbursement)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status != paymentService.DisbursementPending {
+			t.Fatalf("expected %v, got %v", paymentService.DisbursementPending, status)
+		}
+
+		saved := models.Disbursement{DisbursementID: disbursement.DisbursementID}
+		_, err = saved.GetDisbursementByDisbursementID(db.Payment)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if saved.Tries != 2 {
+			t.Errorf("expected the transfer not to be sent again, got %v tries", saved.Tries)
+		}
+		if saved.GatewayReference != disbursement.Reference+"-2" {
+			t.Errorf("expected gateway reference %v, got %v", disbursement.Reference+"-2", saved.GatewayReference)
+		}
+	})
+}
+
+func TestHandleWalletDisbursementFailure(t *testing.T) {
+	logger := tst.Setup()
+	db := postgresql.Connection()
+	extReq := request.ExternalRequest{
+		Logger: logger,
+		Test:   true,
+	}
+	cause := &external.RequestError{Name: request.RaveInitTransfer, Code: http.StatusBadRequest}
+
+	tests := []struct {
+		Name    string
+		Created bool
+		Status  paymentService.DisbursementStatus
+	}{
+		{
+			Name:   "transfer was not created",
+			Status: paymentService.DisbursementFailed,
+		}, {
+			Name:    "transfer was created",
+			Created: true,
+			Status:  paymentService.DisbursementDeadLetter,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			disbursement := models.Disbursement{
+				DisbursementID: utility.GetRandomNumbersInRange(1000000000, 9999999999),
+				RecipientID:    utility.GetRandomNumbersInRange(1000000000, 9999999999),
+				Reference:      utility.RandomString(10),
+				Currency:       "NGN",
+				Amount:         "1000",
+				Gateway:        "rave",
+				Status:         "new",
+				Type:           "wallet",
+			}
+			err := disbursement.CreateDisbursement(db.Payment)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if test.Created {
+				_, err = paymentService.GetGateway(extReq, "rave").InitTransfer(paymentService.GatewayTransferRequest{
+					BankCode:      "058",
+					AccountNumber: "0690000031",
+					Currency:      "NGN",
+					Reference:     disbursement.Reference,
+					Amount:        1000,
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			status, err := paymentService.HandleDisbursementFailure(extReq, db, &disbursement, "test", cause, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status != test.Status {
+				t.Errorf("expected %v, got %v", test.Status, status)
+			}
+		})
+	}
+}
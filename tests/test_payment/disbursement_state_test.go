@@ -0,0 +1,126 @@
+package test_payment
+
+import (
+	"testing"
+
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	paymentService "github.com/vesicash/payment-ms/services/payment"
+	tst "github.com/vesicash/payment-ms/tests"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+func TestTransitionDisbursement(t *testing.T) {
+	tst.Setup()
+	db := postgresql.Connection()
+
+	tests := []struct {
+		Name     string
+		From     string
+		Approved string
+		To       paymentService.DisbursementStatus
+		Allowed  bool
+	}{
+		{
+			Name:    "pending to completed",
+			From:    "pending",
+			To:      paymentService.DisbursementCompleted,
+			Allowed: true,
+		}, {
+			Name:    "new to pending",
+			From:    "new",
+			To:      paymentService.DisbursementPending,
+			Allowed: true,
+		}, {
+			Name:    "new to queued",
+			From:    "new",
+			To:      paymentService.DisbursementQueued,
+			Allowed: true,
+		}, {
+			Name: "queued to completed",
+			From: "queued",
+			To:   paymentService.DisbursementCompleted,
+		}, {
+			Name: "completed to pending",
+			From: "completed",
+			To:   paymentService.DisbursementPending,
+		}, {
+			Name: "failed to completed",
+			From: "failed",
+			To:   paymentService.DisbursementCompleted,
+		}, {
+			Name: "manual to approved",
+			From: "manual",
+			To:   paymentService.DisbursementApproved,
+		}, {
+			Name: "awaiting approval to new without approval",
+			From: "awaiting_approval",
+			To:   paymentService.DisbursementNew,
+		}, {
+			Name:     "awaiting approval to new after approval",
+			From:     "awaiting_approval",
+			Approved: "yes",
+			To:       paymentService.DisbursementNew,
+			Allowed:  true,
+		}, {
+			Name:    "awaiting approval to rejected",
+			From:    "awaiting_approval",
+			To:      paymentService.DisbursementRejected,
+			Allowed: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			disbursement := models.Disbursement{
+				DisbursementID: utility.GetRandomNumbersInRange(1000000000, 9999999999),
+				RecipientID:    utility.GetRandomNumbersInRange(1000000000, 9999999999),
+				Reference:      utility.RandomString(10),
+				Currency:       "NGN",
+				Amount:         "1000",
+				Status:         test.From,
+				Approved:       test.Approved,
+				Type:           "wallet",
+			}
+			err := disbursement.CreateDisbursement(db.Payment)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = paymentService.TransitionDisbursement(db, &disbursement, test.To, "test", map[string]interface{}{"status": test.To})
+			if test.Allowed && err != nil {
+				t.Fatalf("expected transition from %v to %v to be allowed, got %v", test.From, test.To, err.Error())
+			}
+			if !test.Allowed && err == nil {
+				t.Fatalf("expected transition from %v to %v to be refused", test.From, test.To)
+			}
+
+			saved := models.Disbursement{DisbursementID: disbursement.DisbursementID}
+			_, err = saved.GetDisbursementByDisbursementID(db.Payment)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			history, _, err := paymentService.GetDisbursementStatusHistoryService(db, disbursement.DisbursementID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if test.Allowed {
+				if saved.Status != string(test.To) {
+					t.Errorf("expected status %v, got %v", test.To, saved.Status)
+				}
+				if len(history) != 1 || history[0].FromStatus != test.From || history[0].ToStatus != string(test.To) || history[0].Actor != "test" {
+					t.Errorf("expected one history record from %v to %v, got %+v", test.From, test.To, history)
+				}
+			} else {
+				if saved.Status != test.From || disbursement.Status != test.From {
+					t.Errorf("expected status to remain %v, got %v", test.From, saved.Status)
+				}
+				if len(history) != 0 {
+					t.Errorf("expected no history record, got %+v", history)
+				}
+			}
+		})
+	}
+}
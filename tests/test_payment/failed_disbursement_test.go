@@ -0,0 +1,162 @@
+package test_payment
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/vesicash/payment-ms/external/external_models"
+	"github.com/vesicash/payment-ms/external/mocks/auth_mocks"
+	"github.com/vesicash/payment-ms/external/request"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/controller/payment"
+	"github.com/vesicash/payment-ms/pkg/middleware"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	paymentService "github.com/vesicash/payment-ms/services/payment"
+	tst "github.com/vesicash/payment-ms/tests"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+func TestRecordFailedDisbursement(t *testing.T) {
+	tst.Setup()
+	db := postgresql.Connection()
+
+	var (
+		paymnt      = models.Payment{PaymentID: utility.RandomString(10), Currency: "NGN", TotalAmount: 5000}
+		transaction = external_models.TransactionByID{TransactionID: utility.RandomString(10), BusinessID: utility.GetRandomNumbersInRange(1000000000, 9999999999)}
+		accountID   = utility.GetRandomNumbersInRange(1000000000, 9999999999)
+	)
+
+	failedDisbursement, created, err := paymentService.RecordFailedDisbursement(db, paymnt, transaction, accountID, []string{"Bank Account", "Identity Card"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created || failedDisbursement.ResolutionStatus != paymentService.FailedDisbursementOpen {
+		t.Fatalf("expected an open failed disbursement to be created, got %+v", failedDisbursement)
+	}
+
+	failedDisbursement, created, err = paymentService.RecordFailedDisbursement(db, paymnt, transaction, accountID, []string{"Identity Card"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created || failedDisbursement.Reasons != "Identity Card" {
+		t.Errorf("expected the open failed disbursement to be updated, got %+v", failedDisbursement)
+	}
+
+	err = paymentService.ResolveFailedDisbursement(db, paymnt.PaymentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resolved := models.FailedDisbursement{ID: failedDisbursement.ID}
+	_, err = resolved.GetFailedDisbursementByID(db.Payment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved.ResolutionStatus != paymentService.FailedDisbursementResolved || resolved.ResolvedAt == nil {
+		t.Errorf("expected failed disbursement to be resolved, got %+v", resolved)
+	}
+}
+
+func TestListFailedDisbursements(t *testing.T) {
+	logger := tst.Setup()
+	gin.SetMode(gin.TestMode)
+	validatorRef := validator.New()
+	db := postgresql.Connection()
+
+	auth_mocks.ValidateAuthorizationRes = &external_models.ValidateAuthorizationDataModel{
+		Status:  true,
+		Message: "authorized",
+	}
+
+	paymnt := payment.Controller{Db: db, Validator: validatorRef, Logger: logger, ExtReq: request.ExternalRequest{
+		Logger: logger,
+		Test:   true,
+	}}
+	r := gin.Default()
+
+	businessID := utility.GetRandomNumbersInRange(1000000000, 9999999999)
+	for _, reasons := range []string{"Bank Account", "CAC, Utility Bill"} {
+		failedDisbursement := models.FailedDisbursement{
+			PaymentID:        utility.RandomString(10),
+			BusinessID:       businessID,
+			Reasons:          reasons,
+			ResolutionStatus: paymentService.FailedDisbursementOpen,
+		}
+		err := failedDisbursement.CreateFailedDisbursement(db.Payment)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		Name         string
+		Query        string
+		ExpectedCode int
+		Count        int
+	}{
+		{
+			Name:         "OK list by business",
+			Query:        fmt.Sprintf("business_id=%v", businessID),
+			ExpectedCode: http.StatusOK,
+			Count:        2,
+		}, {
+			Name:         "OK filter by reason",
+			Query:        fmt.Sprintf("business_id=%v&reason=cac", businessID),
+			ExpectedCode: http.StatusOK,
+			Count:        1,
+		}, {
+			Name:         "OK filter by resolution status",
+			Query:        fmt.Sprintf("business_id=%v&resolution_status=resolved", businessID),
+			ExpectedCode: http.StatusOK,
+			Count:        0,
+		}, {
+			Name:         "invalid resolution status",
+			Query:        "resolution_status=closed",
+			ExpectedCode: http.StatusBadRequest,
+		}, {
+			Name:         "invalid from date",
+			Query:        "from=01-01-2023",
+			ExpectedCode: http.StatusBadRequest,
+		},
+	}
+
+	paymentBusinessAdminUrl := r.Group(fmt.Sprintf("%v", "v2"), middleware.Authorize(db, paymnt.ExtReq, middleware.BusinessAdmin))
+	{
+		paymentBusinessAdminUrl.GET("/disbursement/failed", paymnt.ListFailedDisbursements)
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			URI := url.URL{Path: "/v2/disbursement/failed", RawQuery: test.Query}
+
+			req, err := http.NewRequest(http.MethodGet, URI.String(), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("v-private-key", utility.RandomString(20))
+			req.Header.Set("v-public-key", utility.RandomString(20))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			tst.AssertStatusCode(t, rr.Code, test.ExpectedCode)
+
+			data := tst.ParseResponse(rr)
+
+			code := int(data["code"].(float64))
+			tst.AssertStatusCode(t, code, test.ExpectedCode)
+
+			if test.ExpectedCode == http.StatusOK {
+				list := data["data"].([]interface{})
+				if len(list) != test.Count {
+					t.Errorf("expected %v failed disbursements, got %v", test.Count, len(list))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,691 @@
+package test_payment
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/vesicash/payment-ms/external"
+	"github.com/vesicash/payment-ms/external/external_models"
+	"github.com/vesicash/payment-ms/external/mocks/auth_mocks"
+	"github.com/vesicash/payment-ms/external/mocks/rave_mocks"
+	"github.com/vesicash/payment-ms/external/request"
+	"github.com/vesicash/payment-ms/internal/config"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/controller/payment"
+	"github.com/vesicash/payment-ms/pkg/middleware"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	paymentService "github.com/vesicash/payment-ms/services/payment"
+	tst "github.com/vesicash/payment-ms/tests"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+func TestSetGatewayPreference(t *testing.T) {
+	logger := tst.Setup()
+	gin.SetMode(gin.TestMode)
+	validatorRef := validator.New()
+	app := config.GetConfig().App
+	db := postgresql.Connection()
+
+	businessID := utility.GetRandomNumbersInRange(1000000000, 9999999999)
+	pvKey := utility.RandomString(20)
+	pbKey := utility.RandomString(20)
+
+	auth_mocks.ValidateAuthorizationRes = &external_models.ValidateAuthorizationDataModel{
+		Status:  true,
+		Message: "authorized",
+	}
+
+	paymnt := payment.Controller{Db: db, Validator: validatorRef, Logger: logger, ExtReq: request.ExternalRequest{
+		Logger: logger,
+		Test:   true,
+	}}
+	r := gin.Default()
+
+	tests := []struct {
+		Name         string
+		RequestBody  models.SetGatewayPreferenceRequest
+		ExpectedCode int
+		Headers      map[string]string
+		Message      string
+	}{
+		{
+			Name: "OK set gateway preference",
+			RequestBody: models.SetGatewayPreferenceRequest{
+				BusinessID:           businessID,
+				Country:              "NG",
+				PaymentGateways:      []string{"monnify", "paystack"},
+				DisbursementGateways: []string{"paystack"},
+			},
+			ExpectedCode: http.StatusOK,
+			Message:      "successful",
+			Headers: map[string]string{
+				"Content-Type":  "application/json",
+				"v-private-key": pvKey,
+				"v-public-key":  pbKey,
+			},
+		}, {
+			Name: "OK update gateway preference",
+			RequestBody: models.SetGatewayPreferenceRequest{
+				BusinessID:      businessID,
+				Country:         "NG",
+				PaymentGateways: []string{"paystack"},
+			},
+			ExpectedCode: http.StatusOK,
+			Message:      "successful",
+			Headers: map[string]string{
+				"Content-Type":  "application/json",
+				"v-private-key": pvKey,
+				"v-public-key":  pbKey,
+			},
+		}, {
+			Name: "unsupported gateway",
+			RequestBody: models.SetGatewayPreferenceRequest{
+				BusinessID:      businessID,
+				Country:         "NG",
+				PaymentGateways: []string{"unknown"},
+			},
+			ExpectedCode: http.StatusBadRequest,
+			Headers: map[string]string{
+				"Content-Type":  "application/json",
+				"v-private-key": pvKey,
+				"v-public-key":  pbKey,
+			},
+		}, {
+			Name: "no country",
+			RequestBody: models.SetGatewayPreferenceRequest{
+				BusinessID:      businessID,
+				PaymentGateways: []string{"paystack"},
+			},
+			ExpectedCode: http.StatusBadRequest,
+			Headers: map[string]string{
+				"Content-Type":  "application/json",
+				"v-private-key": pvKey,
+				"v-public-key":  pbKey,
+			},
+		}, {
+			Name: "no api keys",
+			RequestBody: models.SetGatewayPreferenceRequest{
+				BusinessID:      businessID,
+				Country:         "NG",
+				PaymentGateways: []string{"paystack"},
+			},
+			ExpectedCode: http.StatusUnauthorized,
+			Headers: map[string]string{
+				"Content-Type": "application/json",
+			},
+		},
+	}
+
+	paymentAppUrl := r.Group(fmt.Sprintf("%v", "v2"), middleware.Authorize(db, paymnt.ExtReq, middleware.AppType))
+	{
+		paymentAppUrl.GET("/gateway/preference", paymnt.GetGatewayPreference)
+	}
+
+	paymentBusinessAdminUrl := r.Group(fmt.Sprintf("%v", "v2"), middleware.Authorize(db, paymnt.ExtReq, middleware.BusinessAdmin))
+	{
+		paymentBusinessAdminUrl.POST("/gateway/preference", paymnt.SetGatewayPreference)
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+
+			var b bytes.Buffer
+			json.NewEncoder(&b).Encode(test.RequestBody)
+			URI := url.URL{Path: "/v2/gateway/preference"}
+
+			req, err := http.NewRequest(http.MethodPost, URI.String(), &b)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i, v := range test.Headers {
+				req.Header.Set(i, v)
+			}
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			tst.AssertStatusCode(t, rr.Code, test.ExpectedCode)
+
+			data := tst.ParseResponse(rr)
+
+			code := int(data["code"].(float64))
+			tst.AssertStatusCode(t, code, test.ExpectedCode)
+
+			if test.Message != "" {
+				message := data["message"]
+				if message != nil {
+					tst.AssertResponseMessage(t, message.(string), test.Message)
+				} else {
+					tst.AssertResponseMessage(t, "", test.Message)
+				}
+
+			}
+
+		})
+
+	}
+
+	t.Run("OK get gateway preference", func(t *testing.T) {
+		URI := url.URL{Path: "/v2/gateway/preference", RawQuery: fmt.Sprintf("business_id=%v&country=NG", businessID)}
+
+		req, err := http.NewRequest(http.MethodGet, URI.String(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("v-app", app.Key)
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		tst.AssertStatusCode(t, rr.Code, http.StatusOK)
+
+		data := tst.ParseResponse(rr)
+		preference := data["data"].(map[string]interface{})
+		tst.AssertResponseMessage(t, preference["payment_gateways"].(string), "paystack")
+	})
+
+}
+
+func TestUpdateGatewayHealth(t *testing.T) {
+	logger := tst.Setup()
+	gin.SetMode(gin.TestMode)
+	validatorRef := validator.New()
+	app := config.GetConfig().App
+	db := postgresql.Connection()
+
+	paymnt := payment.Controller{Db: db, Validator: validatorRef, Logger: logger, ExtReq: request.ExternalRequest{
+		Logger: logger,
+		Test:   true,
+	}}
+	r := gin.Default()
+	pvKey := utility.RandomString(20)
+	pbKey := utility.RandomString(20)
+
+	auth_mocks.ValidateAuthorizationRes = &external_models.ValidateAuthorizationDataModel{
+		Status:  true,
+		Message: "authorized",
+	}
+
+	tests := []struct {
+		Name         string
+		RequestBody  models.UpdateGatewayHealthRequest
+		ExpectedCode int
+		Headers      map[string]string
+		Available    bool
+	}{
+		{
+			Name: "OK disable gateway",
+			RequestBody: models.UpdateGatewayHealthRequest{
+				Gateway: "campay",
+				Status:  "disabled",
+				Reason:  "provider outage",
+			},
+			ExpectedCode: http.StatusOK,
+			Headers: map[string]string{
+				"Content-Type":  "application/json",
+				"v-private-key": pvKey,
+				"v-public-key":  pbKey,
+			},
+			Available: false,
+		}, {
+			Name: "OK enable gateway",
+			RequestBody: models.UpdateGatewayHealthRequest{
+				Gateway: "campay",
+				Status:  "enabled",
+			},
+			ExpectedCode: http.StatusOK,
+			Headers: map[string]string{
+				"Content-Type":  "application/json",
+				"v-private-key": pvKey,
+				"v-public-key":  pbKey,
+			},
+			Available: true,
+		}, {
+			Name: "unsupported gateway",
+			RequestBody: models.UpdateGatewayHealthRequest{
+				Gateway: "unknown",
+				Status:  "disabled",
+			},
+			ExpectedCode: http.StatusBadRequest,
+			Headers: map[string]string{
+				"Content-Type":  "application/json",
+				"v-private-key": pvKey,
+				"v-public-key":  pbKey,
+			},
+			Available: true,
+		}, {
+			Name: "app key cannot change gateway health",
+			RequestBody: models.UpdateGatewayHealthRequest{
+				Gateway: "campay",
+				Status:  "disabled",
+			},
+			ExpectedCode: http.StatusUnauthorized,
+			Headers: map[string]string{
+				"Content-Type": "application/json",
+				"v-app":        app.Key,
+			},
+			Available: true,
+		}, {
+			Name: "invalid status",
+			RequestBody: models.UpdateGatewayHealthRequest{
+				Gateway: "campay",
+				Status:  "paused",
+			},
+			ExpectedCode: http.StatusBadRequest,
+			Headers: map[string]string{
+				"Content-Type":  "application/json",
+				"v-private-key": pvKey,
+				"v-public-key":  pbKey,
+			},
+			Available: true,
+		},
+	}
+
+	paymentAppUrl := r.Group(fmt.Sprintf("%v", "v2"), middleware.Authorize(db, paymnt.ExtReq, middleware.AppType))
+	{
+		paymentAppUrl.GET("/gateway/health", paymnt.ListGatewayHealth)
+	}
+
+	paymentBusinessAdminUrl := r.Group(fmt.Sprintf("%v", "v2"), middleware.Authorize(db, paymnt.ExtReq, middleware.BusinessAdmin))
+	{
+		paymentBusinessAdminUrl.PATCH("/gateway/health", paymnt.UpdateGatewayHealth)
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+
+			var b bytes.Buffer
+			json.NewEncoder(&b).Encode(test.RequestBody)
+			URI := url.URL{Path: "/v2/gateway/health"}
+
+			req, err := http.NewRequest(http.MethodPatch, URI.String(), &b)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i, v := range test.Headers {
+				req.Header.Set(i, v)
+			}
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			tst.AssertStatusCode(t, rr.Code, test.ExpectedCode)
+
+			data := tst.ParseResponse(rr)
+
+			code := int(data["code"].(float64))
+			tst.AssertStatusCode(t, code, test.ExpectedCode)
+
+			if available := paymentService.IsGatewayAvailable("campay"); available != test.Available {
+				t.Errorf("gateway availability: got %v, expected %v", available, test.Available)
+			}
+
+		})
+
+	}
+
+	t.Run("OK list gateway health", func(t *testing.T) {
+		URI := url.URL{Path: "/v2/gateway/health"}
+
+		req, err := http.NewRequest(http.MethodGet, URI.String(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("v-app", app.Key)
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		tst.AssertStatusCode(t, rr.Code, http.StatusOK)
+	})
+
+}
+
+type registryTestGateway struct {
+	*paymentService.RaveGateway
+}
+
+func (g *registryTestGateway) Name() string {
+	return "campay"
+}
+
+func TestGatewayRegistry(t *testing.T) {
+	logger := tst.Setup()
+	extReq := request.ExternalRequest{
+		Logger: logger,
+		Test:   true,
+	}
+
+	paymentService.RegisterGateway("CAMPAY", func(extReq request.ExternalRequest) paymentService.PaymentGateway {
+		return &registryTestGateway{RaveGateway: &paymentService.RaveGateway{}}
+	})
+	defer paymentService.RegisterGateway("campay", paymentService.NewCampayGateway)
+
+	t.Run("OK find registered gateway", func(t *testing.T) {
+		gateway, err := paymentService.FindGateway(extReq, "Campay")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := gateway.(*registryTestGateway); !ok {
+			t.Errorf("find gateway: got %T, expected registered constructor", gateway)
+		}
+	})
+
+	t.Run("unknown gateway", func(t *testing.T) {
+		if _, err := paymentService.FindGateway(extReq, "unknown"); err == nil {
+			t.Errorf("find gateway: expected error for unknown gateway")
+		}
+		if paymentService.IsGatewayAvailable("unknown") {
+			t.Errorf("gateway availability: expected unknown gateway to be unavailable")
+		}
+		if name := paymentService.GetGateway(extReq, "unknown").Name(); name != paymentService.DefaultGateway {
+			t.Errorf("get gateway: got %v, expected %v", name, paymentService.DefaultGateway)
+		}
+		if _, err := paymentService.RecordedGateway(extReq, "unknown"); err == nil {
+			t.Errorf("recorded gateway: expected error for unknown gateway")
+		}
+	})
+
+	t.Run("disabled gateway", func(t *testing.T) {
+		paymentService.DisableGateway("campay")
+		defer paymentService.EnableGateway("campay")
+
+		if _, err := paymentService.FindGateway(extReq, "campay"); err == nil {
+			t.Errorf("find gateway: expected error for disabled gateway")
+		}
+		if paymentService.IsGatewayAvailable("campay") {
+			t.Errorf("gateway availability: expected disabled gateway to be unavailable")
+		}
+		if name := paymentService.GetGateway(extReq, "campay").Name(); name != paymentService.DefaultGateway {
+			t.Errorf("get gateway: got %v, expected %v", name, paymentService.DefaultGateway)
+		}
+		gateway, err := paymentService.RecordedGateway(extReq, "campay")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name := gateway.Name(); name != "campay" {
+			t.Errorf("recorded gateway: got %v, expected campay", name)
+		}
+	})
+
+	t.Run("OK enabled gateway", func(t *testing.T) {
+		if !paymentService.IsGatewayAvailable("campay") {
+			t.Errorf("gateway availability: expected re-enabled gateway to be available")
+		}
+		if name := paymentService.GetGateway(extReq, "campay").Name(); name != "campay" {
+			t.Errorf("get gateway: got %v, expected campay", name)
+		}
+		if name := paymentService.GetGateway(extReq, "").Name(); name != paymentService.DefaultGateway {
+			t.Errorf("get gateway: got %v, expected %v", name, paymentService.DefaultGateway)
+		}
+	})
+
+	t.Run("OK gateway names", func(t *testing.T) {
+		names := paymentService.GatewayNames()
+		for _, name := range []string{"campay", "monnify", "paystack", "rave", "rave_momo"} {
+			if !utility.InStringSlice(name, names) {
+				t.Errorf("gateway names: %v missing from %v", name, names)
+			}
+		}
+		for i := 1; i < len(names); i++ {
+			if names[i-1] > names[i] {
+				t.Errorf("gateway names: expected sorted names, got %v", names)
+			}
+		}
+	})
+}
+
+func TestPaystackTransferRecipient(t *testing.T) {
+	logger := tst.Setup()
+	paystack := paymentService.Paystack{ExtReq: request.ExternalRequest{
+		Logger: logger,
+		Test:   true,
+	}}
+
+	tests := []struct {
+		Name     string
+		BankCode string
+		Currency string
+		Error    bool
+	}{
+		{Name: "OK nigerian bank", BankCode: "058", Currency: "NGN"},
+		{Name: "OK ghanaian bank", BankCode: "GH280100", Currency: "GHS"},
+		{Name: "OK ghanaian mobile money", BankCode: "MTN", Currency: "GHS"},
+		{Name: "OK kenyan mobile money", BankCode: "MPESA", Currency: "KES"},
+		{Name: "OK south african bank", BankCode: "632005", Currency: "ZAR"},
+		{Name: "unsupported currency", BankCode: "058", Currency: "USD", Error: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			recipientCode, err := paystack.CreateTransferRecipient(test.BankCode, "0690000031", "Test Account", test.Currency)
+			if test.Error {
+				if err == nil {
+					t.Errorf("create transfer recipient: expected error for %v", test.Currency)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if recipientCode == "" {
+				t.Errorf("create transfer recipient: expected recipient code")
+			}
+		})
+	}
+}
+
+func TestTransferFailover(t *testing.T) {
+	logger := tst.Setup()
+	extReq := request.ExternalRequest{
+		Logger: logger,
+		Test:   true,
+	}
+
+	transferRequest := func(reference string) paymentService.GatewayTransferRequest {
+		return paymentService.GatewayTransferRequest{
+			BankCode:      "058",
+			AccountNumber: "0690000031",
+			AccountName:   "test user",
+			Narration:     "Vesicash Disbursement",
+			Currency:      "NGN",
+			Reference:     reference,
+			Amount:        200,
+		}
+	}
+	noop := func(gateway string) error { return nil }
+
+	t.Run("OK rave fails and payout goes to next gateway", func(t *testing.T) {
+		rave_mocks.InitTransferError = fmt.Errorf("rave unavailable")
+		defer func() { rave_mocks.InitTransferError = nil }()
+
+		reference := utility.RandomString(20)
+		gateway, resData, err := paymentService.InitTransferWithFailover(extReq, []string{"rave", "paystack"}, transferRequest(reference), noop)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gateway != "paystack" {
+			t.Errorf("failover gateway: got %v, expected paystack", gateway)
+		}
+		if resData.Response == nil {
+			t.Errorf("failover gateway: expected transfer response")
+		}
+	})
+
+	t.Run("rave fails after creating the transfer", func(t *testing.T) {
+		reference := utility.RandomString(20)
+		_, err := paymentService.GetGateway(extReq, "rave").InitTransfer(transferRequest(reference))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rave_mocks.InitTransferError = fmt.Errorf("rave timed out")
+		defer func() { rave_mocks.InitTransferError = nil }()
+
+		gateway, _, err := paymentService.InitTransferWithFailover(extReq, []string{"rave", "paystack"}, transferRequest(reference), noop)
+		if err == nil {
+			t.Errorf("expected transfer %v not to fail over once rave has it", reference)
+		}
+		if gateway != "rave" {
+			t.Errorf("failover gateway: got %v, expected rave", gateway)
+		}
+	})
+
+	t.Run("OK rave transfer exists", func(t *testing.T) {
+		reference := utility.RandomString(20)
+		gateway := paymentService.GetGateway(extReq, "rave")
+
+		exists, err := gateway.TransferExists(reference)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exists {
+			t.Errorf("expected transfer %v not to exist", reference)
+		}
+
+		_, err = gateway.InitTransfer(transferRequest(reference))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		exists, err = gateway.TransferExists(reference)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !exists {
+			t.Errorf("expected transfer %v to exist", reference)
+		}
+	})
+}
+
+func TestGatewayObserver(t *testing.T) {
+	logger := tst.Setup()
+	extReq := request.ExternalRequest{
+		Logger: logger,
+		Test:   true,
+	}
+
+	var (
+		mutex    = &sync.Mutex{}
+		observed = map[string]string{}
+	)
+	external.AddGatewayObserver(func(gateway, name string, statusCode int, latency time.Duration, err error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		observed[name] = gateway
+	})
+
+	_, _, _, _, err := paymentService.GetGateway(extReq, "rave_momo").TransferStatus(utility.RandomString(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = paymentService.GetGateway(extReq, "paystack").ListBanks("NG")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = paymentService.GetGateway(extReq, "rave").ListBanks("NG")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	expected := map[string]string{
+		request.RaveGetTransfer:       "rave_momo",
+		request.ListBanksWithPaystack: "paystack",
+		request.ListBanksWithRave:     "rave",
+	}
+	for name, gateway := range expected {
+		if observed[name] != gateway {
+			t.Errorf("request %v: observed for gateway %v, expected %v", name, observed[name], gateway)
+		}
+	}
+}
+
+func TestGatewayRecoveryProbe(t *testing.T) {
+	logger := tst.Setup()
+	db := postgresql.Connection()
+	extReq := request.ExternalRequest{
+		Logger: logger,
+		Test:   true,
+	}
+
+	settings := &config.GetConfig().GatewayHealth
+	cooldown := settings.RecoveryCooldownSeconds
+	settings.RecoveryCooldownSeconds = 1
+	defer func() { settings.RecoveryCooldownSeconds = cooldown }()
+
+	for gateway, updatedBy := range map[string]string{"paystack": "auto", "campay": "admin"} {
+		health := models.GatewayHealth{Gateway: gateway}
+		code, err := health.GetGatewayHealthByGateway(db.Payment)
+		if err != nil && code == http.StatusInternalServerError {
+			t.Fatal(err)
+		}
+		health.Status = "disabled"
+		health.Reason = "test outage"
+		health.UpdatedBy = updatedBy
+		if health.ID == 0 {
+			err = health.CreateGatewayHealth(db.Payment)
+		} else {
+			err = health.UpdateAllFields(db.Payment)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer paymentService.UpdateGatewayHealthService(extReq, db, models.UpdateGatewayHealthRequest{Gateway: "campay", Status: "enabled"})
+
+	paymentService.LoadGatewayHealth(extReq, db)
+	if paymentService.IsGatewayAvailable("paystack") {
+		t.Fatalf("expected paystack to be disabled before the cooldown")
+	}
+
+	time.Sleep(2 * time.Second)
+	paymentService.SyncGatewayHealth(extReq, db)
+
+	tests := []struct {
+		Name      string
+		Gateway   string
+		Status    string
+		Available bool
+	}{
+		{
+			Name:      "OK automatically disabled gateway enabled after probe",
+			Gateway:   "paystack",
+			Status:    "enabled",
+			Available: true,
+		}, {
+			Name:    "gateway disabled by admin stays disabled",
+			Gateway: "campay",
+			Status:  "disabled",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			if available := paymentService.IsGatewayAvailable(test.Gateway); available != test.Available {
+				t.Errorf("gateway availability: got %v, expected %v", available, test.Available)
+			}
+
+			health := models.GatewayHealth{Gateway: test.Gateway}
+			_, err := health.GetGatewayHealthByGateway(db.Payment)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if health.Status != test.Status {
+				t.Errorf("stored status: got %v, expected %v", health.Status, test.Status)
+			}
+		})
+	}
+}
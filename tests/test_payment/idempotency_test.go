@@ -0,0 +1,234 @@
+package test_payment
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/vesicash/payment-ms/external/external_models"
+	"github.com/vesicash/payment-ms/external/mocks/auth_mocks"
+	"github.com/vesicash/payment-ms/external/request"
+	"github.com/vesicash/payment-ms/internal/config"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/controller/payment"
+	"github.com/vesicash/payment-ms/pkg/middleware"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	tst "github.com/vesicash/payment-ms/tests"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+func TestIdempotencyKey(t *testing.T) {
+	logger := tst.Setup()
+	gin.SetMode(gin.TestMode)
+	validatorRef := validator.New()
+	app := config.GetConfig().App
+	db := postgresql.Connection()
+
+	var (
+		businessID     = utility.GetRandomNumbersInRange(1000000000, 9999999999)
+		idempotencyKey = utility.RandomString(20)
+	)
+
+	paymnt := payment.Controller{Db: db, Validator: validatorRef, Logger: logger, ExtReq: request.ExternalRequest{
+		Logger: logger,
+		Test:   true,
+	}}
+	r := gin.Default()
+
+	tests := []struct {
+		Name         string
+		RequestBody  models.CreditWalletRequest
+		ExpectedCode int
+		Headers      map[string]string
+		Replayed     bool
+	}{
+		{
+			Name: "OK first request",
+			RequestBody: models.CreditWalletRequest{
+				Amount:       200,
+				Currency:     "NGN",
+				BusinessID:   businessID,
+				EscrowWallet: "no",
+				MorWallet:    "no",
+			},
+			ExpectedCode: http.StatusOK,
+			Headers: map[string]string{
+				"Content-Type":    "application/json",
+				"v-app":           app.Key,
+				"Idempotency-Key": idempotencyKey,
+			},
+		}, {
+			Name: "OK retried request is replayed",
+			RequestBody: models.CreditWalletRequest{
+				Amount:       200,
+				Currency:     "NGN",
+				BusinessID:   businessID,
+				EscrowWallet: "no",
+				MorWallet:    "no",
+			},
+			ExpectedCode: http.StatusOK,
+			Replayed:     true,
+			Headers: map[string]string{
+				"Content-Type":    "application/json",
+				"v-app":           app.Key,
+				"Idempotency-Key": idempotencyKey,
+			},
+		}, {
+			Name: "same key with a different body",
+			RequestBody: models.CreditWalletRequest{
+				Amount:       300,
+				Currency:     "NGN",
+				BusinessID:   businessID,
+				EscrowWallet: "no",
+				MorWallet:    "no",
+			},
+			ExpectedCode: http.StatusConflict,
+			Headers: map[string]string{
+				"Content-Type":    "application/json",
+				"v-app":           app.Key,
+				"Idempotency-Key": idempotencyKey,
+			},
+		}, {
+			Name: "OK request without key",
+			RequestBody: models.CreditWalletRequest{
+				Amount:       300,
+				Currency:     "NGN",
+				BusinessID:   businessID,
+				EscrowWallet: "no",
+				MorWallet:    "no",
+			},
+			ExpectedCode: http.StatusOK,
+			Headers: map[string]string{
+				"Content-Type": "application/json",
+				"v-app":        app.Key,
+			},
+		},
+	}
+
+	paymentAppUrl := r.Group(fmt.Sprintf("%v", "v2"), middleware.Authorize(db, paymnt.ExtReq, middleware.AppType))
+	{
+		paymentAppUrl.POST("/wallet/credit", middleware.Idempotency(db), paymnt.CreditWallet)
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+
+			var b bytes.Buffer
+			json.NewEncoder(&b).Encode(test.RequestBody)
+			URI := url.URL{Path: "/v2/wallet/credit"}
+
+			req, err := http.NewRequest(http.MethodPost, URI.String(), &b)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i, v := range test.Headers {
+				req.Header.Set(i, v)
+			}
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			tst.AssertStatusCode(t, rr.Code, test.ExpectedCode)
+
+			data := tst.ParseResponse(rr)
+
+			code := int(data["code"].(float64))
+			tst.AssertStatusCode(t, code, test.ExpectedCode)
+
+			if replayed := rr.Header().Get("Idempotent-Replayed") == "true"; replayed != test.Replayed {
+				t.Errorf("replayed: got %v, expected %v", replayed, test.Replayed)
+			}
+
+		})
+
+	}
+
+}
+
+func TestIdempotencyKeyScope(t *testing.T) {
+	logger := tst.Setup()
+	gin.SetMode(gin.TestMode)
+	db := postgresql.Connection()
+
+	var (
+		idempotencyKey = utility.RandomString(20)
+		publicKey      = utility.RandomString(20)
+		privateKey     = utility.RandomString(20)
+		calls          = 0
+	)
+
+	auth_mocks.ValidateAuthorizationRes = &external_models.ValidateAuthorizationDataModel{
+		Status:  true,
+		Message: "authorized",
+	}
+
+	extReq := request.ExternalRequest{
+		Logger: logger,
+		Test:   true,
+	}
+	r := gin.Default()
+
+	paymentApiUrl := r.Group(fmt.Sprintf("%v", "v2"), middleware.Authorize(db, extReq, middleware.ApiType))
+	{
+		paymentApiUrl.POST("/idempotent", middleware.Idempotency(db), func(c *gin.Context) {
+			calls++
+			c.JSON(http.StatusOK, utility.BuildSuccessResponse(http.StatusOK, "successful", calls))
+		})
+	}
+
+	tests := []struct {
+		Name       string
+		PrivateKey string
+		Replayed   bool
+		Calls      int
+	}{
+		{
+			Name:       "OK first request",
+			PrivateKey: privateKey,
+			Calls:      1,
+		}, {
+			Name:       "OK retried request is replayed",
+			PrivateKey: privateKey,
+			Replayed:   true,
+			Calls:      1,
+		}, {
+			Name:       "OK same public key with other credentials is not replayed",
+			PrivateKey: utility.RandomString(20),
+			Calls:      2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			URI := url.URL{Path: "/v2/idempotent"}
+
+			req, err := http.NewRequest(http.MethodPost, URI.String(), bytes.NewBufferString("{}"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("v-public-key", publicKey)
+			req.Header.Set("v-private-key", test.PrivateKey)
+			req.Header.Set("Idempotency-Key", idempotencyKey)
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			tst.AssertStatusCode(t, rr.Code, http.StatusOK)
+
+			if replayed := rr.Header().Get("Idempotent-Replayed") == "true"; replayed != test.Replayed {
+				t.Errorf("replayed: got %v, expected %v", replayed, test.Replayed)
+			}
+			if calls != test.Calls {
+				t.Errorf("handler calls: got %v, expected %v", calls, test.Calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package test_payment
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/vesicash/payment-ms/external/external_models"
+	"github.com/vesicash/payment-ms/external/mocks/auth_mocks"
+	"github.com/vesicash/payment-ms/external/request"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/controller/payment"
+	"github.com/vesicash/payment-ms/pkg/middleware"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	paymentService "github.com/vesicash/payment-ms/services/payment"
+	tst "github.com/vesicash/payment-ms/tests"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+func TestPostLedgerEntry(t *testing.T) {
+	tst.Setup()
+	db := postgresql.Connection()
+
+	tests := []struct {
+		Name  string
+		Entry models.LedgerEntry
+		Error bool
+	}{
+		{
+			Name: "OK balanced entry",
+			Entry: models.LedgerEntry{
+				Type:      "wallet_credit",
+				Reference: utility.RandomString(10),
+				Lines: []models.LedgerLine{
+					{Account: "external", Currency: "NGN", Debit: 100},
+					{Account: "wallet", AccountID: 1, Currency: "NGN", Credit: 100},
+				},
+			},
+		}, {
+			Name: "unbalanced entry",
+			Entry: models.LedgerEntry{
+				Type:      "wallet_credit",
+				Reference: utility.RandomString(10),
+				Lines: []models.LedgerLine{
+					{Account: "external", Currency: "NGN", Debit: 100},
+					{Account: "wallet", AccountID: 1, Currency: "NGN", Credit: 90},
+				},
+			},
+			Error: true,
+		}, {
+			Name: "unbalanced currencies",
+			Entry: models.LedgerEntry{
+				Type:      "wallet_credit",
+				Reference: utility.RandomString(10),
+				Lines: []models.LedgerLine{
+					{Account: "external", Currency: "USD", Debit: 100},
+					{Account: "wallet", AccountID: 1, Currency: "NGN", Credit: 100},
+				},
+			},
+			Error: true,
+		}, {
+			Name: "single line",
+			Entry: models.LedgerEntry{
+				Type:      "wallet_credit",
+				Reference: utility.RandomString(10),
+				Lines: []models.LedgerLine{
+					{Account: "wallet", AccountID: 1, Currency: "NGN", Credit: 100},
+				},
+			},
+			Error: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			err := paymentService.PostLedgerEntry(db.Payment, test.Entry)
+			if (err != nil) != test.Error {
+				t.Errorf("post ledger entry: got error %v, expected error %v", err, test.Error)
+			}
+		})
+	}
+}
+
+func TestGetLedgerBalance(t *testing.T) {
+	logger := tst.Setup()
+	gin.SetMode(gin.TestMode)
+	validatorRef := validator.New()
+	db := postgresql.Connection()
+
+	accountID := utility.GetRandomNumbersInRange(1000000000, 9999999999)
+	pvKey := utility.RandomString(20)
+	pbKey := utility.RandomString(20)
+
+	auth_mocks.ValidateAuthorizationRes = &external_models.ValidateAuthorizationDataModel{
+		Status:  true,
+		Message: "authorized",
+	}
+
+	paymnt := payment.Controller{Db: db, Validator: validatorRef, Logger: logger, ExtReq: request.ExternalRequest{
+		Logger: logger,
+		Test:   true,
+	}}
+	r := gin.Default()
+
+	// the mocked wallet holds 20000, so the first credit opens the ledger with that balance
+	_, err := paymentService.CreditWallet(paymnt.ExtReq, db, 500, "NGN", accountID, false, paymentService.DefaultWalletType, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		Name          string
+		Query         string
+		ExpectedCode  int
+		Headers       map[string]string
+		LedgerBalance float64
+	}{
+		{
+			Name:          "OK get ledger balance",
+			Query:         fmt.Sprintf("account_id=%v&currency=ngn", accountID),
+			ExpectedCode:  http.StatusOK,
+			LedgerBalance: 20500,
+			Headers: map[string]string{
+				"v-private-key": pvKey,
+				"v-public-key":  pbKey,
+			},
+		}, {
+			Name:         "no account id",
+			Query:        "currency=NGN",
+			ExpectedCode: http.StatusBadRequest,
+			Headers: map[string]string{
+				"v-private-key": pvKey,
+				"v-public-key":  pbKey,
+			},
+		}, {
+			Name:         "no api keys",
+			Query:        fmt.Sprintf("account_id=%v&currency=NGN", accountID),
+			ExpectedCode: http.StatusUnauthorized,
+		},
+	}
+
+	paymentBusinessAdminUrl := r.Group(fmt.Sprintf("%v", "v2"), middleware.Authorize(db, paymnt.ExtReq, middleware.BusinessAdmin))
+	{
+		paymentBusinessAdminUrl.GET("/ledger/balance", paymnt.GetLedgerBalance)
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			URI := url.URL{Path: "/v2/ledger/balance", RawQuery: test.Query}
+
+			req, err := http.NewRequest(http.MethodGet, URI.String(), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i, v := range test.Headers {
+				req.Header.Set(i, v)
+			}
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			tst.AssertStatusCode(t, rr.Code, test.ExpectedCode)
+
+			data := tst.ParseResponse(rr)
+
+			code := int(data["code"].(float64))
+			tst.AssertStatusCode(t, code, test.ExpectedCode)
+
+			if test.ExpectedCode == http.StatusOK {
+				balance := data["data"].(map[string]interface{})
+				if ledgerBalance := balance["ledger_balance"].(float64); ledgerBalance != test.LedgerBalance {
+					t.Errorf("ledger balance: got %v, expected %v", ledgerBalance, test.LedgerBalance)
+				}
+			}
+
+		})
+
+	}
+
+}
@@ -0,0 +1,169 @@
+package test_payment
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/vesicash/payment-ms/external/external_models"
+	"github.com/vesicash/payment-ms/external/mocks/auth_mocks"
+	"github.com/vesicash/payment-ms/external/request"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/controller/payment"
+	"github.com/vesicash/payment-ms/pkg/middleware"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	tst "github.com/vesicash/payment-ms/tests"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+func TestManualDisbursements(t *testing.T) {
+	logger := tst.Setup()
+	gin.SetMode(gin.TestMode)
+	validatorRef := validator.New()
+	db := postgresql.Connection()
+
+	auth_mocks.ValidateAuthorizationRes = &external_models.ValidateAuthorizationDataModel{
+		Status:  true,
+		Message: "authorized",
+	}
+	auth_mocks.AccessToken = external_models.AccessToken{AccountID: utility.GetRandomNumbersInRange(1000000000, 9999999999)}
+
+	paymnt := payment.Controller{Db: db, Validator: validatorRef, Logger: logger, ExtReq: request.ExternalRequest{
+		Logger: logger,
+		Test:   true,
+	}}
+	r := gin.Default()
+
+	newManualDisbursement := func() models.Disbursement {
+		disbursement := models.Disbursement{
+			DisbursementID: utility.GetRandomNumbersInRange(1000000000, 9999999999),
+			RecipientID:    utility.GetRandomNumbersInRange(1000000000, 9999999999),
+			Reference:      utility.RandomString(10),
+			Currency:       "USD",
+			Amount:         "100",
+			Status:         "manual",
+			Type:           "wallet",
+		}
+		err := disbursement.CreateDisbursement(db.Payment)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return disbursement
+	}
+
+	completed := newManualDisbursement()
+	failed := newManualDisbursement()
+
+	paymentBusinessAdminUrl := r.Group(fmt.Sprintf("%v", "v2"), middleware.Authorize(db, paymnt.ExtReq, middleware.BusinessAdmin))
+	{
+		paymentBusinessAdminUrl.POST("/disbursement/manual/complete", paymnt.CompleteManualDisbursement)
+		paymentBusinessAdminUrl.POST("/disbursement/manual/fail", paymnt.FailManualDisbursement)
+		paymentBusinessAdminUrl.POST("/disbursement/manual/reroute", paymnt.RerouteManualDisbursement)
+	}
+
+	completeBody := func(disbursementID int, withProof bool) (*bytes.Buffer, string) {
+		body := new(bytes.Buffer)
+		writer := multipart.NewWriter(body)
+		writer.WriteField("disbursement_id", fmt.Sprintf("%v", disbursementID))
+		writer.WriteField("comment", "paid by wire transfer")
+		if withProof {
+			part, _ := writer.CreateFormFile("proof", "receipt.pdf")
+			part.Write([]byte("receipt"))
+		}
+		writer.Close()
+		return body, writer.FormDataContentType()
+	}
+
+	jsonBody := func(data interface{}) (*bytes.Buffer, string) {
+		body := new(bytes.Buffer)
+		json.NewEncoder(body).Encode(data)
+		return body, "application/json"
+	}
+
+	tests := []struct {
+		Name         string
+		Path         string
+		Body         func() (*bytes.Buffer, string)
+		ExpectedCode int
+		Status       string
+	}{
+		{
+			Name:         "complete without proof",
+			Path:         "/v2/disbursement/manual/complete",
+			Body:         func() (*bytes.Buffer, string) { return completeBody(completed.DisbursementID, false) },
+			ExpectedCode: http.StatusBadRequest,
+		}, {
+			Name:         "OK complete with proof",
+			Path:         "/v2/disbursement/manual/complete",
+			Body:         func() (*bytes.Buffer, string) { return completeBody(completed.DisbursementID, true) },
+			ExpectedCode: http.StatusOK,
+			Status:       "completed",
+		}, {
+			Name:         "complete disbursement that is no longer manual",
+			Path:         "/v2/disbursement/manual/complete",
+			Body:         func() (*bytes.Buffer, string) { return completeBody(completed.DisbursementID, true) },
+			ExpectedCode: http.StatusBadRequest,
+		}, {
+			Name: "fail without reason",
+			Path: "/v2/disbursement/manual/fail",
+			Body: func() (*bytes.Buffer, string) {
+				return jsonBody(models.FailManualDisbursementRequest{DisbursementID: failed.DisbursementID})
+			},
+			ExpectedCode: http.StatusBadRequest,
+		}, {
+			Name: "reroute to unknown gateway",
+			Path: "/v2/disbursement/manual/reroute",
+			Body: func() (*bytes.Buffer, string) {
+				return jsonBody(models.RerouteManualDisbursementRequest{DisbursementID: failed.DisbursementID, Gateway: "unknown"})
+			},
+			ExpectedCode: http.StatusBadRequest,
+		}, {
+			Name: "OK fail",
+			Path: "/v2/disbursement/manual/fail",
+			Body: func() (*bytes.Buffer, string) {
+				return jsonBody(models.FailManualDisbursementRequest{DisbursementID: failed.DisbursementID, Reason: "beneficiary bank rejected the payment"})
+			},
+			ExpectedCode: http.StatusOK,
+			Status:       "failed",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			body, contentType := test.Body()
+			req, err := http.NewRequest(http.MethodPost, test.Path, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", contentType)
+			req.Header.Set("v-private-key", utility.RandomString(20))
+			req.Header.Set("v-public-key", utility.RandomString(20))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			tst.AssertStatusCode(t, rr.Code, test.ExpectedCode)
+
+			data := tst.ParseResponse(rr)
+
+			code := int(data["code"].(float64))
+			tst.AssertStatusCode(t, code, test.ExpectedCode)
+
+			if test.ExpectedCode == http.StatusOK {
+				disbursement := data["data"].(map[string]interface{})
+				if disbursement["status"] != test.Status {
+					t.Errorf("expected status %v, got %v", test.Status, disbursement["status"])
+				}
+				if test.Status == "completed" && disbursement["proof_url"] == "" {
+					t.Errorf("expected proof url to be saved")
+				}
+			}
+		})
+	}
+}
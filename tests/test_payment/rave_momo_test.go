@@ -0,0 +1,257 @@
+package test_payment
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/vesicash/payment-ms/external/external_models"
+	"github.com/vesicash/payment-ms/external/mocks/auth_mocks"
+	"github.com/vesicash/payment-ms/external/request"
+	"github.com/vesicash/payment-ms/internal/config"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/controller/payment"
+	"github.com/vesicash/payment-ms/pkg/middleware"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	paymentService "github.com/vesicash/payment-ms/services/payment"
+	tst "github.com/vesicash/payment-ms/tests"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+func TestRaveMomoTransfer(t *testing.T) {
+	logger := tst.Setup()
+	extReq := request.ExternalRequest{
+		Logger: logger,
+		Test:   true,
+	}
+
+	tests := []struct {
+		Name     string
+		Request  paymentService.GatewayTransferRequest
+		Error    bool
+		BankCode string
+	}{
+		{
+			Name: "OK momo disbursement",
+			Request: paymentService.GatewayTransferRequest{
+				BankCode:      "mtn",
+				AccountNumber: "+233244000000",
+				AccountName:   "test user",
+				Narration:     "Vesicash Disbursement",
+				Currency:      "GHS",
+				Reference:     utility.RandomString(20),
+				Amount:        200,
+			},
+			BankCode: "MTN",
+		}, {
+			Name: "OK momo refund",
+			Request: paymentService.GatewayTransferRequest{
+				BankCode:      "mps",
+				AccountNumber: "+254700000000",
+				AccountName:   "test user",
+				Narration:     "Vesicash Refund",
+				Currency:      "KES",
+				Reference:     utility.RandomString(20),
+				Amount:        200,
+			},
+			BankCode: "MPS",
+		}, {
+			Name: "OK momo manual debit",
+			Request: paymentService.GatewayTransferRequest{
+				BankCode:      "airtel",
+				AccountNumber: "+256700000000",
+				AccountName:   "test user",
+				Narration:     "narration",
+				Currency:      "UGX",
+				Reference:     utility.RandomString(20),
+				Amount:        200,
+			},
+			BankCode: "MPS",
+		}, {
+			Name: "unsupported currency",
+			Request: paymentService.GatewayTransferRequest{
+				BankCode:      "mtn",
+				AccountNumber: "+2348000000000",
+				Currency:      "NGN",
+				Reference:     utility.RandomString(20),
+				Amount:        200,
+			},
+			Error: true,
+		}, {
+			Name: "unsupported operator",
+			Request: paymentService.GatewayTransferRequest{
+				BankCode:      "mps",
+				AccountNumber: "+233244000000",
+				Currency:      "GHS",
+				Reference:     utility.RandomString(20),
+				Amount:        200,
+			},
+			Error: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			resData, err := paymentService.GetGateway(extReq, "rave_momo").InitTransfer(test.Request)
+			if test.Error {
+				if err == nil {
+					t.Errorf("expected momo transfer in %v to %v to fail", test.Request.Currency, test.Request.BankCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			response, ok := resData.Response.(external_models.RaveInitTransferResponse)
+			if !ok {
+				t.Fatalf("expected rave transfer response, got %T", resData.Response)
+			}
+			if response.Data.BankCode != test.BankCode {
+				t.Errorf("account bank: got %v, expected %v", response.Data.BankCode, test.BankCode)
+			}
+			if response.Data.AccountNumber[0] == '+' {
+				t.Errorf("expected mobile number without leading +, got %v", response.Data.AccountNumber)
+			}
+		})
+	}
+}
+
+func TestRaveMomoWebhookSettlement(t *testing.T) {
+	logger := tst.Setup()
+	configData := config.GetConfig()
+	gin.SetMode(gin.TestMode)
+	validatorRef := validator.New()
+	db := postgresql.Connection()
+
+	accountID := utility.GetRandomNumbersInRange(1000000000, 9999999999)
+	auth_mocks.ValidateAuthorizationRes = &external_models.ValidateAuthorizationDataModel{
+		Status:  true,
+		Message: "authorized",
+		Data:    external_models.User{AccountID: uint(accountID)},
+	}
+
+	paymnt := payment.Controller{Db: db, Validator: validatorRef, Logger: logger, ExtReq: request.ExternalRequest{
+		Logger: logger,
+		Test:   true,
+	}}
+	r := gin.Default()
+	pvKey := utility.RandomString(20)
+	pbKey := utility.RandomString(20)
+
+	tests := []struct {
+		Name           string
+		Type           string
+		Gateway        string
+		TransferStatus string
+		Status         string
+	}{
+		{
+			Name:           "OK momo manual debit settled",
+			Type:           "wallet",
+			Gateway:        "rave_momo",
+			TransferStatus: "SUCCESSFUL",
+			Status:         string(paymentService.DisbursementCompleted),
+		}, {
+			Name:           "OK momo refund left for disbursement check",
+			Type:           "refund",
+			Gateway:        "rave_momo",
+			TransferStatus: "SUCCESSFUL",
+			Status:         string(paymentService.DisbursementPending),
+		}, {
+			Name:           "OK momo disbursement left for disbursement check",
+			Type:           "disbursement",
+			Gateway:        "rave_momo",
+			TransferStatus: "SUCCESSFUL",
+			Status:         string(paymentService.DisbursementPending),
+		}, {
+			Name:           "OK bank payout not settled from webhook",
+			Type:           "wallet",
+			Gateway:        "rave",
+			TransferStatus: "SUCCESSFUL",
+			Status:         string(paymentService.DisbursementPending),
+		},
+	}
+
+	paymentApiUrl := r.Group(fmt.Sprintf("%v", "v2"), middleware.Authorize(db, paymnt.ExtReq, middleware.ApiType))
+	{
+		paymentApiUrl.POST("/webhook/rave", paymnt.RaveWebhook)
+	}
+
+	disbursements := []models.Disbursement{}
+	for _, test := range tests {
+		disbursement := models.Disbursement{
+			DisbursementID: utility.GetRandomNumbersInRange(1000000000, 9999999999),
+			RecipientID:    accountID,
+			BusinessID:     accountID,
+			Reference:      fmt.Sprintf("vc%v", utility.GetRandomNumbersInRange(1000000000, 9999999999)),
+			Currency:       "GHS",
+			DebitCurrency:  "GHS",
+			Amount:         "200",
+			Status:         string(paymentService.DisbursementPending),
+			Type:           test.Type,
+			Gateway:        test.Gateway,
+		}
+		err := disbursement.CreateDisbursement(db.Payment)
+		if err != nil {
+			t.Fatal(err)
+		}
+		disbursements = append(disbursements, disbursement)
+
+		var (
+			amount   float64 = 200
+			currency         = "GHS"
+			status           = test.TransferStatus
+		)
+
+		var b bytes.Buffer
+		json.NewEncoder(&b).Encode(models.RaveWebhookRequest{
+			Event: "transfer.completed",
+			Data: &models.RaveWebhookRequestData{
+				Reference: &disbursement.Reference,
+				Amount:    &amount,
+				Currency:  &currency,
+				Status:    &status,
+			},
+		})
+		URI := url.URL{Path: "/v2/webhook/rave"}
+
+		req, err := http.NewRequest(http.MethodPost, URI.String(), &b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("v-private-key", pvKey)
+		req.Header.Set("v-public-key", pbKey)
+		req.Header.Set("verif-hash", configData.Rave.WebhookSecret)
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		tst.AssertStatusCode(t, rr.Code, http.StatusOK)
+	}
+
+	// webhooks are handled in a go routine
+	time.Sleep(time.Duration(waitingTime) * time.Second)
+
+	for i, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			saved := models.Disbursement{DisbursementID: disbursements[i].DisbursementID}
+			_, err := saved.GetDisbursementByDisbursementID(db.Payment)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if saved.Status != test.Status {
+				t.Errorf("disbursement status: got %v, expected %v", saved.Status, test.Status)
+			}
+		})
+	}
+}
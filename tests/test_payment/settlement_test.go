@@ -0,0 +1,132 @@
+package test_payment
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	paymentService "github.com/vesicash/payment-ms/services/payment"
+	tst "github.com/vesicash/payment-ms/tests"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+func TestSettlementWindow(t *testing.T) {
+	tst.Setup()
+	lagos, _ := time.LoadLocation("Africa/Lagos")
+
+	tests := []struct {
+		Name     string
+		Schedule models.SettlementSchedule
+		Holidays []string
+		From     time.Time
+		Expected time.Time
+	}{
+		{
+			Name:     "instant",
+			Schedule: models.SettlementSchedule{Frequency: "instant", Timezone: "Africa/Lagos"},
+			From:     time.Date(2026, 10, 14, 8, 0, 0, 0, lagos),
+			Expected: time.Date(2026, 10, 14, 8, 0, 0, 0, lagos),
+		}, {
+			Name:     "daily before the hour",
+			Schedule: models.SettlementSchedule{Frequency: "daily", Hour: 9, Timezone: "Africa/Lagos"},
+			From:     time.Date(2026, 10, 14, 8, 0, 0, 0, lagos),
+			Expected: time.Date(2026, 10, 14, 9, 0, 0, 0, lagos),
+		}, {
+			Name:     "daily after the hour",
+			Schedule: models.SettlementSchedule{Frequency: "daily", Hour: 9, Timezone: "Africa/Lagos"},
+			From:     time.Date(2026, 10, 14, 10, 0, 0, 0, lagos),
+			Expected: time.Date(2026, 10, 15, 9, 0, 0, 0, lagos),
+		}, {
+			Name:     "daily on a holiday",
+			Schedule: models.SettlementSchedule{Frequency: "daily", Hour: 9, Timezone: "Africa/Lagos"},
+			Holidays: []string{"2026-10-15"},
+			From:     time.Date(2026, 10, 14, 10, 0, 0, 0, lagos),
+			Expected: time.Date(2026, 10, 16, 9, 0, 0, 0, lagos),
+		}, {
+			Name:     "weekly on monday",
+			Schedule: models.SettlementSchedule{Frequency: "weekly", DayOfWeek: 1, Hour: 10, Timezone: "Africa/Lagos"},
+			From:     time.Date(2026, 10, 14, 10, 0, 0, 0, lagos),
+			Expected: time.Date(2026, 10, 19, 10, 0, 0, 0, lagos),
+		}, {
+			Name:     "t+1 over a weekend",
+			Schedule: models.SettlementSchedule{Frequency: "t_plus_n", DelayDays: 1, Hour: 8, Timezone: "Africa/Lagos", SkipWeekends: true},
+			From:     time.Date(2026, 10, 16, 12, 0, 0, 0, lagos),
+			Expected: time.Date(2026, 10, 19, 8, 0, 0, 0, lagos),
+		}, {
+			Name:     "t+1 over a weekend and a holiday",
+			Schedule: models.SettlementSchedule{Frequency: "t_plus_n", DelayDays: 1, Hour: 8, Timezone: "Africa/Lagos", SkipWeekends: true},
+			Holidays: []string{"2026-10-19"},
+			From:     time.Date(2026, 10, 16, 12, 0, 0, 0, lagos),
+			Expected: time.Date(2026, 10, 20, 8, 0, 0, 0, lagos),
+		}, {
+			Name:     "t+0 after the hour",
+			Schedule: models.SettlementSchedule{Frequency: "t_plus_n", Hour: 8, Timezone: "Africa/Lagos"},
+			From:     time.Date(2026, 10, 16, 12, 0, 0, 0, lagos),
+			Expected: time.Date(2026, 10, 17, 8, 0, 0, 0, lagos),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			window := paymentService.SettlementWindow(test.Schedule, test.Holidays, test.From)
+			if !window.Equal(test.Expected) {
+				t.Errorf("expected window %v, got %v", test.Expected, window)
+			}
+		})
+	}
+}
+
+func TestAddToSettlementBatch(t *testing.T) {
+	tst.Setup()
+	db := postgresql.Connection()
+
+	var (
+		schedule = models.SettlementSchedule{
+			BusinessID: utility.GetRandomNumbersInRange(1000000000, 9999999999),
+			Frequency:  "daily",
+			Hour:       9,
+			Timezone:   "Africa/Lagos",
+		}
+		recipientID = utility.GetRandomNumbersInRange(1000000000, 9999999999)
+		items       = []models.SettlementBatchItem{
+			{PaymentID: utility.RandomString(10), TransactionID: utility.RandomString(10), Amount: 1000, Currency: "NGN"},
+			{PaymentID: utility.RandomString(10), TransactionID: utility.RandomString(10), Amount: 2500, Currency: "NGN"},
+		}
+		batchID string
+	)
+
+	// the second item is added twice, a payment is only ever settled once
+	for _, item := range append(items, items[1]) {
+		batch, err := paymentService.AddToSettlementBatch(db, schedule, recipientID, item)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if batch.BatchID != "" && batchID != "" && batch.BatchID != batchID {
+			t.Fatalf("expected payments to be grouped in batch %v, got %v", batchID, batch.BatchID)
+		}
+		if batchID == "" {
+			batchID = batch.BatchID
+		}
+	}
+
+	_, code, err := paymentService.GetSettlementReportService(db, utility.GetRandomNumbersInRange(1000000000, 9999999999), batchID)
+	if err == nil || code != http.StatusNotFound {
+		t.Errorf("expected batch of another business not to be found, got %v", code)
+	}
+
+	report, _, err := paymentService.GetSettlementReportService(db, schedule.BusinessID, batchID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Batch.PaymentCount != 2 || len(report.Items) != 2 {
+		t.Errorf("expected 2 payments in batch, got %v with %v items", report.Batch.PaymentCount, len(report.Items))
+	}
+	if report.Batch.TotalAmount != 3500 {
+		t.Errorf("expected batch total 3500, got %v", report.Batch.TotalAmount)
+	}
+	if report.Batch.Status != "open" || !report.Batch.WindowOpensAt.After(time.Now()) {
+		t.Errorf("expected an open batch with a window in the future, got %v at %v", report.Batch.Status, report.Batch.WindowOpensAt)
+	}
+}
@@ -0,0 +1,300 @@
+package test_payment
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/vesicash/payment-ms/external/request"
+	"github.com/vesicash/payment-ms/internal/config"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/controller/payment"
+	"github.com/vesicash/payment-ms/pkg/middleware"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	paymentService "github.com/vesicash/payment-ms/services/payment"
+	tst "github.com/vesicash/payment-ms/tests"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+func TestPlaceWalletHold(t *testing.T) {
+	logger := tst.Setup()
+	gin.SetMode(gin.TestMode)
+	validatorRef := validator.New()
+	app := config.GetConfig().App
+	db := postgresql.Connection()
+
+	accountID := utility.GetRandomNumbersInRange(1000000000, 9999999999)
+
+	paymnt := payment.Controller{Db: db, Validator: validatorRef, Logger: logger, ExtReq: request.ExternalRequest{
+		Logger: logger,
+		Test:   true,
+	}}
+	r := gin.Default()
+
+	tests := []struct {
+		Name         string
+		RequestBody  models.PlaceWalletHoldRequest
+		ExpectedCode int
+		Headers      map[string]string
+	}{
+		{
+			Name: "OK place wallet hold",
+			RequestBody: models.PlaceWalletHoldRequest{
+				AccountID:       accountID,
+				Currency:        "NGN",
+				Amount:          1000,
+				Type:            "dispute",
+				SourceReference: utility.RandomString(10),
+				Reason:          "chargeback",
+			},
+			ExpectedCode: http.StatusOK,
+			Headers: map[string]string{
+				"Content-Type": "application/json",
+				"v-app":        app.Key,
+			},
+		}, {
+			Name: "amount greater than wallet balance",
+			RequestBody: models.PlaceWalletHoldRequest{
+				AccountID:       accountID,
+				Currency:        "NGN",
+				Amount:          50000,
+				Type:            "dispute",
+				SourceReference: utility.RandomString(10),
+			},
+			ExpectedCode: http.StatusBadRequest,
+			Headers: map[string]string{
+				"Content-Type": "application/json",
+				"v-app":        app.Key,
+			},
+		}, {
+			Name: "invalid hold type",
+			RequestBody: models.PlaceWalletHoldRequest{
+				AccountID:       accountID,
+				Currency:        "NGN",
+				Amount:          1000,
+				Type:            "reserve",
+				SourceReference: utility.RandomString(10),
+			},
+			ExpectedCode: http.StatusBadRequest,
+			Headers: map[string]string{
+				"Content-Type": "application/json",
+				"v-app":        app.Key,
+			},
+		}, {
+			Name: "no app key",
+			RequestBody: models.PlaceWalletHoldRequest{
+				AccountID:       accountID,
+				Currency:        "NGN",
+				Amount:          1000,
+				Type:            "dispute",
+				SourceReference: utility.RandomString(10),
+			},
+			ExpectedCode: http.StatusUnauthorized,
+			Headers: map[string]string{
+				"Content-Type": "application/json",
+			},
+		},
+	}
+
+	paymentAppUrl := r.Group(fmt.Sprintf("%v", "v2"), middleware.Authorize(db, paymnt.ExtReq, middleware.AppType))
+	{
+		paymentAppUrl.POST("/wallet/holds", paymnt.PlaceWalletHold)
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+
+			var b bytes.Buffer
+			json.NewEncoder(&b).Encode(test.RequestBody)
+			URI := url.URL{Path: "/v2/wallet/holds"}
+
+			req, err := http.NewRequest(http.MethodPost, URI.String(), &b)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i, v := range test.Headers {
+				req.Header.Set(i, v)
+			}
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			tst.AssertStatusCode(t, rr.Code, test.ExpectedCode)
+
+			data := tst.ParseResponse(rr)
+
+			code := int(data["code"].(float64))
+			tst.AssertStatusCode(t, code, test.ExpectedCode)
+
+		})
+
+	}
+
+}
+
+func TestSettleWalletHold(t *testing.T) {
+	logger := tst.Setup()
+	gin.SetMode(gin.TestMode)
+	validatorRef := validator.New()
+	app := config.GetConfig().App
+	db := postgresql.Connection()
+
+	accountID := utility.GetRandomNumbersInRange(1000000000, 9999999999)
+
+	paymnt := payment.Controller{Db: db, Validator: validatorRef, Logger: logger, ExtReq: request.ExternalRequest{
+		Logger: logger,
+		Test:   true,
+	}}
+	r := gin.Default()
+
+	holds := []models.WalletHold{}
+	for i := 0; i < 2; i++ {
+		hold, _, err := paymentService.PlaceWalletHold(paymnt.ExtReq, db, models.WalletHold{
+			AccountID:       accountID,
+			Currency:        "NGN",
+			Amount:          500,
+			Type:            string(paymentService.WalletHoldDisbursement),
+			SourceReference: utility.RandomString(10),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		holds = append(holds, hold)
+	}
+
+	tests := []struct {
+		Name         string
+		Path         string
+		RequestBody  models.SettleWalletHoldRequest
+		ExpectedCode int
+		Status       string
+	}{
+		{
+			Name:         "OK capture wallet hold",
+			Path:         "/v2/wallet/holds/capture",
+			RequestBody:  models.SettleWalletHoldRequest{Reference: holds[0].Reference},
+			ExpectedCode: http.StatusOK,
+			Status:       string(paymentService.WalletHoldCaptured),
+		}, {
+			Name:         "release captured hold",
+			Path:         "/v2/wallet/holds/release",
+			RequestBody:  models.SettleWalletHoldRequest{Reference: holds[0].Reference},
+			ExpectedCode: http.StatusBadRequest,
+		}, {
+			Name:         "OK release wallet hold",
+			Path:         "/v2/wallet/holds/release",
+			RequestBody:  models.SettleWalletHoldRequest{Reference: holds[1].Reference},
+			ExpectedCode: http.StatusOK,
+			Status:       string(paymentService.WalletHoldReleased),
+		}, {
+			Name:         "hold not found",
+			Path:         "/v2/wallet/holds/capture",
+			RequestBody:  models.SettleWalletHoldRequest{Reference: utility.RandomString(10)},
+			ExpectedCode: http.StatusBadRequest,
+		}, {
+			Name:         "no reference",
+			Path:         "/v2/wallet/holds/release",
+			RequestBody:  models.SettleWalletHoldRequest{},
+			ExpectedCode: http.StatusBadRequest,
+		},
+	}
+
+	paymentAppUrl := r.Group(fmt.Sprintf("%v", "v2"), middleware.Authorize(db, paymnt.ExtReq, middleware.AppType))
+	{
+		paymentAppUrl.POST("/wallet/holds/capture", paymnt.CaptureWalletHold)
+		paymentAppUrl.POST("/wallet/holds/release", paymnt.ReleaseWalletHold)
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+
+			var b bytes.Buffer
+			json.NewEncoder(&b).Encode(test.RequestBody)
+			URI := url.URL{Path: test.Path}
+
+			req, err := http.NewRequest(http.MethodPost, URI.String(), &b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("v-app", app.Key)
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			tst.AssertStatusCode(t, rr.Code, test.ExpectedCode)
+
+			data := tst.ParseResponse(rr)
+
+			code := int(data["code"].(float64))
+			tst.AssertStatusCode(t, code, test.ExpectedCode)
+
+			if test.ExpectedCode == http.StatusOK {
+				hold := data["data"].(map[string]interface{})
+				if status := hold["status"].(string); status != test.Status {
+					t.Errorf("hold status: got %v, expected %v", status, test.Status)
+				}
+			}
+
+		})
+
+	}
+
+}
+
+func TestExpireWalletHolds(t *testing.T) {
+	logger := tst.Setup()
+	db := postgresql.Connection()
+	extReq := request.ExternalRequest{
+		Logger: logger,
+		Test:   true,
+	}
+
+	var (
+		accountID = utility.GetRandomNumbersInRange(1000000000, 9999999999)
+		expiredAt = time.Now().Add(-time.Hour)
+		holds     = []models.WalletHold{}
+	)
+	for i := 0; i < 2; i++ {
+		hold, _, err := paymentService.PlaceWalletHold(extReq, db, models.WalletHold{
+			AccountID:       accountID,
+			Currency:        "NGN",
+			Amount:          500,
+			Type:            string(paymentService.WalletHoldTransfer),
+			SourceReference: utility.RandomString(10),
+			ExpiresAt:       &expiredAt,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		holds = append(holds, hold)
+	}
+
+	// a release that credited the wallet but could not save its final status
+	holds[1].Status = string(paymentService.WalletHoldReleasing)
+	err := holds[1].UpdateAllFields(db.Payment)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	paymentService.ExpireWalletHolds(extReq, db)
+
+	for i, status := range []paymentService.WalletHoldStatus{paymentService.WalletHoldExpired, paymentService.WalletHoldReleasing} {
+		hold := models.WalletHold{Reference: holds[i].Reference}
+		_, err := hold.GetWalletHoldByReference(db.Payment)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hold.Status != string(status) {
+			t.Errorf("hold %v status: got %v, expected %v", i, hold.Status, status)
+		}
+	}
+}
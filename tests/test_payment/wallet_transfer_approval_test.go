@@ -0,0 +1,163 @@
+package test_payment
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/vesicash/payment-ms/external/external_models"
+	"github.com/vesicash/payment-ms/external/mocks/auth_mocks"
+	"github.com/vesicash/payment-ms/external/request"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/controller/payment"
+	"github.com/vesicash/payment-ms/pkg/middleware"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	paymentService "github.com/vesicash/payment-ms/services/payment"
+	tst "github.com/vesicash/payment-ms/tests"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+func TestDecideWalletTransfer(t *testing.T) {
+	logger := tst.Setup()
+	gin.SetMode(gin.TestMode)
+	validatorRef := validator.New()
+	db := postgresql.Connection()
+
+	var (
+		adminAccountID  = utility.GetRandomNumbersInRange(1000000000, 9999999999)
+		senderAccountID = utility.GetRandomNumbersInRange(1000000000, 9999999999)
+	)
+
+	auth_mocks.AccessToken = external_models.AccessToken{AccountID: adminAccountID}
+	auth_mocks.ValidateAuthorizationRes = &external_models.ValidateAuthorizationDataModel{
+		Status:  true,
+		Message: "authorized",
+	}
+
+	paymnt := payment.Controller{Db: db, Validator: validatorRef, Logger: logger, ExtReq: request.ExternalRequest{
+		Logger: logger,
+		Test:   true,
+	}}
+	r := gin.Default()
+
+	// queued transfers hold the sender's funds until an admin decides on them
+	transfers := []models.WalletTransfer{}
+	for _, accountID := range []int{senderAccountID, senderAccountID, adminAccountID} {
+		transfer := models.WalletTransfer{
+			Reference:          paymentService.NewWalletTransferReference(),
+			SenderAccountID:    accountID,
+			RecipientAccountID: utility.GetRandomNumbersInRange(1000000000, 9999999999),
+			SenderCurrency:     "NGN",
+			RecipientCurrency:  "NGN",
+			Amount:             1000,
+			RecipientAmount:    1000,
+			EscrowWallet:       "no",
+			Status:             string(paymentService.WalletTransferQueued),
+		}
+		err := transfer.CreateWalletTransfer(db.Payment)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, _, err = paymentService.PlaceWalletHold(paymnt.ExtReq, db, models.WalletHold{
+			AccountID:       accountID,
+			Currency:        "NGN",
+			Amount:          transfer.Amount,
+			Type:            string(paymentService.WalletHoldTransfer),
+			SourceReference: transfer.Reference,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		transfers = append(transfers, transfer)
+	}
+
+	tests := []struct {
+		Name         string
+		Path         string
+		RequestBody  models.WalletTransferDecisionRequest
+		ExpectedCode int
+		Status       paymentService.WalletTransferStatus
+	}{
+		{
+			Name:         "OK approve wallet transfer",
+			Path:         "/v2/disbursement/wallet/wallet-transfer/approve",
+			RequestBody:  models.WalletTransferDecisionRequest{Reference: transfers[0].Reference, Reason: "verified with customer"},
+			ExpectedCode: http.StatusOK,
+			Status:       paymentService.WalletTransferCompleted,
+		}, {
+			Name:         "reject approved wallet transfer",
+			Path:         "/v2/disbursement/wallet/wallet-transfer/reject",
+			RequestBody:  models.WalletTransferDecisionRequest{Reference: transfers[0].Reference, Reason: "changed mind"},
+			ExpectedCode: http.StatusBadRequest,
+		}, {
+			Name:         "OK reject wallet transfer",
+			Path:         "/v2/disbursement/wallet/wallet-transfer/reject",
+			RequestBody:  models.WalletTransferDecisionRequest{Reference: transfers[1].Reference, Reason: "suspected fraud"},
+			ExpectedCode: http.StatusOK,
+			Status:       paymentService.WalletTransferRejected,
+		}, {
+			Name:         "approve own wallet transfer",
+			Path:         "/v2/disbursement/wallet/wallet-transfer/approve",
+			RequestBody:  models.WalletTransferDecisionRequest{Reference: transfers[2].Reference, Reason: "mine"},
+			ExpectedCode: http.StatusForbidden,
+		}, {
+			Name:         "no reason",
+			Path:         "/v2/disbursement/wallet/wallet-transfer/approve",
+			RequestBody:  models.WalletTransferDecisionRequest{Reference: transfers[2].Reference},
+			ExpectedCode: http.StatusBadRequest,
+		}, {
+			Name:         "wallet transfer not found",
+			Path:         "/v2/disbursement/wallet/wallet-transfer/approve",
+			RequestBody:  models.WalletTransferDecisionRequest{Reference: paymentService.NewWalletTransferReference(), Reason: "verified"},
+			ExpectedCode: http.StatusBadRequest,
+		},
+	}
+
+	paymentBusinessAdminUrl := r.Group(fmt.Sprintf("%v", "v2"), middleware.Authorize(db, paymnt.ExtReq, middleware.BusinessAdmin))
+	{
+		paymentBusinessAdminUrl.POST("/disbursement/wallet/wallet-transfer/approve", paymnt.ApproveWalletTransfer)
+		paymentBusinessAdminUrl.POST("/disbursement/wallet/wallet-transfer/reject", paymnt.RejectWalletTransfer)
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+
+			var b bytes.Buffer
+			json.NewEncoder(&b).Encode(test.RequestBody)
+			URI := url.URL{Path: test.Path}
+
+			req, err := http.NewRequest(http.MethodPost, URI.String(), &b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("v-private-key", utility.RandomString(20))
+			req.Header.Set("v-public-key", utility.RandomString(20))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			tst.AssertStatusCode(t, rr.Code, test.ExpectedCode)
+
+			data := tst.ParseResponse(rr)
+
+			code := int(data["code"].(float64))
+			tst.AssertStatusCode(t, code, test.ExpectedCode)
+
+			if test.ExpectedCode == http.StatusOK {
+				transfer := data["data"].(map[string]interface{})
+				tst.AssertResponseMessage(t, transfer["status"].(string), string(test.Status))
+			}
+
+		})
+
+	}
+
+}
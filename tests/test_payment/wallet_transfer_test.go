@@ -0,0 +1,88 @@
+package test_payment
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vesicash/payment-ms/external/request"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	paymentService "github.com/vesicash/payment-ms/services/payment"
+	tst "github.com/vesicash/payment-ms/tests"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+func TestRecoverWalletTransfers(t *testing.T) {
+	logger := tst.Setup()
+	db := postgresql.Connection()
+	extReq := request.ExternalRequest{
+		Logger: logger,
+		Test:   true,
+	}
+
+	tests := []struct {
+		Name           string
+		Status         paymentService.WalletTransferStatus
+		ExpectedStatus paymentService.WalletTransferStatus
+	}{
+		{
+			Name:           "pending transfer without debit is left for review",
+			Status:         paymentService.WalletTransferPending,
+			ExpectedStatus: paymentService.WalletTransferReview,
+		}, {
+			Name:           "debited transfer is rolled back",
+			Status:         paymentService.WalletTransferDebited,
+			ExpectedStatus: paymentService.WalletTransferCompensated,
+		}, {
+			Name:           "exchanged transfer is completed",
+			Status:         paymentService.WalletTransferExchanged,
+			ExpectedStatus: paymentService.WalletTransferCompleted,
+		}, {
+			Name:           "approved transfer is completed",
+			Status:         paymentService.WalletTransferApproved,
+			ExpectedStatus: paymentService.WalletTransferCompleted,
+		}, {
+			Name:           "compensating transfer is rolled back",
+			Status:         paymentService.WalletTransferCompensating,
+			ExpectedStatus: paymentService.WalletTransferCompensated,
+		}, {
+			Name:           "queued transfer is left alone",
+			Status:         paymentService.WalletTransferQueued,
+			ExpectedStatus: paymentService.WalletTransferQueued,
+		},
+	}
+
+	transfers := []models.WalletTransfer{}
+	for _, test := range tests {
+		transfer := models.WalletTransfer{
+			Reference:          paymentService.NewWalletTransferReference(),
+			SenderAccountID:    utility.GetRandomNumbersInRange(1000000000, 9999999999),
+			RecipientAccountID: utility.GetRandomNumbersInRange(1000000000, 9999999999),
+			SenderCurrency:     "USD",
+			RecipientCurrency:  "USD",
+			Amount:             100,
+			RecipientAmount:    100,
+			EscrowWallet:       "no",
+			Status:             string(test.Status),
+			UpdatedAt:          time.Now().Add(-time.Hour),
+		}
+		err := transfer.CreateWalletTransfer(db.Payment)
+		if err != nil {
+			t.Fatal(err)
+		}
+		transfers = append(transfers, transfer)
+	}
+
+	paymentService.RecoverWalletTransfers(extReq, db)
+
+	for i, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			transfer := models.WalletTransfer{Reference: transfers[i].Reference}
+			_, err := transfer.GetWalletTransferByReference(db.Payment)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tst.AssertResponseMessage(t, transfer.Status, string(test.ExpectedStatus))
+		})
+	}
+}
@@ -2,6 +2,9 @@ package test_payment
 
 import (
 	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -1667,3 +1670,329 @@ func TestMonnifyDisbursementCallback(t *testing.T) {
 	}
 
 }
+
+func TestPaystackWebhook(t *testing.T) {
+	logger := tst.Setup()
+	configData := config.GetConfig()
+	gin.SetMode(gin.TestMode)
+	validatorRef := validator.New()
+	db := postgresql.Connection()
+	var (
+		muuid, _  = uuid.NewV4()
+		accountID = uint(utility.GetRandomNumbersInRange(1000000000, 9999999999))
+		testUser  = external_models.User{
+			ID:           uint(utility.GetRandomNumbersInRange(1000000000, 9999999999)),
+			AccountID:    accountID,
+			EmailAddress: fmt.Sprintf("testuser%v@qa.team", muuid.String()),
+			PhoneNumber:  fmt.Sprintf("+234%v", utility.GetRandomNumbersInRange(7000000000, 9099999999)),
+			AccountType:  "individual",
+			Firstname:    "test",
+			Lastname:     "user",
+			Username:     fmt.Sprintf("test_username%v", muuid.String()),
+		}
+	)
+
+	auth_mocks.User = &testUser
+	auth_mocks.ValidateAuthorizationRes = &external_models.ValidateAuthorizationDataModel{
+		Status:  true,
+		Message: "authorized",
+		Data:    testUser,
+	}
+	auth_mocks.BusinessProfile = &external_models.BusinessProfile{
+		ID:        uint(utility.GetRandomNumbersInRange(1000000000, 9999999999)),
+		AccountID: int(testUser.AccountID),
+		Country:   "NG",
+		Currency:  "NGN",
+	}
+
+	var (
+		chargeReference   = utility.RandomString(20)
+		transferReference = utility.RandomString(20)
+		amount            = int64(20000)
+		currency          = "NGN"
+		status            = "success"
+	)
+
+	paymentData := models.Payment{
+		ID:          int64(utility.GetRandomNumbersInRange(1000000000, 9999999999)),
+		PaymentID:   utility.RandomString(20),
+		TotalAmount: 200,
+		IsPaid:      false,
+		AccountID:   int64(accountID),
+		BusinessID:  int64(accountID),
+		Currency:    "NGN",
+	}
+	err := paymentData.CreatePayment(db.Payment)
+	if err != nil {
+		t.Fatal("error creating payment: " + err.Error())
+	}
+
+	paymentInfo := models.PaymentInfo{
+		PaymentID: paymentData.PaymentID,
+		Reference: chargeReference,
+		Status:    "pending",
+		Gateway:   "paystack",
+	}
+	err = paymentInfo.CreatePaymentInfo(db.Payment)
+	if err != nil {
+		t.Fatal("error creating payment info: " + err.Error())
+	}
+
+	disbursement := models.Disbursement{
+		RecipientID:    int(testUser.AccountID),
+		PaymentID:      paymentData.PaymentID,
+		DisbursementID: utility.GetRandomNumbersInRange(100000000000, 999999999999),
+		Reference:      transferReference,
+		Currency:       "NGN",
+		BusinessID:     int(testUser.AccountID),
+		Amount:         "200",
+		Status:         "new",
+		Type:           "wallet",
+		Approved:       "pending",
+		Gateway:        "paystack",
+	}
+	err = disbursement.CreateDisbursement(db.Payment)
+	if err != nil {
+		t.Fatal("error creating disbursement: " + err.Error())
+	}
+
+	paymnt := payment.Controller{Db: db, Validator: validatorRef, Logger: logger, ExtReq: request.ExternalRequest{
+		Logger: logger,
+		Test:   true,
+	}}
+	r := gin.Default()
+
+	tests := []struct {
+		Name         string
+		RequestBody  models.PaystackWebhookRequest
+		ExpectedCode int
+		Signature    string
+		Message      string
+	}{
+		{
+			Name: "OK paystack charge success",
+			RequestBody: models.PaystackWebhookRequest{
+				Event: "charge.success",
+				Data: &models.PaystackWebhookRequestData{
+					Reference: &chargeReference,
+					Amount:    &amount,
+					Currency:  &currency,
+					Status:    &status,
+				},
+			},
+			ExpectedCode: http.StatusOK,
+		}, {
+			Name: "OK paystack transfer failed",
+			RequestBody: models.PaystackWebhookRequest{
+				Event: "transfer.failed",
+				Data: &models.PaystackWebhookRequestData{
+					Reference: &transferReference,
+					Amount:    &amount,
+					Currency:  &currency,
+				},
+			},
+			ExpectedCode: http.StatusOK,
+		}, {
+			Name: "invalid signature",
+			RequestBody: models.PaystackWebhookRequest{
+				Event: "charge.success",
+				Data: &models.PaystackWebhookRequestData{
+					Reference: &chargeReference,
+				},
+			},
+			Signature:    "invalid",
+			ExpectedCode: http.StatusUnauthorized,
+		}, {
+			Name: "no event data",
+			RequestBody: models.PaystackWebhookRequest{
+				Event: "charge.success",
+			},
+			ExpectedCode: http.StatusBadRequest,
+			Message:      "event data not found",
+		}, {
+			Name:         "no request data",
+			ExpectedCode: http.StatusBadRequest,
+		},
+	}
+
+	paymentUrl := r.Group(fmt.Sprintf("%v", "v2"))
+	{
+		paymentUrl.POST("/webhook/paystack", paymnt.PaystackWebhook)
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+
+			var b bytes.Buffer
+			json.NewEncoder(&b).Encode(test.RequestBody)
+			URI := url.URL{Path: "/v2/webhook/paystack"}
+
+			signature := test.Signature
+			if signature == "" {
+				signature = utility.Sha512Hmac(configData.Paystack.SecretKey, b.Bytes())
+			}
+			req, err := http.NewRequest(http.MethodPost, URI.String(), &b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("x-paystack-signature", signature)
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			tst.AssertStatusCode(t, rr.Code, test.ExpectedCode)
+
+			data := tst.ParseResponse(rr)
+
+			code := int(data["code"].(float64))
+			tst.AssertStatusCode(t, code, test.ExpectedCode)
+
+			if test.Message != "" {
+				message := data["message"]
+				if message != nil {
+					tst.AssertResponseMessage(t, message.(string), test.Message)
+				} else {
+					tst.AssertResponseMessage(t, "", test.Message)
+				}
+			}
+
+		})
+
+	}
+
+	time.Sleep(time.Duration(waitingTime) * time.Second)
+}
+
+func TestCampayWebhook(t *testing.T) {
+	logger := tst.Setup()
+	configData := config.GetConfig()
+	gin.SetMode(gin.TestMode)
+	validatorRef := validator.New()
+	db := postgresql.Connection()
+	var (
+		accountID = uint(utility.GetRandomNumbersInRange(1000000000, 9999999999))
+		reference = utility.RandomString(20)
+	)
+
+	paymentData := models.Payment{
+		ID:          int64(utility.GetRandomNumbersInRange(1000000000, 9999999999)),
+		PaymentID:   utility.RandomString(20),
+		TotalAmount: 200,
+		IsPaid:      false,
+		AccountID:   int64(accountID),
+		BusinessID:  int64(accountID),
+		Currency:    "XAF",
+	}
+	err := paymentData.CreatePayment(db.Payment)
+	if err != nil {
+		t.Fatal("error creating payment: " + err.Error())
+	}
+
+	paymentInfo := models.PaymentInfo{
+		PaymentID: paymentData.PaymentID,
+		Reference: reference,
+		Status:    "pending",
+		Gateway:   "campay",
+	}
+	err = paymentInfo.CreatePaymentInfo(db.Payment)
+	if err != nil {
+		t.Fatal("error creating payment info: " + err.Error())
+	}
+
+	paymnt := payment.Controller{Db: db, Validator: validatorRef, Logger: logger, ExtReq: request.ExternalRequest{
+		Logger: logger,
+		Test:   true,
+	}}
+	r := gin.Default()
+
+	tests := []struct {
+		Name         string
+		Query        map[string]string
+		Secret       string
+		ExpectedCode int
+		Message      string
+	}{
+		{
+			Name: "OK campay webhook",
+			Query: map[string]string{
+				"status":             "SUCCESSFUL",
+				"reference":          "bcedde9b-62a7-4421-96ac-2e6179552a1a",
+				"external_reference": reference,
+				"amount":             "200",
+				"currency":           "XAF",
+				"operator":           "MTN",
+			},
+			Secret:       configData.Campay.WebhookKey,
+			ExpectedCode: http.StatusOK,
+		}, {
+			Name: "invalid signature",
+			Query: map[string]string{
+				"status":             "SUCCESSFUL",
+				"external_reference": reference,
+			},
+			Secret:       "invalid secret",
+			ExpectedCode: http.StatusUnauthorized,
+		}, {
+			Name: "no external reference",
+			Query: map[string]string{
+				"status": "SUCCESSFUL",
+			},
+			Secret:       configData.Campay.WebhookKey,
+			ExpectedCode: http.StatusBadRequest,
+			Message:      "external reference not found",
+		},
+	}
+
+	paymentUrl := r.Group(fmt.Sprintf("%v", "v2"))
+	{
+		paymentUrl.GET("/webhook/campay", paymnt.CampayWebhook)
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			query := url.Values{}
+			for i, v := range test.Query {
+				query.Set(i, v)
+			}
+			query.Set("signature", campaySignature(test.Secret))
+			URI := url.URL{Path: "/v2/webhook/campay", RawQuery: query.Encode()}
+
+			req, err := http.NewRequest(http.MethodGet, URI.String(), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			tst.AssertStatusCode(t, rr.Code, test.ExpectedCode)
+
+			data := tst.ParseResponse(rr)
+
+			code := int(data["code"].(float64))
+			tst.AssertStatusCode(t, code, test.ExpectedCode)
+
+			if test.Message != "" {
+				message := data["message"]
+				if message != nil {
+					tst.AssertResponseMessage(t, message.(string), test.Message)
+				} else {
+					tst.AssertResponseMessage(t, "", test.Message)
+				}
+			}
+
+		})
+
+	}
+
+	time.Sleep(time.Duration(waitingTime) * time.Second)
+}
+
+func campaySignature(secret string) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"iat":1600000000}`))
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(header + "." + payload))
+	return header + "." + payload + "." + base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
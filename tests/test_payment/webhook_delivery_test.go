@@ -0,0 +1,202 @@
+package test_payment
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/vesicash/payment-ms/external/external_models"
+	"github.com/vesicash/payment-ms/external/mocks/auth_mocks"
+	"github.com/vesicash/payment-ms/external/request"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/controller/payment"
+	"github.com/vesicash/payment-ms/pkg/middleware"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	paymentService "github.com/vesicash/payment-ms/services/payment"
+	tst "github.com/vesicash/payment-ms/tests"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+func TestWebhookDeliveries(t *testing.T) {
+	logger := tst.Setup()
+	db := postgresql.Connection()
+	extReq := request.ExternalRequest{
+		Logger: logger,
+		Test:   true,
+	}
+
+	businessID := utility.GetRandomNumbersInRange(1000000000, 9999999999)
+	endpoint, _, err := paymentService.CreateWebhookEndpointService(db, businessID, models.CreateWebhookEndpointRequest{
+		Url:    "https://example.com/webhooks",
+		Events: []string{"*"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err = paymentService.SendTestWebhookService(extReq, db, businessID, int(endpoint.ID), models.SendTestWebhookRequest{Event: "transfer.reversed"})
+	if err == nil {
+		t.Error("expected an unsupported test event to be rejected")
+	}
+
+	test, _, err := paymentService.SendTestWebhookService(extReq, db, businessID, int(endpoint.ID), models.SendTestWebhookRequest{Event: "disbursement.failed"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !test.IsTest || test.EndpointID != endpoint.ID || test.Event != "disbursement.failed" {
+		t.Errorf("expected a test disbursement.failed delivery to endpoint %v, got %+v", endpoint.ID, test)
+	}
+
+	redelivery, _, err := paymentService.RedeliverWebhookService(extReq, db, businessID, int(test.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if redelivery.ID == test.ID || redelivery.RedeliveryOf != test.ID || redelivery.RequestPayload != test.RequestPayload {
+		t.Errorf("expected a new delivery of webhook %v, got %+v", test.ID, redelivery)
+	}
+
+	_, code, err := paymentService.RedeliverWebhookService(extReq, db, businessID+1, int(test.ID))
+	if err == nil || code != http.StatusNotFound {
+		t.Errorf("expected webhook to be hidden from other businesses, got %v", code)
+	}
+
+	deliveries, _, _, err := paymentService.ListWebhookDeliveriesService(db, businessID, models.ListWebhookDeliveriesRequest{Event: "disbursement.failed", Status: "pending"}, postgresql.Pagination{Page: 1, Limit: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 2 {
+		t.Errorf("expected 2 pending deliveries, got %v", len(deliveries))
+	}
+
+	deliveries, _, _, _ = paymentService.ListWebhookDeliveriesService(db, businessID, models.ListWebhookDeliveriesRequest{Status: "delivered"}, postgresql.Pagination{Page: 1, Limit: 20})
+	if len(deliveries) != 0 {
+		t.Errorf("expected no delivered webhooks, got %v", len(deliveries))
+	}
+}
+
+func TestWebhookDeliveryRoutes(t *testing.T) {
+	logger := tst.Setup()
+	gin.SetMode(gin.TestMode)
+	validatorRef := validator.New()
+	db := postgresql.Connection()
+
+	var (
+		businessID = utility.GetRandomNumbersInRange(1000000000, 9999999999)
+		otherID    = utility.GetRandomNumbersInRange(1000000000, 9999999999)
+	)
+
+	auth_mocks.ValidateAuthorizationRes = &external_models.ValidateAuthorizationDataModel{
+		Status:  true,
+		Message: "authorized",
+	}
+
+	paymnt := payment.Controller{Db: db, Validator: validatorRef, Logger: logger, ExtReq: request.ExternalRequest{
+		Logger: logger,
+		Test:   true,
+	}}
+	r := gin.Default()
+
+	paymentApiUrl := r.Group(fmt.Sprintf("%v", "v2"), middleware.Authorize(db, paymnt.ExtReq, middleware.ApiType))
+	{
+		paymentApiUrl.POST("/webhooks/endpoints/:id/test", paymnt.SendTestWebhook)
+		paymentApiUrl.GET("/webhooks/deliveries", paymnt.ListWebhookDeliveries)
+		paymentApiUrl.GET("/webhooks/deliveries/:id", paymnt.GetWebhookDelivery)
+		paymentApiUrl.POST("/webhooks/deliveries/:id/redeliver", paymnt.RedeliverWebhook)
+	}
+
+	endpoint, _, err := paymentService.CreateWebhookEndpointService(db, businessID, models.CreateWebhookEndpointRequest{
+		Url:    "https://example.com/webhooks",
+		Events: []string{"*"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	delivery, _, err := paymentService.SendTestWebhookService(paymnt.ExtReq, db, businessID, int(endpoint.ID), models.SendTestWebhookRequest{Event: "payment.success"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		Name         string
+		AccountID    int
+		Method       string
+		Path         string
+		Body         interface{}
+		ExpectedCode int
+		Deliveries   int
+	}{
+		{
+			Name:         "OK get",
+			AccountID:    businessID,
+			Method:       http.MethodGet,
+			Path:         fmt.Sprintf("/v2/webhooks/deliveries/%v", delivery.ID),
+			ExpectedCode: http.StatusOK,
+		}, {
+			Name:         "OK list",
+			AccountID:    businessID,
+			Method:       http.MethodGet,
+			Path:         "/v2/webhooks/deliveries",
+			ExpectedCode: http.StatusOK,
+			Deliveries:   1,
+		}, {
+			Name:         "OK list of another business",
+			AccountID:    otherID,
+			Method:       http.MethodGet,
+			Path:         "/v2/webhooks/deliveries",
+			ExpectedCode: http.StatusOK,
+			Deliveries:   0,
+		}, {
+			Name:         "get delivery of another business",
+			AccountID:    otherID,
+			Method:       http.MethodGet,
+			Path:         fmt.Sprintf("/v2/webhooks/deliveries/%v", delivery.ID),
+			ExpectedCode: http.StatusNotFound,
+		}, {
+			Name:         "redeliver delivery of another business",
+			AccountID:    otherID,
+			Method:       http.MethodPost,
+			Path:         fmt.Sprintf("/v2/webhooks/deliveries/%v/redeliver", delivery.ID),
+			ExpectedCode: http.StatusNotFound,
+		}, {
+			Name:         "test event to endpoint of another business",
+			AccountID:    otherID,
+			Method:       http.MethodPost,
+			Path:         fmt.Sprintf("/v2/webhooks/endpoints/%v/test", endpoint.ID),
+			Body:         models.SendTestWebhookRequest{Event: "payment.success"},
+			ExpectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			auth_mocks.AccessToken = external_models.AccessToken{AccountID: test.AccountID}
+
+			var b bytes.Buffer
+			json.NewEncoder(&b).Encode(test.Body)
+			req, err := http.NewRequest(test.Method, test.Path, &b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("v-private-key", utility.RandomString(20))
+			req.Header.Set("v-public-key", utility.RandomString(20))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			tst.AssertStatusCode(t, rr.Code, test.ExpectedCode)
+
+			if test.Path == "/v2/webhooks/deliveries" {
+				data, _ := tst.ParseResponse(rr)["data"].([]interface{})
+				if len(data) != test.Deliveries {
+					t.Errorf("deliveries: got %v, expected %v", len(data), test.Deliveries)
+				}
+			}
+		})
+	}
+}
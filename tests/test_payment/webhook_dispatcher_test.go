@@ -0,0 +1,51 @@
+package test_payment
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vesicash/payment-ms/internal/config"
+	"github.com/vesicash/payment-ms/internal/models"
+	paymentService "github.com/vesicash/payment-ms/services/payment"
+)
+
+func TestWebhookRetryDelay(t *testing.T) {
+	settings := config.WebhookDispatcher{BaseDelaySeconds: 10, MaxDelaySeconds: 3600, JitterPercent: 10}
+	delays := []struct {
+		Tries int
+		Base  time.Duration
+	}{
+		{Tries: 1, Base: 10 * time.Second},
+		{Tries: 2, Base: 20 * time.Second},
+		{Tries: 5, Base: 160 * time.Second},
+		{Tries: 12, Base: time.Hour},
+	}
+	for _, test := range delays {
+		delay := paymentService.WebhookRetryDelay(settings, test.Tries)
+		if delay < test.Base*9/10 || delay > test.Base*11/10 {
+			t.Errorf("try %v: expected delay within 10%% of %v, got %v", test.Tries, test.Base, delay)
+		}
+	}
+}
+
+func TestInterleaveWebhooks(t *testing.T) {
+	webhooks := []models.Webhook{
+		{ID: 1, EndpointID: 1},
+		{ID: 2, EndpointID: 1},
+		{ID: 3, EndpointID: 1},
+		{ID: 4, EndpointID: 2},
+		{ID: 5, WebhookUri: "https://example.com/webhook"},
+		{ID: 6, EndpointID: 2},
+	}
+
+	expected := []uint{1, 4, 5, 2, 6, 3}
+	list := paymentService.InterleaveWebhooks(webhooks)
+	if len(list) != len(expected) {
+		t.Fatalf("expected %v webhooks, got %v", len(expected), len(list))
+	}
+	for i, webhook := range list {
+		if webhook.ID != expected[i] {
+			t.Errorf("position %v: expected webhook %v, got %v", i, expected[i], webhook.ID)
+		}
+	}
+}
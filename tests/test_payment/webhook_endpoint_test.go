@@ -0,0 +1,234 @@
+package test_payment
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/vesicash/payment-ms/external/external_models"
+	"github.com/vesicash/payment-ms/external/mocks/auth_mocks"
+	"github.com/vesicash/payment-ms/external/request"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/controller/payment"
+	"github.com/vesicash/payment-ms/pkg/middleware"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	paymentService "github.com/vesicash/payment-ms/services/payment"
+	tst "github.com/vesicash/payment-ms/tests"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+func TestWebhookEndpointSubscribed(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Events   string
+		Event    string
+		Expected bool
+	}{
+		{Name: "all events", Events: "*", Event: "payment.success", Expected: true},
+		{Name: "subscribed", Events: "payment.success,disbursement.failed", Event: "disbursement.failed", Expected: true},
+		{Name: "not subscribed", Events: "payment.success,disbursement.failed", Event: "payment.failed", Expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			subscribed := paymentService.WebhookEndpointSubscribed(models.WebhookEndpoint{Events: test.Events}, test.Event)
+			if subscribed != test.Expected {
+				t.Errorf("expected subscribed to be %v, got %v", test.Expected, subscribed)
+			}
+		})
+	}
+}
+
+func TestWebhookEndpoints(t *testing.T) {
+	tst.Setup()
+	db := postgresql.Connection()
+
+	var (
+		businessID = utility.GetRandomNumbersInRange(1000000000, 9999999999)
+		disabled   = false
+	)
+
+	_, _, err := paymentService.CreateWebhookEndpointService(db, businessID, models.CreateWebhookEndpointRequest{
+		Url:    "https://example.com/webhooks",
+		Events: []string{"transfer.reversed"},
+	})
+	if err == nil {
+		t.Fatal("expected an unsupported event to be rejected")
+	}
+
+	payments, _, err := paymentService.CreateWebhookEndpointService(db, businessID, models.CreateWebhookEndpointRequest{
+		Url:    "https://example.com/webhooks/payments",
+		Events: []string{"payment.success", "payment.failed"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payments.Secret == "" || !payments.Enabled {
+		t.Errorf("expected an enabled endpoint with a secret, got %+v", payments)
+	}
+
+	all, _, err := paymentService.CreateWebhookEndpointService(db, businessID, models.CreateWebhookEndpointRequest{
+		Url:    "https://example.com/webhooks/all",
+		Events: []string{"*"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err = paymentService.UpdateWebhookEndpointService(db, businessID, int(all.ID), models.UpdateWebhookEndpointRequest{Enabled: &disabled})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	endpoints, _, err := paymentService.WebhookEndpointsForEvent(db, businessID, "payment.success")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(endpoints) != 1 || endpoints[0].ID != payments.ID {
+		t.Errorf("expected only endpoint %v to receive payment.success, got %+v", payments.ID, endpoints)
+	}
+
+	_, code, err := paymentService.GetWebhookEndpointService(db, businessID+1, int(payments.ID))
+	if err == nil || code != http.StatusNotFound {
+		t.Errorf("expected endpoint to be hidden from other businesses, got %v", code)
+	}
+
+	_, _, err = paymentService.DeleteWebhookEndpointService(db, businessID, int(payments.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoints, _, _ = paymentService.WebhookEndpointsForEvent(db, businessID, "payment.success")
+	if len(endpoints) != 0 {
+		t.Errorf("expected no endpoint to receive payment.success, got %v", len(endpoints))
+	}
+}
+
+func TestWebhookEndpointRoutes(t *testing.T) {
+	logger := tst.Setup()
+	gin.SetMode(gin.TestMode)
+	validatorRef := validator.New()
+	db := postgresql.Connection()
+
+	var (
+		businessID = utility.GetRandomNumbersInRange(1000000000, 9999999999)
+		otherID    = utility.GetRandomNumbersInRange(1000000000, 9999999999)
+	)
+
+	auth_mocks.ValidateAuthorizationRes = &external_models.ValidateAuthorizationDataModel{
+		Status:  true,
+		Message: "authorized",
+	}
+
+	paymnt := payment.Controller{Db: db, Validator: validatorRef, Logger: logger, ExtReq: request.ExternalRequest{
+		Logger: logger,
+		Test:   true,
+	}}
+	r := gin.Default()
+
+	paymentApiUrl := r.Group(fmt.Sprintf("%v", "v2"), middleware.Authorize(db, paymnt.ExtReq, middleware.ApiType))
+	{
+		paymentApiUrl.GET("/webhooks/endpoints", paymnt.ListWebhookEndpoints)
+		paymentApiUrl.POST("/webhooks/endpoints", paymnt.CreateWebhookEndpoint)
+		paymentApiUrl.GET("/webhooks/endpoints/:id", paymnt.GetWebhookEndpoint)
+		paymentApiUrl.PATCH("/webhooks/endpoints/:id", paymnt.UpdateWebhookEndpoint)
+		paymentApiUrl.DELETE("/webhooks/endpoints/:id", paymnt.DeleteWebhookEndpoint)
+	}
+
+	send := func(accountID int, method, path string, body interface{}) *httptest.ResponseRecorder {
+		auth_mocks.AccessToken = external_models.AccessToken{AccountID: accountID}
+
+		var b bytes.Buffer
+		json.NewEncoder(&b).Encode(body)
+		req, err := http.NewRequest(method, path, &b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("v-private-key", utility.RandomString(20))
+		req.Header.Set("v-public-key", utility.RandomString(20))
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := send(businessID, http.MethodPost, "/v2/webhooks/endpoints", models.CreateWebhookEndpointRequest{
+		Url:    "https://example.com/webhooks/" + utility.RandomString(10),
+		Events: []string{"*"},
+	})
+	tst.AssertStatusCode(t, rr.Code, http.StatusOK)
+	data, _ := tst.ParseResponse(rr)["data"].(map[string]interface{})
+	if secret, _ := data["secret"].(string); secret == "" {
+		t.Errorf("expected the secret in the create response, got %+v", data)
+	}
+	if businessIDGot, _ := data["business_id"].(float64); int(businessIDGot) != businessID {
+		t.Errorf("expected endpoint of business %v, got %v", businessID, data["business_id"])
+	}
+	path := fmt.Sprintf("/v2/webhooks/endpoints/%v", data["id"])
+	enabled := false
+
+	tests := []struct {
+		Name         string
+		AccountID    int
+		Method       string
+		Path         string
+		Body         interface{}
+		ExpectedCode int
+	}{
+		{
+			Name:         "OK get",
+			AccountID:    businessID,
+			Method:       http.MethodGet,
+			Path:         path,
+			ExpectedCode: http.StatusOK,
+		}, {
+			Name:         "OK list",
+			AccountID:    businessID,
+			Method:       http.MethodGet,
+			Path:         "/v2/webhooks/endpoints",
+			ExpectedCode: http.StatusOK,
+		}, {
+			Name:         "get endpoint of another business",
+			AccountID:    otherID,
+			Method:       http.MethodGet,
+			Path:         path,
+			ExpectedCode: http.StatusNotFound,
+		}, {
+			Name:         "update endpoint of another business",
+			AccountID:    otherID,
+			Method:       http.MethodPatch,
+			Path:         path,
+			Body:         models.UpdateWebhookEndpointRequest{Enabled: &enabled},
+			ExpectedCode: http.StatusNotFound,
+		}, {
+			Name:         "delete endpoint of another business",
+			AccountID:    otherID,
+			Method:       http.MethodDelete,
+			Path:         path,
+			ExpectedCode: http.StatusNotFound,
+		}, {
+			Name:         "OK update",
+			AccountID:    businessID,
+			Method:       http.MethodPatch,
+			Path:         path,
+			Body:         models.UpdateWebhookEndpointRequest{Enabled: &enabled},
+			ExpectedCode: http.StatusOK,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			rr := send(test.AccountID, test.Method, test.Path, test.Body)
+			tst.AssertStatusCode(t, rr.Code, test.ExpectedCode)
+
+			if bytes.Contains(rr.Body.Bytes(), []byte(`"secret"`)) {
+				t.Errorf("expected the secret to be left out of the response, got %v", rr.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package test_payment
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/vesicash/payment-ms/external/external_models"
+	"github.com/vesicash/payment-ms/external/mocks/auth_mocks"
+	"github.com/vesicash/payment-ms/external/request"
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/controller/payment"
+	"github.com/vesicash/payment-ms/pkg/middleware"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"github.com/vesicash/payment-ms/pkg/webhook"
+	paymentService "github.com/vesicash/payment-ms/services/payment"
+	tst "github.com/vesicash/payment-ms/tests"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+func TestWebhookEventPayload(t *testing.T) {
+	legacy := []struct {
+		Name     string
+		Data     webhook.EventData
+		Expected string
+	}{
+		{
+			Name:     "transaction payment",
+			Data:     webhook.Payment{TransactionID: "txn", TransactionTitle: "title", Status: "success"},
+			Expected: `{"payment_status":"success","transaction_id":"txn","transaction_title":"title"}`,
+		}, {
+			Name:     "payment account payment",
+			Data:     webhook.Payment{Reference: "ref", Amount: 1000, Status: "success"},
+			Expected: `{"amount":1000,"reference":"ref","status":"success"}`,
+		}, {
+			Name:     "bank transfer",
+			Data:     webhook.BankTransfer{PaymentID: "pay", Amount: 0, Status: "failed"},
+			Expected: `{"amount":0,"payment_id":"pay","status":"failed"}`,
+		}, {
+			Name:     "disbursement",
+			Data:     webhook.Disbursement{DisbursementID: 12, Reference: "ref", Status: "success"},
+			Expected: `{"disbursement_id":12,"reference":"ref","status":"success"}`,
+		},
+	}
+	for _, test := range legacy {
+		t.Run(test.Name, func(t *testing.T) {
+			payload, err := webhook.Payload(webhook.Event{APIVersion: webhook.APIVersionLegacy}, test.Data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(payload) != test.Expected {
+				t.Errorf("expected legacy payload %v, got %v", test.Expected, string(payload))
+			}
+		})
+	}
+
+	data := webhook.Disbursement{DisbursementID: 12, Reference: "ref", Status: "failed"}
+	payload, err := webhook.Payload(webhook.Event{ID: "evt_1", Type: webhook.EventDisbursementFailed, APIVersion: webhook.APIVersion1, CreatedAt: time.Now()}, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	event, err := webhook.ParseEvent(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.ID != "evt_1" || event.Type != webhook.EventDisbursementFailed || event.APIVersion != webhook.APIVersion1 {
+		t.Errorf("unexpected envelope %+v", event)
+	}
+	parsed := webhook.Disbursement{}
+	err = json.Unmarshal(event.Data, &parsed)
+	if err != nil || parsed != data {
+		t.Errorf("expected data %+v, got %+v (%v)", data, parsed, err)
+	}
+}
+
+func TestWebhookEventSchema(t *testing.T) {
+	schemas, _, err := paymentService.GetWebhookEventSchemaService(models.GetWebhookEventSchemaRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schemas) != len(paymentService.WebhookEvents) {
+		t.Errorf("expected a schema for each of %v events, got %v", len(paymentService.WebhookEvents), len(schemas))
+	}
+
+	schema, err := webhook.Schema(webhook.EventDisbursementSuccess, webhook.APIVersion1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	properties := schema["properties"].(map[string]interface{})
+	if properties["type"].(map[string]interface{})["const"] != webhook.EventDisbursementSuccess {
+		t.Errorf("expected type to be fixed to %v, got %v", webhook.EventDisbursementSuccess, properties["type"])
+	}
+	data := properties["data"].(map[string]interface{})
+	required := data["required"].([]string)
+	if len(required) != 3 {
+		t.Errorf("expected disbursement_id, reference and status to be required, got %v", required)
+	}
+	if data["properties"].(map[string]interface{})["disbursement_id"].(map[string]interface{})["type"] != "integer" {
+		t.Errorf("expected disbursement_id to be an integer, got %v", data["properties"])
+	}
+
+	_, _, err = paymentService.GetWebhookEventSchemaService(models.GetWebhookEventSchemaRequest{ApiVersion: "2000-01-01"})
+	if err == nil {
+		t.Error("expected an unknown api version to be rejected")
+	}
+}
+
+func TestWebhookApiVersion(t *testing.T) {
+	tst.Setup()
+	db := postgresql.Connection()
+	businessID := utility.GetRandomNumbersInRange(1000000000, 9999999999)
+
+	if version := paymentService.WebhookApiVersion(db, businessID); version != webhook.APIVersionLegacy {
+		t.Errorf("expected businesses without a pin to get %v, got %v", webhook.APIVersionLegacy, version)
+	}
+
+	_, _, err := paymentService.SetWebhookApiVersionService(db, businessID, models.SetWebhookApiVersionRequest{ApiVersion: "2000-01-01"})
+	if err == nil {
+		t.Error("expected an unknown api version to be rejected")
+	}
+
+	_, _, err = paymentService.SetWebhookApiVersionService(db, businessID, models.SetWebhookApiVersionRequest{ApiVersion: webhook.APIVersion1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version := paymentService.WebhookApiVersion(db, businessID); version != webhook.APIVersion1 {
+		t.Errorf("expected pinned version %v, got %v", webhook.APIVersion1, version)
+	}
+
+	setting, _, err := paymentService.GetWebhookSettingService(db, businessID+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if setting.ApiVersion != webhook.APIVersionLegacy {
+		t.Errorf("expected the pin to apply to its business only, got %v", setting.ApiVersion)
+	}
+}
+
+func TestWebhookSettingRoutes(t *testing.T) {
+	logger := tst.Setup()
+	gin.SetMode(gin.TestMode)
+	validatorRef := validator.New()
+	db := postgresql.Connection()
+
+	var (
+		businessID = utility.GetRandomNumbersInRange(1000000000, 9999999999)
+		otherID    = utility.GetRandomNumbersInRange(1000000000, 9999999999)
+	)
+
+	auth_mocks.ValidateAuthorizationRes = &external_models.ValidateAuthorizationDataModel{
+		Status:  true,
+		Message: "authorized",
+	}
+
+	paymnt := payment.Controller{Db: db, Validator: validatorRef, Logger: logger, ExtReq: request.ExternalRequest{
+		Logger: logger,
+		Test:   true,
+	}}
+	r := gin.Default()
+
+	paymentApiUrl := r.Group(fmt.Sprintf("%v", "v2"), middleware.Authorize(db, paymnt.ExtReq, middleware.ApiType))
+	{
+		paymentApiUrl.GET("/webhooks/version", paymnt.GetWebhookSetting)
+		paymentApiUrl.POST("/webhooks/version", paymnt.SetWebhookApiVersion)
+	}
+
+	tests := []struct {
+		Name       string
+		AccountID  int
+		Method     string
+		Body       interface{}
+		ApiVersion string
+	}{
+		{
+			Name:       "OK pin version",
+			AccountID:  businessID,
+			Method:     http.MethodPost,
+			Body:       models.SetWebhookApiVersionRequest{ApiVersion: webhook.APIVersion1},
+			ApiVersion: webhook.APIVersion1,
+		}, {
+			Name:       "OK get pinned version",
+			AccountID:  businessID,
+			Method:     http.MethodGet,
+			ApiVersion: webhook.APIVersion1,
+		}, {
+			Name:       "OK other business keeps its version",
+			AccountID:  otherID,
+			Method:     http.MethodGet,
+			ApiVersion: webhook.APIVersionLegacy,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			auth_mocks.AccessToken = external_models.AccessToken{AccountID: test.AccountID}
+
+			var b bytes.Buffer
+			json.NewEncoder(&b).Encode(test.Body)
+			req, err := http.NewRequest(test.Method, "/v2/webhooks/version", &b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("v-private-key", utility.RandomString(20))
+			req.Header.Set("v-public-key", utility.RandomString(20))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			tst.AssertStatusCode(t, rr.Code, http.StatusOK)
+
+			data, _ := tst.ParseResponse(rr)["data"].(map[string]interface{})
+			if data["api_version"] != test.ApiVersion {
+				t.Errorf("api version: got %v, expected %v", data["api_version"], test.ApiVersion)
+			}
+			if businessIDGot, _ := data["business_id"].(float64); int(businessIDGot) != test.AccountID {
+				t.Errorf("business: got %v, expected %v", data["business_id"], test.AccountID)
+			}
+		})
+	}
+}
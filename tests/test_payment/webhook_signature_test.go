@@ -0,0 +1,94 @@
+package test_payment
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/vesicash/payment-ms/internal/models"
+	"github.com/vesicash/payment-ms/pkg/repository/storage/postgresql"
+	"github.com/vesicash/payment-ms/pkg/webhook"
+	paymentService "github.com/vesicash/payment-ms/services/payment"
+	tst "github.com/vesicash/payment-ms/tests"
+	"github.com/vesicash/payment-ms/utility"
+)
+
+func TestWebhookSignature(t *testing.T) {
+	var (
+		body      = []byte(`{"event":"payment.success","amount":1000}`)
+		oldSecret = "whsec_old"
+		newSecret = "whsec_new"
+		now       = time.Now()
+	)
+
+	header := webhook.Sign(now, body, newSecret, oldSecret)
+
+	tests := []struct {
+		Name   string
+		Body   []byte
+		Header string
+		Secret string
+		Err    error
+	}{
+		{Name: "new secret", Body: body, Header: header, Secret: newSecret},
+		{Name: "old secret during rotation", Body: body, Header: header, Secret: oldSecret},
+		{Name: "wrong secret", Body: body, Header: header, Secret: "whsec_other", Err: webhook.ErrNoValidSignature},
+		{Name: "tampered body", Body: []byte(`{"event":"payment.success","amount":9000}`), Header: header, Secret: newSecret, Err: webhook.ErrNoValidSignature},
+		{Name: "replayed", Body: body, Header: webhook.Sign(now.Add(-time.Hour), body, newSecret), Secret: newSecret, Err: webhook.ErrTooOld},
+		{Name: "static signature", Body: body, Header: fmt.Sprintf("v1=%v", webhook.ComputeSignature(now, body, newSecret)), Secret: newSecret, Err: webhook.ErrInvalidHeader},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			err := webhook.Verify(test.Body, test.Header, test.Secret, webhook.DefaultTolerance)
+			if err != test.Err {
+				t.Errorf("expected %v, got %v", test.Err, err)
+			}
+		})
+	}
+}
+
+func TestRotateWebhookEndpointSecret(t *testing.T) {
+	tst.Setup()
+	db := postgresql.Connection()
+
+	var (
+		businessID = utility.GetRandomNumbersInRange(1000000000, 9999999999)
+		overlap    = 24
+		none       = 0
+	)
+
+	endpoint, _, err := paymentService.CreateWebhookEndpointService(db, businessID, models.CreateWebhookEndpointRequest{
+		Url:    "https://example.com/webhooks",
+		Events: []string{"*"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, code, err := paymentService.RotateWebhookEndpointSecretService(db, businessID+1, int(endpoint.ID), models.RotateWebhookEndpointSecretRequest{OverlapHours: &overlap})
+	if err == nil || code != http.StatusNotFound {
+		t.Errorf("expected endpoint of another business not to be rotated, got %v", code)
+	}
+
+	rotated, _, err := paymentService.RotateWebhookEndpointSecretService(db, businessID, int(endpoint.ID), models.RotateWebhookEndpointSecretRequest{OverlapHours: &overlap})
+	if err != nil {
+		t.Fatal(err)
+	}
+	secrets := paymentService.WebhookEndpointSecrets(rotated, time.Now())
+	if len(secrets) != 2 || secrets[0] == endpoint.Secret || secrets[1] != endpoint.Secret {
+		t.Errorf("expected the new and old secret during the overlap, got %v", secrets)
+	}
+	if secrets = paymentService.WebhookEndpointSecrets(rotated, time.Now().Add(25*time.Hour)); len(secrets) != 1 {
+		t.Errorf("expected only the new secret after the overlap, got %v", secrets)
+	}
+
+	rotated, _, err = paymentService.RotateWebhookEndpointSecretService(db, businessID, int(endpoint.ID), models.RotateWebhookEndpointSecretRequest{OverlapHours: &none})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secrets = paymentService.WebhookEndpointSecrets(rotated, time.Now()); len(secrets) != 1 {
+		t.Errorf("expected the old secret to be dropped at once, got %v", secrets)
+	}
+}
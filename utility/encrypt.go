@@ -5,8 +5,11 @@ import (
 	"crypto/sha1"
 	"crypto/sha256"
 	"crypto/sha512"
+	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"strings"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -44,3 +47,30 @@ func Sha256Hmac(secret string, body []byte) string {
 	h.Write(body)
 	return hex.EncodeToString(h.Sum(nil))
 }
+
+func VerifyHS256Jwt(token, secret string) bool {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return false
+	}
+
+	header, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return false
+	}
+	var jwtHeader struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(header, &jwtHeader); err != nil || jwtHeader.Alg != "HS256" {
+		return false
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(parts[0] + "." + parts[1]))
+	return hmac.Equal(signature, h.Sum(nil))
+}